@@ -5,15 +5,37 @@ import (
 	"io/ioutil"
 	"log"
 	"os"
+	"time"
 )
 
 type ConfigFile struct {
-	ServerAddress   string `json:"server_address"`
-	BaseURL         string `json:"base_url"`
-	FileStoragePath string `json:"file_storage_path"`
-	DatabaseDSN     string `json:"database_dsn"`
-	EnableHTTPS     bool   `json:"enable_https"`
-	TrustedSubnet   string `json:"trusted_subnet"`
+	ServerAddress       string   `json:"server_address"`
+	BaseURL             string   `json:"base_url"`
+	Domains             []string `json:"domains"`
+	FileStoragePath     string   `json:"file_storage_path"`
+	DatabaseDSN         string   `json:"database_dsn"`
+	EnableHTTPS         bool     `json:"enable_https"`
+	TrustedSubnet       string   `json:"trusted_subnet"`
+	AdminClientCA       string   `json:"admin_client_ca"`
+	GrpcPort            int      `json:"grpc_port"`
+	UnifiedListener     bool     `json:"unified_listener"`
+	MaxURLLength        int      `json:"max_url_length"`
+	SortQueryParams     bool     `json:"sort_query_params"`
+	AllowDomains        []string `json:"allow_domains"`
+	DenyDomains         []string `json:"deny_domains"`
+	DenyPatterns        []string `json:"deny_patterns"`
+	BlockPrivateIPs     bool     `json:"block_private_ips"`
+	BlocklistFile       string   `json:"blocklist_file"`
+	RateLimitCreate     int      `json:"rate_limit_create"`
+	RateLimitRedirect   int      `json:"rate_limit_redirect"`
+	RateLimitShared     bool     `json:"rate_limit_shared"`
+	SessionTTL          string   `json:"session_ttl"`
+	SessionSecureCookie bool     `json:"session_secure_cookie"`
+	SessionAcceptLegacy bool     `json:"session_accept_legacy"`
+	KeysFile            string   `json:"keys_file"`
+	DeletedRetention    string   `json:"deleted_retention"`
+	FetchTitles         bool     `json:"fetch_titles"`
+	GeoIPFile           string   `json:"geoip_file"`
 }
 
 func getConfigFromFIle(fileName string) Config {
@@ -25,21 +47,64 @@ func getConfigFromFIle(fileName string) Config {
 	if err != nil {
 		log.Fatal()
 	}
-	cfg := ConfigFile{}
+	cfg := ConfigFile{
+		GrpcPort:            GrpcPort,
+		MaxURLLength:        MaxURLLength,
+		BlockPrivateIPs:     BlockPrivateIPs,
+		RateLimitCreate:     RateLimitCreate,
+		RateLimitRedirect:   RateLimitRedirect,
+		SessionTTL:          SessionTTL.String(),
+		SessionAcceptLegacy: SessionAcceptLegacy,
+		DeletedRetention:    DeletedRetention.String(),
+	}
 	err = json.Unmarshal(data, &cfg)
 	if err != nil {
 		log.Fatal(err)
 	}
+	sessionTTL, err := time.ParseDuration(cfg.SessionTTL)
+	if err != nil {
+		log.Fatal(err)
+	}
+	deletedRetention, err := time.ParseDuration(cfg.DeletedRetention)
+	if err != nil {
+		log.Fatal(err)
+	}
 
 	return Config{
 		ServerAddress: cfg.ServerAddress,
 		BaseURL:       cfg.BaseURL,
+		Domains:       cfg.Domains,
 		FilePath:      cfg.FileStoragePath,
 		EnableHTTPS:   cfg.EnableHTTPS,
 		TrustedSubnet: cfg.TrustedSubnet,
+		AdminClientCA: cfg.AdminClientCA,
 		DataBase: ConfigDatabase{
 			DataBaseURI: cfg.DatabaseDSN,
 		},
-		GrpcPort: grpcPort,
+		GrpcPort:        cfg.GrpcPort,
+		UnifiedListener: cfg.UnifiedListener,
+		MaxURLLength:    cfg.MaxURLLength,
+		SortQueryParams: cfg.SortQueryParams,
+		Policy: ConfigPolicy{
+			AllowDomains:    cfg.AllowDomains,
+			DenyDomains:     cfg.DenyDomains,
+			DenyPatterns:    cfg.DenyPatterns,
+			BlockPrivateIPs: cfg.BlockPrivateIPs,
+			BlocklistFile:   cfg.BlocklistFile,
+		},
+		RateLimit: ConfigRateLimit{
+			Create:   cfg.RateLimitCreate,
+			Redirect: cfg.RateLimitRedirect,
+			Shared:   cfg.RateLimitShared,
+		},
+		KeysFile: cfg.KeysFile,
+		Session: ConfigSession{
+			TTL:          sessionTTL,
+			SecureCookie: cfg.SessionSecureCookie,
+			AcceptLegacy: cfg.SessionAcceptLegacy,
+		},
+		DeletedRetention: deletedRetention,
+		FetchTitles:      cfg.FetchTitles,
+		GeoIPFile:        cfg.GeoIPFile,
 	}
 }
@@ -2,12 +2,11 @@ package main
 
 import (
 	"context"
-	"crypto/tls"
 	"database/sql"
+	"flag"
 	"fmt"
 	grpchandler "github.com/p7chkn/go-musthave-shortener-tpl/internal/app/grpc_handler"
 	"github.com/p7chkn/go-musthave-shortener-tpl/internal/app/services"
-	"github.com/p7chkn/go-musthave-shortener-tpl/internal/pb"
 	"google.golang.org/grpc"
 	"log"
 	"net"
@@ -28,6 +27,8 @@ import (
 	"github.com/p7chkn/go-musthave-shortener-tpl/cmd/shortener/setup"
 	"github.com/p7chkn/go-musthave-shortener-tpl/internal/database"
 	"github.com/p7chkn/go-musthave-shortener-tpl/internal/filebase"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/geoip"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/normalizer"
 	"github.com/p7chkn/go-musthave-shortener-tpl/internal/workers"
 )
 
@@ -55,6 +56,12 @@ func main() {
 	defer signal.Stop(interrupt)
 
 	cfg := configuration.New()
+	if flag.NArg() > 0 {
+		if err := setup.RunCommand(ctx, cfg, flag.Args()); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
 
 	var handler *gin.Engine
 	var service *services.URLService
@@ -65,13 +72,38 @@ func main() {
 	}
 
 	wp := workers.New(ctx, cfg.NumOfWorkers, cfg.WorkersBuffer)
+	norm := normalizer.New(cfg.MaxURLLength, cfg.SortQueryParams)
+	pol, err := setup.SetupPolicy(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	dom, err := setup.SetupDomains(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fetcher := setup.SetupTitleFetcher(cfg)
+	var geo geoip.Resolver
+	geoDB, err := setup.SetupGeoIP(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if geoDB != nil {
+		defer geoDB.Close()
+		geo = geoDB
+	}
 
 	go func() {
 		wp.Run(ctx)
 	}()
 
+	var db *sql.DB
+	var accounts *services.AccountService
+	var workspaces *services.WorkspaceService
+	var hooks *services.WebhookService
 	if cfg.DataBase.DataBaseURI != "" {
-		db, err := sql.Open("postgres", cfg.DataBase.DataBaseURI)
+		db, err = sql.Open("postgres", cfg.DataBase.DataBaseURI)
 		if err != nil {
 			log.Fatal(err)
 		}
@@ -80,58 +112,87 @@ func main() {
 		if err != nil {
 			log.Fatal(err.Error())
 		}
-		service = services.NewURLService(database.NewDatabaseRepository(cfg.BaseURL, db), cfg.BaseURL, wp, subnet)
+		repo := database.NewDatabase(db)
+		dispatcher := setup.SetupWebhooks(cfg, wp, repo)
+		service = services.NewURLService(repo, dom, wp, subnet, norm, pol, cfg.DeletedRetention, fetcher, geo, dispatcher)
+		accounts = services.NewAccountService(repo)
+		workspaces = services.NewWorkspaceService(repo, repo)
+		hooks = services.NewWebhookService(repo, dispatcher)
 	} else {
-		service = services.NewURLService(filebase.NewFileRepository(ctx, cfg.FilePath, cfg.BaseURL), cfg.BaseURL, wp, subnet)
+		repo := filebase.NewRepositoryMap(ctx, cfg.FilePath)
+		dispatcher := setup.SetupWebhooks(cfg, wp, repo)
+		service = services.NewURLService(repo, dom, wp, subnet, norm, pol, cfg.DeletedRetention, fetcher, geo, dispatcher)
+		accounts = services.NewAccountService(repo)
+		workspaces = services.NewWorkspaceService(repo, repo)
+		hooks = services.NewWebhookService(repo, dispatcher)
+	}
+	service.ApplyPolicy()
+	go pol.Watch(ctx, setup.BlocklistReloadInterval, service.ApplyPolicy)
+	go service.WatchRetention(ctx, setup.RetentionInterval)
+	go service.WatchClicks(ctx, setup.ClickFlushInterval)
+
+	grpcHandler := grpchandler.NewGRPCHandler(service, dom)
+	gateway, err := grpchandler.NewGateway(ctx, grpcHandler)
+	if err != nil {
+		log.Fatal(err)
+	}
+	sessions, err := setup.SetupSessions(cfg)
+	if err != nil {
+		log.Fatal(err)
 	}
-	handler = setup.SetupRouter(service, cfg)
-	grpcHandler := grpchandler.NewGRPCHandler(service)
+	limiters := setup.SetupRateLimiters(cfg, db)
+	handler = setup.SetupRouter(service, accounts, workspaces, hooks, sessions, gateway, limiters, dom)
 
 	g, ctx := errgroup.WithContext(ctx)
 
-	tlsS := &tls.Config{
-		MinVersion:       tls.VersionTLS12,
-		CurvePreferences: []tls.CurveID{tls.CurveP521, tls.CurveP384, tls.CurveP256},
-		CipherSuites: []uint16{
-			tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
-			tls.TLS_ECDHE_RSA_WITH_AES_256_CBC_SHA,
-			tls.TLS_RSA_WITH_AES_256_GCM_SHA384,
-			tls.TLS_RSA_WITH_AES_256_CBC_SHA,
-			tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
-		},
-	}
-	g.Go(func() error {
-		httpServer = &http.Server{
-			Addr:      cfg.ServerAddress,
-			Handler:   handler,
-			TLSConfig: tlsS,
-		}
-		log.Printf("httpServer starting at: %v", cfg.ServerAddress)
-		if cfg.EnableHTTPS {
-			if err := httpServer.ListenAndServeTLS(
-				"localhost.crt",
-				"localhost.key"); err != http.ErrServerClosed {
+	tlsS, err := setup.SetupTLS(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	httpServer = &http.Server{
+		Addr:      cfg.ServerAddress,
+		Handler:   handler,
+		TLSConfig: tlsS,
+	}
+	grpcServer = setup.SetupGRPCServer(ctx, service, grpcHandler, grpchandler.NewWorkspacesHandler(workspaces, dom), accounts, cfg, subnet, tlsS, limiters)
+
+	if cfg.UnifiedListener {
+		g.Go(func() error {
+			lis, err := net.Listen("tcp", cfg.ServerAddress)
+			if err != nil {
 				return err
 			}
-		} else {
-			if err := httpServer.ListenAndServe(); err != http.ErrServerClosed {
-				return err
+			log.Printf("HTTP and gRPC server listening at %v", lis.Addr())
+			if !cfg.EnableHTTPS {
+				return setup.ServeUnified(ctx, lis, httpServer, grpcServer, nil)
 			}
-		}
-		return nil
-	})
+			return setup.ServeUnified(ctx, lis, httpServer, grpcServer, tlsS)
+		})
+	} else {
+		g.Go(func() error {
+			log.Printf("httpServer starting at: %v", cfg.ServerAddress)
+			if cfg.EnableHTTPS {
+				if err := httpServer.ListenAndServeTLS("", ""); err != http.ErrServerClosed {
+					return err
+				}
+			} else {
+				if err := httpServer.ListenAndServe(); err != http.ErrServerClosed {
+					return err
+				}
+			}
+			return nil
+		})
 
-	g.Go(func() error {
-		lis, err := net.Listen("tcp", fmt.Sprintf(":%d", cfg.GrpcPort))
-		if err != nil {
-			log.Printf("gRPC server failed to listen: %v", err.Error())
-			return err
-		}
-		grpcServer = grpc.NewServer()
-		pb.RegisterURLServer(grpcServer, grpcHandler)
-		log.Printf("server listening at %v", lis.Addr())
-		return grpcServer.Serve(lis)
-	})
+		g.Go(func() error {
+			lis, err := net.Listen("tcp", fmt.Sprintf(":%d", cfg.GrpcPort))
+			if err != nil {
+				log.Printf("gRPC server failed to listen: %v", err.Error())
+				return err
+			}
+			log.Printf("server listening at %v", lis.Addr())
+			return grpcServer.Serve(lis)
+		})
+	}
 
 	select {
 	case <-interrupt:
@@ -160,4 +221,8 @@ func main() {
 		log.Printf("server returning an error: %v", err)
 	}
 
+	if err := service.FlushClicks(shutdownCtx); err != nil {
+		log.Printf("saving clicks: %v", err)
+	}
+
 }
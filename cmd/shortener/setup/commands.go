@@ -0,0 +1,221 @@
+package setup
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/p7chkn/go-musthave-shortener-tpl/cmd/shortener/configuration"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/app/responses"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/app/services"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/database"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/domains"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/filebase"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/shortener"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/transfer"
+)
+
+var (
+	// ErrUnknownCommand - неизвестная команда командной строки.
+	ErrUnknownCommand = errors.New("unknown command, expected export, import or migrate-storage")
+	// ErrUnknownStorage - адрес хранилища не начинается с file:, postgres://
+	// или postgresql://.
+	ErrUnknownStorage = errors.New("storage must be file:<path> or postgres://<dsn>")
+)
+
+// RunCommand - выполнение команды администратора args вместо запуска
+// сервера. Команды работают напрямую с хранилищем из cfg:
+//
+//	export [-format csv|jsonl] [-o file] - выгрузка всех ссылок всех
+//	пользователей, по умолчанию в stdout;
+//	import [-format csv|jsonl] [-user id] [file] - загрузка ссылок из
+//	выгрузки, по умолчанию из stdin. Ссылки сохраняются с прежними id,
+//	владельцами и временем создания, -user задает владельца строк без
+//	user_id;
+//	migrate-storage -from storage -to storage - перенос ссылок между
+//	хранилищами, см. runMigrate.
+func RunCommand(ctx context.Context, cfg *configuration.Config, args []string) error {
+	if len(args) == 0 {
+		return ErrUnknownCommand
+	}
+	switch args[0] {
+	case "export":
+		return runExport(ctx, cfg, args[1:])
+	case "import":
+		return runImport(ctx, cfg, args[1:])
+	case "migrate-storage":
+		return runMigrate(ctx, cfg, args[1:])
+	default:
+		return fmt.Errorf("%w: %s", ErrUnknownCommand, args[0])
+	}
+}
+
+// runExport - команда export.
+func runExport(ctx context.Context, cfg *configuration.Config, args []string) error {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	format := flags.String("format", transfer.FormatJSONL, "file format: csv or jsonl")
+	output := flags.String("o", "", "output file, stdout by default")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	writer, err := transfer.NewWriter(os.Stdout, *format)
+	if *output != "" && err == nil {
+		file, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		writer, _ = transfer.NewWriter(file, *format)
+	}
+	if err != nil {
+		return err
+	}
+	dom, err := SetupDomains(cfg)
+	if err != nil {
+		return err
+	}
+	repo, closeRepo, err := openRepository(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer closeRepo()
+
+	write := func(u responses.ExportURL) error {
+		domain, _ := domains.Split(u.ID)
+		u.Domain = dom.Name(domain)
+		u.ShortURL = dom.ShortURL(u.ID)
+		return writer.Write(u)
+	}
+	if err := repo.ExportURLs(ctx, false, write); err != nil {
+		return err
+	}
+	return writer.Flush()
+}
+
+// runImport - команда import. Некорректные строки и ссылки, которые уже
+// есть в хранилище, пропускаются и выводятся в stderr.
+func runImport(ctx context.Context, cfg *configuration.Config, args []string) error {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	format := flags.String("format", transfer.FormatJSONL, "file format: csv or jsonl")
+	user := flags.String("user", "", "owner of rows without user_id")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	var input io.Reader = os.Stdin
+	if flags.NArg() > 0 {
+		file, err := os.Open(flags.Arg(0))
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		input = file
+	}
+	reader, err := transfer.NewReader(input, *format)
+	if err != nil {
+		return err
+	}
+	dom, err := SetupDomains(cfg)
+	if err != nil {
+		return err
+	}
+	repo, closeRepo, err := openRepository(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer closeRepo()
+
+	imported, failed := 0, 0
+	for {
+		u, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		var rowErr *transfer.RowError
+		if err == nil {
+			err = importURL(ctx, repo, dom, u, *user)
+			if err != nil {
+				rowErr = &transfer.RowError{Row: reader.Row(), Err: err}
+			}
+		} else if !errors.As(err, &rowErr) {
+			return err
+		}
+		if rowErr != nil {
+			fmt.Fprintln(os.Stderr, rowErr)
+			failed++
+			continue
+		}
+		imported++
+	}
+	fmt.Fprintf(os.Stderr, "imported %d, failed %d\n", imported, failed)
+	return nil
+}
+
+// importURL - сохранение ссылки из выгрузки с ее id и владельцем. Ссылка
+// без id получает id по адресу назначения в домене из dom, как при
+// создании. Ссылки, id
+// которых уже есть в хранилище, в том числе удаленные, не загружаются
+// повторно.
+func importURL(ctx context.Context, repo services.UserRepositoryInterface, dom *domains.Domains, u responses.ExportURL, user string) error {
+	if u.OriginalURL == "" {
+		return errors.New("original_url is empty")
+	}
+	if u.UserID == "" {
+		u.UserID = user
+	}
+	if u.UserID == "" {
+		return errors.New("user_id is empty, set -user")
+	}
+	if u.ID == "" {
+		domain, err := dom.Resolve(u.Domain)
+		if err != nil {
+			return err
+		}
+		u.ID = domains.Key(domain, shortener.ShorterURL(u.OriginalURL))
+	}
+	u.DeletedAt = nil
+	imported, err := repo.ImportURL(ctx, u)
+	if err == nil && !imported {
+		return fmt.Errorf("link %s already exists", u.ID)
+	}
+	return err
+}
+
+// openRepository - открытие хранилища из cfg: базы данных, если задан ее
+// адрес, иначе файла. Возвращает функцию закрытия хранилища.
+func openRepository(ctx context.Context, cfg *configuration.Config) (services.UserRepositoryInterface, func(), error) {
+	if cfg.DataBase.DataBaseURI == "" {
+		return filebase.NewRepositoryMap(ctx, cfg.FilePath), func() {}, nil
+	}
+	return openDataBase(ctx, cfg.DataBase.DataBaseURI)
+}
+
+// openStorage - открытие хранилища по адресу вида file:<path> или
+// postgres://<dsn>.
+func openStorage(ctx context.Context, storage string) (services.UserRepositoryInterface, func(), error) {
+	switch {
+	case strings.HasPrefix(storage, "file:"):
+		return filebase.NewRepositoryMap(ctx, strings.TrimPrefix(storage, "file:")), func() {}, nil
+	case strings.HasPrefix(storage, "postgres://"), strings.HasPrefix(storage, "postgresql://"):
+		return openDataBase(ctx, storage)
+	default:
+		return nil, nil, fmt.Errorf("%w: %s", ErrUnknownStorage, storage)
+	}
+}
+
+// openDataBase - подключение к базе данных dsn с накатыванием миграций.
+func openDataBase(ctx context.Context, dsn string) (services.UserRepositoryInterface, func(), error) {
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := SetUpDataBase(db, ctx); err != nil {
+		db.Close()
+		return nil, nil, err
+	}
+	return database.NewDatabase(db), func() { db.Close() }, nil
+}
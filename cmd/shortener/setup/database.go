@@ -31,5 +31,145 @@ func SetUpDataBase(db *sql.DB, ctx context.Context) error {
 					);`
 	res, err := db.ExecContext(ctx, sqlCreateDB)
 	log.Println("Create table", err, res)
+	sqlAddBlocked := `ALTER TABLE urls ADD COLUMN IF NOT EXISTS is_blocked BOOLEAN NOT NULL DEFAULT FALSE;`
+	if _, err := db.ExecContext(ctx, sqlAddBlocked); err != nil {
+		return err
+	}
+	sqlAddDeletedAt := `ALTER TABLE urls ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;`
+	if _, err := db.ExecContext(ctx, sqlAddDeletedAt); err != nil {
+		return err
+	}
+	sqlAddCreatedAt := `ALTER TABLE urls ADD COLUMN IF NOT EXISTS created_at TIMESTAMPTZ NOT NULL DEFAULT now();`
+	if _, err := db.ExecContext(ctx, sqlAddCreatedAt); err != nil {
+		return err
+	}
+	sqlCreatedAtIndex := `CREATE INDEX IF NOT EXISTS urls_user_created_at ON urls (user_id, created_at, short_url);`
+	if _, err := db.ExecContext(ctx, sqlCreatedAtIndex); err != nil {
+		return err
+	}
+	sqlAddMeta := `ALTER TABLE urls ADD COLUMN IF NOT EXISTS title VARCHAR NOT NULL DEFAULT '',
+				   ADD COLUMN IF NOT EXISTS description VARCHAR NOT NULL DEFAULT '',
+				   ADD COLUMN IF NOT EXISTS tags TEXT[] NOT NULL DEFAULT '{}';`
+	if _, err := db.ExecContext(ctx, sqlAddMeta); err != nil {
+		return err
+	}
+	sqlTagsIndex := `CREATE INDEX IF NOT EXISTS urls_tags ON urls USING GIN (tags);`
+	if _, err := db.ExecContext(ctx, sqlTagsIndex); err != nil {
+		return err
+	}
+	sqlAddClicks := `ALTER TABLE urls ADD COLUMN IF NOT EXISTS clicks BIGINT NOT NULL DEFAULT 0;`
+	if _, err := db.ExecContext(ctx, sqlAddClicks); err != nil {
+		return err
+	}
+	sqlAddRedirect := `ALTER TABLE urls ADD COLUMN IF NOT EXISTS redirect JSONB NOT NULL DEFAULT '{}';`
+	if _, err := db.ExecContext(ctx, sqlAddRedirect); err != nil {
+		return err
+	}
+	sqlAddAccess := `ALTER TABLE urls ADD COLUMN IF NOT EXISTS password_hash VARCHAR NOT NULL DEFAULT '',
+					 ADD COLUMN IF NOT EXISTS max_clicks BIGINT NOT NULL DEFAULT 0,
+					 ADD COLUMN IF NOT EXISTS uses BIGINT NOT NULL DEFAULT 0;`
+	if _, err := db.ExecContext(ctx, sqlAddAccess); err != nil {
+		return err
+	}
+	sqlCreateRateLimits := `CREATE TABLE IF NOT EXISTS rate_limits (
+								key VARCHAR PRIMARY KEY,
+								tokens DOUBLE PRECISION NOT NULL,
+								updated_at TIMESTAMPTZ NOT NULL
+					);`
+	if _, err := db.ExecContext(ctx, sqlCreateRateLimits); err != nil {
+		return err
+	}
+	sqlCreateAccounts := `CREATE TABLE IF NOT EXISTS accounts (
+								id uuid PRIMARY KEY,
+								login VARCHAR NOT NULL UNIQUE,
+								password_hash VARCHAR NOT NULL,
+								created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+					);`
+	if _, err := db.ExecContext(ctx, sqlCreateAccounts); err != nil {
+		return err
+	}
+	sqlCreateAPIKeys := `CREATE TABLE IF NOT EXISTS api_keys (
+								id uuid PRIMARY KEY,
+								user_id uuid NOT NULL REFERENCES accounts (id),
+								name VARCHAR NOT NULL,
+								prefix VARCHAR NOT NULL,
+								key_hash VARCHAR NOT NULL UNIQUE,
+								created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+					);`
+	if _, err := db.ExecContext(ctx, sqlCreateAPIKeys); err != nil {
+		return err
+	}
+	sqlCreateWorkspaces := `CREATE TABLE IF NOT EXISTS workspaces (
+								id uuid PRIMARY KEY,
+								name VARCHAR NOT NULL,
+								created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+					);`
+	if _, err := db.ExecContext(ctx, sqlCreateWorkspaces); err != nil {
+		return err
+	}
+	sqlCreateMembers := `CREATE TABLE IF NOT EXISTS workspace_members (
+								workspace_id uuid NOT NULL REFERENCES workspaces (id) ON DELETE CASCADE,
+								user_id uuid NOT NULL REFERENCES accounts (id),
+								role VARCHAR NOT NULL,
+								PRIMARY KEY (workspace_id, user_id)
+					);`
+	if _, err := db.ExecContext(ctx, sqlCreateMembers); err != nil {
+		return err
+	}
+	sqlAddWorkspace := `ALTER TABLE urls ADD COLUMN IF NOT EXISTS workspace_id uuid REFERENCES workspaces (id);`
+	if _, err := db.ExecContext(ctx, sqlAddWorkspace); err != nil {
+		return err
+	}
+	sqlCreateRevisions := `CREATE TABLE IF NOT EXISTS url_revisions (
+								id serial PRIMARY KEY,
+								short_url VARCHAR NOT NULL REFERENCES urls (short_url),
+								revision INT NOT NULL,
+								origin_url VARCHAR NOT NULL,
+								changed_by uuid,
+								created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+								UNIQUE (short_url, revision)
+					);`
+	if _, err := db.ExecContext(ctx, sqlCreateRevisions); err != nil {
+		return err
+	}
+	sqlCreateDestinationClicks := `CREATE TABLE IF NOT EXISTS destination_clicks (
+								short_url VARCHAR NOT NULL REFERENCES urls (short_url),
+								url VARCHAR NOT NULL,
+								clicks BIGINT NOT NULL DEFAULT 0,
+								PRIMARY KEY (short_url, url)
+					);`
+	if _, err := db.ExecContext(ctx, sqlCreateDestinationClicks); err != nil {
+		return err
+	}
+	sqlCreateWebhooks := `CREATE TABLE IF NOT EXISTS webhooks (
+								id uuid PRIMARY KEY,
+								user_id uuid NOT NULL,
+								url VARCHAR NOT NULL,
+								secret VARCHAR NOT NULL,
+								events TEXT[] NOT NULL DEFAULT '{}',
+								click_threshold BIGINT NOT NULL DEFAULT 0,
+								created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+					);`
+	if _, err := db.ExecContext(ctx, sqlCreateWebhooks); err != nil {
+		return err
+	}
+	sqlCreateDeliveries := `CREATE TABLE IF NOT EXISTS webhook_deliveries (
+								id uuid PRIMARY KEY,
+								webhook_id uuid NOT NULL REFERENCES webhooks (id) ON DELETE CASCADE,
+								event_id uuid NOT NULL,
+								event VARCHAR NOT NULL,
+								attempt INT NOT NULL,
+								success BOOLEAN NOT NULL,
+								status_code INT NOT NULL DEFAULT 0,
+								error VARCHAR NOT NULL DEFAULT '',
+								created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+					);`
+	if _, err := db.ExecContext(ctx, sqlCreateDeliveries); err != nil {
+		return err
+	}
+	sqlDeliveriesIndex := `CREATE INDEX IF NOT EXISTS webhook_deliveries_created_at ON webhook_deliveries (webhook_id, created_at);`
+	if _, err := db.ExecContext(ctx, sqlDeliveriesIndex); err != nil {
+		return err
+	}
 	return nil
 }
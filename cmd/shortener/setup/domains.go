@@ -0,0 +1,12 @@
+package setup
+
+import (
+	"github.com/p7chkn/go-musthave-shortener-tpl/cmd/shortener/configuration"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/domains"
+)
+
+// SetupDomains - домены коротких ссылок: BaseURL - домен по умолчанию,
+// Domains - дополнительные.
+func SetupDomains(cfg *configuration.Config) (*domains.Domains, error) {
+	return domains.New(cfg.BaseURL, cfg.Domains)
+}
@@ -0,0 +1,15 @@
+package setup
+
+import (
+	"github.com/p7chkn/go-musthave-shortener-tpl/cmd/shortener/configuration"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/geoip"
+)
+
+// SetupGeoIP - открытие базы GeoIP для правил перехода по стране, nil
+// если файл базы не задан.
+func SetupGeoIP(cfg *configuration.Config) (*geoip.DB, error) {
+	if cfg.GeoIPFile == "" {
+		return nil, nil
+	}
+	return geoip.Open(cfg.GeoIPFile)
+}
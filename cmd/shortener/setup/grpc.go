@@ -0,0 +1,59 @@
+package setup
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"time"
+
+	"github.com/p7chkn/go-musthave-shortener-tpl/cmd/shortener/configuration"
+	grpchandler "github.com/p7chkn/go-musthave-shortener-tpl/internal/app/grpc_handler"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/app/services"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/pb"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/ratelimit"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+)
+
+// healthCheckInterval - период обновления статуса grpc.health.v1.
+const healthCheckInterval = 5 * time.Second
+
+// SetupGRPCServer - подготавливает gRPC сервер: сервис URL, сервис рабочих
+// пространств Workspaces, служебный сервис Admin, grpc.health.v1 и server
+// reflection.
+// Если задан AdminClientCA, отдельный gRPC сервер работает по TLS из
+// tlsConfig и принимает клиентские сертификаты, подписанные этим CA.
+// При общем с HTTP listener TLS обслуживает HTTP сервер (см. ServeUnified).
+// Вызовы Create, CreateBatch и Retrieve ограничиваются limiters.
+// Пользователь определяется по ключу API учетной записи из accounts.
+func SetupGRPCServer(ctx context.Context, service *services.URLService, urlServer pb.URLServer,
+	workspacesServer pb.WorkspacesServer, accounts grpchandler.Authenticator, cfg *configuration.Config, subnet *net.IPNet, tlsConfig *tls.Config,
+	limiters RateLimiters) *grpc.Server {
+
+	method := func(name string) string {
+		return "/" + pb.URL_ServiceDesc.ServiceName + "/" + name
+	}
+	rateLimits := map[string]ratelimit.Limiter{
+		method("Create"):      limiters.Create,
+		method("CreateBatch"): limiters.Create,
+		method("Retrieve"):    limiters.Redirect,
+		method("GetQRCode"):   limiters.Redirect,
+	}
+	opts := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(grpchandler.AdminGuard(subnet), grpchandler.APIKeyAuth(accounts),
+			grpchandler.RateLimit(rateLimits)),
+	}
+	if !cfg.UnifiedListener && cfg.AdminClientCA != "" {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+
+	server := grpc.NewServer(opts...)
+	pb.RegisterURLServer(server, urlServer)
+	pb.RegisterWorkspacesServer(server, workspacesServer)
+	pb.RegisterAdminServer(server, grpchandler.NewAdminHandler(service))
+	healthpb.RegisterHealthServer(server, grpchandler.NewHealthServer(ctx, service.Health, healthCheckInterval))
+	reflection.Register(server)
+	return server
+}
@@ -0,0 +1,78 @@
+package setup
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/soheilhy/cmux"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/grpc"
+)
+
+// ServeUnified - обслуживает HTTP и gRPC на одном listener.
+// Через cmux соединения делятся на TLS и открытые: если передан tlsConfig,
+// на одном порту принимаются оба вида соединений. Открытые соединения
+// поддерживают HTTP/2 без TLS через h2c. Запросы gRPC в обоих случаях
+// передаются в grpcServer по content-type.
+func ServeUnified(ctx context.Context, lis net.Listener, httpServer *http.Server, grpcServer *grpc.Server, tlsConfig *tls.Config) error {
+	m := cmux.New(lis)
+
+	var tlsListener net.Listener
+	if tlsConfig != nil {
+		tlsListener = m.Match(cmux.TLS())
+	}
+	plainListener := m.Match(cmux.Any())
+
+	httpServer.Handler = h2c.NewHandler(UnifiedHandler(httpServer.Handler, grpcServer), &http2.Server{})
+	if tlsConfig != nil {
+		httpServer.TLSConfig = tlsConfig
+		if err := http2.ConfigureServer(httpServer, &http2.Server{}); err != nil {
+			return err
+		}
+	}
+
+	g := errgroup.Group{}
+	g.Go(func() error {
+		if err := httpServer.Serve(plainListener); err != http.ErrServerClosed {
+			return err
+		}
+		return nil
+	})
+	if tlsListener != nil {
+		g.Go(func() error {
+			if err := httpServer.ServeTLS(tlsListener, "", ""); err != http.ErrServerClosed {
+				return err
+			}
+			return nil
+		})
+	}
+	g.Go(func() error {
+		err := m.Serve()
+		if ctx.Err() != nil {
+			return nil
+		}
+		return err
+	})
+	go func() {
+		<-ctx.Done()
+		lis.Close()
+	}()
+	return g.Wait()
+}
+
+// UnifiedHandler - передает запросы gRPC поверх HTTP/2 в grpcServer,
+// остальные - в httpHandler.
+func UnifiedHandler(httpHandler http.Handler, grpcServer *grpc.Server) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ProtoMajor == 2 && strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc") {
+			grpcServer.ServeHTTP(w, r)
+			return
+		}
+		httpHandler.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,73 @@
+package setup
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/p7chkn/go-musthave-shortener-tpl/cmd/shortener/configuration"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/migration"
+)
+
+// MigrateProgressInterval - через сколько прочитанных ссылок выводится ход
+// переноса.
+const MigrateProgressInterval = 1000
+
+// runMigrate - команда migrate-storage: перенос всех ссылок, в том числе
+// удаленных, из хранилища -from в хранилище -to с проверкой количества
+// ссылок и контрольных сумм. Ссылки, которые уже есть в -to, пропускаются,
+// поэтому команду можно запускать повторно, в том числе после сбоя.
+// Рабочие пространства, учетные записи и история изменений не переносятся,
+// ссылки рабочих пространств становятся личными ссылками владельцев.
+func runMigrate(ctx context.Context, cfg *configuration.Config, args []string) error {
+	flags := flag.NewFlagSet("migrate-storage", flag.ContinueOnError)
+	from := flags.String("from", "", "source storage: file:<path> or postgres://<dsn>")
+	to := flags.String("to", "", "target storage: file:<path> or postgres://<dsn>")
+	verifyOnly := flags.Bool("verify", false, "only compare storages without copying")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *from == "" || *to == "" {
+		return errors.New("both -from and -to must be set")
+	}
+	if *from == *to {
+		return errors.New("-from and -to must be different storages")
+	}
+	if path := strings.TrimPrefix(*from, "file:"); path != *from {
+		if _, err := os.Stat(path); err != nil {
+			return err
+		}
+	}
+	source, closeSource, err := openStorage(ctx, *from)
+	if err != nil {
+		return err
+	}
+	defer closeSource()
+	target, closeTarget, err := openStorage(ctx, *to)
+	if err != nil {
+		return err
+	}
+	defer closeTarget()
+
+	if !*verifyOnly {
+		stats, err := migration.Copy(ctx, source, target, func(stats migration.Stats) {
+			if stats.Read%MigrateProgressInterval == 0 {
+				log.Printf("read %d links, copied %d, skipped %d", stats.Read, stats.Copied, stats.Skipped)
+			}
+		})
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "read %d links, copied %d, already present %d\n", stats.Read, stats.Copied, stats.Skipped)
+	}
+	sourceSummary, targetSummary, err := migration.Verify(ctx, source, target)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "source: %s\ntarget: %s\n", sourceSummary, targetSummary)
+	return nil
+}
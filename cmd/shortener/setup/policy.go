@@ -0,0 +1,26 @@
+package setup
+
+import (
+	"net"
+	"time"
+
+	"github.com/p7chkn/go-musthave-shortener-tpl/cmd/shortener/configuration"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/policy"
+)
+
+// BlocklistReloadInterval - период проверки изменений файла блок-листа.
+const BlocklistReloadInterval = 10 * time.Second
+
+// SetupPolicy - создание политики адресов назначения из конфигурации.
+func SetupPolicy(cfg *configuration.Config) (*policy.Engine, error) {
+	patterns, err := policy.ParsePatterns(cfg.Policy.DenyPatterns)
+	if err != nil {
+		return nil, err
+	}
+	rules := policy.Rules{
+		AllowDomains: cfg.Policy.AllowDomains,
+		DenyDomains:  cfg.Policy.DenyDomains,
+		DenyPatterns: patterns,
+	}
+	return policy.New(rules, cfg.Policy.BlockPrivateIPs, cfg.Policy.BlocklistFile, net.DefaultResolver)
+}
@@ -0,0 +1,41 @@
+package setup
+
+import (
+	"database/sql"
+	"log"
+
+	"github.com/p7chkn/go-musthave-shortener-tpl/cmd/shortener/configuration"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/ratelimit"
+)
+
+// RateLimiters - ограничители частоты запросов по классам маршрутов.
+// nil означает, что запросы класса не ограничиваются.
+type RateLimiters struct {
+	// Create - создание ссылок.
+	Create ratelimit.Limiter
+	// Redirect - переход по ссылкам.
+	Redirect ratelimit.Limiter
+}
+
+// SetupRateLimiters - создание ограничителей из конфигурации. Общие для
+// реплик лимиты хранятся в базе данных db, без нее используются лимиты
+// в памяти.
+func SetupRateLimiters(cfg *configuration.Config, db *sql.DB) RateLimiters {
+	if cfg.RateLimit.Shared && db == nil {
+		log.Println("Shared rate limits require database, using in-memory limits")
+	}
+	newLimiter := func(class string, perMinute int) ratelimit.Limiter {
+		switch {
+		case perMinute <= 0:
+			return nil
+		case cfg.RateLimit.Shared && db != nil:
+			return ratelimit.NewPostgresLimiter(db, class, perMinute)
+		default:
+			return ratelimit.NewMemoryLimiter(perMinute)
+		}
+	}
+	return RateLimiters{
+		Create:   newLimiter("create", cfg.RateLimit.Create),
+		Redirect: newLimiter("redirect", cfg.RateLimit.Redirect),
+	}
+}
@@ -0,0 +1,10 @@
+package setup
+
+import "time"
+
+// RetentionInterval - период окончательного удаления ссылок, срок хранения
+// которых после удаления истек.
+const RetentionInterval = time.Hour
+
+// ClickFlushInterval - период сохранения накопленных переходов по ссылкам.
+const ClickFlushInterval = 10 * time.Second
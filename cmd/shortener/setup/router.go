@@ -1,32 +1,153 @@
 package setup
 
 import (
+	"net/http"
+	"path"
+	"strings"
+
 	"github.com/gin-gonic/gin"
-	"github.com/p7chkn/go-musthave-shortener-tpl/cmd/shortener/configuration"
 	"github.com/p7chkn/go-musthave-shortener-tpl/internal/app/handlers"
 	"github.com/p7chkn/go-musthave-shortener-tpl/internal/app/middlewares"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/app/openapi"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/domains"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/session"
 )
 
 // SetupRouter - подготоваливает роутер для обработки запросов.
-func SetupRouter(useCase handlers.URLServiceInterface, cfg *configuration.Config) *gin.Engine {
+// REST шлюз gRPC сервиса (gateway) обслуживает запросы с префиксом /api/v1.
+// Все запросы, включая запросы к шлюзу, проверяются по документу OpenAPI,
+// который отдается на /api/openapi.json. Создание ссылок и переход по ним ограничиваются
+// limiters. Пользователь определяется по токену сессии sessions или по
+// ключу API учетной записи из accounts. Рабочие пространства обслуживает
+// workspaces, вебхуки пользователей - webhooks. Домен ссылок выбирается по
+// заголовку Host запроса из dom.
+func SetupRouter(useCase handlers.URLServiceInterface, accounts AccountService,
+	workspaces handlers.WorkspaceServiceInterface, webhooks handlers.WebhookServiceInterface,
+	sessions *session.Manager, gateway http.Handler, limiters RateLimiters, dom *domains.Domains) *gin.Engine {
 	router := gin.Default()
 
 	handler := handlers.New(useCase)
+	accountHandler := handlers.NewAccountHandler(accounts, sessions)
+	workspaceHandler := handlers.NewWorkspaceHandler(workspaces)
+	webhookHandler := handlers.NewWebhookHandler(webhooks)
+	doc, err := openapi.Load()
+	if err != nil {
+		panic(err)
+	}
 
 	router.Use(middlewares.GzipEncodeMiddleware())
 	router.Use(middlewares.GzipDecodeMiddleware())
-	router.Use(middlewares.CookiMiddleware(cfg))
+	router.Use(middlewares.CookiMiddleware(sessions))
+	router.Use(middlewares.DomainMiddleware(dom))
+	router.Use(middlewares.APIKeyMiddleware(accounts))
+	router.Use(middlewares.ValidationMiddleware(doc))
+
+	createLimit := middlewares.RateLimitMiddleware(limiters.Create)
+	redirectLimit := middlewares.RateLimitMiddleware(limiters.Redirect)
 
-	router.GET("/:id", handler.RetrieveShortURL)
-	router.POST("/", handler.CreateShortURL)
-	router.POST("/api/shorten", handler.ShortenURL)
+	router.GET("/:id", redirectLimit, handler.RetrieveShortURL)
+	router.POST("/:id/unlock", redirectLimit, handler.UnlockShortURL)
+	router.POST("/", createLimit, handler.CreateShortURL)
+	router.POST("/api/shorten", createLimit, handler.ShortenURL)
 	router.GET("/api/user/urls", handler.GetUserURL)
 	router.GET("/ping", handler.PingDB)
-	router.POST("/api/shorten/batch", handler.CreateBatch)
+	router.POST("/api/shorten/batch", createLimit, handler.CreateBatch)
 	router.DELETE("/api/user/urls", handler.DeleteBatch)
+	router.POST("/api/user/urls/restore", handler.RestoreURLs)
+	router.POST("/api/user/urls/import", createLimit, handler.ImportURLs)
+	router.GET("/api/user/urls/export", handler.ExportURLs)
+	router.GET("/api/qr/:file", redirectLimit, handler.QRCode)
+	router.PATCH("/api/user/urls/:id", handler.UpdateURL)
+	router.PUT("/api/user/urls/:id/meta", handler.UpdateMeta)
+	router.PUT("/api/user/urls/:id/redirect", handler.UpdateRedirect)
+	router.PUT("/api/user/urls/:id/access", handler.UpdateAccess)
+	router.GET("/api/user/urls/:id/revisions", handler.GetRevisions)
+	router.POST("/api/user/urls/:id/revisions/:revision/restore", handler.RestoreRevision)
 	router.GET("/api/internal/stats", handler.GetStats)
+	router.GET("/api/openapi.json", openapi.Handler)
+	router.POST("/api/user/register", createLimit, accountHandler.Register)
+	router.POST("/api/user/login", createLimit, accountHandler.Login)
+	router.POST("/api/user/logout", accountHandler.Logout)
+	router.GET("/api/user/keys", accountHandler.ListAPIKeys)
+	router.POST("/api/user/keys", accountHandler.CreateAPIKey)
+	router.DELETE("/api/user/keys/:id", accountHandler.DeleteAPIKey)
+	router.GET("/api/user/webhooks", webhookHandler.ListWebhooks)
+	router.POST("/api/user/webhooks", webhookHandler.CreateWebhook)
+	router.DELETE("/api/user/webhooks/:id", webhookHandler.DeleteWebhook)
+	router.GET("/api/user/webhooks/:id/deliveries", webhookHandler.ListDeliveries)
+	router.POST("/api/user/webhooks/:id/test", createLimit, webhookHandler.TestWebhook)
+	router.POST("/api/workspaces", workspaceHandler.Create)
+	router.GET("/api/workspaces", workspaceHandler.List)
+	router.GET("/api/workspaces/:id/members", workspaceHandler.Members)
+	router.POST("/api/workspaces/:id/members", workspaceHandler.SetMember)
+	router.DELETE("/api/workspaces/:id/members/:user_id", workspaceHandler.RemoveMember)
+	router.POST("/api/workspaces/:id/urls", workspaceHandler.AddURLs)
+
+	if gateway != nil {
+		router.Any("/api/v1/*path", gatewayRateLimit(createLimit, redirectLimit),
+			middlewares.RequestUserMiddleware(accounts, gatewayUserID), gatewayClientIP, gin.WrapH(gateway))
+	}
 
 	router.HandleMethodNotAllowed = true
 
 	return router
 }
+
+// AccountService - сервис учетных записей: обработчики запросов и
+// проверка ключей API.
+type AccountService interface {
+	handlers.AccountServiceInterface
+	middlewares.Authenticator
+}
+
+// gatewayUserID - user_id из пути запроса к REST шлюзу вида
+// /api/v1/users/{user_id}/... Шлюз вызывает сервер напрямую, без
+// перехватчиков gRPC, поэтому пользователь из пути проверяется здесь.
+func gatewayUserID(c *gin.Context) string {
+	path := strings.TrimPrefix(cleanPath(c.Param("path")), "/users/")
+	if path == cleanPath(c.Param("path")) {
+		return ""
+	}
+	return strings.SplitN(path, "/", 2)[0]
+}
+
+// cleanPath - путь без повторных слешей и элементов "." и "..", как его
+// разбирает шлюз.
+func cleanPath(p string) string {
+	return path.Clean("/" + p)
+}
+
+// gatewayClientIP - параметр ip_address запросов к REST шлюзу берется из
+// самого HTTP запроса, а не от клиента: для статистики - из заголовка
+// X-Real-IP, как в GET /api/internal/stats, для перехода - адрес клиента,
+// как при переходе по HTTP. Иначе клиент мог бы назвать адрес из
+// доверенной подсети.
+func gatewayClientIP(c *gin.Context) {
+	query := c.Request.URL.Query()
+	// Шлюз принимает поле и под именем из proto, и под именем JSON.
+	query.Del("ip_address")
+	query.Del("ipAddress")
+	switch p := cleanPath(c.Param("path")); {
+	case p == "/internal/stats":
+		query.Set("ip_address", c.GetHeader("X-Real-IP"))
+	case strings.HasPrefix(p, "/urls/"):
+		query.Set("ip_address", c.ClientIP())
+	}
+	c.Request.URL.RawQuery = query.Encode()
+}
+
+// gatewayRateLimit - выбор ограничителя для запроса к REST шлюзу: создание
+// ссылок - POST на /api/v1/users/{user_id}/urls и /batch, переход -
+// GET на /api/v1/urls/{short_url_id}.
+func gatewayRateLimit(createLimit gin.HandlerFunc, redirectLimit gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		path := cleanPath(c.Param("path"))
+		switch {
+		case c.Request.Method == http.MethodGet && strings.HasPrefix(path, "/urls/"):
+			redirectLimit(c)
+		case c.Request.Method == http.MethodPost && strings.HasPrefix(path, "/users/") &&
+			!strings.HasSuffix(path, "/delete"):
+			createLimit(c)
+		}
+	}
+}
@@ -0,0 +1,229 @@
+package setup
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/p7chkn/go-musthave-shortener-tpl/cmd/shortener/configuration"
+	grpchandler "github.com/p7chkn/go-musthave-shortener-tpl/internal/app/grpc_handler"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/app/handlers"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/app/openapi"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/app/responses"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/domains"
+	custom_errors "github.com/p7chkn/go-musthave-shortener-tpl/internal/errors"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/session"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// ginParam - параметр пути gin вида :id.
+var ginParam = regexp.MustCompile(`:([^/]+)`)
+
+func TestSetupRouterRoutesDocumented(t *testing.T) {
+	doc, err := openapi.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, _ := configuration.GenerateRandom(16)
+	cfg := &configuration.Config{BaseURL: configuration.BaseURL, Keys: [][]byte{key}, Session: configuration.ConfigSession{
+		TTL: configuration.SessionTTL,
+	}}
+	sessions, err := SetupSessions(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	router := SetupRouter(new(handlers.MockUserUseCaseInterface), nil, new(handlers.MockWorkspaceServiceInterface),
+		new(handlers.MockWebhookServiceInterface), sessions, nil, RateLimiters{}, nil)
+
+	for _, route := range router.Routes() {
+		// Маршрут REST шлюза проверяется по операциям gRPC сервиса ниже.
+		if route.Path == "/api/v1/*path" {
+			continue
+		}
+		path := ginParam.ReplaceAllString(route.Path, "{$1}")
+		item := doc.Paths.Find(path)
+		if item == nil || item.GetOperation(route.Method) == nil {
+			t.Errorf("route %s %s is not described in OpenAPI document", route.Method, route.Path)
+		}
+	}
+
+	data, err := os.ReadFile("../../../internal/proto/urls.swagger.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var gateway struct {
+		Paths map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	if err := json.Unmarshal(data, &gateway); err != nil {
+		t.Fatal(err)
+	}
+	if len(gateway.Paths) == 0 {
+		t.Fatal("no gateway operations")
+	}
+	for path, operations := range gateway.Paths {
+		item := doc.Paths.Find(path)
+		for method := range operations {
+			if item == nil || item.GetOperation(strings.ToUpper(method)) == nil {
+				t.Errorf("gateway route %s %s is not described in OpenAPI document", strings.ToUpper(method), path)
+			}
+		}
+	}
+}
+
+func TestGatewayValidation(t *testing.T) {
+	service := new(handlers.MockUserUseCaseInterface)
+	service.On("GetUserURL", mock.Anything, "user-1", mock.Anything).
+		Return(responses.UserURLs{}, custom_errors.NewCustomError(errors.New("no content"), http.StatusNoContent))
+	router, sessions := setupGatewayRouter(t, service)
+	token, _ := sessions.Issue("user-1")
+
+	tests := []struct {
+		name     string
+		method   string
+		target   string
+		body     string
+		wantCode int
+	}{
+		{name: "valid query", method: http.MethodGet, target: "/api/v1/users/user-1/urls?limit=10", wantCode: http.StatusNoContent},
+		{name: "invalid query", method: http.MethodGet, target: "/api/v1/users/user-1/urls?limit=abc", wantCode: http.StatusBadRequest},
+		{
+			name:     "invalid body",
+			method:   http.MethodPost,
+			target:   "/api/v1/users/user-1/urls",
+			body:     `{"originalUrl":5}`,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "invalid body with extra slashes",
+			method:   http.MethodPost,
+			target:   "/api/v1//users/user-1/urls/batch",
+			body:     `{"urls":"abc"}`,
+			wantCode: http.StatusBadRequest,
+		},
+		{name: "unknown route", method: http.MethodGet, target: "/api/v1/unknown", wantCode: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			request.AddCookie(sessions.Cookie(token))
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, request)
+			assert.Equal(t, tt.wantCode, w.Code)
+			if tt.wantCode == http.StatusBadRequest {
+				assert.Equal(t, responses.ProblemContentType, w.Header().Get("Content-Type"))
+			}
+		})
+	}
+	service.AssertNotCalled(t, "CreateURL", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	service.AssertNotCalled(t, "CreateBatch", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// setupGatewayRouter - роутер с REST шлюзом поверх service.
+func setupGatewayRouter(t *testing.T, service *handlers.MockUserUseCaseInterface) (*gin.Engine, *session.Manager) {
+	key, _ := configuration.GenerateRandom(16)
+	cfg := &configuration.Config{BaseURL: configuration.BaseURL, Keys: [][]byte{key}, Session: configuration.ConfigSession{
+		TTL: configuration.SessionTTL,
+	}}
+	sessions, err := SetupSessions(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dom, err := domains.New(configuration.BaseURL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gateway, err := grpchandler.NewGateway(context.Background(), grpchandler.NewGRPCHandler(service, dom))
+	if err != nil {
+		t.Fatal(err)
+	}
+	router := SetupRouter(service, nil, new(handlers.MockWorkspaceServiceInterface),
+		new(handlers.MockWebhookServiceInterface), sessions, gateway, RateLimiters{}, dom)
+	return router, sessions
+}
+
+func TestGatewayRequestUser(t *testing.T) {
+	service := new(handlers.MockUserUseCaseInterface)
+	service.On("GetUserURL", mock.Anything, "user-1", mock.Anything).
+		Return(responses.UserURLs{}, custom_errors.NewCustomError(errors.New("no content"), http.StatusNoContent))
+	router, sessions := setupGatewayRouter(t, service)
+	token, _ := sessions.Issue("user-1")
+
+	tests := []struct {
+		name     string
+		method   string
+		target   string
+		body     string
+		wantCode int
+	}{
+		{name: "own links", method: http.MethodGet, target: "/api/v1/users/user-1/urls", wantCode: http.StatusNoContent},
+		{name: "foreign links", method: http.MethodGet, target: "/api/v1/users/user-2/urls", wantCode: http.StatusForbidden},
+		{name: "foreign links with extra slashes", method: http.MethodGet, target: "/api/v1//users/user-2/urls", wantCode: http.StatusForbidden},
+		{
+			name:     "create for foreign user",
+			method:   http.MethodPost,
+			target:   "/api/v1/users/user-2/urls",
+			body:     `{"originalUrl":"https://example.com/","userId":"user-1"}`,
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "delete foreign links",
+			method:   http.MethodPost,
+			target:   "/api/v1/users/user-2/urls/delete",
+			body:     `{"urls":["abc"]}`,
+			wantCode: http.StatusForbidden,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			request.AddCookie(sessions.Cookie(token))
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, request)
+			assert.Equal(t, tt.wantCode, w.Code)
+		})
+	}
+	service.AssertNotCalled(t, "GetUserURL", mock.Anything, "user-2", mock.Anything)
+	service.AssertNotCalled(t, "CreateURL", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	service.AssertNotCalled(t, "DeleteBatch", mock.Anything, mock.Anything)
+}
+
+func TestGatewayStatsClientIP(t *testing.T) {
+	trusted := net.ParseIP("127.0.0.1")
+	service := new(handlers.MockUserUseCaseInterface)
+	service.On("GetStats", mock.Anything, mock.Anything).Return(func(ctx context.Context, ip net.IP) bool {
+		return trusted.Equal(ip)
+	}, responses.StatResponse{}, nil)
+	router, _ := setupGatewayRouter(t, service)
+
+	tests := []struct {
+		name     string
+		target   string
+		realIP   string
+		wantCode int
+	}{
+		{name: "ip from query", target: "/api/v1/internal/stats?ip_address=127.0.0.1", wantCode: http.StatusForbidden},
+		{name: "ip from json name", target: "/api/v1/internal/stats?ipAddress=127.0.0.1", wantCode: http.StatusForbidden},
+		{name: "untrusted real ip", target: "/api/v1/internal/stats?ip_address=127.0.0.1", realIP: "203.0.113.1", wantCode: http.StatusForbidden},
+		{name: "trusted real ip", target: "/api/v1/internal/stats", realIP: "127.0.0.1", wantCode: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			if tt.realIP != "" {
+				request.Header.Set("X-Real-IP", tt.realIP)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, request)
+			assert.Equal(t, tt.wantCode, w.Code)
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package setup
+
+import (
+	"errors"
+
+	"github.com/p7chkn/go-musthave-shortener-tpl/cmd/shortener/configuration"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/session"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/utils"
+)
+
+// SessionCookieName - имя cookie с токеном сессии.
+const SessionCookieName = "userId"
+
+// SetupSessions - создание Manager для токенов сессии из конфигурации.
+// Токены выдаются первым ключом и проверяются всеми ключами. Cookie старого
+// формата принимаются, пока включен Session.AcceptLegacy, и расшифровываются
+// последним, самым старым ключом.
+func SetupSessions(cfg *configuration.Config) (*session.Manager, error) {
+	if len(cfg.Keys) == 0 {
+		return nil, errors.New("no encryption keys configured")
+	}
+	var sessionKeys []session.Key
+	for _, secret := range cfg.Keys {
+		key, err := session.NewKey(secret)
+		if err != nil {
+			return nil, err
+		}
+		sessionKeys = append(sessionKeys, key)
+	}
+	opts := session.Options{
+		TTL:        cfg.Session.TTL,
+		CookieName: SessionCookieName,
+		Secure:     cfg.Session.SecureCookie || cfg.EnableHTTPS,
+	}
+	if cfg.Session.AcceptLegacy {
+		var err error
+		opts.Legacy, err = utils.New(cfg.Keys[len(cfg.Keys)-1])
+		if err != nil {
+			return nil, err
+		}
+	}
+	return session.NewManager(sessionKeys, opts)
+}
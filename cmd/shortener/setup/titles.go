@@ -0,0 +1,16 @@
+package setup
+
+import (
+	"github.com/p7chkn/go-musthave-shortener-tpl/cmd/shortener/configuration"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/titles"
+)
+
+// SetupTitleFetcher - получение заголовков страниц для ссылок без
+// заголовка, nil если заполнение заголовков выключено. Частные адреса
+// запрещены так же, как в политике адресов назначения.
+func SetupTitleFetcher(cfg *configuration.Config) titles.Fetcher {
+	if !cfg.FetchTitles {
+		return nil
+	}
+	return titles.NewHTTPFetcher(titles.DefaultTimeout, cfg.Policy.BlockPrivateIPs)
+}
@@ -0,0 +1,15 @@
+package setup
+
+import (
+	"github.com/p7chkn/go-musthave-shortener-tpl/cmd/shortener/configuration"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/webhooks"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/workers"
+)
+
+// SetupWebhooks - доставка событий вебхукам в пуле воркеров wp. Адреса
+// вебхуков проверяются так же, как адреса назначения ссылок: частные и
+// локальные адреса запрещены, если это задано политикой.
+func SetupWebhooks(cfg *configuration.Config, wp *workers.WorkerPool, store webhooks.Store) *webhooks.Dispatcher {
+	sender := webhooks.NewSender(webhooks.DefaultTimeout, cfg.Policy.BlockPrivateIPs)
+	return webhooks.NewDispatcher(wp, store, sender, nil)
+}
@@ -0,0 +1,100 @@
+// Package access - ограничение доступа к короткой ссылке: пароль, который
+// нужно ввести перед переходом, и максимальное количество переходов.
+package access
+
+import (
+	"errors"
+	"unicode/utf8"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	// MinPasswordLength - минимальная длина пароля ссылки.
+	MinPasswordLength = 4
+	// MaxPasswordLength - максимальная длина пароля ссылки в байтах,
+	// ограничение bcrypt.
+	MaxPasswordLength = 72
+)
+
+var (
+	// ErrInvalidPassword - пароль не подходит по длине.
+	ErrInvalidPassword = errors.New("password must be from 4 characters to 72 bytes")
+	// ErrInvalidMaxClicks - отрицательное количество переходов.
+	ErrInvalidMaxClicks = errors.New("max_clicks must not be negative")
+	// ErrPasswordRequired - для перехода по ссылке нужен пароль.
+	ErrPasswordRequired = errors.New("password required")
+	// ErrWrongPassword - неверный пароль ссылки.
+	ErrWrongPassword = errors.New("wrong password")
+)
+
+// Access - ограничение доступа к ссылке. Хранится только хеш пароля.
+// Нулевое значение - доступ без ограничений.
+type Access struct {
+	// PasswordHash - bcrypt хеш пароля, пустой - пароль не нужен.
+	PasswordHash string `json:"password_hash,omitempty"`
+	// MaxClicks - максимальное количество переходов, 0 - без ограничения.
+	MaxClicks int64 `json:"max_clicks,omitempty"`
+	// Uses - сколько переходов из MaxClicks уже сделано.
+	Uses int64 `json:"uses,omitempty"`
+}
+
+// New - ограничение доступа с паролем password и максимальным количеством
+// переходов maxClicks. Пустой пароль - ссылка без пароля, maxClicks 0 -
+// без ограничения переходов. Счетчик переходов начинается заново.
+func New(password string, maxClicks int64) (Access, error) {
+	if maxClicks < 0 {
+		return Access{}, ErrInvalidMaxClicks
+	}
+	a := Access{MaxClicks: maxClicks}
+	if password == "" {
+		return a, nil
+	}
+	if utf8.RuneCountInString(password) < MinPasswordLength || len(password) > MaxPasswordLength {
+		return Access{}, ErrInvalidPassword
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return Access{}, err
+	}
+	a.PasswordHash = string(hash)
+	return a, nil
+}
+
+// IsZero - доступ без ограничений.
+func (a Access) IsZero() bool {
+	return a == Access{}
+}
+
+// Protected - для перехода нужен пароль.
+func (a Access) Protected() bool {
+	return a.PasswordHash != ""
+}
+
+// Exhausted - все разрешенные переходы уже сделаны.
+func (a Access) Exhausted() bool {
+	return a.MaxClicks > 0 && a.Uses >= a.MaxClicks
+}
+
+// Remaining - сколько переходов осталось, 0 для ссылки без ограничения.
+func (a Access) Remaining() int64 {
+	if a.MaxClicks == 0 || a.Uses >= a.MaxClicks {
+		return 0
+	}
+	return a.MaxClicks - a.Uses
+}
+
+// CheckPassword - проверка пароля для перехода по ссылке. Для ссылки без
+// пароля проверка всегда успешна.
+func (a Access) CheckPassword(password string) error {
+	if !a.Protected() {
+		return nil
+	}
+	if password == "" {
+		return ErrPasswordRequired
+	}
+	if bcrypt.CompareHashAndPassword([]byte(a.PasswordHash), []byte(password)) != nil {
+		return ErrWrongPassword
+	}
+	return nil
+}
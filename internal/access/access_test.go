@@ -0,0 +1,43 @@
+package access
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNew(t *testing.T) {
+	a, err := New("open sesame", 3)
+	assert.NoError(t, err)
+	assert.True(t, a.Protected())
+	assert.NotContains(t, a.PasswordHash, "open sesame")
+	assert.NoError(t, a.CheckPassword("open sesame"))
+	assert.ErrorIs(t, a.CheckPassword("open"), ErrWrongPassword)
+	assert.ErrorIs(t, a.CheckPassword(""), ErrPasswordRequired)
+
+	a, err = New("", 0)
+	assert.NoError(t, err)
+	assert.True(t, a.IsZero())
+	assert.NoError(t, a.CheckPassword(""))
+
+	_, err = New("abc", 0)
+	assert.ErrorIs(t, err, ErrInvalidPassword)
+	_, err = New(strings.Repeat("a", MaxPasswordLength+1), 0)
+	assert.ErrorIs(t, err, ErrInvalidPassword)
+	_, err = New("", -1)
+	assert.ErrorIs(t, err, ErrInvalidMaxClicks)
+}
+
+func TestAccess_Exhausted(t *testing.T) {
+	assert.False(t, Access{}.Exhausted())
+	assert.Equal(t, int64(0), Access{Uses: 10}.Remaining())
+
+	a := Access{MaxClicks: 2, Uses: 1}
+	assert.False(t, a.Exhausted())
+	assert.Equal(t, int64(1), a.Remaining())
+
+	a.Uses++
+	assert.True(t, a.Exhausted())
+	assert.Equal(t, int64(0), a.Remaining())
+}
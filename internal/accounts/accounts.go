@@ -0,0 +1,122 @@
+// Package accounts - пакет с учетными записями пользователей и ключами API.
+package accounts
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gofrs/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	// MinLoginLength - минимальная длина логина.
+	MinLoginLength = 3
+	// MaxLoginLength - максимальная длина логина.
+	MaxLoginLength = 64
+	// MinPasswordLength - минимальная длина пароля.
+	MinPasswordLength = 8
+	// APIKeyPrefix - префикс ключей API, чтобы их было легко отличить от
+	// других секретов.
+	APIKeyPrefix = "sk_"
+	// apiKeySize - количество случайных байт ключа API.
+	apiKeySize = 32
+	// displayPrefixLength - длина начала ключа, которая показывается в списке
+	// ключей.
+	displayPrefixLength = 8
+)
+
+var (
+	// ErrInvalidLogin - логин не подходит по длине.
+	ErrInvalidLogin = errors.New("login must be from 3 to 64 characters")
+	// ErrInvalidPassword - пароль слишком короткий.
+	ErrInvalidPassword = errors.New("password must be at least 8 characters")
+	// ErrBadCredentials - неверный логин или пароль.
+	ErrBadCredentials = errors.New("invalid login or password")
+)
+
+// Account - учетная запись пользователя. ID совпадает с userId ссылок.
+type Account struct {
+	ID           string    `json:"id"`
+	Login        string    `json:"login"`
+	PasswordHash string    `json:"password_hash"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// APIKey - ключ API учетной записи. Хранится только хеш ключа.
+type APIKey struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"user_id"`
+	Name      string    `json:"name"`
+	Prefix    string    `json:"prefix"`
+	Hash      string    `json:"hash"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// NewAccount - создание учетной записи с проверкой логина и пароля.
+func NewAccount(login string, password string) (Account, error) {
+	login = strings.TrimSpace(login)
+	if length := utf8.RuneCountInString(login); length < MinLoginLength || length > MaxLoginLength {
+		return Account{}, ErrInvalidLogin
+	}
+	if utf8.RuneCountInString(password) < MinPasswordLength {
+		return Account{}, ErrInvalidPassword
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return Account{}, err
+	}
+	id, err := uuid.NewV4()
+	if err != nil {
+		return Account{}, err
+	}
+	return Account{
+		ID:           id.String(),
+		Login:        login,
+		PasswordHash: string(hash),
+		CreatedAt:    time.Now().UTC(),
+	}, nil
+}
+
+// CheckPassword - проверка пароля учетной записи.
+func (a Account) CheckPassword(password string) error {
+	if bcrypt.CompareHashAndPassword([]byte(a.PasswordHash), []byte(password)) != nil {
+		return ErrBadCredentials
+	}
+	return nil
+}
+
+// NewAPIKey - создание ключа API. Возвращает ключ, который показывается
+// пользователю один раз, и запись для хранения.
+func NewAPIKey(userID string, name string) (string, APIKey, error) {
+	secret := make([]byte, apiKeySize)
+	if _, err := rand.Read(secret); err != nil {
+		return "", APIKey{}, err
+	}
+	id, err := uuid.NewV4()
+	if err != nil {
+		return "", APIKey{}, err
+	}
+	plain := APIKeyPrefix + base64.RawURLEncoding.EncodeToString(secret)
+	return plain, APIKey{
+		ID:        id.String(),
+		UserID:    userID,
+		Name:      strings.TrimSpace(name),
+		Prefix:    plain[:len(APIKeyPrefix)+displayPrefixLength],
+		Hash:      HashAPIKey(plain),
+		CreatedAt: time.Now().UTC(),
+	}, nil
+}
+
+// HashAPIKey - хеш ключа API для хранения и поиска. Ключ случайный и
+// длинный, поэтому достаточно SHA-256 без соли.
+func HashAPIKey(plain string) string {
+	sum := sha256.Sum256([]byte(plain))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,39 @@
+package accounts
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewAccount(t *testing.T) {
+	account, err := NewAccount("alice", "correct horse")
+	assert.NoError(t, err)
+	assert.NotEmpty(t, account.ID)
+	assert.NotEqual(t, "correct horse", account.PasswordHash)
+	assert.NoError(t, account.CheckPassword("correct horse"))
+	assert.ErrorIs(t, account.CheckPassword("wrong password"), ErrBadCredentials)
+
+	_, err = NewAccount("al", "correct horse")
+	assert.ErrorIs(t, err, ErrInvalidLogin)
+	_, err = NewAccount(strings.Repeat("a", 65), "correct horse")
+	assert.ErrorIs(t, err, ErrInvalidLogin)
+	_, err = NewAccount("alice", "short")
+	assert.ErrorIs(t, err, ErrInvalidPassword)
+}
+
+func TestNewAPIKey(t *testing.T) {
+	plain, key, err := NewAPIKey("user-1", "ci")
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(plain, "sk_"))
+	assert.True(t, strings.HasPrefix(plain, key.Prefix))
+	assert.Equal(t, HashAPIKey(plain), key.Hash)
+	assert.NotContains(t, key.Hash, plain)
+	assert.Equal(t, "user-1", key.UserID)
+	assert.Equal(t, "ci", key.Name)
+
+	other, _, err := NewAPIKey("user-1", "ci")
+	assert.NoError(t, err)
+	assert.NotEqual(t, plain, other)
+}
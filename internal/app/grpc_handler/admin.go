@@ -0,0 +1,133 @@
+package grpchandler
+
+import (
+	"context"
+	"net"
+	"strings"
+
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/app/responses"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+//go:generate mockery --name=AdminServiceInterface --case camel --inpackage
+
+// AdminServiceInterface - интерфейс служебных операций сервиса.
+type AdminServiceInterface interface {
+	AdminStats(ctx context.Context) (responses.AdminStatResponse, error)
+	FlushCache(ctx context.Context) (bool, error)
+	SetDraining(draining bool)
+	Draining() bool
+	ResizeWorkers(numOfWorkers int) error
+	PurgeURLs(ctx context.Context, urls []string) (int, error)
+}
+
+// NewAdminHandler - создание обработчика служебного сервиса Admin.
+func NewAdminHandler(service AdminServiceInterface) *AdminServer {
+	return &AdminServer{
+		service: service,
+	}
+}
+
+// AdminServer - реализация служебного сервиса Admin.
+type AdminServer struct {
+	pb.UnimplementedAdminServer
+	service AdminServiceInterface
+}
+
+// Stats - расширенная статистика: пользователи, ссылки, состояние пула
+// воркеров и режима drain.
+func (as *AdminServer) Stats(ctx context.Context, in *pb.AdminStatsRequest) (*pb.AdminStatsResponse, error) {
+	stats, err := as.service.AdminStats(ctx)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &pb.AdminStatsResponse{
+		Users:       int32(stats.CountUser),
+		Urls:        int32(stats.CountURL),
+		Workers:     int32(stats.Workers),
+		QueuedTasks: int32(stats.QueuedTasks),
+		Draining:    stats.Draining,
+	}, nil
+}
+
+// FlushCache - сброс данных, которые репозиторий держит в памяти.
+func (as *AdminServer) FlushCache(ctx context.Context, in *pb.FlushCacheRequest) (*pb.FlushCacheResponse, error) {
+	flushed, err := as.service.FlushCache(ctx)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &pb.FlushCacheResponse{
+		Flushed: flushed,
+	}, nil
+}
+
+// SetDrain - включение и выключение режима drain, в котором сервис не
+// принимает новые ссылки и сообщает о неготовности в health check.
+func (as *AdminServer) SetDrain(ctx context.Context, in *pb.SetDrainRequest) (*pb.SetDrainResponse, error) {
+	as.service.SetDraining(in.Draining)
+	return &pb.SetDrainResponse{
+		Draining: as.service.Draining(),
+	}, nil
+}
+
+// ResizeWorkers - изменение количества воркеров в пуле.
+func (as *AdminServer) ResizeWorkers(ctx context.Context, in *pb.ResizeWorkersRequest) (*pb.ResizeWorkersResponse, error) {
+	if err := as.service.ResizeWorkers(int(in.Workers)); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return &pb.ResizeWorkersResponse{
+		Workers: in.Workers,
+	}, nil
+}
+
+// Purge - окончательное удаление ссылок вместе со всеми данными о них.
+func (as *AdminServer) Purge(ctx context.Context, in *pb.PurgeRequest) (*pb.PurgeResponse, error) {
+	if len(in.Urls) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "urls are required")
+	}
+	purged, err := as.service.PurgeURLs(ctx, in.Urls)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &pb.PurgeResponse{
+		Purged: int32(purged),
+	}, nil
+}
+
+// AdminGuard - перехватчик, который пропускает вызовы сервиса Admin только
+// из доверенной подсети или с проверенным клиентским сертификатом.
+func AdminGuard(subnet *net.IPNet) grpc.UnaryServerInterceptor {
+	prefix := "/" + pb.Admin_ServiceDesc.ServiceName + "/"
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if strings.HasPrefix(info.FullMethod, prefix) && !isTrustedPeer(ctx, subnet) {
+			return nil, status.Error(codes.PermissionDenied, "permission denied")
+		}
+		return handler(ctx, req)
+	}
+}
+
+// isTrustedPeer - проверка, что клиент предъявил проверенный сертификат или
+// подключился из доверенной подсети.
+func isTrustedPeer(ctx context.Context, subnet *net.IPNet) bool {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return false
+	}
+	if tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo); ok && len(tlsInfo.State.VerifiedChains) > 0 {
+		return true
+	}
+	if subnet == nil || p.Addr == nil {
+		return false
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return false
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && subnet.Contains(ip)
+}
@@ -0,0 +1,130 @@
+package grpchandler
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net"
+	"reflect"
+	"testing"
+
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/app/responses"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/pb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+func TestAdminServer_Stats(t *testing.T) {
+	serviceMock := new(MockAdminServiceInterface)
+	serviceMock.On("AdminStats", mock.Anything).Return(responses.AdminStatResponse{
+		StatResponse: responses.StatResponse{CountURL: 5, CountUser: 2},
+		Workers:      10,
+		QueuedTasks:  3,
+		Draining:     true,
+	}, nil)
+
+	got, err := NewAdminHandler(serviceMock).Stats(context.Background(), &pb.AdminStatsRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &pb.AdminStatsResponse{Users: 2, Urls: 5, Workers: 10, QueuedTasks: 3, Draining: true}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Stats() got = %v, want %v", got, want)
+	}
+}
+
+func TestAdminServer_ResizeWorkers(t *testing.T) {
+	tests := []struct {
+		name     string
+		workers  int32
+		err      error
+		wantCode codes.Code
+	}{
+		{
+			name:     "success resize",
+			workers:  4,
+			wantCode: codes.OK,
+		},
+		{
+			name:     "invalid resize",
+			workers:  0,
+			err:      errors.New("number of workers must be positive"),
+			wantCode: codes.InvalidArgument,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			serviceMock := new(MockAdminServiceInterface)
+			serviceMock.On("ResizeWorkers", int(tt.workers)).Return(tt.err)
+
+			_, err := NewAdminHandler(serviceMock).ResizeWorkers(context.Background(), &pb.ResizeWorkersRequest{Workers: tt.workers})
+			assert.Equal(t, tt.wantCode, status.Code(err))
+		})
+	}
+}
+
+func TestAdminServer_Purge(t *testing.T) {
+	serviceMock := new(MockAdminServiceInterface)
+	serviceMock.On("PurgeURLs", mock.Anything, []string{"abc", "def"}).Return(1, nil)
+
+	got, err := NewAdminHandler(serviceMock).Purge(context.Background(), &pb.PurgeRequest{Urls: []string{"abc", "def"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, int32(1), got.Purged)
+
+	_, err = NewAdminHandler(serviceMock).Purge(context.Background(), &pb.PurgeRequest{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestAdminGuard(t *testing.T) {
+	_, subnet, _ := net.ParseCIDR("127.0.0.1/24")
+	verified := credentials.TLSInfo{State: tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{}}}}
+	tests := []struct {
+		name     string
+		method   string
+		peer     *peer.Peer
+		wantCode codes.Code
+	}{
+		{
+			name:     "admin from trusted subnet",
+			method:   "/urls.Admin/Stats",
+			peer:     &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("127.0.0.2"), Port: 5000}},
+			wantCode: codes.OK,
+		},
+		{
+			name:     "admin from untrusted subnet",
+			method:   "/urls.Admin/Stats",
+			peer:     &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 5000}},
+			wantCode: codes.PermissionDenied,
+		},
+		{
+			name:     "admin with client certificate",
+			method:   "/urls.Admin/SetDrain",
+			peer:     &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 5000}, AuthInfo: verified},
+			wantCode: codes.OK,
+		},
+		{
+			name:     "url service from untrusted subnet",
+			method:   "/urls.URL/Retrieve",
+			peer:     &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 5000}},
+			wantCode: codes.OK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := peer.NewContext(context.Background(), tt.peer)
+			handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+				return nil, nil
+			}
+			_, err := AdminGuard(subnet)(ctx, nil, &grpc.UnaryServerInfo{FullMethod: tt.method}, handler)
+			assert.Equal(t, tt.wantCode, status.Code(err))
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package grpchandler
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	custom_errors "github.com/p7chkn/go-musthave-shortener-tpl/internal/errors"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// AuthorizationHeader - ключ метаданных с ключом API в виде "Bearer <ключ>".
+const AuthorizationHeader = "authorization"
+
+// Authenticator - проверка ключей API и учетных записей.
+type Authenticator interface {
+	Authenticate(ctx context.Context, apiKey string) (string, error)
+	IsAccount(ctx context.Context, userID string) (bool, error)
+}
+
+// accountKey - ключ контекста с учетной записью, определенной по ключу API.
+type accountKey struct{}
+
+// accountFromContext - учетная запись, определенная по ключу API, или
+// пустая строка, если запрос без ключа.
+func accountFromContext(ctx context.Context) string {
+	accountID, _ := ctx.Value(accountKey{}).(string)
+	return accountID
+}
+
+// APIKeyAuth - перехватчик, определяющий пользователя по ключу API из
+// метаданных authorization. Пустой user_id запроса заполняется учетной
+// записью ключа, чужой user_id отклоняется с PermissionDenied. Учетная
+// запись ключа передается дальше в контексте.
+// Неизвестный ключ и запрос от имени учетной записи без ключа отклоняются
+// с Unauthenticated. Если auth равен nil, перехватчик ничего не делает.
+func APIKeyAuth(auth Authenticator) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		userID := requestUserID(req)
+		if auth == nil || userID == nil {
+			return handler(ctx, req)
+		}
+		apiKey := apiKeyFromContext(ctx)
+		if apiKey == "" {
+			if *userID == "" {
+				return handler(ctx, req)
+			}
+			isAccount, err := auth.IsAccount(ctx, *userID)
+			if err != nil {
+				return nil, status.Error(codes.Internal, err.Error())
+			}
+			if isAccount {
+				return nil, status.Error(codes.Unauthenticated, "api key of this account required")
+			}
+			return handler(ctx, req)
+		}
+		accountID, err := auth.Authenticate(ctx, apiKey)
+		if err != nil {
+			if custom_errors.ParseError(err) == http.StatusUnauthorized {
+				return nil, status.Error(codes.Unauthenticated, err.Error())
+			}
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+		switch *userID {
+		case "":
+			*userID = accountID
+		case accountID:
+		default:
+			return nil, status.Error(codes.PermissionDenied, "user_id does not match api key")
+		}
+		return handler(context.WithValue(ctx, accountKey{}, accountID), req)
+	}
+}
+
+// requestUserID - поле user_id запроса или nil, если в запросе его нет.
+func requestUserID(req interface{}) *string {
+	switch r := req.(type) {
+	case *pb.CreateRequest:
+		return &r.UserId
+	case *pb.GetUserURLsRequest:
+		return &r.UserId
+	case *pb.CreateBatchRequest:
+		return &r.UserId
+	case *pb.DeleteBatchRequest:
+		return &r.UserId
+	case *pb.UpdateRequest:
+		return &r.UserId
+	case *pb.UpdateMetaRequest:
+		return &r.UserId
+	case *pb.UpdateRedirectRequest:
+		return &r.UserId
+	case *pb.UpdateAccessRequest:
+		return &r.UserId
+	case *pb.CreateWorkspaceRequest:
+		return &r.UserId
+	case *pb.ListWorkspacesRequest:
+		return &r.UserId
+	case *pb.ListMembersRequest:
+		return &r.UserId
+	case *pb.SetMemberRequest:
+		return &r.UserId
+	case *pb.RemoveMemberRequest:
+		return &r.UserId
+	case *pb.AddWorkspaceURLsRequest:
+		return &r.UserId
+	default:
+		return nil
+	}
+}
+
+// apiKeyFromContext - ключ API из метаданных authorization.
+func apiKeyFromContext(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	values := md.Get(AuthorizationHeader)
+	if len(values) == 0 {
+		return ""
+	}
+	const prefix = "bearer "
+	if len(values[0]) <= len(prefix) || !strings.EqualFold(values[0][:len(prefix)], prefix) {
+		return ""
+	}
+	return strings.TrimSpace(values[0][len(prefix):])
+}
@@ -0,0 +1,58 @@
+package grpchandler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	custom_errors "github.com/p7chkn/go-musthave-shortener-tpl/internal/errors"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/pb"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+type stubAuthenticator struct{}
+
+func (stubAuthenticator) Authenticate(ctx context.Context, apiKey string) (string, error) {
+	if apiKey == "sk_valid" {
+		return "account-1", nil
+	}
+	return "", custom_errors.NewCustomError(errors.New("invalid api key"), http.StatusUnauthorized)
+}
+
+func (stubAuthenticator) IsAccount(ctx context.Context, userID string) (bool, error) {
+	return userID == "account-1", nil
+}
+
+func TestAPIKeyAuth(t *testing.T) {
+	interceptor := APIKeyAuth(stubAuthenticator{})
+	var gotUserID string
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		gotUserID = req.(*pb.CreateRequest).UserId
+		return nil, nil
+	}
+	call := func(authorization string, userID string) codes.Code {
+		gotUserID = ""
+		ctx := context.Background()
+		if authorization != "" {
+			ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(AuthorizationHeader, authorization))
+		}
+		_, err := interceptor(ctx, &pb.CreateRequest{UserId: userID, OriginalUrl: "https://example.com"},
+			&grpc.UnaryServerInfo{FullMethod: "/urls.URL/Create"}, handler)
+		return status.Code(err)
+	}
+
+	assert.Equal(t, codes.OK, call("Bearer sk_valid", ""))
+	assert.Equal(t, "account-1", gotUserID)
+	assert.Equal(t, codes.OK, call("bearer sk_valid", "account-1"))
+	assert.Equal(t, "account-1", gotUserID)
+	assert.Equal(t, codes.PermissionDenied, call("Bearer sk_valid", "anonymous-1"))
+	assert.Equal(t, codes.Unauthenticated, call("Bearer sk_revoked", ""))
+	assert.Equal(t, codes.Unauthenticated, call("", "account-1"))
+	assert.Equal(t, codes.OK, call("", "anonymous-1"))
+	assert.Equal(t, "anonymous-1", gotUserID)
+}
@@ -0,0 +1,57 @@
+package grpchandler
+
+import (
+	"context"
+
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/domains"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// hostKeys - ключи метаданных с хостом запроса: X-Forwarded-Host, который
+// передает REST шлюз, и :authority вызова gRPC.
+var hostKeys = []string{"x-forwarded-host", ":authority"}
+
+// requestDomain - домен ссылок из dom по хосту запроса.
+func requestDomain(ctx context.Context, dom *domains.Domains) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, key := range hostKeys {
+		if values := md.Get(key); len(values) > 0 {
+			return dom.Domain(values[0])
+		}
+	}
+	return ""
+}
+
+// linkID - id ссылки в хранилище по ее id в домене запроса. Id с
+// разделителем домена - ошибка InvalidArgument.
+func linkID(ctx context.Context, dom *domains.Domains, id string) (string, error) {
+	if err := domains.CheckID(id); err != nil {
+		return "", status.Error(codes.InvalidArgument, err.Error())
+	}
+	return domains.Key(requestDomain(ctx, dom), id), nil
+}
+
+// linkIDs - id ссылок в хранилище по их id в домене запроса. Id с
+// разделителем домена - ошибка InvalidArgument.
+func linkIDs(ctx context.Context, dom *domains.Domains, ids []string) ([]string, error) {
+	domain := requestDomain(ctx, dom)
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		if err := domains.CheckID(id); err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		keys[i] = domains.Key(domain, id)
+	}
+	return keys, nil
+}
+
+// linkDomain - домен новой ссылки: выбранный пользователем domain или, если
+// он не задан, домен запроса.
+func linkDomain(ctx context.Context, dom *domains.Domains, domain string) string {
+	if domain != "" {
+		return domain
+	}
+	return requestDomain(ctx, dom)
+}
@@ -0,0 +1,59 @@
+package grpchandler
+
+import (
+	"context"
+	"testing"
+
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/domains"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestLinkID(t *testing.T) {
+	dom, err := domains.New("http://localhost:8080/", []string{"go.example.com"})
+	require.NoError(t, err)
+	tests := []struct {
+		name string
+		md   metadata.MD
+		want string
+	}{
+		{name: "no metadata", want: "abc"},
+		{name: "gateway host", md: metadata.Pairs("x-forwarded-host", "go.example.com:443"), want: "go.example.com/abc"},
+		{name: "grpc authority", md: metadata.Pairs(":authority", "go.example.com"), want: "go.example.com/abc"},
+		{name: "default host", md: metadata.Pairs("x-forwarded-host", "localhost:8080"), want: "abc"},
+		{name: "unknown host", md: metadata.Pairs(":authority", "other.example.com"), want: "abc"},
+		{
+			name: "forwarded host first",
+			md:   metadata.Pairs("x-forwarded-host", "localhost:8080", ":authority", "go.example.com"),
+			want: "abc",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.md != nil {
+				ctx = metadata.NewIncomingContext(ctx, tt.md)
+			}
+			id, err := linkID(ctx, dom, "abc")
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, id)
+			ids, err := linkIDs(ctx, dom, []string{"abc"})
+			require.NoError(t, err)
+			assert.Equal(t, []string{tt.want}, ids)
+		})
+	}
+}
+
+func TestLinkIDWithDomain(t *testing.T) {
+	dom, err := domains.New("http://localhost:8080/", []string{"go.example.com"})
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	_, err = linkID(ctx, dom, "go.example.com/abc")
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = linkIDs(ctx, dom, []string{"abc", "go.example.com/abc"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
@@ -0,0 +1,43 @@
+package grpchandler
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/pb"
+	"google.golang.org/protobuf/proto"
+)
+
+// NewGateway - создание REST шлюза, который обслуживает JSON API напрямую
+// через реализацию gRPC сервера, без сетевого вызова.
+func NewGateway(ctx context.Context, server pb.URLServer) (http.Handler, error) {
+	mux := runtime.NewServeMux(
+		runtime.WithForwardResponseOption(forwardHTTPCode),
+	)
+	if err := pb.RegisterURLHandlerServer(ctx, mux, server); err != nil {
+		return nil, err
+	}
+	return mux, nil
+}
+
+// forwardHTTPCode - выставляет HTTP код ответа шлюза из метаданных,
+// переданных обработчиком в HTTPCodeHeader.
+func forwardHTTPCode(ctx context.Context, w http.ResponseWriter, _ proto.Message) error {
+	md, ok := runtime.ServerMetadataFromContext(ctx)
+	if !ok {
+		return nil
+	}
+	values := md.HeaderMD.Get(HTTPCodeHeader)
+	if len(values) == 0 {
+		return nil
+	}
+	w.Header().Del("Grpc-Metadata-" + HTTPCodeHeader)
+	code, err := strconv.Atoi(values[0])
+	if err != nil {
+		return err
+	}
+	w.WriteHeader(code)
+	return nil
+}
@@ -0,0 +1,99 @@
+package grpchandler
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/app/handlers"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/app/responses"
+	custom_errors "github.com/p7chkn/go-musthave-shortener-tpl/internal/errors"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/redirect"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestGateway(t *testing.T) {
+	type want struct {
+		code     int
+		response string
+	}
+	tests := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		mockOn func(m *handlers.MockUserUseCaseInterface)
+		want   want
+	}{
+		{
+			name:   "retrieve found",
+			method: http.MethodGet,
+			target: "/api/v1/urls/98fv58Wr3hGGIzm2-aH2zA628Ng=",
+			mockOn: func(m *handlers.MockUserUseCaseInterface) {
+				m.On("Redirect", mock.Anything, "98fv58Wr3hGGIzm2-aH2zA628Ng=", redirect.Visit{Query: url.Values{}}).
+					Return(redirect.Target{URL: "http://iloverestaurant.ru/", Code: http.StatusTemporaryRedirect, Mode: redirect.ModeHTTP}, nil)
+				m.On("RecordClick", "98fv58Wr3hGGIzm2-aH2zA628Ng=", "")
+			},
+			want: want{
+				code:     http.StatusOK,
+				response: `{"redirectUrl":"http://iloverestaurant.ru/","status":"ok","code":307,"mode":"http"}`,
+			},
+		},
+		{
+			name:   "retrieve gone",
+			method: http.MethodGet,
+			target: "/api/v1/urls/98fv58Wr3hGGIzm2-aH2zA628Ng=",
+			mockOn: func(m *handlers.MockUserUseCaseInterface) {
+				m.On("Redirect", mock.Anything, "98fv58Wr3hGGIzm2-aH2zA628Ng=", redirect.Visit{Query: url.Values{}}).
+					Return(redirect.Target{}, custom_errors.NewCustomError(errors.New("deleted"), http.StatusGone))
+			},
+			want: want{
+				code:     http.StatusGone,
+				response: `{"redirectUrl":"","status":"gone","code":0,"mode":""}`,
+			},
+		},
+		{
+			name:   "create conflict",
+			method: http.MethodPost,
+			target: "/api/v1/users/1/urls",
+			body:   `{"originalUrl":"http://iloverestaurant.ru/"}`,
+			mockOn: func(m *handlers.MockUserUseCaseInterface) {
+				m.On("CreateURL", mock.Anything, "http://iloverestaurant.ru/", responses.LinkMeta{}, "", "1").
+					Return("", custom_errors.NewCustomError(errors.New("conflict"), http.StatusConflict))
+			},
+			want: want{
+				code:     http.StatusConflict,
+				response: `{"responseUrl":"","status":"conflict","qr":""}`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			serviceMock := new(handlers.MockUserUseCaseInterface)
+			tt.mockOn(serviceMock)
+
+			gateway, err := NewGateway(ctx, NewGRPCHandler(serviceMock, nil))
+			if err != nil {
+				t.Fatal(err)
+			}
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			gateway.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.want.code, w.Code)
+			assert.Empty(t, w.Header().Get("Grpc-Metadata-"+HTTPCodeHeader))
+			resBody, err := ioutil.ReadAll(w.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.JSONEq(t, tt.want.response, string(resBody))
+		})
+	}
+}
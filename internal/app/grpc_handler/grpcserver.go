@@ -4,96 +4,144 @@ import (
 	"context"
 	"github.com/p7chkn/go-musthave-shortener-tpl/internal/app/handlers"
 	"github.com/p7chkn/go-musthave-shortener-tpl/internal/app/responses"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/domains"
 	custom_errors "github.com/p7chkn/go-musthave-shortener-tpl/internal/errors"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/listing"
 	"github.com/p7chkn/go-musthave-shortener-tpl/internal/pb"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/qr"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/redirect"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
 	"net"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
+	"time"
 )
 
-func NewGRPCHandler(service handlers.URLServiceInterface) *URLServer {
+// HTTPCodeHeader - ключ метаданных, в котором обработчик передает HTTP код
+// ответа для REST шлюза.
+const HTTPCodeHeader = "x-http-code"
+
+func NewGRPCHandler(service handlers.URLServiceInterface, dom *domains.Domains) *URLServer {
 	return &URLServer{
 		service: service,
+		domains: dom,
 	}
 }
 
 type URLServer struct {
 	pb.UnimplementedURLServer
 	service handlers.URLServiceInterface
+	// domains - домены ссылок, домен выбирается по хосту запроса.
+	domains *domains.Domains
 }
 
 func (us *URLServer) Retrieve(ctx context.Context, in *pb.RetrieveRequest) (*pb.RetrieveResponse, error) {
 
-	long, err := us.service.GetURL(ctx, in.ShortUrlId)
+	// Некорректные параметры пропускаются, как при переходе по HTTP.
+	query, _ := url.ParseQuery(in.Query)
+	id, err := linkID(ctx, us.domains, in.ShortUrlId)
+	if err != nil {
+		return nil, err
+	}
+	target, err := us.service.Redirect(ctx, id, redirect.Visit{
+		Query:          query,
+		UserAgent:      in.UserAgent,
+		AcceptLanguage: in.AcceptLanguage,
+		IP:             net.ParseIP(in.IpAddress),
+		Visitor:        in.VisitorId,
+		Password:       in.Password,
+	})
 	if err != nil {
 		statusCode := custom_errors.ParseError(err)
-		switch statusCode {
-		case http.StatusGone:
-			return &pb.RetrieveResponse{
-				Status: "gone",
-			}, nil
-		case http.StatusNotFound:
-			return &pb.RetrieveResponse{
-				Status: "not found",
-			}, nil
-		default:
-			return &pb.RetrieveResponse{
-				Status: "internal server error",
-			}, nil
+		response := &pb.RetrieveResponse{
+			Status: statusFor(ctx, statusCode),
 		}
+		// Для отключенной политикой ссылки адрес возвращается вместе со
+		// статусом forbidden, чтобы клиент мог показать предупреждение.
+		if statusCode == http.StatusForbidden {
+			response.RedirectUrl = target.URL
+		}
+		return response, nil
 	}
+	us.service.RecordClick(id, target.Destination)
 	return &pb.RetrieveResponse{
-		RedirectUrl: long,
-		Status:      "ok",
+		RedirectUrl: target.URL,
+		Status:      statusFor(ctx, http.StatusOK),
+		Code:        int32(target.Code),
+		Mode:        target.Mode,
 	}, nil
 }
 
 func (us *URLServer) Create(ctx context.Context, in *pb.CreateRequest) (*pb.CreateResponse, error) {
-	responseURL, err := us.service.CreateURL(ctx, in.OriginalUrl, in.UserId)
+	meta := responses.LinkMeta{
+		Title:       in.Title,
+		Description: in.Description,
+		Tags:        in.Tags,
+	}
+	responseURL, err := us.service.CreateURL(ctx, in.OriginalUrl, meta, linkDomain(ctx, us.domains, in.Domain), in.UserId)
 	if err != nil {
-		statusCode := custom_errors.ParseError(err)
-		switch statusCode {
-		case http.StatusConflict:
-			return &pb.CreateResponse{
-				Status: "conflict",
-			}, nil
-		default:
-			return &pb.CreateResponse{
-				Status: "internal server error",
-			}, nil
-		}
+		return &pb.CreateResponse{
+			Status: statusFor(ctx, custom_errors.ParseError(err)),
+		}, nil
 	}
-	return &pb.CreateResponse{
-		Status:      "ok",
+	response := &pb.CreateResponse{
+		Status:      statusFor(ctx, http.StatusOK),
 		ResponseUrl: responseURL,
-	}, nil
+	}
+	if in.Qr {
+		response.Qr, _ = qr.DataURL(responseURL)
+	}
+	return response, nil
 }
 
+// GetUserURLs - страница ссылок пользователя с фильтрами и сортировкой.
 func (us *URLServer) GetUserURLs(ctx context.Context, in *pb.GetUserURLsRequest) (*pb.GetUserURLsResponse, error) {
-	urls, err := us.service.GetUserURL(ctx, in.UserId)
+	query, err := listing.Params{
+		Limit:       int(in.Limit),
+		Cursor:      in.Cursor,
+		Domain:      in.Domain,
+		Search:      in.Search,
+		Tag:         in.Tag,
+		CreatedFrom: in.CreatedFrom,
+		CreatedTo:   in.CreatedTo,
+		Sort:        in.Sort,
+	}.Query()
 	if err != nil {
-		statusCode := custom_errors.ParseError(err)
-		switch statusCode {
-		case http.StatusNoContent:
-			return &pb.GetUserURLsResponse{
-				Status: "no content",
-			}, nil
-		default:
-			return &pb.GetUserURLsResponse{
-				Status: "internal server error",
-			}, nil
-		}
+		return &pb.GetUserURLsResponse{
+			Status: statusFor(ctx, http.StatusBadRequest),
+		}, nil
+	}
+	page, err := us.service.GetUserURL(ctx, in.UserId, query)
+	if err != nil {
+		return &pb.GetUserURLsResponse{
+			Status: statusFor(ctx, custom_errors.ParseError(err)),
+		}, nil
 	}
+	urls := page.URLs
 	var result []*pb.GetUserURLsResponse_URL
 	for i := 0; i < len(urls); i++ {
-		result = append(result, &pb.GetUserURLsResponse_URL{
-			OriginalUrl: urls[0].OriginalURL,
-			ShortUrl:    urls[0].ShortURL,
-		})
+		url := &pb.GetUserURLsResponse_URL{
+			OriginalUrl: urls[i].OriginalURL,
+			ShortUrl:    urls[i].ShortURL,
+			Id:          urls[i].ID,
+			WorkspaceId: urls[i].WorkspaceID,
+			Title:       urls[i].Title,
+			Description: urls[i].Description,
+			Tags:        urls[i].Tags,
+			Domain:      urls[i].Domain,
+		}
+		if urls[i].CreatedAt != nil {
+			url.CreatedAt = urls[i].CreatedAt.Format(time.RFC3339Nano)
+		}
+		result = append(result, url)
 	}
 	return &pb.GetUserURLsResponse{
-		Status: "ok",
-		Urls:   result,
+		Status:     statusFor(ctx, http.StatusOK),
+		Urls:       result,
+		NextCursor: page.NextCursor,
 	}, nil
 }
 
@@ -103,50 +151,249 @@ func (us *URLServer) CreateBatch(ctx context.Context, in *pb.CreateBatchRequest)
 		data = append(data, responses.ManyPostURL{
 			CorrelationID: strconv.Itoa(int(in.Urls[i].CorrelationId)),
 			OriginalURL:   in.Urls[i].OriginalUrl,
+			LinkMeta: responses.LinkMeta{
+				Title:       in.Urls[i].Title,
+				Description: in.Urls[i].Description,
+				Tags:        in.Urls[i].Tags,
+			},
+			Domain: linkDomain(ctx, us.domains, in.Urls[i].Domain),
 		})
 	}
 	urls, err := us.service.CreateBatch(ctx, data, in.UserId)
 	if err != nil {
 		return &pb.CreateBatchResponse{
-			Status: "internal server error",
+			Status: statusFor(ctx, custom_errors.ParseError(err)),
 		}, nil
 	}
 	var response []*pb.CreateBatchResponse_URL
 	for i := 0; i < len(urls); i++ {
 		id, _ := strconv.ParseInt(urls[i].CorrelationID, 10, 32)
-		response = append(response, &pb.CreateBatchResponse_URL{
+		url := &pb.CreateBatchResponse_URL{
 			CorrelationId: int32(id),
 			ShortUrl:      urls[i].ShortURL,
-		})
+		}
+		if in.Qr {
+			url.Qr, _ = qr.DataURL(urls[i].ShortURL)
+		}
+		response = append(response, url)
 	}
 	return &pb.CreateBatchResponse{
-		Status: "ok",
+		Status: statusFor(ctx, http.StatusOK),
 		Urls:   response,
 	}, nil
 }
 
 func (us *URLServer) DeleteBatch(ctx context.Context, in *pb.DeleteBatchRequest) (*pb.DeleteBatchResponse, error) {
-	us.service.DeleteBatch(in.Urls, in.UserId)
+	keys, err := linkIDs(ctx, us.domains, in.Urls)
+	if err != nil {
+		return nil, err
+	}
+	us.service.DeleteBatch(keys, in.UserId)
 	return &pb.DeleteBatchResponse{
-		Status: "accepted",
+		Status: statusFor(ctx, http.StatusAccepted),
+	}, nil
+}
+
+// Update - изменение адреса назначения ссылки с сохранением прежнего адреса
+// в истории изменений.
+func (us *URLServer) Update(ctx context.Context, in *pb.UpdateRequest) (*pb.UpdateResponse, error) {
+	id, err := linkID(ctx, us.domains, in.ShortUrlId)
+	if err != nil {
+		return nil, err
+	}
+	url, err := us.service.UpdateURL(ctx, id, in.OriginalUrl, in.UserId)
+	if err != nil {
+		return &pb.UpdateResponse{
+			Status: statusFor(ctx, custom_errors.ParseError(err)),
+		}, nil
+	}
+	return &pb.UpdateResponse{
+		ShortUrl:    url.ShortURL,
+		OriginalUrl: url.OriginalURL,
+		Status:      statusFor(ctx, http.StatusOK),
+	}, nil
+}
+
+// UpdateMeta - замена заголовка, описания и тегов ссылки.
+func (us *URLServer) UpdateMeta(ctx context.Context, in *pb.UpdateMetaRequest) (*pb.UpdateMetaResponse, error) {
+	meta := responses.LinkMeta{
+		Title:       in.Title,
+		Description: in.Description,
+		Tags:        in.Tags,
+	}
+	id, err := linkID(ctx, us.domains, in.ShortUrlId)
+	if err != nil {
+		return nil, err
+	}
+	meta, err = us.service.UpdateMeta(ctx, id, meta, in.UserId)
+	if err != nil {
+		return &pb.UpdateMetaResponse{
+			Status: statusFor(ctx, custom_errors.ParseError(err)),
+		}, nil
+	}
+	return &pb.UpdateMetaResponse{
+		Title:       meta.Title,
+		Description: meta.Description,
+		Tags:        meta.Tags,
+		Status:      statusFor(ctx, http.StatusOK),
+	}, nil
+}
+
+// UpdateRedirect - замена настроек перехода по ссылке.
+func (us *URLServer) UpdateRedirect(ctx context.Context, in *pb.UpdateRedirectRequest) (*pb.UpdateRedirectResponse, error) {
+	settings := redirect.Settings{
+		Code:        int(in.Code),
+		Mode:        in.Mode,
+		Passthrough: in.Passthrough,
+	}
+	if in.Utm != nil {
+		settings.UTM = &redirect.UTM{
+			Source:   in.Utm.Source,
+			Medium:   in.Utm.Medium,
+			Campaign: in.Utm.Campaign,
+			Term:     in.Utm.Term,
+			Content:  in.Utm.Content,
+		}
+	}
+	for _, destination := range in.Destinations {
+		settings.Destinations = append(settings.Destinations, redirect.Destination{
+			URL:    destination.Url,
+			Weight: int(destination.Weight),
+		})
+	}
+	for _, rule := range in.Rules {
+		settings.Rules = append(settings.Rules, redirect.Rule{
+			URL:       rule.Url,
+			Platforms: rule.Platforms,
+			Devices:   rule.Devices,
+			Countries: rule.Countries,
+			Languages: rule.Languages,
+		})
+	}
+	id, err := linkID(ctx, us.domains, in.ShortUrlId)
+	if err != nil {
+		return nil, err
+	}
+	settings, err = us.service.UpdateRedirect(ctx, id, settings, in.UserId)
+	if err != nil {
+		return &pb.UpdateRedirectResponse{
+			Status: statusFor(ctx, custom_errors.ParseError(err)),
+		}, nil
+	}
+	response := &pb.UpdateRedirectResponse{
+		Code:        int32(settings.Code),
+		Mode:        settings.Mode,
+		Passthrough: settings.Passthrough,
+		Status:      statusFor(ctx, http.StatusOK),
+	}
+	if settings.UTM != nil {
+		response.Utm = &pb.UTM{
+			Source:   settings.UTM.Source,
+			Medium:   settings.UTM.Medium,
+			Campaign: settings.UTM.Campaign,
+			Term:     settings.UTM.Term,
+			Content:  settings.UTM.Content,
+		}
+	}
+	for _, destination := range settings.Destinations {
+		response.Destinations = append(response.Destinations, &pb.Destination{
+			Url:    destination.URL,
+			Weight: int32(destination.Weight),
+		})
+	}
+	for _, rule := range settings.Rules {
+		response.Rules = append(response.Rules, &pb.RedirectRule{
+			Url:       rule.URL,
+			Platforms: rule.Platforms,
+			Devices:   rule.Devices,
+			Countries: rule.Countries,
+			Languages: rule.Languages,
+		})
+	}
+	return response, nil
+}
+
+// UpdateAccess - замена ограничения доступа к ссылке: пароля и
+// максимального количества переходов.
+func (us *URLServer) UpdateAccess(ctx context.Context, in *pb.UpdateAccessRequest) (*pb.UpdateAccessResponse, error) {
+	id, err := linkID(ctx, us.domains, in.ShortUrlId)
+	if err != nil {
+		return nil, err
+	}
+	state, err := us.service.UpdateAccess(ctx, id, responses.LinkAccess{
+		Password:  in.Password,
+		MaxClicks: in.MaxClicks,
+	}, in.UserId)
+	if err != nil {
+		return &pb.UpdateAccessResponse{
+			Status: statusFor(ctx, custom_errors.ParseError(err)),
+		}, nil
+	}
+	return &pb.UpdateAccessResponse{
+		Protected: state.Protected,
+		MaxClicks: state.MaxClicks,
+		Remaining: state.Remaining,
+		Status:    statusFor(ctx, http.StatusOK),
+	}, nil
+}
+
+// GetQRCode - QR код короткой ссылки, по умолчанию в формате PNG.
+// Незаданные параметры изображения принимают значения по умолчанию.
+func (us *URLServer) GetQRCode(ctx context.Context, in *pb.GetQRCodeRequest) (*pb.GetQRCodeResponse, error) {
+	opts := qr.DefaultOptions()
+	if in.Size != 0 {
+		opts.Size = int(in.Size)
+	}
+	if in.Margin != nil {
+		opts.Margin = int(*in.Margin)
+	}
+	if in.Level != "" {
+		opts.Level = strings.ToUpper(in.Level)
+	}
+	format := strings.ToLower(in.Format)
+	if format == "" {
+		format = qr.FormatPNG
+	}
+	id, err := linkID(ctx, us.domains, in.ShortUrlId)
+	if err != nil {
+		return nil, err
+	}
+	image, err := us.service.QRCode(ctx, id, format, opts)
+	if err != nil {
+		return &pb.GetQRCodeResponse{
+			Status: statusFor(ctx, custom_errors.ParseError(err)),
+		}, nil
+	}
+	return &pb.GetQRCodeResponse{
+		Image:       image,
+		ContentType: qr.ContentType(format),
+		Status:      statusFor(ctx, http.StatusOK),
 	}, nil
 }
 
 func (us *URLServer) GetStats(ctx context.Context, in *pb.GetStatsRequest) (*pb.GetStatsResponse, error) {
-	hasPermission, response, err := us.service.GetStats(ctx, net.IP(in.IpAddress))
+	hasPermission, response, err := us.service.GetStats(ctx, net.ParseIP(in.IpAddress))
 	if !hasPermission {
 		return &pb.GetStatsResponse{
-			Status: "forbidden",
+			Status: statusFor(ctx, http.StatusForbidden),
 		}, nil
 	}
 	if err != nil {
 		return &pb.GetStatsResponse{
-			Status: "bad request",
+			Status: statusFor(ctx, http.StatusBadRequest),
 		}, nil
 	}
 	return &pb.GetStatsResponse{
-		Status: "ok",
+		Status: statusFor(ctx, http.StatusOK),
 		Users:  int32(response.CountUser),
 		Urls:   int32(response.CountURL),
 	}, nil
 }
+
+// statusFor - единое для gRPC и REST шлюза отображение HTTP кода в статус
+// ответа. Код дополнительно передается в заголовке HTTPCodeHeader, чтобы
+// шлюз ответил тем же кодом, что и HTTP обработчики.
+func statusFor(ctx context.Context, code int) string {
+	_ = grpc.SetHeader(ctx, metadata.Pairs(HTTPCodeHeader, strconv.Itoa(code)))
+	return strings.ToLower(http.StatusText(code))
+}
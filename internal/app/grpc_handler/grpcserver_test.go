@@ -3,13 +3,18 @@ package grpchandler
 import (
 	"context"
 	"errors"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/access"
 	"github.com/p7chkn/go-musthave-shortener-tpl/internal/app/handlers"
 	"github.com/p7chkn/go-musthave-shortener-tpl/internal/app/responses"
 	custom_errors "github.com/p7chkn/go-musthave-shortener-tpl/internal/errors"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/listing"
 	"github.com/p7chkn/go-musthave-shortener-tpl/internal/pb"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/qr"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/redirect"
 	"github.com/stretchr/testify/mock"
 	"net"
 	"net/http"
+	"net/url"
 	"reflect"
 	"testing"
 )
@@ -23,6 +28,7 @@ func TestURLServer_Create(t *testing.T) {
 	tests := []struct {
 		name    string
 		query   string
+		meta    responses.LinkMeta
 		request *pb.CreateRequest
 		result  result
 		want    *pb.CreateResponse
@@ -44,6 +50,24 @@ func TestURLServer_Create(t *testing.T) {
 				ResponseUrl: "http://localhost:8080/98fv58Wr3hGGIzm2-aH2zA628Ng=",
 			},
 		},
+		{
+			name:  "POST with title and tags",
+			query: "http://iloverestaurant.ru/",
+			meta:  responses.LinkMeta{Title: "Restaurant", Tags: []string{"food"}},
+			request: &pb.CreateRequest{
+				OriginalUrl: "http://iloverestaurant.ru/",
+				UserId:      "1",
+				Title:       "Restaurant",
+				Tags:        []string{"food"},
+			},
+			result: result{
+				res: "http://localhost:8080/98fv58Wr3hGGIzm2-aH2zA628Ng=",
+			},
+			want: &pb.CreateResponse{
+				Status:      "ok",
+				ResponseUrl: "http://localhost:8080/98fv58Wr3hGGIzm2-aH2zA628Ng=",
+			},
+		},
 		{
 			name:  "conflict POST",
 			query: "http://iloverestaurant.ru/",
@@ -65,10 +89,10 @@ func TestURLServer_Create(t *testing.T) {
 			ctx := context.Background()
 			serviceMock := new(handlers.MockUserUseCaseInterface)
 
-			serviceMock.On("CreateURL", mock.Anything, tt.query, mock.Anything).
+			serviceMock.On("CreateURL", mock.Anything, tt.query, tt.meta, "", mock.Anything).
 				Return(tt.result.res, tt.result.err)
 
-			us := NewGRPCHandler(serviceMock)
+			us := NewGRPCHandler(serviceMock, nil)
 			got, err := us.Create(ctx, tt.request)
 
 			if (err != nil) != tt.wantErr {
@@ -174,7 +198,7 @@ func TestURLServer_CreateBatch(t *testing.T) {
 			serviceMock.On("CreateBatch", mock.Anything, tt.query, mock.Anything).
 				Return(tt.result.res, tt.result.err)
 
-			us := NewGRPCHandler(serviceMock)
+			us := NewGRPCHandler(serviceMock, nil)
 			got, err := us.CreateBatch(ctx, tt.request)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("CreateBatch() error = %v, wantErr %v", err, tt.wantErr)
@@ -205,6 +229,14 @@ func TestURLServer_DeleteBatch(t *testing.T) {
 				Status: "accepted",
 			},
 		},
+		{
+			name: "urls of other domain",
+			request: &pb.DeleteBatchRequest{
+				UserId: "1",
+				Urls:   []string{"1", "go.example.com/2"},
+			},
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -213,7 +245,7 @@ func TestURLServer_DeleteBatch(t *testing.T) {
 
 			serviceMock.On("DeleteBatch", mock.Anything, mock.Anything).Return(nil)
 
-			us := NewGRPCHandler(serviceMock)
+			us := NewGRPCHandler(serviceMock, nil)
 			got, err := us.DeleteBatch(ctx, tt.request)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("DeleteBatch() error = %v, wantErr %v", err, tt.wantErr)
@@ -226,6 +258,344 @@ func TestURLServer_DeleteBatch(t *testing.T) {
 	}
 }
 
+func TestURLServer_Update(t *testing.T) {
+	type result struct {
+		res responses.GetURL
+		err error
+	}
+
+	tests := []struct {
+		name    string
+		request *pb.UpdateRequest
+		result  result
+		want    *pb.UpdateResponse
+		wantErr bool
+	}{
+		{
+			name: "success update",
+			request: &pb.UpdateRequest{
+				UserId:      "1",
+				ShortUrlId:  "abc",
+				OriginalUrl: "https://example.com/new",
+			},
+			result: result{
+				res: responses.GetURL{
+					ShortURL:    "http://localhost:8080/abc",
+					OriginalURL: "https://example.com/new",
+				},
+			},
+			want: &pb.UpdateResponse{
+				ShortUrl:    "http://localhost:8080/abc",
+				OriginalUrl: "https://example.com/new",
+				Status:      "ok",
+			},
+		},
+		{
+			name: "update foreign link",
+			request: &pb.UpdateRequest{
+				UserId:      "2",
+				ShortUrlId:  "abc",
+				OriginalUrl: "https://example.com/new",
+			},
+			result: result{
+				err: custom_errors.NewCustomError(errors.New("url not found"), http.StatusNotFound),
+			},
+			want: &pb.UpdateResponse{
+				Status: "not found",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			serviceMock := new(handlers.MockUserUseCaseInterface)
+
+			serviceMock.On("UpdateURL", mock.Anything, tt.request.ShortUrlId, tt.request.OriginalUrl, tt.request.UserId).
+				Return(tt.result.res, tt.result.err)
+
+			us := NewGRPCHandler(serviceMock, nil)
+			got, err := us.Update(ctx, tt.request)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Update() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Update() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestURLServer_UpdateMeta(t *testing.T) {
+	type result struct {
+		res responses.LinkMeta
+		err error
+	}
+
+	tests := []struct {
+		name    string
+		request *pb.UpdateMetaRequest
+		meta    responses.LinkMeta
+		result  result
+		want    *pb.UpdateMetaResponse
+	}{
+		{
+			name: "success update",
+			request: &pb.UpdateMetaRequest{
+				UserId:     "1",
+				ShortUrlId: "abc",
+				Title:      "Docs",
+				Tags:       []string{"Go"},
+			},
+			meta: responses.LinkMeta{Title: "Docs", Tags: []string{"Go"}},
+			result: result{
+				res: responses.LinkMeta{Title: "Docs", Tags: []string{"go"}},
+			},
+			want: &pb.UpdateMetaResponse{
+				Title:  "Docs",
+				Tags:   []string{"go"},
+				Status: "ok",
+			},
+		},
+		{
+			name: "update foreign link",
+			request: &pb.UpdateMetaRequest{
+				UserId:     "2",
+				ShortUrlId: "abc",
+			},
+			result: result{
+				err: custom_errors.NewCustomError(errors.New("url not found"), http.StatusNotFound),
+			},
+			want: &pb.UpdateMetaResponse{
+				Status: "not found",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			serviceMock := new(handlers.MockUserUseCaseInterface)
+
+			serviceMock.On("UpdateMeta", mock.Anything, tt.request.ShortUrlId, tt.meta, tt.request.UserId).
+				Return(tt.result.res, tt.result.err)
+
+			us := NewGRPCHandler(serviceMock, nil)
+			got, err := us.UpdateMeta(ctx, tt.request)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("UpdateMeta() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestURLServer_UpdateRedirect(t *testing.T) {
+	type result struct {
+		res redirect.Settings
+		err error
+	}
+
+	tests := []struct {
+		name     string
+		request  *pb.UpdateRedirectRequest
+		settings redirect.Settings
+		result   result
+		want     *pb.UpdateRedirectResponse
+	}{
+		{
+			name: "success update",
+			request: &pb.UpdateRedirectRequest{
+				UserId:      "1",
+				ShortUrlId:  "abc",
+				Code:        http.StatusFound,
+				Mode:        "META",
+				Passthrough: true,
+				Utm:         &pb.UTM{Source: " mail "},
+			},
+			settings: redirect.Settings{Code: http.StatusFound, Mode: "META", Passthrough: true, UTM: &redirect.UTM{Source: " mail "}},
+			result: result{
+				res: redirect.Settings{Code: http.StatusFound, Mode: redirect.ModeMeta, Passthrough: true, UTM: &redirect.UTM{Source: "mail"}},
+			},
+			want: &pb.UpdateRedirectResponse{
+				Code:        http.StatusFound,
+				Mode:        redirect.ModeMeta,
+				Passthrough: true,
+				Utm:         &pb.UTM{Source: "mail"},
+				Status:      "ok",
+			},
+		},
+		{
+			name: "rules",
+			request: &pb.UpdateRedirectRequest{
+				UserId:     "1",
+				ShortUrlId: "abc",
+				Rules: []*pb.RedirectRule{
+					{Url: "https://apps.apple.com/", Platforms: []string{"iOS"}},
+					{Url: "https://example.de/", Countries: []string{"de"}, Languages: []string{"de"}},
+				},
+			},
+			settings: redirect.Settings{Rules: []redirect.Rule{
+				{URL: "https://apps.apple.com/", Platforms: []string{"iOS"}},
+				{URL: "https://example.de/", Countries: []string{"de"}, Languages: []string{"de"}},
+			}},
+			result: result{
+				res: redirect.Settings{Rules: []redirect.Rule{
+					{URL: "https://apps.apple.com/", Platforms: []string{redirect.PlatformIOS}},
+					{URL: "https://example.de/", Countries: []string{"DE"}, Languages: []string{"de"}},
+				}},
+			},
+			want: &pb.UpdateRedirectResponse{
+				Rules: []*pb.RedirectRule{
+					{Url: "https://apps.apple.com/", Platforms: []string{redirect.PlatformIOS}},
+					{Url: "https://example.de/", Countries: []string{"DE"}, Languages: []string{"de"}},
+				},
+				Status: "ok",
+			},
+		},
+		{
+			name: "split destinations",
+			request: &pb.UpdateRedirectRequest{
+				UserId:     "1",
+				ShortUrlId: "abc",
+				Destinations: []*pb.Destination{
+					{Url: "https://example.com/a", Weight: 1},
+					{Url: "https://example.com/b"},
+				},
+			},
+			settings: redirect.Settings{Destinations: []redirect.Destination{
+				{URL: "https://example.com/a", Weight: 1},
+				{URL: "https://example.com/b"},
+			}},
+			result: result{
+				res: redirect.Settings{Destinations: []redirect.Destination{
+					{URL: "https://example.com/a", Weight: 1},
+					{URL: "https://example.com/b", Weight: 1},
+				}},
+			},
+			want: &pb.UpdateRedirectResponse{
+				Destinations: []*pb.Destination{
+					{Url: "https://example.com/a", Weight: 1},
+					{Url: "https://example.com/b", Weight: 1},
+				},
+				Status: "ok",
+			},
+		},
+		{
+			name: "invalid code",
+			request: &pb.UpdateRedirectRequest{
+				UserId:     "1",
+				ShortUrlId: "abc",
+				Code:       http.StatusOK,
+			},
+			settings: redirect.Settings{Code: http.StatusOK},
+			result: result{
+				err: custom_errors.NewCustomError(redirect.ErrInvalidCode, http.StatusBadRequest),
+			},
+			want: &pb.UpdateRedirectResponse{
+				Status: "bad request",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			serviceMock := new(handlers.MockUserUseCaseInterface)
+
+			serviceMock.On("UpdateRedirect", mock.Anything, tt.request.ShortUrlId, tt.settings, tt.request.UserId).
+				Return(tt.result.res, tt.result.err)
+
+			us := NewGRPCHandler(serviceMock, nil)
+			got, err := us.UpdateRedirect(ctx, tt.request)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("UpdateRedirect() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestURLServer_GetQRCode(t *testing.T) {
+	type result struct {
+		res []byte
+		err error
+	}
+	margin := int32(0)
+
+	tests := []struct {
+		name    string
+		request *pb.GetQRCodeRequest
+		format  string
+		opts    qr.Options
+		result  result
+		want    *pb.GetQRCodeResponse
+	}{
+		{
+			name:    "png with defaults",
+			request: &pb.GetQRCodeRequest{ShortUrlId: "abc"},
+			format:  qr.FormatPNG,
+			opts:    qr.DefaultOptions(),
+			result:  result{res: []byte("png")},
+			want: &pb.GetQRCodeResponse{
+				Image:       []byte("png"),
+				ContentType: "image/png",
+				Status:      "ok",
+			},
+		},
+		{
+			name: "svg with options",
+			request: &pb.GetQRCodeRequest{
+				ShortUrlId: "abc",
+				Format:     "SVG",
+				Size:       128,
+				Margin:     &margin,
+				Level:      "h",
+			},
+			format: qr.FormatSVG,
+			opts:   qr.Options{Size: 128, Margin: 0, Level: "H"},
+			result: result{res: []byte("<svg/>")},
+			want: &pb.GetQRCodeResponse{
+				Image:       []byte("<svg/>"),
+				ContentType: "image/svg+xml",
+				Status:      "ok",
+			},
+		},
+		{
+			name:    "missing link",
+			request: &pb.GetQRCodeRequest{ShortUrlId: "abc"},
+			format:  qr.FormatPNG,
+			opts:    qr.DefaultOptions(),
+			result: result{
+				err: custom_errors.NewCustomError(errors.New("url not found"), http.StatusNotFound),
+			},
+			want: &pb.GetQRCodeResponse{
+				Status: "not found",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			serviceMock := new(handlers.MockUserUseCaseInterface)
+
+			serviceMock.On("QRCode", mock.Anything, tt.request.ShortUrlId, tt.format, tt.opts).
+				Return(tt.result.res, tt.result.err)
+
+			us := NewGRPCHandler(serviceMock, nil)
+			got, err := us.GetQRCode(ctx, tt.request)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetQRCode() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestURLServer_GetStats(t *testing.T) {
 	type result struct {
 		hasPermission bool
@@ -242,7 +612,7 @@ func TestURLServer_GetStats(t *testing.T) {
 	}{
 		{
 			name:  "success get stats",
-			query: net.IP("127.0.0.1"),
+			query: net.ParseIP("127.0.0.1"),
 			request: &pb.GetStatsRequest{
 				IpAddress: "127.0.0.1",
 			},
@@ -262,7 +632,7 @@ func TestURLServer_GetStats(t *testing.T) {
 		},
 		{
 			name:  "forbidden get stats",
-			query: net.IP("127.0.0.1"),
+			query: net.ParseIP("127.0.0.1"),
 			request: &pb.GetStatsRequest{
 				IpAddress: "127.0.0.1",
 			},
@@ -284,7 +654,7 @@ func TestURLServer_GetStats(t *testing.T) {
 			serviceMock.On("GetStats", mock.Anything, tt.query).
 				Return(tt.result.hasPermission, tt.result.res, tt.result.err)
 
-			us := NewGRPCHandler(serviceMock)
+			us := NewGRPCHandler(serviceMock, nil)
 			got, err := us.GetStats(ctx, tt.request)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("GetStats() error = %v, wantErr %v", err, tt.wantErr)
@@ -333,6 +703,38 @@ func TestURLServer_GetUserURLs(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "success get many users urls",
+			result: result{
+				res: []responses.GetURL{
+					{
+						ShortURL:    "http://localhost:8080/1yhVmSPGQlZn3EnrI2kd7Oxu5UM=",
+						OriginalURL: "http://hbqouwjbx5jl.ru/lkm0skvkix1ejv",
+					},
+					{
+						ShortURL:    "http://localhost:8080/RrbgmrELxXSzwnYKBcJInKtp-_I=",
+						OriginalURL: "https://twitter.com/home",
+					},
+				},
+				err: nil,
+			},
+			request: &pb.GetUserURLsRequest{
+				UserId: "1",
+			},
+			want: &pb.GetUserURLsResponse{
+				Status: "ok",
+				Urls: []*pb.GetUserURLsResponse_URL{
+					{
+						ShortUrl:    "http://localhost:8080/1yhVmSPGQlZn3EnrI2kd7Oxu5UM=",
+						OriginalUrl: "http://hbqouwjbx5jl.ru/lkm0skvkix1ejv",
+					},
+					{
+						ShortUrl:    "http://localhost:8080/RrbgmrELxXSzwnYKBcJInKtp-_I=",
+						OriginalUrl: "https://twitter.com/home",
+					},
+				},
+			},
+		},
 		{
 			name: "no content get users urls",
 			result: result{
@@ -352,10 +754,10 @@ func TestURLServer_GetUserURLs(t *testing.T) {
 			ctx := context.Background()
 			serviceMock := new(handlers.MockUserUseCaseInterface)
 
-			serviceMock.On("GetUserURL", mock.Anything, mock.Anything).
-				Return(tt.result.res, tt.result.err)
+			serviceMock.On("GetUserURL", mock.Anything, mock.Anything, listing.Query{}).
+				Return(responses.UserURLs{URLs: tt.result.res}, tt.result.err)
 
-			us := NewGRPCHandler(serviceMock)
+			us := NewGRPCHandler(serviceMock, nil)
 			got, err := us.GetUserURLs(ctx, tt.request)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("GetUserURLs() error = %v, wantErr %v", err, tt.wantErr)
@@ -368,6 +770,74 @@ func TestURLServer_GetUserURLs(t *testing.T) {
 	}
 }
 
+func TestURLServer_UpdateAccess(t *testing.T) {
+	type result struct {
+		res responses.AccessState
+		err error
+	}
+
+	tests := []struct {
+		name    string
+		request *pb.UpdateAccessRequest
+		req     responses.LinkAccess
+		result  result
+		want    *pb.UpdateAccessResponse
+	}{
+		{
+			name: "success update",
+			request: &pb.UpdateAccessRequest{
+				UserId:     "1",
+				ShortUrlId: "abc",
+				Password:   "secret",
+				MaxClicks:  3,
+			},
+			req: responses.LinkAccess{Password: "secret", MaxClicks: 3},
+			result: result{
+				res: responses.AccessState{Protected: true, MaxClicks: 3, Remaining: 3},
+			},
+			want: &pb.UpdateAccessResponse{
+				Protected: true,
+				MaxClicks: 3,
+				Remaining: 3,
+				Status:    "ok",
+			},
+		},
+		{
+			name: "negative max clicks",
+			request: &pb.UpdateAccessRequest{
+				UserId:     "1",
+				ShortUrlId: "abc",
+				MaxClicks:  -1,
+			},
+			req: responses.LinkAccess{MaxClicks: -1},
+			result: result{
+				err: custom_errors.NewCustomError(access.ErrInvalidMaxClicks, http.StatusBadRequest),
+			},
+			want: &pb.UpdateAccessResponse{
+				Status: "bad request",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			serviceMock := new(handlers.MockUserUseCaseInterface)
+
+			serviceMock.On("UpdateAccess", mock.Anything, tt.request.ShortUrlId, tt.req, tt.request.UserId).
+				Return(tt.result.res, tt.result.err)
+
+			us := NewGRPCHandler(serviceMock, nil)
+			got, err := us.UpdateAccess(ctx, tt.request)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("UpdateAccess() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestURLServer_Retrieve(t *testing.T) {
 	type result struct {
 		res string
@@ -378,6 +848,7 @@ func TestURLServer_Retrieve(t *testing.T) {
 		name    string
 		query   string
 		request *pb.RetrieveRequest
+		visit   redirect.Visit
 		result  result
 		want    *pb.RetrieveResponse
 		wantErr bool
@@ -409,6 +880,53 @@ func TestURLServer_Retrieve(t *testing.T) {
 			want: &pb.RetrieveResponse{
 				Status:      "ok",
 				RedirectUrl: "98fv58Wr3hGGIzm2-aH2zA628Ng=",
+				Code:        http.StatusTemporaryRedirect,
+				Mode:        redirect.ModeHTTP,
+			},
+		},
+		{
+			name:  "GET with query",
+			query: "98fv58Wr3hGGIzm2-aH2zA628Ng=",
+			request: &pb.RetrieveRequest{
+				ShortUrlId: "98fv58Wr3hGGIzm2-aH2zA628Ng=",
+				Query:      "a=1&b=%20",
+			},
+			visit: redirect.Visit{Query: url.Values{"a": {"1"}, "b": {" "}}},
+			result: result{
+				res: "http://iloverestaurant.ru/?a=1&b=+",
+			},
+			want: &pb.RetrieveResponse{
+				Status:      "ok",
+				RedirectUrl: "http://iloverestaurant.ru/?a=1&b=+",
+				Code:        http.StatusTemporaryRedirect,
+				Mode:        redirect.ModeHTTP,
+			},
+		},
+		{
+			name:  "GET with visitor",
+			query: "98fv58Wr3hGGIzm2-aH2zA628Ng=",
+			request: &pb.RetrieveRequest{
+				ShortUrlId:     "98fv58Wr3hGGIzm2-aH2zA628Ng=",
+				UserAgent:      "Mozilla/5.0 (Linux; Android 14) Mobile",
+				AcceptLanguage: "de",
+				IpAddress:      "203.0.113.7",
+				VisitorId:      "visitor",
+			},
+			visit: redirect.Visit{
+				Query:          url.Values{},
+				UserAgent:      "Mozilla/5.0 (Linux; Android 14) Mobile",
+				AcceptLanguage: "de",
+				IP:             net.ParseIP("203.0.113.7"),
+				Visitor:        "visitor",
+			},
+			result: result{
+				res: "https://play.google.com/",
+			},
+			want: &pb.RetrieveResponse{
+				Status:      "ok",
+				RedirectUrl: "https://play.google.com/",
+				Code:        http.StatusTemporaryRedirect,
+				Mode:        redirect.ModeHTTP,
 			},
 		},
 		{
@@ -425,15 +943,51 @@ func TestURLServer_Retrieve(t *testing.T) {
 				Status: "internal server error",
 			},
 		},
+		{
+			name:  "GET link disabled by policy",
+			query: "98fv58Wr3hGGIzm2-aH2zA628Ng=",
+			request: &pb.RetrieveRequest{
+				ShortUrlId: "98fv58Wr3hGGIzm2-aH2zA628Ng=",
+			},
+			result: result{
+				res: "http://phishing.example/",
+				err: custom_errors.NewCustomError(errors.New("link is disabled by policy"), http.StatusForbidden),
+			},
+			want: &pb.RetrieveResponse{
+				Status:      "forbidden",
+				RedirectUrl: "http://phishing.example/",
+			},
+		},
+		{
+			name:  "GET with wrong password",
+			query: "98fv58Wr3hGGIzm2-aH2zA628Ng=",
+			request: &pb.RetrieveRequest{
+				ShortUrlId: "98fv58Wr3hGGIzm2-aH2zA628Ng=",
+				Password:   "guess",
+			},
+			visit: redirect.Visit{Password: "guess"},
+			result: result{
+				err: custom_errors.NewCustomError(access.ErrWrongPassword, http.StatusUnauthorized),
+			},
+			want: &pb.RetrieveResponse{
+				Status: "unauthorized",
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			ctx := context.Background()
 			serviceMock := new(handlers.MockUserUseCaseInterface)
 
-			serviceMock.On("GetURL", mock.Anything, tt.query).Return(tt.result.res, tt.result.err)
+			visit := tt.visit
+			if visit.Query == nil {
+				visit.Query = url.Values{}
+			}
+			serviceMock.On("Redirect", mock.Anything, tt.query, visit).
+				Return(redirect.Target{URL: tt.result.res, Code: http.StatusTemporaryRedirect, Mode: redirect.ModeHTTP}, tt.result.err)
+			serviceMock.On("RecordClick", tt.query, "")
 
-			us := NewGRPCHandler(serviceMock)
+			us := NewGRPCHandler(serviceMock, nil)
 			got, err := us.Retrieve(ctx, tt.request)
 
 			if (err != nil) != tt.wantErr {
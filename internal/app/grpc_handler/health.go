@@ -0,0 +1,42 @@
+package grpchandler
+
+import (
+	"context"
+	"time"
+
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/pb"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// NewHealthServer - создание сервиса grpc.health.v1. Статус обновляется
+// раз в interval по результату check и переводится в NOT_SERVING при
+// завершении контекста.
+func NewHealthServer(ctx context.Context, check func(ctx context.Context) error, interval time.Duration) *health.Server {
+	srv := health.NewServer()
+	update := func() {
+		checkCtx, cancel := context.WithTimeout(ctx, interval)
+		defer cancel()
+		servingStatus := healthpb.HealthCheckResponse_SERVING
+		if err := check(checkCtx); err != nil {
+			servingStatus = healthpb.HealthCheckResponse_NOT_SERVING
+		}
+		srv.SetServingStatus("", servingStatus)
+		srv.SetServingStatus(pb.URL_ServiceDesc.ServiceName, servingStatus)
+	}
+	update()
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				update()
+			case <-ctx.Done():
+				srv.Shutdown()
+				return
+			}
+		}
+	}()
+	return srv
+}
@@ -0,0 +1,58 @@
+package grpchandler
+
+import (
+	"context"
+	"log"
+	"net"
+
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/ratelimit"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// RetryAfterHeader - ключ метаданных с временем до повторного запроса.
+const RetryAfterHeader = "retry-after"
+
+// RateLimit - перехватчик, ограничивающий частоту вызовов методов из
+// limiters (ключ - полное имя метода). Вызовы считаются по учетной записи,
+// если она определена по ключу API перехватчиком APIKeyAuth, иначе по IP
+// клиента: user_id из запроса без ключа не проверяется и может быть любым.
+// При превышении лимита возвращается ResourceExhausted и заголовок
+// retry-after.
+func RateLimit(limiters map[string]ratelimit.Limiter) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		limiter, ok := limiters[info.FullMethod]
+		if !ok || limiter == nil {
+			return handler(ctx, req)
+		}
+		allowed, wait, err := limiter.Allow(ctx, rateLimitKey(ctx))
+		if err != nil {
+			log.Printf("Error while checking rate limit: %v\n", err)
+			return handler(ctx, req)
+		}
+		if !allowed {
+			grpc.SetHeader(ctx, metadata.Pairs(RetryAfterHeader, ratelimit.RetryAfter(wait)))
+			return nil, status.Error(codes.ResourceExhausted, "too many requests")
+		}
+		return handler(ctx, req)
+	}
+}
+
+// rateLimitKey - ключ для подсчета вызовов: учетная запись или IP клиента.
+func rateLimitKey(ctx context.Context) string {
+	if accountID := accountFromContext(ctx); accountID != "" {
+		return "user:" + accountID
+	}
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return "ip:"
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return "ip:" + p.Addr.String()
+	}
+	return "ip:" + host
+}
@@ -0,0 +1,71 @@
+package grpchandler
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/pb"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/ratelimit"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+func TestRateLimit(t *testing.T) {
+	interceptor := RateLimit(map[string]ratelimit.Limiter{
+		"/urls.URL/Create":   ratelimit.NewMemoryLimiter(1),
+		"/urls.URL/Retrieve": ratelimit.NewMemoryLimiter(1),
+	})
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, nil
+	}
+	call := func(method string, ip string, req interface{}) codes.Code {
+		ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP(ip), Port: 5000}})
+		_, err := interceptor(ctx, req, &grpc.UnaryServerInfo{FullMethod: method}, handler)
+		return status.Code(err)
+	}
+
+	retrieve := &pb.RetrieveRequest{ShortUrlId: "1234"}
+	assert.Equal(t, codes.OK, call("/urls.URL/Retrieve", "10.0.0.1", retrieve))
+	assert.Equal(t, codes.ResourceExhausted, call("/urls.URL/Retrieve", "10.0.0.1", retrieve))
+	assert.Equal(t, codes.OK, call("/urls.URL/Retrieve", "10.0.0.2", retrieve))
+
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, codes.OK, call("/urls.URL/GetUserURLs", "10.0.0.1", &pb.GetUserURLsRequest{UserId: "user-1"}))
+	}
+}
+
+func TestRateLimitKey(t *testing.T) {
+	auth := APIKeyAuth(stubAuthenticator{})
+	limit := RateLimit(map[string]ratelimit.Limiter{
+		"/urls.URL/Create": ratelimit.NewMemoryLimiter(1),
+	})
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, nil
+	}
+	info := &grpc.UnaryServerInfo{FullMethod: "/urls.URL/Create"}
+	call := func(ip string, authorization string, userID string) codes.Code {
+		ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP(ip), Port: 5000}})
+		if authorization != "" {
+			ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(AuthorizationHeader, authorization))
+		}
+		_, err := auth(ctx, &pb.CreateRequest{UserId: userID}, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+			return limit(ctx, req, info, handler)
+		})
+		return status.Code(err)
+	}
+
+	// Без ключа API user_id не проверяется, поэтому вызовы считаются по IP.
+	assert.Equal(t, codes.OK, call("10.0.0.1", "", "anonymous-1"))
+	assert.Equal(t, codes.ResourceExhausted, call("10.0.0.1", "", "anonymous-2"))
+	assert.Equal(t, codes.ResourceExhausted, call("10.0.0.1", "", ""))
+	assert.Equal(t, codes.OK, call("10.0.0.2", "", "anonymous-1"))
+
+	// С ключом API вызовы считаются по учетной записи с любого IP.
+	assert.Equal(t, codes.OK, call("10.0.0.1", "Bearer sk_valid", ""))
+	assert.Equal(t, codes.ResourceExhausted, call("10.0.0.3", "Bearer sk_valid", "account-1"))
+}
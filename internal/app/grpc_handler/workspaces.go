@@ -0,0 +1,135 @@
+package grpchandler
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/app/handlers"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/app/responses"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/domains"
+	custom_errors "github.com/p7chkn/go-musthave-shortener-tpl/internal/errors"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// NewWorkspacesHandler - создание обработчика сервиса рабочих пространств.
+// id переносимых ссылок относятся к домену запроса из dom.
+func NewWorkspacesHandler(service handlers.WorkspaceServiceInterface, dom *domains.Domains) *WorkspacesServer {
+	return &WorkspacesServer{
+		service: service,
+		domains: dom,
+	}
+}
+
+// WorkspacesServer - реализация сервиса рабочих пространств.
+type WorkspacesServer struct {
+	pb.UnimplementedWorkspacesServer
+	service handlers.WorkspaceServiceInterface
+	domains *domains.Domains
+}
+
+// CreateWorkspace - создание рабочего пространства, user_id становится его
+// владельцем.
+func (ws *WorkspacesServer) CreateWorkspace(ctx context.Context, in *pb.CreateWorkspaceRequest) (*pb.CreateWorkspaceResponse, error) {
+	workspace, err := ws.service.Create(ctx, in.UserId, in.Name)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return &pb.CreateWorkspaceResponse{Workspace: workspaceMessage(workspace)}, nil
+}
+
+// ListWorkspaces - рабочие пространства пользователя с его ролями.
+func (ws *WorkspacesServer) ListWorkspaces(ctx context.Context, in *pb.ListWorkspacesRequest) (*pb.ListWorkspacesResponse, error) {
+	workspaces, err := ws.service.List(ctx, in.UserId)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	response := &pb.ListWorkspacesResponse{}
+	for _, workspace := range workspaces {
+		response.Workspaces = append(response.Workspaces, workspaceMessage(workspace))
+	}
+	return response, nil
+}
+
+// ListMembers - участники рабочего пространства.
+func (ws *WorkspacesServer) ListMembers(ctx context.Context, in *pb.ListMembersRequest) (*pb.ListMembersResponse, error) {
+	members, err := ws.service.Members(ctx, in.WorkspaceId, in.UserId)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	response := &pb.ListMembersResponse{}
+	for _, member := range members {
+		response.Members = append(response.Members, memberMessage(member))
+	}
+	return response, nil
+}
+
+// SetMember - добавление участника по логину или изменение его роли.
+func (ws *WorkspacesServer) SetMember(ctx context.Context, in *pb.SetMemberRequest) (*pb.SetMemberResponse, error) {
+	member, err := ws.service.SetMember(ctx, in.WorkspaceId, in.UserId, responses.SetMember{
+		Login: in.Login,
+		Role:  in.Role,
+	})
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return &pb.SetMemberResponse{Member: memberMessage(member)}, nil
+}
+
+// RemoveMember - удаление участника рабочего пространства.
+func (ws *WorkspacesServer) RemoveMember(ctx context.Context, in *pb.RemoveMemberRequest) (*pb.RemoveMemberResponse, error) {
+	if err := ws.service.RemoveMember(ctx, in.WorkspaceId, in.UserId, in.MemberId); err != nil {
+		return nil, statusError(err)
+	}
+	return &pb.RemoveMemberResponse{}, nil
+}
+
+// AddURLs - перенос ссылок в рабочее пространство.
+func (ws *WorkspacesServer) AddURLs(ctx context.Context, in *pb.AddWorkspaceURLsRequest) (*pb.AddWorkspaceURLsResponse, error) {
+	keys, err := linkIDs(ctx, ws.domains, in.Urls)
+	if err != nil {
+		return nil, err
+	}
+	result, err := ws.service.AddURLs(ctx, in.WorkspaceId, in.UserId, keys)
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return &pb.AddWorkspaceURLsResponse{Moved: int32(result.Moved)}, nil
+}
+
+// grpcCodes - соответствие HTTP кодов ошибок сервиса кодам gRPC.
+var grpcCodes = map[int]codes.Code{
+	http.StatusBadRequest:   codes.InvalidArgument,
+	http.StatusUnauthorized: codes.Unauthenticated,
+	http.StatusForbidden:    codes.PermissionDenied,
+	http.StatusNotFound:     codes.NotFound,
+	http.StatusConflict:     codes.FailedPrecondition,
+}
+
+// statusError - ошибка сервиса в виде статуса gRPC.
+func statusError(err error) error {
+	code, ok := grpcCodes[custom_errors.ParseError(err)]
+	if !ok {
+		code = codes.Internal
+	}
+	return status.Error(code, err.Error())
+}
+
+func workspaceMessage(workspace responses.WorkspaceResponse) *pb.Workspace {
+	return &pb.Workspace{
+		Id:        workspace.ID,
+		Name:      workspace.Name,
+		Role:      workspace.Role,
+		CreatedAt: workspace.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+func memberMessage(member responses.MemberResponse) *pb.Member {
+	return &pb.Member{
+		UserId: member.UserID,
+		Login:  member.Login,
+		Role:   member.Role,
+	}
+}
@@ -0,0 +1,44 @@
+package grpchandler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/app/handlers"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/app/responses"
+	custom_errors "github.com/p7chkn/go-musthave-shortener-tpl/internal/errors"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/pb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestWorkspacesServer(t *testing.T) {
+	service := new(handlers.MockWorkspaceServiceInterface)
+	service.On("SetMember", mock.Anything, "ws-1", "account-1", responses.SetMember{Login: "bob", Role: "viewer"}).
+		Return(responses.MemberResponse{UserID: "account-2", Login: "bob", Role: "viewer"}, nil)
+	service.On("SetMember", mock.Anything, "ws-1", "account-2", mock.Anything).
+		Return(responses.MemberResponse{}, custom_errors.NewCustomError(errors.New("insufficient workspace role"), http.StatusForbidden))
+	service.On("Members", mock.Anything, "ws-2", "account-1").
+		Return(nil, custom_errors.NewCustomError(errors.New("workspace not found"), http.StatusNotFound))
+	service.On("AddURLs", mock.Anything, "ws-1", "account-1", []string{"abc"}).
+		Return(responses.WorkspaceURLsResponse{}, errors.New("connection refused"))
+	server := NewWorkspacesHandler(service, nil)
+	ctx := context.Background()
+
+	response, err := server.SetMember(ctx, &pb.SetMemberRequest{UserId: "account-1", WorkspaceId: "ws-1", Login: "bob", Role: "viewer"})
+	assert.NoError(t, err)
+	assert.Equal(t, "account-2", response.Member.UserId)
+
+	_, err = server.SetMember(ctx, &pb.SetMemberRequest{UserId: "account-2", WorkspaceId: "ws-1", Login: "alice", Role: "owner"})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	_, err = server.ListMembers(ctx, &pb.ListMembersRequest{UserId: "account-1", WorkspaceId: "ws-2"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = server.AddURLs(ctx, &pb.AddWorkspaceURLsRequest{UserId: "account-1", WorkspaceId: "ws-1", Urls: []string{"abc"}})
+	assert.Equal(t, codes.Internal, status.Code(err))
+}
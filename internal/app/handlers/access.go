@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"errors"
+	"html/template"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/access"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/app/responses"
+	custom_errors "github.com/p7chkn/go-musthave-shortener-tpl/internal/errors"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/redirect"
+)
+
+// unlockTemplate - страница ввода пароля ссылки. Форма отправляется на
+// адрес разблокировки ссылки вместе с параметрами запроса.
+var unlockTemplate = template.Must(template.New("unlock").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><meta name="robots" content="noindex"><title>Password required</title></head>
+<body>
+<h1>Password required</h1>
+<p>This link is password-protected.</p>
+{{if .Wrong}}<p><strong>Wrong password, try again.</strong></p>
+{{end}}<form method="post" action="{{.Action}}">
+<input type="password" name="password" autocomplete="off" autofocus required>
+<button type="submit">Continue</button>
+</form>
+</body>
+</html>
+`))
+
+// unlockRequest - пароль ссылки из формы или JSON.
+type unlockRequest struct {
+	Password string `json:"password" form:"password"`
+}
+
+// unlockView - данные страницы ввода пароля.
+type unlockView struct {
+	Action string
+	Wrong  bool
+}
+
+// UnlockShortURL - переход по ссылке с паролем. Пароль передается полем
+// password формы или JSON.
+// Если пароль верный - для JSON код ответа 200 и адрес перехода, для формы
+// переход как в RetrieveShortURL, но перенаправление всегда с кодом 303,
+// чтобы браузер не повторил POST запрос на адрес назначения.
+// Если пароль неверный - код ответа 401 и страница ввода пароля.
+// Остальные ответы такие же, как у RetrieveShortURL.
+func (h *Handler) UnlockShortURL(c *gin.Context) {
+	id := linkID(c, c.Param("id"))
+	var req unlockRequest
+	if err := c.ShouldBind(&req); err != nil {
+		h.handleError(c, err)
+		return
+	}
+	target, err := h.service.Redirect(c.Request.Context(), id, visit(c, req.Password))
+	if err != nil {
+		h.redirectError(c, target, err)
+		return
+	}
+	h.service.RecordClick(id, target.Destination)
+	if wantsJSON(c) {
+		c.JSON(http.StatusOK, responses.PostURL{URL: target.URL})
+		return
+	}
+	if target.Mode == redirect.ModeHTTP {
+		target.Code = http.StatusSeeOther
+	}
+	h.redirect(c, target)
+}
+
+// unlockPage - ответ на переход по ссылке без верного пароля: для JSON
+// запроса - ошибка, иначе страница ввода пароля. Код ответа 401.
+func (h *Handler) unlockPage(c *gin.Context, err error) {
+	if wantsJSON(c) {
+		h.handleProblem(c, http.StatusUnauthorized, err)
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	c.Status(http.StatusUnauthorized)
+	action := "/" + c.Param("id") + "/unlock"
+	if c.Request.URL.RawQuery != "" {
+		action += "?" + c.Request.URL.RawQuery
+	}
+	unlockTemplate.Execute(c.Writer, unlockView{
+		Action: action,
+		Wrong:  errors.Is(err, access.ErrWrongPassword),
+	})
+}
+
+// wantsJSON - запрос в формате JSON или клиент предпочитает ответ в JSON.
+func wantsJSON(c *gin.Context) bool {
+	return c.ContentType() == gin.MIMEJSON || c.NegotiateFormat(gin.MIMEHTML, gin.MIMEJSON) == gin.MIMEJSON
+}
+
+// UpdateAccess - замена ограничения доступа к ссылке id: пароля и
+// максимального количества переходов. Незаданные поля снимают
+// ограничение, счетчик переходов начинается заново.
+// При успешном изменении код ответа 200 и действующее ограничение.
+// В случае ошибки в формате запроса или некорректного пароля - код ответа
+// 400.
+// Если ссылки нет, она удалена или пользователь не может ее изменять -
+// код ответа 404.
+func (h *Handler) UpdateAccess(c *gin.Context) {
+	var req responses.LinkAccess
+	if err := readJSON(c, &req); err != nil {
+		h.handleError(c, err)
+		return
+	}
+	result, err := h.service.UpdateAccess(c.Request.Context(), linkID(c, c.Param("id")), req, c.GetString("userId"))
+	if err != nil {
+		h.handleProblem(c, custom_errors.ParseError(err), err)
+		return
+	}
+	c.IndentedJSON(http.StatusOK, result)
+}
@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gofrs/uuid"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/app/responses"
+	custom_errors "github.com/p7chkn/go-musthave-shortener-tpl/internal/errors"
+)
+
+//go:generate mockery --name=AccountServiceInterface --case camel --inpackage
+
+// AccountServiceInterface - интерфейс сервиса учетных записей.
+type AccountServiceInterface interface {
+	Register(ctx context.Context, credentials responses.Credentials, anonymousID string) (responses.AccountResponse, error)
+	Login(ctx context.Context, credentials responses.Credentials, anonymousID string) (responses.AccountResponse, error)
+	CreateAPIKey(ctx context.Context, userID string, name string) (responses.APIKeyResponse, error)
+	ListAPIKeys(ctx context.Context, userID string) ([]responses.APIKeyResponse, error)
+	DeleteAPIKey(ctx context.Context, userID string, keyID string) error
+}
+
+// SessionIssuer - выдача токена сессии в cookie.
+type SessionIssuer interface {
+	Issue(userID string) (string, error)
+	Cookie(token string) *http.Cookie
+}
+
+// AccountHandler - обработчик запросов учетных записей.
+type AccountHandler struct {
+	service  AccountServiceInterface
+	sessions SessionIssuer
+}
+
+// NewAccountHandler - создание обработчика запросов учетных записей.
+func NewAccountHandler(service AccountServiceInterface, sessions SessionIssuer) *AccountHandler {
+	return &AccountHandler{
+		service:  service,
+		sessions: sessions,
+	}
+}
+
+// Register - регистрация учетной записи.
+// Формат запроса Credentials.
+// При успешной регистрации код ответа 201, описание учетной записи в формате
+// AccountResponse и cookie сессии учетной записи.
+// Если claim - ссылки текущего анонимного пользователя переносятся в
+// учетную запись.
+// В случае некорректного логина или пароля - код ответа 400.
+// В случае занятого логина - код ответа 409.
+func (ah *AccountHandler) Register(c *gin.Context) {
+	credentials, err := readCredentials(c)
+	if err != nil {
+		handleProblem(c, http.StatusBadRequest, err)
+		return
+	}
+	account, err := ah.service.Register(c.Request.Context(), credentials, c.GetString("userId"))
+	if err != nil {
+		handleProblem(c, custom_errors.ParseError(err), err)
+		return
+	}
+	if err := ah.setSession(c, account.ID); err != nil {
+		handleProblem(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.IndentedJSON(http.StatusCreated, account)
+}
+
+// Login - вход в учетную запись.
+// Формат запроса Credentials.
+// При успешном входе код ответа 200, описание учетной записи в формате
+// AccountResponse и cookie сессии учетной записи.
+// Если claim - ссылки текущего анонимного пользователя переносятся в
+// учетную запись.
+// В случае неверного логина или пароля - код ответа 401.
+func (ah *AccountHandler) Login(c *gin.Context) {
+	credentials, err := readCredentials(c)
+	if err != nil {
+		handleProblem(c, http.StatusBadRequest, err)
+		return
+	}
+	account, err := ah.service.Login(c.Request.Context(), credentials, c.GetString("userId"))
+	if err != nil {
+		handleProblem(c, custom_errors.ParseError(err), err)
+		return
+	}
+	if err := ah.setSession(c, account.ID); err != nil {
+		handleProblem(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.IndentedJSON(http.StatusOK, account)
+}
+
+// Logout - выход из учетной записи: выдается cookie нового анонимного
+// пользователя, код ответа 204.
+func (ah *AccountHandler) Logout(c *gin.Context) {
+	id, err := uuid.NewV4()
+	if err == nil {
+		err = ah.setSession(c, id.String())
+	}
+	if err != nil {
+		handleProblem(c, http.StatusInternalServerError, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// ListAPIKeys - список ключей API учетной записи в формате APIKeyResponse,
+// код ответа 200. Сами ключи не возвращаются.
+// Для анонимного пользователя - код ответа 401.
+func (ah *AccountHandler) ListAPIKeys(c *gin.Context) {
+	keys, err := ah.service.ListAPIKeys(c.Request.Context(), c.GetString("userId"))
+	if err != nil {
+		handleProblem(c, custom_errors.ParseError(err), err)
+		return
+	}
+	c.IndentedJSON(http.StatusOK, keys)
+}
+
+// CreateAPIKey - создание ключа API учетной записи.
+// Формат запроса CreateAPIKey.
+// При успешном создании код ответа 201 и описание ключа в формате
+// APIKeyResponse. Ключ возвращается только в этом ответе.
+// Для анонимного пользователя - код ответа 401.
+func (ah *AccountHandler) CreateAPIKey(c *gin.Context) {
+	var request responses.CreateAPIKey
+	if err := readJSON(c, &request); err != nil {
+		handleProblem(c, http.StatusBadRequest, err)
+		return
+	}
+	key, err := ah.service.CreateAPIKey(c.Request.Context(), c.GetString("userId"), request.Name)
+	if err != nil {
+		handleProblem(c, custom_errors.ParseError(err), err)
+		return
+	}
+	c.IndentedJSON(http.StatusCreated, key)
+}
+
+// DeleteAPIKey - отзыв ключа API учетной записи.
+// Обязательный параметр URL - id ключа.
+// При успешном отзыве код ответа 204.
+// Для анонимного пользователя - код ответа 401.
+// Если ключ не найден - код ответа 404.
+func (ah *AccountHandler) DeleteAPIKey(c *gin.Context) {
+	err := ah.service.DeleteAPIKey(c.Request.Context(), c.GetString("userId"), c.Param("id"))
+	if err != nil {
+		handleProblem(c, custom_errors.ParseError(err), err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// setSession - выдача cookie сессии пользователя userID.
+func (ah *AccountHandler) setSession(c *gin.Context, userID string) error {
+	token, err := ah.sessions.Issue(userID)
+	if err != nil {
+		return err
+	}
+	http.SetCookie(c.Writer, ah.sessions.Cookie(token))
+	c.Set("userId", userID)
+	return nil
+}
+
+// readCredentials - чтение логина и пароля из тела запроса.
+func readCredentials(c *gin.Context) (responses.Credentials, error) {
+	var credentials responses.Credentials
+	err := readJSON(c, &credentials)
+	return credentials, err
+}
@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/app/responses"
+	custom_errors "github.com/p7chkn/go-musthave-shortener-tpl/internal/errors"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/session"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func setupAccountRouter(service AccountServiceInterface) (*gin.Engine, *session.Manager) {
+	router, sessions := setupRouter(new(MockUserUseCaseInterface))
+	handler := NewAccountHandler(service, sessions)
+	router.POST("/api/user/register", handler.Register)
+	router.POST("/api/user/login", handler.Login)
+	router.POST("/api/user/logout", handler.Logout)
+	router.POST("/api/user/keys", handler.CreateAPIKey)
+	router.DELETE("/api/user/keys/:id", handler.DeleteAPIKey)
+	return router, sessions
+}
+
+func TestRegister(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		result     responses.AccountResponse
+		err        error
+		wantCode   int
+		wantCookie bool
+	}{
+		{
+			name:       "register and claim",
+			body:       `{"login":"alice","password":"correct horse","claim":true}`,
+			result:     responses.AccountResponse{ID: "account-1", Login: "alice", ClaimedURLs: 2},
+			wantCode:   http.StatusCreated,
+			wantCookie: true,
+		},
+		{
+			name:     "login taken",
+			body:     `{"login":"alice","password":"correct horse"}`,
+			err:      custom_errors.NewCustomError(errors.New("login already taken"), http.StatusConflict),
+			wantCode: http.StatusConflict,
+		},
+		{
+			name:     "bad json",
+			body:     `{"login":`,
+			wantCode: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := new(MockAccountServiceInterface)
+			service.On("Register", mock.Anything, mock.Anything, mock.Anything).Return(tt.result, tt.err)
+			router, sessions := setupAccountRouter(service)
+
+			request := httptest.NewRequest(http.MethodPost, "/api/user/register", strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, request)
+			result := w.Result()
+			defer result.Body.Close()
+
+			assert.Equal(t, tt.wantCode, result.StatusCode)
+			var accountCookie *http.Cookie
+			for _, cookie := range result.Cookies() {
+				claims, err := sessions.Parse(cookie.Value)
+				if err == nil && claims.Subject == tt.result.ID {
+					accountCookie = cookie
+				}
+			}
+			assert.Equal(t, tt.wantCookie, accountCookie != nil)
+			if tt.wantCode != http.StatusCreated {
+				assert.Equal(t, responses.ProblemContentType, result.Header.Get("Content-Type"))
+			}
+		})
+	}
+}
+
+func TestLogin(t *testing.T) {
+	service := new(MockAccountServiceInterface)
+	service.On("Login", mock.Anything, responses.Credentials{Login: "alice", Password: "wrong password"}, mock.Anything).
+		Return(responses.AccountResponse{}, custom_errors.NewCustomError(errors.New("invalid login or password"), http.StatusUnauthorized))
+	router, _ := setupAccountRouter(service)
+
+	request := httptest.NewRequest(http.MethodPost, "/api/user/login", strings.NewReader(`{"login":"alice","password":"wrong password"}`))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, request)
+	result := w.Result()
+	defer result.Body.Close()
+
+	assert.Equal(t, http.StatusUnauthorized, result.StatusCode)
+}
+
+func TestAPIKeys(t *testing.T) {
+	service := new(MockAccountServiceInterface)
+	service.On("CreateAPIKey", mock.Anything, "account-1", "ci").
+		Return(responses.APIKeyResponse{ID: "key-1", Name: "ci", Prefix: "sk_abcdefgh", Key: "sk_abcdefgh123"}, nil)
+	service.On("CreateAPIKey", mock.Anything, mock.Anything, "ci").
+		Return(responses.APIKeyResponse{}, custom_errors.NewCustomError(errors.New("login required"), http.StatusUnauthorized))
+	service.On("DeleteAPIKey", mock.Anything, "account-1", "key-1").Return(nil)
+	router, sessions := setupAccountRouter(service)
+	token, _ := sessions.Issue("account-1")
+
+	request := httptest.NewRequest(http.MethodPost, "/api/user/keys", strings.NewReader(`{"name":"ci"}`))
+	request.AddCookie(sessions.Cookie(token))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, request)
+	result := w.Result()
+	body, _ := ioutil.ReadAll(result.Body)
+	result.Body.Close()
+	assert.Equal(t, http.StatusCreated, result.StatusCode)
+	assert.Contains(t, string(body), `"key": "sk_abcdefgh123"`)
+
+	request = httptest.NewRequest(http.MethodPost, "/api/user/keys", strings.NewReader(`{"name":"ci"}`))
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, request)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	request = httptest.NewRequest(http.MethodDelete, "/api/user/keys/key-1", nil)
+	request.AddCookie(sessions.Cookie(token))
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, request)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+}
+
+func TestLogout(t *testing.T) {
+	router, sessions := setupAccountRouter(new(MockAccountServiceInterface))
+	token, _ := sessions.Issue("account-1")
+
+	request := httptest.NewRequest(http.MethodPost, "/api/user/logout", nil)
+	request.AddCookie(sessions.Cookie(token))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, request)
+	result := w.Result()
+	defer result.Body.Close()
+
+	assert.Equal(t, http.StatusNoContent, result.StatusCode)
+	cookies := result.Cookies()
+	if assert.NotEmpty(t, cookies) {
+		claims, err := sessions.Parse(cookies[len(cookies)-1].Value)
+		assert.NoError(t, err)
+		assert.NotEqual(t, "account-1", claims.Subject)
+	}
+}
@@ -6,10 +6,18 @@ import (
 	"encoding/json"
 	"errors"
 	"github.com/p7chkn/go-musthave-shortener-tpl/internal/app/responses"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/domains"
 	custom_errors "github.com/p7chkn/go-musthave-shortener-tpl/internal/errors"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/listing"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/qr"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/redirect"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/transfer"
+	"html/template"
 	"io/ioutil"
 	"net"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
@@ -19,14 +27,41 @@ import (
 // URLServiceInterface - интерфейс для взаимодействия с репозиторием.
 type URLServiceInterface interface {
 	GetURL(ctx context.Context, url string) (string, error)
-	CreateURL(ctx context.Context, longURL string, user string) (string, error)
-	GetUserURL(ctx context.Context, userID string) ([]responses.GetURL, error)
+	CreateURL(ctx context.Context, longURL string, meta responses.LinkMeta, domain string, user string) (string, error)
+	GetUserURL(ctx context.Context, userID string, query listing.Query) (responses.UserURLs, error)
 	PingDB(ctx context.Context) error
 	CreateBatch(ctx context.Context, urls []responses.ManyPostURL, userID string) ([]responses.ManyPostResponse, error)
 	DeleteBatch(urls []string, userID string)
+	RestoreURLs(ctx context.Context, urls []string, userID string) (responses.RestoreURLsResponse, error)
 	GetStats(ctx context.Context, ip net.IP) (bool, responses.StatResponse, error)
+	UpdateURL(ctx context.Context, shortURL string, longURL string, userID string) (responses.GetURL, error)
+	GetRevisions(ctx context.Context, shortURL string, userID string) ([]responses.URLRevision, error)
+	RestoreRevision(ctx context.Context, shortURL string, revision int, userID string) (responses.GetURL, error)
+	UpdateMeta(ctx context.Context, shortURL string, meta responses.LinkMeta, userID string) (responses.LinkMeta, error)
+	ImportURLs(ctx context.Context, reader transfer.Reader, userID string) (responses.ImportReport, error)
+	ExportURLs(ctx context.Context, userID string, writer transfer.Writer) error
+	QRCode(ctx context.Context, shortURL string, format string, opts qr.Options) ([]byte, error)
+	Preview(ctx context.Context, shortURL string) (responses.LinkPreview, error)
+	RecordClick(shortURL string, destination string)
+	UpdateAccess(ctx context.Context, shortURL string, req responses.LinkAccess, userID string) (responses.AccessState, error)
+	Redirect(ctx context.Context, shortURL string, visit redirect.Visit) (redirect.Target, error)
+	UpdateRedirect(ctx context.Context, shortURL string, settings redirect.Settings, userID string) (redirect.Settings, error)
 }
 
+// interstitialTemplate - страница предупреждения для ссылки, отключенной
+// политикой адресов назначения.
+var interstitialTemplate = template.Must(template.New("interstitial").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Warning</title></head>
+<body>
+<h1>This link has been disabled</h1>
+<p>The destination of this short link matches a blocked destination rule and may be unsafe.</p>
+<p>Destination: <code>{{.}}</code></p>
+<p><a href="{{.}}" rel="noopener noreferrer nofollow">Continue at your own risk</a></p>
+</body>
+</html>
+`))
+
 // Handler - структура обработчика запросов.
 type Handler struct {
 	service URLServiceInterface
@@ -41,35 +76,104 @@ func New(service URLServiceInterface) *Handler {
 
 // RetrieveShortURL - получение оригинальной ссылки по укороченному URL.
 // Обязательный параметр URL - id.
-// Если ссылка верная - код ответа 307 и заголовок "location" с искомой ссылкой.
-// Если ссылка была удалена - код ответа 410.
+// Если ссылка верная - код ответа из настроек перехода ссылки, по умолчанию
+// 307, и заголовок "location" с искомой ссылкой. В режимах meta и js - код
+// ответа 200 и страница, которая переходит по ссылке без передачи Referer.
+// Если ссылка защищена паролем - код ответа 401 и страница ввода пароля.
+// Если ссылка была удалена или переходы по ней закончились - код ответа 410.
 // Если ссылка не найдена - код ответа 404.
+// Если ссылка отключена политикой - код ответа 200 и страница с
+// предупреждением вместо перенаправления.
+// Если id оканчивается на "+" или задан параметр preview - страница
+// предпросмотра ссылки вместо перенаправления.
 func (h *Handler) RetrieveShortURL(c *gin.Context) {
-	result := map[string]string{}
-	long, err := h.service.GetURL(c.Request.Context(), c.Param("id"))
+	id := linkID(c, c.Param("id"))
+	if strings.HasSuffix(id, PreviewSuffix) {
+		h.previewURL(c, strings.TrimSuffix(id, PreviewSuffix))
+		return
+	}
+	if _, ok := c.GetQuery("preview"); ok {
+		h.previewURL(c, id)
+		return
+	}
+	target, err := h.service.Redirect(c.Request.Context(), id, visit(c, ""))
 	if err != nil {
-		statusCode := custom_errors.ParseError(err)
-		switch statusCode {
-		case http.StatusGone:
-			c.Status(statusCode)
-			return
-		case http.StatusNotFound:
-			result["detail"] = err.Error()
-			c.IndentedJSON(statusCode, result)
-			return
-		default:
-			c.Status(http.StatusInternalServerError)
-			return
+		h.redirectError(c, target, err)
+		return
+	}
+	h.service.RecordClick(id, target.Destination)
+	h.redirect(c, target)
+}
+
+// visit - запрос перехода по ссылке с введенным паролем password.
+func visit(c *gin.Context, password string) redirect.Visit {
+	return redirect.Visit{
+		Query:          c.Request.URL.Query(),
+		UserAgent:      c.Request.UserAgent(),
+		AcceptLanguage: c.GetHeader("Accept-Language"),
+		IP:             net.ParseIP(c.ClientIP()),
+		Visitor:        c.GetString("userId"),
+		Password:       password,
+	}
+}
+
+// linkID - id ссылки в хранилище по ее id в домене запроса.
+func linkID(c *gin.Context, id string) string {
+	return domains.Key(c.GetString("domain"), id)
+}
+
+// linkIDs - id ссылок в хранилище по их id в домене запроса из тела
+// запроса. Id с разделителем домена - ошибка с кодом 400.
+func linkIDs(c *gin.Context, ids []string) ([]string, error) {
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		if err := domains.CheckID(id); err != nil {
+			return nil, custom_errors.NewCustomError(err, http.StatusBadRequest)
 		}
+		keys[i] = linkID(c, id)
+	}
+	return keys, nil
+}
+
+// linkDomain - домен новой ссылки: выбранный пользователем domain или, если
+// он не задан, домен запроса.
+func linkDomain(c *gin.Context, domain string) string {
+	if domain != "" {
+		return domain
+	}
+	return c.GetString("domain")
+}
+
+// redirectError - ответ на неудачный переход по ссылке.
+// Если нужен пароль - страница ввода пароля или код ответа 401.
+// Если ссылка отключена политикой - страница предупреждения.
+// Если ссылка была удалена или переходы по ней закончились - код ответа 410.
+// Если ссылка не найдена - код ответа 404.
+func (h *Handler) redirectError(c *gin.Context, target redirect.Target, err error) {
+	statusCode := custom_errors.ParseError(err)
+	switch statusCode {
+	case http.StatusUnauthorized:
+		h.unlockPage(c, err)
+	case http.StatusForbidden:
+		c.Header("Content-Type", "text/html; charset=utf-8")
+		c.Status(http.StatusOK)
+		interstitialTemplate.Execute(c.Writer, target.URL)
+	case http.StatusGone:
+		c.Status(statusCode)
+	case http.StatusNotFound:
+		h.handleProblem(c, statusCode, err)
+	default:
+		c.Status(http.StatusInternalServerError)
 	}
-	c.Header("Location", long)
-	c.String(http.StatusTemporaryRedirect, "")
 }
 
 // CreateShortURL - создание укороченной ссылки.
-// Формат запроса - строка с URL (plain text).
+// Формат запроса - строка с URL (plain text). Необязательный параметр
+// domain - домен ссылки, по умолчанию домен запроса.
 // При успешном создании код ответа 201, а так же в ответе будет укороченная ссылка.
-// В случае ошибки в формате запроса - код ответа 400.
+// В случае ошибки в формате запроса или некорректного URL - код ответа 400.
+// В случае запрещенного политикой адреса - код ответа 403.
+// В случае включенного режима drain - код ответа 503.
 // В случае ошибки при записи в базу данных - код ответа 500.
 func (h *Handler) CreateShortURL(c *gin.Context) {
 	defer c.Request.Body.Close()
@@ -80,13 +184,22 @@ func (h *Handler) CreateShortURL(c *gin.Context) {
 		h.handleError(c, err)
 		return
 	}
-	responseURL, err := h.service.CreateURL(c.Request.Context(), string(body), c.GetString("userId"))
+	responseURL, err := h.service.CreateURL(c.Request.Context(), string(body), responses.LinkMeta{}, linkDomain(c, c.Query("domain")), c.GetString("userId"))
 	if err != nil {
 		statusCode := custom_errors.ParseError(err)
 		switch statusCode {
 		case http.StatusConflict:
 			c.String(statusCode, responseURL)
 			return
+		case http.StatusBadRequest:
+			h.handleError(c, err)
+			return
+		case http.StatusForbidden:
+			h.handleProblem(c, statusCode, err)
+			return
+		case http.StatusServiceUnavailable:
+			c.Status(statusCode)
+			return
 		default:
 			c.Status(http.StatusInternalServerError)
 			return
@@ -96,16 +209,20 @@ func (h *Handler) CreateShortURL(c *gin.Context) {
 }
 
 // ShortenURL - создание укороченной ссылки.
-// Формат запроса PostURL.
+// Формат запроса ShortenURL: URL и необязательные заголовок, описание,
+// теги и домен ссылки, по умолчанию домен запроса.
 // При успешном создании код ответа 201, а так же в ответе будет укороченная ссылка
-// в result.
-// В случае ошибки в формате запроса - код ответа 400.
+// в result. С параметром qr=true в qr передается QR код ссылки в виде data
+// URL.
+// В случае ошибки в формате запроса или некорректного URL - код ответа 400.
+// В случае запрещенного политикой адреса - код ответа 403.
 // В случае, если такая ссылка уже имеется - код ответа 409.
+// В случае включенного режима drain - код ответа 503.
 // В случае ошибки при записи в базу данных - код ответа 500.
 func (h *Handler) ShortenURL(c *gin.Context) {
 
 	result := map[string]string{}
-	var url responses.PostURL
+	var url responses.ShortenURL
 
 	defer c.Request.Body.Close()
 
@@ -124,43 +241,94 @@ func (h *Handler) ShortenURL(c *gin.Context) {
 		h.handleError(c, errors.New("bad request"))
 		return
 	}
-	responseURL, err := h.service.CreateURL(c.Request.Context(), url.URL, c.GetString("userId"))
+	responseURL, err := h.service.CreateURL(c.Request.Context(), url.URL, url.LinkMeta, linkDomain(c, url.Domain), c.GetString("userId"))
 	if err != nil {
 
 		statusCode := custom_errors.ParseError(err)
 		switch statusCode {
 		case http.StatusConflict:
 			result["result"] = responseURL
+			if wantQR(c) {
+				result["qr"] = qrDataURL(responseURL)
+			}
 			c.IndentedJSON(http.StatusConflict, result)
 			return
+		case http.StatusBadRequest:
+			h.handleError(c, err)
+			return
+		case http.StatusForbidden:
+			h.handleProblem(c, statusCode, err)
+			return
+		case http.StatusServiceUnavailable:
+			c.Status(statusCode)
+			return
 		default:
 			c.Status(http.StatusInternalServerError)
 			return
 		}
 	}
 	result["result"] = responseURL
+	if wantQR(c) {
+		result["qr"] = qrDataURL(responseURL)
+	}
 	c.IndentedJSON(http.StatusCreated, result)
 }
 
 // GetUserURL - получение списка URL пользователя.
+// Параметры запроса: limit и cursor - размер страницы и курсор следующей
+// страницы, domain, q, created_from и created_to - фильтры по домену,
+// подстроке, тегу tag и времени создания, sort - created_at или -created_at.
 // При успешном запросе - код ответа 200 и списко URL пользователя в
-// формате GetURL.
+// формате GetURL. Если есть следующая страница, ее курсор передается в
+// заголовке NextCursorHeader.
+// В случае некорректных параметров - код ответа 400.
 // В случае ошибки получение ссылок из базы данных - код ответа 500.
 // В случае отсутствия ссылок у пользователя - код ответа 204.
 func (h *Handler) GetUserURL(c *gin.Context) {
-	result, err := h.service.GetUserURL(c.Request.Context(), c.GetString("userId"))
+	query, err := userURLQuery(c)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	result, err := h.service.GetUserURL(c.Request.Context(), c.GetString("userId"), query)
 	if err != nil {
 		statusCode := custom_errors.ParseError(err)
 		switch statusCode {
 		case http.StatusNoContent:
-			c.IndentedJSON(statusCode, result)
+			c.IndentedJSON(statusCode, result.URLs)
 			return
 		default:
 			c.IndentedJSON(http.StatusInternalServerError, err)
 			return
 		}
 	}
-	c.IndentedJSON(http.StatusOK, result)
+	if result.NextCursor != "" {
+		c.Header(NextCursorHeader, result.NextCursor)
+	}
+	c.IndentedJSON(http.StatusOK, result.URLs)
+}
+
+// NextCursorHeader - заголовок ответа с курсором следующей страницы ссылок.
+const NextCursorHeader = "X-Next-Cursor"
+
+// userURLQuery - параметры выборки ссылок пользователя из строки запроса.
+func userURLQuery(c *gin.Context) (listing.Query, error) {
+	params := listing.Params{
+		Cursor:      c.Query("cursor"),
+		Domain:      c.Query("domain"),
+		Search:      c.Query("q"),
+		Tag:         c.Query("tag"),
+		CreatedFrom: c.Query("created_from"),
+		CreatedTo:   c.Query("created_to"),
+		Sort:        c.Query("sort"),
+	}
+	if limit := c.Query("limit"); limit != "" {
+		var err error
+		if params.Limit, err = strconv.Atoi(limit); err != nil {
+			return listing.Query{}, listing.ErrInvalidLimit
+		}
+	}
+	return params.Query()
 }
 
 // PingDB - проверка соединения с базой данных.
@@ -176,11 +344,15 @@ func (h *Handler) PingDB(c *gin.Context) {
 }
 
 // CreateBatch - создание нескольких коротких URL сразу.
-// Формат запроса json в виде списка объектов формата ManyPostURL.
+// Формат запроса json в виде списка объектов формата ManyPostURL. Ссылки
+// без домена создаются в домене запроса.
 // В случае успешного создания - код ответа 201, а так же списко созданных
-// URL в формате ManyPostResponse.
+// URL в формате ManyPostResponse. С параметром qr=true у каждой ссылки
+// передается QR код в виде data URL.
 // В случае ошибки в формате запроса - код ответа 400.
 // В случае ошибки записи в базу данных - код ответа 400.
+// В случае запрещенного политикой адреса - код ответа 403.
+// В случае включенного режима drain - код ответа 503.
 func (h *Handler) CreateBatch(c *gin.Context) {
 	var data []responses.ManyPostURL
 	defer c.Request.Body.Close()
@@ -195,7 +367,18 @@ func (h *Handler) CreateBatch(c *gin.Context) {
 		h.handleError(c, err)
 		return
 	}
+	for i := range data {
+		data[i].Domain = linkDomain(c, data[i].Domain)
+	}
 	response, err := h.service.CreateBatch(c.Request.Context(), data, c.GetString("userId"))
+	switch custom_errors.ParseError(err) {
+	case http.StatusServiceUnavailable:
+		c.Status(http.StatusServiceUnavailable)
+		return
+	case http.StatusForbidden:
+		h.handleProblem(c, http.StatusForbidden, err)
+		return
+	}
 	if err != nil {
 		h.handleError(c, err)
 		return
@@ -204,6 +387,11 @@ func (h *Handler) CreateBatch(c *gin.Context) {
 		h.handleError(c, errors.New("bad request"))
 		return
 	}
+	if wantQR(c) {
+		for i := range response {
+			response[i].QR = qrDataURL(response[i].ShortURL)
+		}
+	}
 	c.IndentedJSON(http.StatusCreated, response)
 }
 
@@ -227,11 +415,220 @@ func (h *Handler) DeleteBatch(c *gin.Context) {
 		h.handleError(c, err)
 		return
 	}
-	h.service.DeleteBatch(data, c.GetString("userId"))
+	keys, err := linkIDs(c, data)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	h.service.DeleteBatch(keys, c.GetString("userId"))
 
 	c.Status(http.StatusAccepted)
 }
 
+// RestoreURLs - восстановление удаленных ссылок.
+// В запросе ожидается список коротких URL. Восстанавливаются только ссылки,
+// которые пользователь может изменять и срок хранения которых после
+// удаления еще не истек.
+// При успешном запросе код ответа 200 и RestoreURLsResponse.
+// В случае ошибки в запросе - код ответа 400.
+func (h *Handler) RestoreURLs(c *gin.Context) {
+	var urls []string
+	if err := readJSON(c, &urls); err != nil {
+		h.handleError(c, err)
+		return
+	}
+	keys, err := linkIDs(c, urls)
+	if err != nil {
+		h.handleProblem(c, custom_errors.ParseError(err), err)
+		return
+	}
+	result, err := h.service.RestoreURLs(c.Request.Context(), keys, c.GetString("userId"))
+	if err != nil {
+		h.handleProblem(c, custom_errors.ParseError(err), err)
+		return
+	}
+	c.IndentedJSON(http.StatusOK, result)
+}
+
+// UpdateURL - изменение адреса назначения ссылки id.
+// Формат запроса PostURL. Прежний адрес сохраняется в истории изменений.
+// При успешном изменении код ответа 200 и ссылка в формате GetURL.
+// В случае ошибки в формате запроса или некорректного URL - код ответа 400.
+// В случае запрещенного политикой адреса - код ответа 403.
+// Если ссылки нет, она удалена или пользователь не может ее изменять -
+// код ответа 404.
+func (h *Handler) UpdateURL(c *gin.Context) {
+	var url responses.PostURL
+	if err := readJSON(c, &url); err != nil {
+		h.handleError(c, err)
+		return
+	}
+	if url.URL == "" {
+		h.handleError(c, errors.New("bad request"))
+		return
+	}
+	result, err := h.service.UpdateURL(c.Request.Context(), linkID(c, c.Param("id")), url.URL, c.GetString("userId"))
+	if err != nil {
+		h.handleProblem(c, custom_errors.ParseError(err), err)
+		return
+	}
+	c.IndentedJSON(http.StatusOK, result)
+}
+
+// UpdateMeta - замена заголовка, описания и тегов ссылки id.
+// Формат запроса LinkMeta, незаданные поля очищаются.
+// При успешном изменении код ответа 200 и сохраненные значения в формате
+// LinkMeta.
+// В случае ошибки в формате запроса или слишком длинных значений - код
+// ответа 400.
+// Если ссылки нет, она удалена или пользователь не может ее изменять -
+// код ответа 404.
+func (h *Handler) UpdateMeta(c *gin.Context) {
+	var meta responses.LinkMeta
+	if err := readJSON(c, &meta); err != nil {
+		h.handleError(c, err)
+		return
+	}
+	result, err := h.service.UpdateMeta(c.Request.Context(), linkID(c, c.Param("id")), meta, c.GetString("userId"))
+	if err != nil {
+		h.handleProblem(c, custom_errors.ParseError(err), err)
+		return
+	}
+	c.IndentedJSON(http.StatusOK, result)
+}
+
+// ImportURLs - создание ссылок из файла CSV или JSONL.
+// Формат задается параметром format или заголовком Content-Type. Строки
+// проверяются по отдельности, как в CreateBatch.
+// При успешной обработке код ответа 200 и ImportReport с количеством
+// созданных ссылок и ошибками строк.
+// В случае неизвестного формата или некорректного заголовка CSV - код
+// ответа 400.
+// В случае включенного режима drain - код ответа 503.
+func (h *Handler) ImportURLs(c *gin.Context) {
+	defer c.Request.Body.Close()
+
+	format := c.Query("format")
+	if format == "" {
+		format = c.ContentType()
+	}
+	format, err := transfer.ParseFormat(format)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	reader, err := transfer.NewReader(c.Request.Body, format)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	report, err := h.service.ImportURLs(c.Request.Context(), reader, c.GetString("userId"))
+	if err != nil {
+		h.handleProblem(c, custom_errors.ParseError(err), err)
+		return
+	}
+	c.IndentedJSON(http.StatusOK, report)
+}
+
+// ExportURLs - выгрузка всех ссылок пользователя в формате CSV или JSONL.
+// Формат задается параметром format, по умолчанию JSONL. Ссылки
+// записываются в ответ по мере чтения из хранилища.
+// В случае неизвестного формата - код ответа 400.
+func (h *Handler) ExportURLs(c *gin.Context) {
+	format, err := transfer.ParseFormat(c.DefaultQuery("format", transfer.FormatJSONL))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	writer, err := transfer.NewWriter(c.Writer, format)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	c.Header("Content-Type", transfer.ContentType(format))
+	c.Header("Content-Disposition", `attachment; filename="urls.`+format+`"`)
+	c.Status(http.StatusOK)
+	if err := h.service.ExportURLs(c.Request.Context(), c.GetString("userId"), writer); err != nil {
+		c.Error(err)
+	}
+}
+
+// QRCode - QR код короткой ссылки.
+// Параметр пути - имя файла вида <id>.png или <id>.svg. Параметры запроса:
+// size - сторона изображения в пикселях, margin - ширина пустого поля в
+// модулях, level - уровень коррекции ошибок L, M, Q или H.
+// При успешном запросе код ответа 200 и изображение.
+// В случае некорректного имени файла или параметров - код ответа 400.
+// Если ссылки нет или она удалена - код ответа 404.
+func (h *Handler) QRCode(c *gin.Context) {
+	id, format, err := qr.ParseFile(c.Param("file"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	opts, err := qr.ParseOptions(c.Query("size"), c.Query("margin"), c.Query("level"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	data, err := h.service.QRCode(c.Request.Context(), linkID(c, id), format, opts)
+	if err != nil {
+		h.handleProblem(c, custom_errors.ParseError(err), err)
+		return
+	}
+	c.Header("Cache-Control", "public, max-age=86400")
+	c.Data(http.StatusOK, qr.ContentType(format), data)
+}
+
+// wantQR - запрошен ли в ответе QR код созданных ссылок.
+func wantQR(c *gin.Context) bool {
+	value, _ := strconv.ParseBool(c.Query("qr"))
+	return value
+}
+
+// qrDataURL - QR код ссылки shortURL в виде data URL. Код короткой ссылки
+// всегда помещается в QR код, поэтому ошибка не ожидается и при ней
+// возвращается пустая строка.
+func qrDataURL(shortURL string) string {
+	dataURL, _ := qr.DataURL(shortURL)
+	return dataURL
+}
+
+// GetRevisions - история изменений адреса назначения ссылки id.
+// При успешном запросе код ответа 200 и список URLRevision.
+// Если ссылки нет, она удалена или пользователь не может ее изменять -
+// код ответа 404.
+func (h *Handler) GetRevisions(c *gin.Context) {
+	result, err := h.service.GetRevisions(c.Request.Context(), linkID(c, c.Param("id")), c.GetString("userId"))
+	if err != nil {
+		h.handleProblem(c, custom_errors.ParseError(err), err)
+		return
+	}
+	if result == nil {
+		result = []responses.URLRevision{}
+	}
+	c.IndentedJSON(http.StatusOK, result)
+}
+
+// RestoreRevision - возврат адреса назначения ссылки id из изменения
+// revision. При успешном возврате код ответа 200 и ссылка в формате GetURL.
+// В случае некорректного номера изменения - код ответа 400.
+// В случае запрещенного политикой адреса - код ответа 403.
+// Если ссылки или изменения нет - код ответа 404.
+func (h *Handler) RestoreRevision(c *gin.Context) {
+	revision, err := strconv.Atoi(c.Param("revision"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	result, err := h.service.RestoreRevision(c.Request.Context(), linkID(c, c.Param("id")), revision, c.GetString("userId"))
+	if err != nil {
+		h.handleProblem(c, custom_errors.ParseError(err), err)
+		return
+	}
+	c.IndentedJSON(http.StatusOK, result)
+}
+
 func (h *Handler) GetStats(c *gin.Context) {
 	hasPermission, response, err := h.service.GetStats(c.Request.Context(), net.ParseIP(c.GetHeader("X-Real-IP")))
 	if !hasPermission {
@@ -247,7 +644,28 @@ func (h *Handler) GetStats(c *gin.Context) {
 
 // handleError обработка типовых ошибок.
 func (h *Handler) handleError(c *gin.Context, err error) {
-	message := make(map[string]string)
-	message["detail"] = err.Error()
-	c.IndentedJSON(http.StatusBadRequest, message)
+	h.handleProblem(c, http.StatusBadRequest, err)
+}
+
+// handleProblem - ответ обработчика с ошибкой в формате
+// application/problem+json.
+func (h *Handler) handleProblem(c *gin.Context, statusCode int, err error) {
+	handleProblem(c, statusCode, err)
+}
+
+// readJSON - чтение тела запроса в формате JSON.
+func readJSON(c *gin.Context, v interface{}) error {
+	defer c.Request.Body.Close()
+
+	body, err := ioutil.ReadAll(c.Request.Body)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(body, v)
+}
+
+// handleProblem - ответ с ошибкой в формате application/problem+json.
+func handleProblem(c *gin.Context, statusCode int, err error) {
+	c.Header("Content-Type", responses.ProblemContentType)
+	c.IndentedJSON(statusCode, responses.NewProblem(statusCode, err.Error()))
 }
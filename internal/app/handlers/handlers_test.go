@@ -5,39 +5,62 @@ import (
 	"errors"
 	"github.com/p7chkn/go-musthave-shortener-tpl/internal/app/responses"
 	custom_errors "github.com/p7chkn/go-musthave-shortener-tpl/internal/errors"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/listing"
 	"io/ioutil"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gofrs/uuid"
 	"github.com/p7chkn/go-musthave-shortener-tpl/cmd/shortener/configuration"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/access"
 	"github.com/p7chkn/go-musthave-shortener-tpl/internal/app/middlewares"
-	"github.com/p7chkn/go-musthave-shortener-tpl/internal/utils"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/domains"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/qr"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/redirect"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/session"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/transfer"
 	"github.com/p7chkn/go-musthave-shortener-tpl/internal/workers"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
 
-func setupRouter(useCase URLServiceInterface) (*gin.Engine, *configuration.Config) {
+func setupRouter(useCase URLServiceInterface) (*gin.Engine, *session.Manager) {
 	router := gin.Default()
 	key, _ := configuration.GenerateRandom(16)
-	cfg := &configuration.Config{
-		Key:     key,
-		BaseURL: configuration.BaseURL,
-	}
+	sessionKey, _ := session.NewKey(key)
+	sessions, _ := session.NewManager([]session.Key{sessionKey}, session.Options{
+		TTL:        configuration.SessionTTL,
+		CookieName: "userId",
+	})
+	dom, _ := domains.New("http://localhost:8080/", []string{"go.example.com"})
 	handler := New(useCase)
-	router.Use(middlewares.CookiMiddleware(cfg))
+	router.Use(middlewares.CookiMiddleware(sessions))
+	router.Use(middlewares.DomainMiddleware(dom))
 	router.GET("/:id", handler.RetrieveShortURL)
+	router.POST("/:id/unlock", handler.UnlockShortURL)
 	router.POST("/", handler.CreateShortURL)
 	router.POST("/api/shorten", handler.ShortenURL)
 	router.GET("/user/urls", handler.GetUserURL)
 	router.POST("/api/shorten/batch", handler.CreateBatch)
 	router.DELETE("/api/user/urls", handler.DeleteBatch)
+	router.POST("/api/user/urls/restore", handler.RestoreURLs)
+	router.POST("/api/user/urls/import", handler.ImportURLs)
+	router.GET("/api/user/urls/export", handler.ExportURLs)
+	router.GET("/api/qr/:file", handler.QRCode)
+	router.PATCH("/api/user/urls/:id", handler.UpdateURL)
+	router.PUT("/api/user/urls/:id/meta", handler.UpdateMeta)
+	router.PUT("/api/user/urls/:id/redirect", handler.UpdateRedirect)
+	router.PUT("/api/user/urls/:id/access", handler.UpdateAccess)
+	router.GET("/api/user/urls/:id/revisions", handler.GetRevisions)
+	router.POST("/api/user/urls/:id/revisions/:revision/restore", handler.RestoreRevision)
 	router.HandleMethodNotAllowed = true
-	return router, cfg
+	return router, sessions
 }
 func TestRetriveShortURL(t *testing.T) {
 	type want struct {
@@ -81,8 +104,19 @@ func TestRetriveShortURL(t *testing.T) {
 			err:    custom_errors.NewCustomError(errors.New("not found"), http.StatusNotFound),
 			want: want{
 				code:        404,
-				response:    `{"detail":"not found"}`,
-				contentType: `application/json; charset=utf-8`,
+				response:    `{"type":"about:blank","title":"Not Found","status":404,"detail":"not found"}`,
+				contentType: `application/problem+json`,
+			},
+		},
+		{
+			name:   "GET link disabled by policy",
+			query:  "98fv58Wr3hGGIzm2-aH2zA628Ng=",
+			result: "http://phishing.example/?a=<b>",
+			err:    custom_errors.NewCustomError(errors.New("link is disabled by policy"), http.StatusForbidden),
+			want: want{
+				code:        200,
+				response:    `http://phishing.example/?a=&lt;b&gt;`,
+				contentType: `text/html; charset=utf-8`,
 			},
 		},
 	}
@@ -95,25 +129,237 @@ func TestRetriveShortURL(t *testing.T) {
 				wp.Run(ctx)
 			}()
 			useCaseMock := new(MockUserUseCaseInterface)
-			useCaseMock.On("GetURL", mock.Anything, tt.query).Return(tt.result, tt.err)
+			useCaseMock.On("Redirect", mock.Anything, tt.query, mock.Anything).
+				Return(redirect.Target{URL: tt.result, Code: http.StatusTemporaryRedirect, Mode: redirect.ModeHTTP}, tt.err)
+			if tt.want.code == http.StatusTemporaryRedirect {
+				useCaseMock.On("RecordClick", tt.query, "")
+			}
 			router, _ := setupRouter(useCaseMock)
 			w := httptest.NewRecorder()
 			req, _ := http.NewRequest(http.MethodGet, "/"+tt.query, nil)
 			router.ServeHTTP(w, req)
+			if tt.want.code == http.StatusTemporaryRedirect {
+				useCaseMock.AssertCalled(t, "RecordClick", tt.query, "")
+			}
 			assert.Equal(t, w.Header()["Content-Type"][0], tt.want.contentType)
 			assert.Equal(t, tt.want.code, w.Code)
 			resBody, err := ioutil.ReadAll(w.Body)
 			if err != nil {
 				t.Fatal(err)
 			}
-			if w.Header()["Content-Type"][0] == `application/json; charset=utf-8` {
+			switch contentType := w.Header()["Content-Type"][0]; {
+			case strings.Contains(contentType, "json"):
 				assert.JSONEq(t, tt.want.response, string(resBody))
-			} else {
+			case strings.Contains(contentType, "html"):
+				assert.Contains(t, string(resBody), tt.want.response)
+			default:
 				assert.Equal(t, tt.want.response, string(resBody))
 			}
 		})
 	}
 }
+func TestRedirectModes(t *testing.T) {
+	type want struct {
+		code     int
+		location string
+		response string
+	}
+	tests := []struct {
+		name    string
+		path    string
+		headers map[string]string
+		visit   redirect.Visit
+		target  redirect.Target
+		want    want
+	}{
+		{
+			name:   "permanent redirect with query",
+			path:   "/abc?utm_source=mail",
+			visit:  redirect.Visit{Query: url.Values{"utm_source": {"mail"}}, IP: net.ParseIP("192.0.2.1")},
+			target: redirect.Target{URL: "https://example.com/?utm_source=mail", Code: http.StatusMovedPermanently, Mode: redirect.ModeHTTP},
+			want:   want{code: http.StatusMovedPermanently, location: "https://example.com/?utm_source=mail"},
+		},
+		{
+			name:   "meta refresh",
+			path:   "/abc",
+			visit:  redirect.Visit{Query: url.Values{}, IP: net.ParseIP("192.0.2.1")},
+			target: redirect.Target{URL: "https://example.com/?a=1&b=2", Code: http.StatusTemporaryRedirect, Mode: redirect.ModeMeta},
+			want:   want{code: http.StatusOK, response: `<meta http-equiv="refresh" content="0; url=https://example.com/?a=1&amp;b=2">`},
+		},
+		{
+			name:   "javascript",
+			path:   "/abc",
+			visit:  redirect.Visit{Query: url.Values{}, IP: net.ParseIP("192.0.2.1")},
+			target: redirect.Target{URL: "https://example.com/</script>", Code: http.StatusTemporaryRedirect, Mode: redirect.ModeJS},
+			want:   want{code: http.StatusOK, response: `window.location.replace("https://example.com/\u003c/script\u003e")`},
+		},
+		{
+			name: "visitor headers",
+			path: "/abc",
+			headers: map[string]string{
+				"User-Agent":      "Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X)",
+				"Accept-Language": "de-DE,de;q=0.9",
+			},
+			visit: redirect.Visit{
+				Query:          url.Values{},
+				UserAgent:      "Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X)",
+				AcceptLanguage: "de-DE,de;q=0.9",
+				IP:             net.ParseIP("192.0.2.1"),
+			},
+			target: redirect.Target{URL: "https://example.com/de", Code: http.StatusTemporaryRedirect, Mode: redirect.ModeHTTP},
+			want:   want{code: http.StatusTemporaryRedirect, location: "https://example.com/de"},
+		},
+		{
+			name:  "split destination",
+			path:  "/abc",
+			visit: redirect.Visit{Query: url.Values{}, IP: net.ParseIP("192.0.2.1")},
+			target: redirect.Target{
+				URL:         "https://example.com/b?utm_source=ab",
+				Code:        http.StatusFound,
+				Mode:        redirect.ModeHTTP,
+				Destination: "https://example.com/b",
+			},
+			want: want{code: http.StatusFound, location: "https://example.com/b?utm_source=ab"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useCaseMock := new(MockUserUseCaseInterface)
+			// Посетитель - новый пользователь, которому выдается cookie сессии.
+			matchVisit := mock.MatchedBy(func(visit redirect.Visit) bool {
+				if visit.Visitor == "" {
+					return false
+				}
+				visit.Visitor = ""
+				return assert.ObjectsAreEqual(tt.visit, visit)
+			})
+			useCaseMock.On("Redirect", mock.Anything, "abc", matchVisit).Return(tt.target, nil)
+			useCaseMock.On("RecordClick", "abc", tt.target.Destination)
+			router, _ := setupRouter(useCaseMock)
+
+			request := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			for key, value := range tt.headers {
+				request.Header.Set(key, value)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, request)
+			result := w.Result()
+			body, _ := ioutil.ReadAll(result.Body)
+			result.Body.Close()
+
+			assert.Equal(t, tt.want.code, result.StatusCode)
+			assert.Equal(t, tt.want.location, result.Header.Get("Location"))
+			assert.Contains(t, string(body), tt.want.response)
+			if tt.target.Mode != redirect.ModeHTTP {
+				assert.Equal(t, "no-referrer", result.Header.Get("Referrer-Policy"))
+			}
+			useCaseMock.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUnlockShortURL(t *testing.T) {
+	type want struct {
+		code     int
+		location string
+		response string
+	}
+	target := redirect.Target{URL: "https://example.com/private", Code: http.StatusFound, Mode: redirect.ModeHTTP}
+	tests := []struct {
+		name        string
+		method      string
+		body        string
+		contentType string
+		accept      string
+		password    string
+		target      redirect.Target
+		err         error
+		want        want
+	}{
+		{
+			name:   "protected link page",
+			method: http.MethodGet,
+			err:    custom_errors.NewCustomError(access.ErrPasswordRequired, http.StatusUnauthorized),
+			want:   want{code: http.StatusUnauthorized, response: `<form method="post" action="/abc/unlock">`},
+		},
+		{
+			name:   "protected link json",
+			method: http.MethodGet,
+			accept: "application/json",
+			err:    custom_errors.NewCustomError(access.ErrPasswordRequired, http.StatusUnauthorized),
+			want:   want{code: http.StatusUnauthorized, response: access.ErrPasswordRequired.Error()},
+		},
+		{
+			name:        "unlock with form",
+			method:      http.MethodPost,
+			body:        "password=secret",
+			contentType: "application/x-www-form-urlencoded",
+			password:    "secret",
+			target:      target,
+			want:        want{code: http.StatusSeeOther, location: "https://example.com/private"},
+		},
+		{
+			name:        "unlock with json",
+			method:      http.MethodPost,
+			body:        `{"password": "secret"}`,
+			contentType: "application/json",
+			password:    "secret",
+			target:      target,
+			want:        want{code: http.StatusOK, response: `"url":"https://example.com/private"`},
+		},
+		{
+			name:        "wrong password",
+			method:      http.MethodPost,
+			body:        "password=guess",
+			contentType: "application/x-www-form-urlencoded",
+			password:    "guess",
+			err:         custom_errors.NewCustomError(access.ErrWrongPassword, http.StatusUnauthorized),
+			want:        want{code: http.StatusUnauthorized, response: `Wrong password`},
+		},
+		{
+			name:   "click limit reached",
+			method: http.MethodGet,
+			err:    custom_errors.NewCustomError(errors.New("click limit reached"), http.StatusGone),
+			want:   want{code: http.StatusGone},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useCaseMock := new(MockUserUseCaseInterface)
+			matchVisit := mock.MatchedBy(func(visit redirect.Visit) bool {
+				return visit.Password == tt.password
+			})
+			useCaseMock.On("Redirect", mock.Anything, "abc", matchVisit).Return(tt.target, tt.err)
+			if tt.err == nil {
+				useCaseMock.On("RecordClick", "abc", "")
+			}
+			router, _ := setupRouter(useCaseMock)
+
+			path := "/abc"
+			if tt.method == http.MethodPost {
+				path += "/unlock"
+			}
+			request := httptest.NewRequest(tt.method, path, strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				request.Header.Set("Content-Type", tt.contentType)
+			}
+			if tt.accept != "" {
+				request.Header.Set("Accept", tt.accept)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, request)
+			result := w.Result()
+			body, _ := ioutil.ReadAll(result.Body)
+			result.Body.Close()
+
+			assert.Equal(t, tt.want.code, result.StatusCode)
+			assert.Equal(t, tt.want.location, result.Header.Get("Location"))
+			assert.Contains(t, string(body), tt.want.response)
+			useCaseMock.AssertExpectations(t)
+		})
+	}
+}
+
 func TestCreateShortURL(t *testing.T) {
 	type want struct {
 		code        int
@@ -125,6 +371,7 @@ func TestCreateShortURL(t *testing.T) {
 		query  string
 		body   string
 		result string
+		err    error
 		want   want
 	}{
 		{
@@ -149,6 +396,18 @@ func TestCreateShortURL(t *testing.T) {
 				contentType: `text/plain`,
 			},
 		},
+		{
+			name:   "POST blocked destination",
+			query:  "",
+			body:   "http://phishing.example/",
+			result: "",
+			err:    custom_errors.NewCustomError(errors.New("destination is blocked by policy"), http.StatusForbidden),
+			want: want{
+				code:        403,
+				response:    `{"type":"about:blank","title":"Forbidden","status":403,"detail":"destination is blocked by policy"}`,
+				contentType: `application/problem+json`,
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -159,7 +418,7 @@ func TestCreateShortURL(t *testing.T) {
 				wp.Run(ctx)
 			}()
 			useCaseMock := new(MockUserUseCaseInterface)
-			useCaseMock.On("CreateURL", mock.Anything, tt.body, mock.Anything).Return(tt.result, nil)
+			useCaseMock.On("CreateURL", mock.Anything, tt.body, responses.LinkMeta{}, "", mock.Anything).Return(tt.result, tt.err)
 			router, _ := setupRouter(useCaseMock)
 			body := strings.NewReader(tt.body)
 			w := httptest.NewRecorder()
@@ -171,7 +430,7 @@ func TestCreateShortURL(t *testing.T) {
 			if err != nil {
 				t.Fatal(err)
 			}
-			if w.Header()["Content-Type"][0] == `application/json; charset=utf-8` {
+			if strings.Contains(w.Header()["Content-Type"][0], "json") {
 				assert.JSONEq(t, tt.want.response, string(resBody))
 			} else {
 				assert.Equal(t, tt.want.response, string(resBody))
@@ -179,6 +438,90 @@ func TestCreateShortURL(t *testing.T) {
 		})
 	}
 }
+func TestCustomDomains(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		host   string
+		path   string
+		body   string
+		setup  func(useCase *MockUserUseCaseInterface)
+		code   int
+	}{
+		{
+			name:   "create in request domain",
+			method: http.MethodPost,
+			host:   "go.example.com",
+			path:   "/",
+			body:   "http://iloverestaurant.ru/",
+			setup: func(useCase *MockUserUseCaseInterface) {
+				useCase.On("CreateURL", mock.Anything, "http://iloverestaurant.ru/", responses.LinkMeta{}, "go.example.com", mock.Anything).
+					Return("https://go.example.com/abc", nil)
+			},
+			code: http.StatusCreated,
+		},
+		{
+			name:   "create in chosen domain",
+			method: http.MethodPost,
+			host:   "localhost:8080",
+			path:   "/?domain=go.example.com",
+			body:   "http://iloverestaurant.ru/",
+			setup: func(useCase *MockUserUseCaseInterface) {
+				useCase.On("CreateURL", mock.Anything, "http://iloverestaurant.ru/", responses.LinkMeta{}, "go.example.com", mock.Anything).
+					Return("https://go.example.com/abc", nil)
+			},
+			code: http.StatusCreated,
+		},
+		{
+			name:   "redirect in request domain",
+			method: http.MethodGet,
+			host:   "go.example.com",
+			path:   "/abc",
+			setup: func(useCase *MockUserUseCaseInterface) {
+				useCase.On("Redirect", mock.Anything, "go.example.com/abc", mock.Anything).
+					Return(redirect.Target{URL: "http://iloverestaurant.ru/", Code: http.StatusTemporaryRedirect}, nil)
+				useCase.On("RecordClick", "go.example.com/abc", "").Return()
+			},
+			code: http.StatusTemporaryRedirect,
+		},
+		{
+			name:   "redirect in default domain",
+			method: http.MethodGet,
+			host:   "localhost:8080",
+			path:   "/abc",
+			setup: func(useCase *MockUserUseCaseInterface) {
+				useCase.On("Redirect", mock.Anything, "abc", mock.Anything).
+					Return(redirect.Target{URL: "http://iloverestaurant.ru/", Code: http.StatusTemporaryRedirect}, nil)
+				useCase.On("RecordClick", "abc", "").Return()
+			},
+			code: http.StatusTemporaryRedirect,
+		},
+		{
+			name:   "delete in request domain",
+			method: http.MethodDelete,
+			host:   "go.example.com",
+			path:   "/api/user/urls",
+			body:   `["abc","def"]`,
+			setup: func(useCase *MockUserUseCaseInterface) {
+				useCase.On("DeleteBatch", []string{"go.example.com/abc", "go.example.com/def"}, mock.Anything).Return()
+			},
+			code: http.StatusAccepted,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useCaseMock := new(MockUserUseCaseInterface)
+			tt.setup(useCaseMock)
+			router, _ := setupRouter(useCaseMock)
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			req.Host = tt.host
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			assert.Equal(t, tt.code, w.Code)
+			useCaseMock.AssertExpectations(t)
+		})
+	}
+}
 func TestShortenURL(t *testing.T) {
 	type want struct {
 		code        int
@@ -190,6 +533,7 @@ func TestShortenURL(t *testing.T) {
 		query   string
 		body    string
 		rawData string
+		meta    responses.LinkMeta
 		result  string
 		want    want
 	}{
@@ -205,6 +549,19 @@ func TestShortenURL(t *testing.T) {
 				contentType: `application/json; charset=utf-8`,
 			},
 		},
+		{
+			name:    "POST with title and tags",
+			query:   "api/shorten",
+			body:    `{"url": "http://iloverestaurant.ru/", "title": "Restaurant", "tags": ["food", "moscow"]}`,
+			rawData: "http://iloverestaurant.ru/",
+			meta:    responses.LinkMeta{Title: "Restaurant", Tags: []string{"food", "moscow"}},
+			result:  "http://localhost:8080/98fv58Wr3hGGIzm2-aH2zA628Ng=",
+			want: want{
+				code:        201,
+				response:    `{"result": "http://localhost:8080/98fv58Wr3hGGIzm2-aH2zA628Ng="}`,
+				contentType: `application/json; charset=utf-8`,
+			},
+		},
 		{
 			name:    "incorrect POST",
 			query:   "api/shorten",
@@ -213,8 +570,8 @@ func TestShortenURL(t *testing.T) {
 			result:  "98fv58Wr3hGGIzm2-aH2zA628Ng=",
 			want: want{
 				code:        400,
-				response:    `{"detail": "bad request"}`,
-				contentType: `application/json; charset=utf-8`,
+				response:    `{"type":"about:blank","title":"Bad Request","status":400,"detail":"bad request"}`,
+				contentType: `application/problem+json`,
 			},
 		},
 	}
@@ -227,7 +584,7 @@ func TestShortenURL(t *testing.T) {
 				wp.Run(ctx)
 			}()
 			useCaseMock := new(MockUserUseCaseInterface)
-			useCaseMock.On("CreateURL", mock.Anything, tt.rawData, mock.Anything).Return(tt.result, nil)
+			useCaseMock.On("CreateURL", mock.Anything, tt.rawData, tt.meta, "", mock.Anything).Return(tt.result, nil)
 			router, _ := setupRouter(useCaseMock)
 			body := strings.NewReader(tt.body)
 			w := httptest.NewRecorder()
@@ -239,7 +596,7 @@ func TestShortenURL(t *testing.T) {
 			if err != nil {
 				t.Fatal(err)
 			}
-			if w.Header()["Content-Type"][0] == `application/json; charset=utf-8` {
+			if strings.Contains(w.Header()["Content-Type"][0], "json") {
 				assert.JSONEq(t, tt.want.response, string(resBody))
 			} else {
 				assert.Equal(t, tt.want.response, string(resBody))
@@ -288,14 +645,15 @@ func TestGetUserURL(t *testing.T) {
 			}()
 			userID, _ := uuid.NewV4()
 			useCaseMock := new(MockUserUseCaseInterface)
-			useCaseMock.On("GetUserURL", mock.Anything, userID.String()).Return(tt.response, nil)
-			router, cfg := setupRouter(useCaseMock)
+			useCaseMock.On("GetUserURL", mock.Anything, userID.String(), listing.Query{}).
+				Return(responses.UserURLs{URLs: tt.response}, nil)
+			router, sessions := setupRouter(useCaseMock)
 
-			encoder, _ := utils.New(cfg.Key)
+			token, _ := sessions.Issue(userID.String())
 
 			cookie := http.Cookie{
 				Name:  "userId",
-				Value: encoder.EncodeUUIDtoString(userID.Bytes()),
+				Value: token,
 			}
 
 			w := httptest.NewRecorder()
@@ -316,6 +674,59 @@ func TestGetUserURL(t *testing.T) {
 	}
 }
 
+func TestGetUserURLPage(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		setup      func(useCase *MockUserUseCaseInterface)
+		code       int
+		nextCursor string
+	}{
+		{
+			name:  "first page",
+			query: "?limit=1&sort=created_at&domain=example.com&q=docs",
+			setup: func(useCase *MockUserUseCaseInterface) {
+				query := listing.Query{Limit: 1, Domain: "example.com", Search: "docs", Ascending: true}
+				useCase.On("GetUserURL", mock.Anything, "user-1", query).Return(responses.UserURLs{
+					URLs:       []responses.GetURL{{ID: "abc", ShortURL: "http://localhost:8080/abc", OriginalURL: "https://example.com/docs"}},
+					NextCursor: "next",
+				}, nil)
+			},
+			code:       http.StatusOK,
+			nextCursor: "next",
+		},
+		{
+			name:  "invalid limit",
+			query: "?limit=many",
+			setup: func(useCase *MockUserUseCaseInterface) {},
+			code:  http.StatusBadRequest,
+		},
+		{
+			name:  "invalid date",
+			query: "?created_from=yesterday",
+			setup: func(useCase *MockUserUseCaseInterface) {},
+			code:  http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useCaseMock := new(MockUserUseCaseInterface)
+			tt.setup(useCaseMock)
+			router, sessions := setupRouter(useCaseMock)
+			token, _ := sessions.Issue("user-1")
+
+			request := httptest.NewRequest(http.MethodGet, "/user/urls"+tt.query, nil)
+			request.AddCookie(sessions.Cookie(token))
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, request)
+
+			assert.Equal(t, tt.code, w.Code)
+			assert.Equal(t, tt.nextCursor, w.Header().Get(NextCursorHeader))
+			useCaseMock.AssertExpectations(t)
+		})
+	}
+}
+
 func TestCreateBatch(t *testing.T) {
 	type want struct {
 		code        int
@@ -409,12 +820,12 @@ func TestCreateBatch(t *testing.T) {
 			useCaseMock := new(MockUserUseCaseInterface)
 			useCaseMock.On("CreateBatch", mock.Anything, tt.mockData, userID.String()).Return(tt.mockResponce, nil)
 
-			router, cfg := setupRouter(useCaseMock)
-			encoder, _ := utils.New(cfg.Key)
+			router, sessions := setupRouter(useCaseMock)
+			token, _ := sessions.Issue(userID.String())
 
 			cookie := http.Cookie{
 				Name:  "userId",
-				Value: encoder.EncodeUUIDtoString(userID.Bytes()),
+				Value: token,
 			}
 
 			body := strings.NewReader(tt.body)
@@ -461,6 +872,14 @@ func TestDeleteBatch(t *testing.T) {
 				}]`,
 			},
 		},
+		{
+			name:  "DELETE urls of other domain",
+			query: "api/user/urls",
+			body:  `["1", "go.example.com/2"]`,
+			want: want{
+				code: 400,
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -474,13 +893,13 @@ func TestDeleteBatch(t *testing.T) {
 			userID, _ := uuid.NewV4()
 			useCaseMock := new(MockUserUseCaseInterface)
 			useCaseMock.On("DeleteBatch", mock.Anything, mock.Anything, mock.Anything).Return(nil)
-			router, cfg := setupRouter(useCaseMock)
+			router, sessions := setupRouter(useCaseMock)
 
-			encoder, _ := utils.New(cfg.Key)
+			token, _ := sessions.Issue(userID.String())
 
 			cookie := http.Cookie{
 				Name:  "userId",
-				Value: encoder.EncodeUUIDtoString(userID.Bytes()),
+				Value: token,
 			}
 			body := strings.NewReader(tt.body)
 			w := httptest.NewRecorder()
@@ -489,7 +908,589 @@ func TestDeleteBatch(t *testing.T) {
 
 			router.ServeHTTP(w, req)
 			assert.Equal(t, tt.want.code, w.Code)
+			if tt.want.code == http.StatusBadRequest {
+				useCaseMock.AssertNotCalled(t, "DeleteBatch", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
 
+func TestRestoreURLs(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		setup    func(useCase *MockUserUseCaseInterface)
+		code     int
+		response string
+	}{
+		{
+			name: "restore urls",
+			body: `["abc", "def"]`,
+			setup: func(useCase *MockUserUseCaseInterface) {
+				useCase.On("RestoreURLs", mock.Anything, []string{"abc", "def"}, "user-1").
+					Return(responses.RestoreURLsResponse{Restored: 1}, nil)
+			},
+			code:     http.StatusOK,
+			response: `"restored": 1`,
+		},
+		{
+			name:     "restore with bad body",
+			body:     `{"urls": "abc"}`,
+			setup:    func(useCase *MockUserUseCaseInterface) {},
+			code:     http.StatusBadRequest,
+			response: `cannot unmarshal`,
+		},
+		{
+			name:     "restore urls of other domain",
+			body:     `["abc", "go.example.com/def"]`,
+			setup:    func(useCase *MockUserUseCaseInterface) {},
+			code:     http.StatusBadRequest,
+			response: `invalid link id`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useCaseMock := new(MockUserUseCaseInterface)
+			tt.setup(useCaseMock)
+			router, sessions := setupRouter(useCaseMock)
+			token, _ := sessions.Issue("user-1")
+
+			request := httptest.NewRequest(http.MethodPost, "/api/user/urls/restore", strings.NewReader(tt.body))
+			request.AddCookie(sessions.Cookie(token))
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, request)
+			result := w.Result()
+			body, _ := ioutil.ReadAll(result.Body)
+			result.Body.Close()
+
+			assert.Equal(t, tt.code, result.StatusCode)
+			assert.Contains(t, string(body), tt.response)
+			useCaseMock.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUpdateURL(t *testing.T) {
+	type want struct {
+		code     int
+		response string
+	}
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		setup  func(useCase *MockUserUseCaseInterface)
+		want   want
+	}{
+		{
+			name:   "update destination",
+			method: http.MethodPatch,
+			path:   "/api/user/urls/abc",
+			body:   `{"url": "https://example.com/new"}`,
+			setup: func(useCase *MockUserUseCaseInterface) {
+				useCase.On("UpdateURL", mock.Anything, "abc", "https://example.com/new", "user-1").
+					Return(responses.GetURL{ShortURL: "http://localhost:8080/abc", OriginalURL: "https://example.com/new"}, nil)
+			},
+			want: want{code: http.StatusOK, response: `"original_url": "https://example.com/new"`},
+		},
+		{
+			name:   "update without url",
+			method: http.MethodPatch,
+			path:   "/api/user/urls/abc",
+			body:   `{}`,
+			setup:  func(useCase *MockUserUseCaseInterface) {},
+			want:   want{code: http.StatusBadRequest, response: `bad request`},
+		},
+		{
+			name:   "update foreign link",
+			method: http.MethodPatch,
+			path:   "/api/user/urls/abc",
+			body:   `{"url": "https://example.com/new"}`,
+			setup: func(useCase *MockUserUseCaseInterface) {
+				useCase.On("UpdateURL", mock.Anything, "abc", "https://example.com/new", "user-1").
+					Return(responses.GetURL{}, custom_errors.NewCustomError(errors.New("url not found"), http.StatusNotFound))
+			},
+			want: want{code: http.StatusNotFound, response: `url not found`},
+		},
+		{
+			name:   "update meta",
+			method: http.MethodPut,
+			path:   "/api/user/urls/abc/meta",
+			body:   `{"title": "Docs", "tags": ["Go"]}`,
+			setup: func(useCase *MockUserUseCaseInterface) {
+				useCase.On("UpdateMeta", mock.Anything, "abc", responses.LinkMeta{Title: "Docs", Tags: []string{"Go"}}, "user-1").
+					Return(responses.LinkMeta{Title: "Docs", Tags: []string{"go"}}, nil)
+			},
+			want: want{code: http.StatusOK, response: `"go"`},
+		},
+		{
+			name:   "update meta with long title",
+			method: http.MethodPut,
+			path:   "/api/user/urls/abc/meta",
+			body:   `{"title": "Docs"}`,
+			setup: func(useCase *MockUserUseCaseInterface) {
+				useCase.On("UpdateMeta", mock.Anything, "abc", responses.LinkMeta{Title: "Docs"}, "user-1").
+					Return(responses.LinkMeta{}, custom_errors.NewCustomError(errors.New("title is longer than 256 characters"), http.StatusBadRequest))
+			},
+			want: want{code: http.StatusBadRequest, response: `title is longer than 256 characters`},
+		},
+		{
+			name:   "update redirect",
+			method: http.MethodPut,
+			path:   "/api/user/urls/abc/redirect",
+			body:   `{"code": 301, "passthrough": true, "utm": {"source": "Mail"}}`,
+			setup: func(useCase *MockUserUseCaseInterface) {
+				settings := redirect.Settings{Code: http.StatusMovedPermanently, Passthrough: true, UTM: &redirect.UTM{Source: "Mail"}}
+				useCase.On("UpdateRedirect", mock.Anything, "abc", settings, "user-1").Return(settings, nil)
+			},
+			want: want{code: http.StatusOK, response: `"code": 301`},
+		},
+		{
+			name:   "update redirect with invalid code",
+			method: http.MethodPut,
+			path:   "/api/user/urls/abc/redirect",
+			body:   `{"code": 200}`,
+			setup: func(useCase *MockUserUseCaseInterface) {
+				useCase.On("UpdateRedirect", mock.Anything, "abc", redirect.Settings{Code: http.StatusOK}, "user-1").
+					Return(redirect.Settings{}, custom_errors.NewCustomError(redirect.ErrInvalidCode, http.StatusBadRequest))
+			},
+			want: want{code: http.StatusBadRequest, response: redirect.ErrInvalidCode.Error()},
+		},
+		{
+			name:   "update access",
+			method: http.MethodPut,
+			path:   "/api/user/urls/abc/access",
+			body:   `{"password": "secret", "max_clicks": 5}`,
+			setup: func(useCase *MockUserUseCaseInterface) {
+				useCase.On("UpdateAccess", mock.Anything, "abc", responses.LinkAccess{Password: "secret", MaxClicks: 5}, "user-1").
+					Return(responses.AccessState{Protected: true, MaxClicks: 5, Remaining: 5}, nil)
+			},
+			want: want{code: http.StatusOK, response: `"remaining": 5`},
+		},
+		{
+			name:   "update access with short password",
+			method: http.MethodPut,
+			path:   "/api/user/urls/abc/access",
+			body:   `{"password": "abc"}`,
+			setup: func(useCase *MockUserUseCaseInterface) {
+				useCase.On("UpdateAccess", mock.Anything, "abc", responses.LinkAccess{Password: "abc"}, "user-1").
+					Return(responses.AccessState{}, custom_errors.NewCustomError(access.ErrInvalidPassword, http.StatusBadRequest))
+			},
+			want: want{code: http.StatusBadRequest, response: access.ErrInvalidPassword.Error()},
+		},
+		{
+			name:   "list revisions",
+			method: http.MethodGet,
+			path:   "/api/user/urls/abc/revisions",
+			setup: func(useCase *MockUserUseCaseInterface) {
+				useCase.On("GetRevisions", mock.Anything, "abc", "user-1").
+					Return([]responses.URLRevision{{Revision: 1, OriginalURL: "https://example.com/old", ChangedBy: "user-1"}}, nil)
+			},
+			want: want{code: http.StatusOK, response: `"original_url": "https://example.com/old"`},
+		},
+		{
+			name:   "empty revisions",
+			method: http.MethodGet,
+			path:   "/api/user/urls/abc/revisions",
+			setup: func(useCase *MockUserUseCaseInterface) {
+				useCase.On("GetRevisions", mock.Anything, "abc", "user-1").Return(nil, nil)
+			},
+			want: want{code: http.StatusOK, response: `[]`},
+		},
+		{
+			name:   "restore revision",
+			method: http.MethodPost,
+			path:   "/api/user/urls/abc/revisions/1/restore",
+			setup: func(useCase *MockUserUseCaseInterface) {
+				useCase.On("RestoreRevision", mock.Anything, "abc", 1, "user-1").
+					Return(responses.GetURL{ShortURL: "http://localhost:8080/abc", OriginalURL: "https://example.com/old"}, nil)
+			},
+			want: want{code: http.StatusOK, response: `"original_url": "https://example.com/old"`},
+		},
+		{
+			name:   "restore invalid revision",
+			method: http.MethodPost,
+			path:   "/api/user/urls/abc/revisions/first/restore",
+			setup:  func(useCase *MockUserUseCaseInterface) {},
+			want:   want{code: http.StatusBadRequest, response: `invalid syntax`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useCaseMock := new(MockUserUseCaseInterface)
+			tt.setup(useCaseMock)
+			router, sessions := setupRouter(useCaseMock)
+			token, _ := sessions.Issue("user-1")
+
+			request := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			request.AddCookie(sessions.Cookie(token))
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, request)
+			result := w.Result()
+			body, _ := ioutil.ReadAll(result.Body)
+			result.Body.Close()
+
+			assert.Equal(t, tt.want.code, result.StatusCode)
+			assert.Contains(t, string(body), tt.want.response)
+			useCaseMock.AssertExpectations(t)
+		})
+	}
+}
+
+func TestImportExportURLs(t *testing.T) {
+	type want struct {
+		code        int
+		response    string
+		contentType string
+	}
+	tests := []struct {
+		name        string
+		method      string
+		path        string
+		contentType string
+		body        string
+		setup       func(useCase *MockUserUseCaseInterface)
+		want        want
+	}{
+		{
+			name:        "import csv",
+			method:      http.MethodPost,
+			path:        "/api/user/urls/import",
+			contentType: "text/csv",
+			body:        "original_url,tags\nhttps://example.com/a,go|docs\n",
+			setup: func(useCase *MockUserUseCaseInterface) {
+				useCase.On("ImportURLs", mock.Anything, mock.Anything, "user-1").
+					Return(func(ctx context.Context, reader transfer.Reader, userID string) responses.ImportReport {
+						u, err := reader.Read()
+						if err != nil || u.OriginalURL != "https://example.com/a" || len(u.Tags) != 2 {
+							return responses.ImportReport{}
+						}
+						return responses.ImportReport{Imported: 1, Errors: []responses.ImportError{}}
+					}, nil)
+			},
+			want: want{code: http.StatusOK, response: `"imported": 1`, contentType: "application/json; charset=utf-8"},
+		},
+		{
+			name:   "import jsonl by format param",
+			method: http.MethodPost,
+			path:   "/api/user/urls/import?format=jsonl",
+			body:   `{"original_url": "https://example.com/a"}`,
+			setup: func(useCase *MockUserUseCaseInterface) {
+				useCase.On("ImportURLs", mock.Anything, mock.Anything, "user-1").
+					Return(responses.ImportReport{Imported: 1, Errors: []responses.ImportError{}}, nil)
+			},
+			want: want{code: http.StatusOK, response: `"imported": 1`, contentType: "application/json; charset=utf-8"},
+		},
+		{
+			name:        "import unknown format",
+			method:      http.MethodPost,
+			path:        "/api/user/urls/import",
+			contentType: "application/xml",
+			body:        `<urls/>`,
+			setup:       func(useCase *MockUserUseCaseInterface) {},
+			want:        want{code: http.StatusBadRequest, response: transfer.ErrUnknownFormat.Error(), contentType: responses.ProblemContentType},
+		},
+		{
+			name:        "import csv without url column",
+			method:      http.MethodPost,
+			path:        "/api/user/urls/import",
+			contentType: "text/csv",
+			body:        "url\nhttps://example.com/a\n",
+			setup:       func(useCase *MockUserUseCaseInterface) {},
+			want:        want{code: http.StatusBadRequest, response: transfer.ErrNoURLColumn.Error(), contentType: responses.ProblemContentType},
+		},
+		{
+			name:        "import while draining",
+			method:      http.MethodPost,
+			path:        "/api/user/urls/import",
+			contentType: "application/x-ndjson",
+			setup: func(useCase *MockUserUseCaseInterface) {
+				useCase.On("ImportURLs", mock.Anything, mock.Anything, "user-1").
+					Return(responses.ImportReport{}, custom_errors.NewCustomError(errors.New("service is draining"), http.StatusServiceUnavailable))
+			},
+			want: want{code: http.StatusServiceUnavailable, response: `service is draining`, contentType: responses.ProblemContentType},
+		},
+		{
+			name:   "export csv",
+			method: http.MethodGet,
+			path:   "/api/user/urls/export?format=csv",
+			setup: func(useCase *MockUserUseCaseInterface) {
+				useCase.On("ExportURLs", mock.Anything, "user-1", mock.Anything).
+					Run(func(args mock.Arguments) {
+						writer := args.Get(2).(transfer.Writer)
+						writer.Write(responses.ExportURL{GetURL: responses.GetURL{ID: "abc", OriginalURL: "https://example.com/a"}})
+						writer.Flush()
+					}).
+					Return(nil)
+			},
+			want: want{code: http.StatusOK, response: "abc,,,https://example.com/a", contentType: "text/csv; charset=utf-8"},
+		},
+		{
+			name:   "export jsonl by default",
+			method: http.MethodGet,
+			path:   "/api/user/urls/export",
+			setup: func(useCase *MockUserUseCaseInterface) {
+				useCase.On("ExportURLs", mock.Anything, "user-1", mock.Anything).Return(nil)
+			},
+			want: want{code: http.StatusOK, contentType: "application/x-ndjson"},
+		},
+		{
+			name:   "export unknown format",
+			method: http.MethodGet,
+			path:   "/api/user/urls/export?format=xml",
+			setup:  func(useCase *MockUserUseCaseInterface) {},
+			want:   want{code: http.StatusBadRequest, response: transfer.ErrUnknownFormat.Error(), contentType: responses.ProblemContentType},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useCaseMock := new(MockUserUseCaseInterface)
+			tt.setup(useCaseMock)
+			router, sessions := setupRouter(useCaseMock)
+			token, _ := sessions.Issue("user-1")
+
+			request := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				request.Header.Set("Content-Type", tt.contentType)
+			}
+			request.AddCookie(sessions.Cookie(token))
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, request)
+			result := w.Result()
+			body, _ := ioutil.ReadAll(result.Body)
+			result.Body.Close()
+
+			assert.Equal(t, tt.want.code, result.StatusCode)
+			assert.Equal(t, tt.want.contentType, result.Header.Get("Content-Type"))
+			assert.Contains(t, string(body), tt.want.response)
+			useCaseMock.AssertExpectations(t)
+		})
+	}
+}
+
+func TestQRCode(t *testing.T) {
+	type want struct {
+		code        int
+		response    string
+		contentType string
+	}
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		setup  func(useCase *MockUserUseCaseInterface)
+		want   want
+	}{
+		{
+			name:   "png with options",
+			method: http.MethodGet,
+			path:   "/api/qr/abc.png?size=512&margin=1&level=q",
+			setup: func(useCase *MockUserUseCaseInterface) {
+				useCase.On("QRCode", mock.Anything, "abc", qr.FormatPNG, qr.Options{Size: 512, Margin: 1, Level: "Q"}).
+					Return([]byte("png"), nil)
+			},
+			want: want{code: http.StatusOK, response: "png", contentType: "image/png"},
+		},
+		{
+			name:   "svg with defaults",
+			method: http.MethodGet,
+			path:   "/api/qr/abc.svg",
+			setup: func(useCase *MockUserUseCaseInterface) {
+				useCase.On("QRCode", mock.Anything, "abc", qr.FormatSVG, qr.DefaultOptions()).
+					Return([]byte("<svg/>"), nil)
+			},
+			want: want{code: http.StatusOK, response: "<svg/>", contentType: "image/svg+xml"},
+		},
+		{
+			name:   "unknown format",
+			method: http.MethodGet,
+			path:   "/api/qr/abc.gif",
+			setup:  func(useCase *MockUserUseCaseInterface) {},
+			want:   want{code: http.StatusBadRequest, response: qr.ErrUnknownFormat.Error(), contentType: responses.ProblemContentType},
+		},
+		{
+			name:   "invalid size",
+			method: http.MethodGet,
+			path:   "/api/qr/abc.png?size=5000",
+			setup:  func(useCase *MockUserUseCaseInterface) {},
+			want:   want{code: http.StatusBadRequest, response: qr.ErrInvalidSize.Error(), contentType: responses.ProblemContentType},
+		},
+		{
+			name:   "missing link",
+			method: http.MethodGet,
+			path:   "/api/qr/abc.png",
+			setup: func(useCase *MockUserUseCaseInterface) {
+				useCase.On("QRCode", mock.Anything, "abc", qr.FormatPNG, qr.DefaultOptions()).
+					Return(nil, custom_errors.NewCustomError(errors.New("url not found"), http.StatusNotFound))
+			},
+			want: want{code: http.StatusNotFound, response: "url not found", contentType: responses.ProblemContentType},
+		},
+		{
+			name:   "shorten with qr",
+			method: http.MethodPost,
+			path:   "/api/shorten?qr=true",
+			body:   `{"url": "http://iloverestaurant.ru/"}`,
+			setup: func(useCase *MockUserUseCaseInterface) {
+				useCase.On("CreateURL", mock.Anything, "http://iloverestaurant.ru/", responses.LinkMeta{}, "", mock.Anything).
+					Return("http://localhost:8080/abc", nil)
+			},
+			want: want{code: http.StatusCreated, response: `"qr": "data:image/png;base64,`, contentType: "application/json; charset=utf-8"},
+		},
+		{
+			name:   "batch with qr",
+			method: http.MethodPost,
+			path:   "/api/shorten/batch?qr=1",
+			body:   `[{"correlation_id": "1", "original_url": "http://iloverestaurant.ru/"}]`,
+			setup: func(useCase *MockUserUseCaseInterface) {
+				useCase.On("CreateBatch", mock.Anything, mock.Anything, mock.Anything).
+					Return([]responses.ManyPostResponse{{CorrelationID: "1", ShortURL: "http://localhost:8080/abc"}}, nil)
+			},
+			want: want{code: http.StatusCreated, response: `"qr": "data:image/png;base64,`, contentType: "application/json; charset=utf-8"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useCaseMock := new(MockUserUseCaseInterface)
+			tt.setup(useCaseMock)
+			router, _ := setupRouter(useCaseMock)
+
+			request := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, request)
+			result := w.Result()
+			body, _ := ioutil.ReadAll(result.Body)
+			result.Body.Close()
+
+			assert.Equal(t, tt.want.code, result.StatusCode)
+			assert.Equal(t, tt.want.contentType, result.Header.Get("Content-Type"))
+			assert.Contains(t, string(body), tt.want.response)
+			useCaseMock.AssertExpectations(t)
+		})
+	}
+}
+
+func TestPreview(t *testing.T) {
+	type want struct {
+		code        int
+		response    string
+		contentType string
+	}
+	createdAt := time.Date(2021, 10, 5, 12, 30, 0, 0, time.UTC)
+	preview := responses.LinkPreview{
+		GetURL: responses.GetURL{
+			ID:          "abc",
+			ShortURL:    "http://localhost:8080/abc",
+			OriginalURL: "https://example.com/docs?a=<b>",
+			CreatedAt:   &createdAt,
+			LinkMeta:    responses.LinkMeta{Title: "Docs", Tags: []string{"go", "web"}},
+		},
+		Clicks: 42,
+	}
+	tests := []struct {
+		name   string
+		path   string
+		accept string
+		result responses.LinkPreview
+		err    error
+		want   want
+	}{
+		{
+			name:   "plus suffix html",
+			path:   "/abc+",
+			result: preview,
+			want: want{
+				code:        http.StatusOK,
+				response:    `<dt>Clicks</dt><dd>42</dd>`,
+				contentType: "text/html; charset=utf-8",
+			},
+		},
+		{
+			name:   "preview parameter html escapes destination",
+			path:   "/abc?preview",
+			result: preview,
+			want: want{
+				code:        http.StatusOK,
+				response:    `https://example.com/docs?a=&lt;b&gt;`,
+				contentType: "text/html; charset=utf-8",
+			},
+		},
+		{
+			name:   "json when requested",
+			path:   "/abc+",
+			accept: "application/json",
+			result: preview,
+			want: want{
+				code:        http.StatusOK,
+				response:    `"clicks":42`,
+				contentType: "application/json; charset=utf-8",
+			},
+		},
+		{
+			name: "split destinations",
+			path: "/abc+",
+			result: responses.LinkPreview{GetURL: preview.GetURL, Clicks: 3, Destinations: []responses.DestinationClicks{
+				{URL: "https://example.com/a", Weight: 1, Clicks: 1},
+				{URL: "https://example.com/b", Weight: 1, Clicks: 2},
+			}},
+			want: want{
+				code:        http.StatusOK,
+				response:    `<td>1</td><td>2</td>`,
+				contentType: "text/html; charset=utf-8",
+			},
+		},
+		{
+			name:   "blocked link",
+			path:   "/abc+",
+			result: responses.LinkPreview{GetURL: preview.GetURL, Blocked: true},
+			want: want{
+				code:        http.StatusOK,
+				response:    `This link has been disabled`,
+				contentType: "text/html; charset=utf-8",
+			},
+		},
+		{
+			name: "deleted link",
+			path: "/abc+",
+			err:  custom_errors.NewCustomError(errors.New("deleted"), http.StatusGone),
+			want: want{code: http.StatusGone},
+		},
+		{
+			name: "missing link",
+			path: "/abc?preview=1",
+			err:  custom_errors.NewCustomError(errors.New("url not found"), http.StatusNotFound),
+			want: want{
+				code:        http.StatusNotFound,
+				response:    "url not found",
+				contentType: responses.ProblemContentType,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useCaseMock := new(MockUserUseCaseInterface)
+			useCaseMock.On("Preview", mock.Anything, "abc").Return(tt.result, tt.err)
+			router, _ := setupRouter(useCaseMock)
+
+			request := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.accept != "" {
+				request.Header.Set("Accept", tt.accept)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, request)
+			result := w.Result()
+			body, _ := ioutil.ReadAll(result.Body)
+			result.Body.Close()
+
+			assert.Equal(t, tt.want.code, result.StatusCode)
+			if tt.want.contentType != "" {
+				assert.Equal(t, tt.want.contentType, result.Header.Get("Content-Type"))
+			}
+			assert.Contains(t, string(body), tt.want.response)
+			useCaseMock.AssertExpectations(t)
+			useCaseMock.AssertNotCalled(t, "Redirect", mock.Anything, mock.Anything, mock.Anything)
+			useCaseMock.AssertNotCalled(t, "RecordClick", mock.Anything, mock.Anything)
 		})
 	}
 }
@@ -506,13 +1507,13 @@ func BenchmarkHandler_GetUserURL(b *testing.B) {
 			userID, _ := uuid.NewV4()
 			useCaseMock := new(MockUserUseCaseInterface)
 			useCaseMock.On("DeleteBatch", mock.Anything, mock.Anything, mock.Anything).Return(nil)
-			router, cfg := setupRouter(useCaseMock)
+			router, sessions := setupRouter(useCaseMock)
 
-			encoder, _ := utils.New(cfg.Key)
+			token, _ := sessions.Issue(userID.String())
 
 			cookie := http.Cookie{
 				Name:  "userId",
-				Value: encoder.EncodeUUIDtoString(userID.Bytes()),
+				Value: token,
 			}
 			body := strings.NewReader(`["1", "2", "3", "4"]`)
 			w := httptest.NewRecorder()
@@ -9,6 +9,14 @@ import (
 	mock "github.com/stretchr/testify/mock"
 
 	responses "github.com/p7chkn/go-musthave-shortener-tpl/internal/app/responses"
+
+	listing "github.com/p7chkn/go-musthave-shortener-tpl/internal/listing"
+
+	qr "github.com/p7chkn/go-musthave-shortener-tpl/internal/qr"
+
+	redirect "github.com/p7chkn/go-musthave-shortener-tpl/internal/redirect"
+
+	transfer "github.com/p7chkn/go-musthave-shortener-tpl/internal/transfer"
 )
 
 // MockUserUseCaseInterface is an autogenerated mock type for the URLServiceInterface type
@@ -39,20 +47,20 @@ func (_m *MockUserUseCaseInterface) CreateBatch(ctx context.Context, urls []resp
 	return r0, r1
 }
 
-// CreateURL provides a mock function with given fields: ctx, longURL, user
-func (_m *MockUserUseCaseInterface) CreateURL(ctx context.Context, longURL string, user string) (string, error) {
-	ret := _m.Called(ctx, longURL, user)
+// CreateURL provides a mock function with given fields: ctx, longURL, meta, domain, user
+func (_m *MockUserUseCaseInterface) CreateURL(ctx context.Context, longURL string, meta responses.LinkMeta, domain string, user string) (string, error) {
+	ret := _m.Called(ctx, longURL, meta, domain, user)
 
 	var r0 string
-	if rf, ok := ret.Get(0).(func(context.Context, string, string) string); ok {
-		r0 = rf(ctx, longURL, user)
+	if rf, ok := ret.Get(0).(func(context.Context, string, responses.LinkMeta, string, string) string); ok {
+		r0 = rf(ctx, longURL, meta, domain, user)
 	} else {
 		r0 = ret.Get(0).(string)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
-		r1 = rf(ctx, longURL, user)
+	if rf, ok := ret.Get(1).(func(context.Context, string, responses.LinkMeta, string, string) error); ok {
+		r1 = rf(ctx, longURL, meta, domain, user)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -65,6 +73,43 @@ func (_m *MockUserUseCaseInterface) DeleteBatch(urls []string, userId string) {
 	_m.Called(urls, userId)
 }
 
+// ExportURLs provides a mock function with given fields: ctx, userID, writer
+func (_m *MockUserUseCaseInterface) ExportURLs(ctx context.Context, userID string, writer transfer.Writer) error {
+	ret := _m.Called(ctx, userID, writer)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, transfer.Writer) error); ok {
+		r0 = rf(ctx, userID, writer)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetRevisions provides a mock function with given fields: ctx, shortURL, userID
+func (_m *MockUserUseCaseInterface) GetRevisions(ctx context.Context, shortURL string, userID string) ([]responses.URLRevision, error) {
+	ret := _m.Called(ctx, shortURL, userID)
+
+	var r0 []responses.URLRevision
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) []responses.URLRevision); ok {
+		r0 = rf(ctx, shortURL, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]responses.URLRevision)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, shortURL, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetStats provides a mock function with given fields: ctx, ip
 func (_m *MockUserUseCaseInterface) GetStats(ctx context.Context, ip net.IP) (bool, responses.StatResponse, error) {
 	ret := _m.Called(ctx, ip)
@@ -114,22 +159,41 @@ func (_m *MockUserUseCaseInterface) GetURL(ctx context.Context, userID string) (
 	return r0, r1
 }
 
-// GetUsersURL provides a mock function with given fields: ctx, userId
-func (_m *MockUserUseCaseInterface) GetUserURL(ctx context.Context, userId string) ([]responses.GetURL, error) {
-	ret := _m.Called(ctx, userId)
+// GetUsersURL provides a mock function with given fields: ctx, userId, query
+func (_m *MockUserUseCaseInterface) GetUserURL(ctx context.Context, userId string, query listing.Query) (responses.UserURLs, error) {
+	ret := _m.Called(ctx, userId, query)
 
-	var r0 []responses.GetURL
-	if rf, ok := ret.Get(0).(func(context.Context, string) []responses.GetURL); ok {
-		r0 = rf(ctx, userId)
+	var r0 responses.UserURLs
+	if rf, ok := ret.Get(0).(func(context.Context, string, listing.Query) responses.UserURLs); ok {
+		r0 = rf(ctx, userId, query)
 	} else {
-		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]responses.GetURL)
-		}
+		r0 = ret.Get(0).(responses.UserURLs)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
-		r1 = rf(ctx, userId)
+	if rf, ok := ret.Get(1).(func(context.Context, string, listing.Query) error); ok {
+		r1 = rf(ctx, userId, query)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ImportURLs provides a mock function with given fields: ctx, reader, userID
+func (_m *MockUserUseCaseInterface) ImportURLs(ctx context.Context, reader transfer.Reader, userID string) (responses.ImportReport, error) {
+	ret := _m.Called(ctx, reader, userID)
+
+	var r0 responses.ImportReport
+	if rf, ok := ret.Get(0).(func(context.Context, transfer.Reader, string) responses.ImportReport); ok {
+		r0 = rf(ctx, reader, userID)
+	} else {
+		r0 = ret.Get(0).(responses.ImportReport)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, transfer.Reader, string) error); ok {
+		r1 = rf(ctx, reader, userID)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -150,3 +214,199 @@ func (_m *MockUserUseCaseInterface) PingDB(ctx context.Context) error {
 
 	return r0
 }
+
+// Preview provides a mock function with given fields: ctx, shortURL
+func (_m *MockUserUseCaseInterface) Preview(ctx context.Context, shortURL string) (responses.LinkPreview, error) {
+	ret := _m.Called(ctx, shortURL)
+
+	var r0 responses.LinkPreview
+	if rf, ok := ret.Get(0).(func(context.Context, string) responses.LinkPreview); ok {
+		r0 = rf(ctx, shortURL)
+	} else {
+		r0 = ret.Get(0).(responses.LinkPreview)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, shortURL)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// QRCode provides a mock function with given fields: ctx, shortURL, format, opts
+func (_m *MockUserUseCaseInterface) QRCode(ctx context.Context, shortURL string, format string, opts qr.Options) ([]byte, error) {
+	ret := _m.Called(ctx, shortURL, format, opts)
+
+	var r0 []byte
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, qr.Options) []byte); ok {
+		r0 = rf(ctx, shortURL, format, opts)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]byte)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, qr.Options) error); ok {
+		r1 = rf(ctx, shortURL, format, opts)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RecordClick provides a mock function with given fields: shortURL, destination
+func (_m *MockUserUseCaseInterface) RecordClick(shortURL string, destination string) {
+	_m.Called(shortURL, destination)
+}
+
+// Redirect provides a mock function with given fields: ctx, shortURL, visit
+func (_m *MockUserUseCaseInterface) Redirect(ctx context.Context, shortURL string, visit redirect.Visit) (redirect.Target, error) {
+	ret := _m.Called(ctx, shortURL, visit)
+
+	var r0 redirect.Target
+	if rf, ok := ret.Get(0).(func(context.Context, string, redirect.Visit) redirect.Target); ok {
+		r0 = rf(ctx, shortURL, visit)
+	} else {
+		r0 = ret.Get(0).(redirect.Target)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, redirect.Visit) error); ok {
+		r1 = rf(ctx, shortURL, visit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RestoreRevision provides a mock function with given fields: ctx, shortURL, revision, userID
+func (_m *MockUserUseCaseInterface) RestoreRevision(ctx context.Context, shortURL string, revision int, userID string) (responses.GetURL, error) {
+	ret := _m.Called(ctx, shortURL, revision, userID)
+
+	var r0 responses.GetURL
+	if rf, ok := ret.Get(0).(func(context.Context, string, int, string) responses.GetURL); ok {
+		r0 = rf(ctx, shortURL, revision, userID)
+	} else {
+		r0 = ret.Get(0).(responses.GetURL)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, int, string) error); ok {
+		r1 = rf(ctx, shortURL, revision, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RestoreURLs provides a mock function with given fields: ctx, urls, userID
+func (_m *MockUserUseCaseInterface) RestoreURLs(ctx context.Context, urls []string, userID string) (responses.RestoreURLsResponse, error) {
+	ret := _m.Called(ctx, urls, userID)
+
+	var r0 responses.RestoreURLsResponse
+	if rf, ok := ret.Get(0).(func(context.Context, []string, string) responses.RestoreURLsResponse); ok {
+		r0 = rf(ctx, urls, userID)
+	} else {
+		r0 = ret.Get(0).(responses.RestoreURLsResponse)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, []string, string) error); ok {
+		r1 = rf(ctx, urls, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateAccess provides a mock function with given fields: ctx, shortURL, req, userID
+func (_m *MockUserUseCaseInterface) UpdateAccess(ctx context.Context, shortURL string, req responses.LinkAccess, userID string) (responses.AccessState, error) {
+	ret := _m.Called(ctx, shortURL, req, userID)
+
+	var r0 responses.AccessState
+	if rf, ok := ret.Get(0).(func(context.Context, string, responses.LinkAccess, string) responses.AccessState); ok {
+		r0 = rf(ctx, shortURL, req, userID)
+	} else {
+		r0 = ret.Get(0).(responses.AccessState)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, responses.LinkAccess, string) error); ok {
+		r1 = rf(ctx, shortURL, req, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateMeta provides a mock function with given fields: ctx, shortURL, meta, userID
+func (_m *MockUserUseCaseInterface) UpdateMeta(ctx context.Context, shortURL string, meta responses.LinkMeta, userID string) (responses.LinkMeta, error) {
+	ret := _m.Called(ctx, shortURL, meta, userID)
+
+	var r0 responses.LinkMeta
+	if rf, ok := ret.Get(0).(func(context.Context, string, responses.LinkMeta, string) responses.LinkMeta); ok {
+		r0 = rf(ctx, shortURL, meta, userID)
+	} else {
+		r0 = ret.Get(0).(responses.LinkMeta)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, responses.LinkMeta, string) error); ok {
+		r1 = rf(ctx, shortURL, meta, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateRedirect provides a mock function with given fields: ctx, shortURL, settings, userID
+func (_m *MockUserUseCaseInterface) UpdateRedirect(ctx context.Context, shortURL string, settings redirect.Settings, userID string) (redirect.Settings, error) {
+	ret := _m.Called(ctx, shortURL, settings, userID)
+
+	var r0 redirect.Settings
+	if rf, ok := ret.Get(0).(func(context.Context, string, redirect.Settings, string) redirect.Settings); ok {
+		r0 = rf(ctx, shortURL, settings, userID)
+	} else {
+		r0 = ret.Get(0).(redirect.Settings)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, redirect.Settings, string) error); ok {
+		r1 = rf(ctx, shortURL, settings, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateURL provides a mock function with given fields: ctx, shortURL, longURL, userID
+func (_m *MockUserUseCaseInterface) UpdateURL(ctx context.Context, shortURL string, longURL string, userID string) (responses.GetURL, error) {
+	ret := _m.Called(ctx, shortURL, longURL, userID)
+
+	var r0 responses.GetURL
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) responses.GetURL); ok {
+		r0 = rf(ctx, shortURL, longURL, userID)
+	} else {
+		r0 = ret.Get(0).(responses.GetURL)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string) error); ok {
+		r1 = rf(ctx, shortURL, longURL, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
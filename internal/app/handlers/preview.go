@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"html/template"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	custom_errors "github.com/p7chkn/go-musthave-shortener-tpl/internal/errors"
+)
+
+// PreviewSuffix - суффикс id ссылки, по которому вместо перенаправления
+// показывается страница предпросмотра.
+const PreviewSuffix = "+"
+
+// previewTemplate - страница предпросмотра ссылки.
+var previewTemplate = template.Must(template.New("preview").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><meta name="robots" content="noindex"><title>{{if .Title}}{{.Title}}{{else}}Link preview{{end}}</title></head>
+<body>
+<h1>{{if .Title}}{{.Title}}{{else}}Link preview{{end}}</h1>
+{{if .Blocked}}<p><strong>This link has been disabled: its destination matches a blocked destination rule and may be unsafe.</strong></p>
+{{end}}{{if .Description}}<p>{{.Description}}</p>
+{{end}}<dl>
+<dt>Short link</dt><dd><code>{{.ShortURL}}</code></dd>
+<dt>Destination</dt><dd>{{if .Protected}}Password-protected{{else}}<a href="{{.OriginalURL}}" rel="noopener noreferrer nofollow">{{.OriginalURL}}</a>{{end}}</dd>
+{{with .CreatedAt}}<dt>Created</dt><dd>{{.UTC.Format "2006-01-02 15:04:05 MST"}}</dd>
+{{end}}{{if .Tags}}<dt>Tags</dt><dd>{{range $i, $tag := .Tags}}{{if $i}}, {{end}}{{$tag}}{{end}}</dd>
+{{end}}<dt>Clicks</dt><dd>{{.Clicks}}</dd>
+</dl>
+{{if .Destinations}}<table>
+<tr><th>Split destination</th><th>Weight</th><th>Clicks</th></tr>
+{{range .Destinations}}<tr><td><a href="{{.URL}}" rel="noopener noreferrer nofollow">{{.URL}}</a></td><td>{{.Weight}}</td><td>{{.Clicks}}</td></tr>
+{{end}}</table>
+{{end}}</body>
+</html>
+`))
+
+// previewURL - страница предпросмотра ссылки id вместо перенаправления:
+// HTML или JSON в зависимости от заголовка Accept.
+// Если ссылка была удалена - код ответа 410.
+// Если ссылка не найдена - код ответа 404.
+func (h *Handler) previewURL(c *gin.Context, id string) {
+	preview, err := h.service.Preview(c.Request.Context(), id)
+	if err != nil {
+		statusCode := custom_errors.ParseError(err)
+		switch statusCode {
+		case http.StatusGone:
+			c.Status(statusCode)
+		case http.StatusNotFound:
+			h.handleProblem(c, statusCode, err)
+		default:
+			c.Status(http.StatusInternalServerError)
+		}
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	if c.NegotiateFormat(gin.MIMEHTML, gin.MIMEJSON) == gin.MIMEJSON {
+		c.JSON(http.StatusOK, preview)
+		return
+	}
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	c.Status(http.StatusOK)
+	previewTemplate.Execute(c.Writer, preview)
+}
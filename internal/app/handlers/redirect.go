@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"html/template"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	custom_errors "github.com/p7chkn/go-musthave-shortener-tpl/internal/errors"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/redirect"
+)
+
+// metaRefreshTemplate - страница перехода через meta refresh.
+var metaRefreshTemplate = template.Must(template.New("meta").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><meta name="referrer" content="no-referrer"><meta http-equiv="refresh" content="0; url={{.}}"><title>Redirecting</title></head>
+<body>
+<p>Redirecting to <a href="{{.}}" rel="noreferrer">{{.}}</a></p>
+</body>
+</html>
+`))
+
+// jsRedirectTemplate - страница перехода через JavaScript.
+var jsRedirectTemplate = template.Must(template.New("js").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><meta name="referrer" content="no-referrer"><title>Redirecting</title></head>
+<body>
+<script>window.location.replace({{.}});</script>
+<noscript><p>Continue to <a href="{{.}}" rel="noreferrer">{{.}}</a></p></noscript>
+</body>
+</html>
+`))
+
+// redirect - ответ с переходом на target: перенаправление с кодом
+// target.Code или страница перехода без передачи Referer.
+func (h *Handler) redirect(c *gin.Context, target redirect.Target) {
+	var page *template.Template
+	switch target.Mode {
+	case redirect.ModeMeta:
+		page = metaRefreshTemplate
+	case redirect.ModeJS:
+		page = jsRedirectTemplate
+	default:
+		c.Header("Location", target.URL)
+		c.String(target.Code, "")
+		return
+	}
+	c.Header("Referrer-Policy", "no-referrer")
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	c.Status(http.StatusOK)
+	page.Execute(c.Writer, target.URL)
+}
+
+// UpdateRedirect - замена настроек перехода по ссылке id.
+// Формат запроса redirect.Settings, незаданные поля принимают значения по
+// умолчанию.
+// При успешном изменении код ответа 200 и сохраненные настройки.
+// В случае ошибки в формате запроса или некорректных настроек - код ответа
+// 400.
+// Если ссылки нет, она удалена или пользователь не может ее изменять -
+// код ответа 404.
+func (h *Handler) UpdateRedirect(c *gin.Context) {
+	var settings redirect.Settings
+	if err := readJSON(c, &settings); err != nil {
+		h.handleError(c, err)
+		return
+	}
+	result, err := h.service.UpdateRedirect(c.Request.Context(), linkID(c, c.Param("id")), settings, c.GetString("userId"))
+	if err != nil {
+		h.handleProblem(c, custom_errors.ParseError(err), err)
+		return
+	}
+	c.IndentedJSON(http.StatusOK, result)
+}
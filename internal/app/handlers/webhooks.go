@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/app/responses"
+	custom_errors "github.com/p7chkn/go-musthave-shortener-tpl/internal/errors"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/webhooks"
+)
+
+//go:generate mockery --name=WebhookServiceInterface --case camel --inpackage
+
+// WebhookServiceInterface - интерфейс сервиса вебхуков.
+type WebhookServiceInterface interface {
+	CreateWebhook(ctx context.Context, userID string, req responses.CreateWebhook) (responses.WebhookResponse, error)
+	ListWebhooks(ctx context.Context, userID string) ([]responses.WebhookResponse, error)
+	DeleteWebhook(ctx context.Context, userID string, id string) error
+	ListDeliveries(ctx context.Context, userID string, id string) ([]webhooks.Delivery, error)
+	TestWebhook(ctx context.Context, userID string, id string) (webhooks.Delivery, error)
+}
+
+// WebhookHandler - обработчик запросов вебхуков.
+type WebhookHandler struct {
+	service WebhookServiceInterface
+}
+
+// NewWebhookHandler - создание обработчика запросов вебхуков.
+func NewWebhookHandler(service WebhookServiceInterface) *WebhookHandler {
+	return &WebhookHandler{
+		service: service,
+	}
+}
+
+// CreateWebhook - регистрация вебхука пользователя.
+// Формат запроса CreateWebhook.
+// При успешной регистрации код ответа 201 и описание вебхука в формате
+// WebhookResponse. Ключ подписи возвращается только в этом ответе.
+// В случае некорректного адреса, событий или порога переходов - код
+// ответа 400.
+// Если у пользователя уже webhooks.MaxPerUser вебхуков - код ответа 409.
+func (wh *WebhookHandler) CreateWebhook(c *gin.Context) {
+	var request responses.CreateWebhook
+	if err := readJSON(c, &request); err != nil {
+		handleProblem(c, http.StatusBadRequest, err)
+		return
+	}
+	hook, err := wh.service.CreateWebhook(c.Request.Context(), c.GetString("userId"), request)
+	if err != nil {
+		handleProblem(c, custom_errors.ParseError(err), err)
+		return
+	}
+	c.IndentedJSON(http.StatusCreated, hook)
+}
+
+// ListWebhooks - список вебхуков пользователя в формате WebhookResponse,
+// код ответа 200. Ключи подписи не возвращаются.
+func (wh *WebhookHandler) ListWebhooks(c *gin.Context) {
+	hooks, err := wh.service.ListWebhooks(c.Request.Context(), c.GetString("userId"))
+	if err != nil {
+		handleProblem(c, custom_errors.ParseError(err), err)
+		return
+	}
+	c.IndentedJSON(http.StatusOK, hooks)
+}
+
+// DeleteWebhook - удаление вебхука пользователя.
+// Обязательный параметр URL - id вебхука.
+// При успешном удалении код ответа 204.
+// Если вебхук не найден - код ответа 404.
+func (wh *WebhookHandler) DeleteWebhook(c *gin.Context) {
+	err := wh.service.DeleteWebhook(c.Request.Context(), c.GetString("userId"), c.Param("id"))
+	if err != nil {
+		handleProblem(c, custom_errors.ParseError(err), err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// ListDeliveries - журнал доставок вебхука, начиная с новых.
+// Обязательный параметр URL - id вебхука.
+// Код ответа 200, если вебхук не найден - 404.
+func (wh *WebhookHandler) ListDeliveries(c *gin.Context) {
+	deliveries, err := wh.service.ListDeliveries(c.Request.Context(), c.GetString("userId"), c.Param("id"))
+	if err != nil {
+		handleProblem(c, custom_errors.ParseError(err), err)
+		return
+	}
+	c.IndentedJSON(http.StatusOK, deliveries)
+}
+
+// TestWebhook - отправка проверочного события webhook.test на адрес
+// вебхука.
+// Обязательный параметр URL - id вебхука.
+// Код ответа 200 и запись журнала доставок, в том числе неудачной
+// доставки. Если вебхук не найден - код ответа 404.
+func (wh *WebhookHandler) TestWebhook(c *gin.Context) {
+	delivery, err := wh.service.TestWebhook(c.Request.Context(), c.GetString("userId"), c.Param("id"))
+	if err != nil {
+		handleProblem(c, custom_errors.ParseError(err), err)
+		return
+	}
+	c.IndentedJSON(http.StatusOK, delivery)
+}
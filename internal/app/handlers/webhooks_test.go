@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/app/responses"
+	custom_errors "github.com/p7chkn/go-musthave-shortener-tpl/internal/errors"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/session"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/webhooks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func setupWebhookRouter(service WebhookServiceInterface) (*gin.Engine, *session.Manager) {
+	router, sessions := setupRouter(new(MockUserUseCaseInterface))
+	handler := NewWebhookHandler(service)
+	router.POST("/api/user/webhooks", handler.CreateWebhook)
+	router.GET("/api/user/webhooks", handler.ListWebhooks)
+	router.DELETE("/api/user/webhooks/:id", handler.DeleteWebhook)
+	router.GET("/api/user/webhooks/:id/deliveries", handler.ListDeliveries)
+	router.POST("/api/user/webhooks/:id/test", handler.TestWebhook)
+	return router, sessions
+}
+
+func TestCreateWebhook(t *testing.T) {
+	request := responses.CreateWebhook{
+		URL:            "https://cms.example.com/hooks",
+		Events:         []string{webhooks.EventClickThreshold},
+		ClickThreshold: 100,
+	}
+	tests := []struct {
+		name     string
+		body     string
+		result   responses.WebhookResponse
+		err      error
+		wantCode int
+		wantBody string
+	}{
+		{
+			name:     "created with secret",
+			body:     `{"url":"https://cms.example.com/hooks","events":["link.click_threshold"],"click_threshold":100}`,
+			result:   responses.WebhookResponse{ID: "hook-1", URL: request.URL, Events: request.Events, ClickThreshold: 100, Secret: "whsec_abc"},
+			wantCode: http.StatusCreated,
+			wantBody: `"secret": "whsec_abc"`,
+		},
+		{
+			name:     "invalid url",
+			body:     `{"url":"https://cms.example.com/hooks","events":["link.click_threshold"],"click_threshold":100}`,
+			err:      custom_errors.NewCustomError(webhooks.ErrInvalidURL, http.StatusBadRequest),
+			wantCode: http.StatusBadRequest,
+			wantBody: webhooks.ErrInvalidURL.Error(),
+		},
+		{
+			name:     "too many webhooks",
+			body:     `{"url":"https://cms.example.com/hooks","events":["link.click_threshold"],"click_threshold":100}`,
+			err:      custom_errors.NewCustomError(errors.New("no more than 10 webhooks per user"), http.StatusConflict),
+			wantCode: http.StatusConflict,
+		},
+		{
+			name:     "bad json",
+			body:     `{"url":`,
+			wantCode: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := new(MockWebhookServiceInterface)
+			service.On("CreateWebhook", mock.Anything, "user-1", request).Return(tt.result, tt.err)
+			router, sessions := setupWebhookRouter(service)
+			token, _ := sessions.Issue("user-1")
+
+			r := httptest.NewRequest(http.MethodPost, "/api/user/webhooks", strings.NewReader(tt.body))
+			r.AddCookie(sessions.Cookie(token))
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, r)
+			result := w.Result()
+			body, _ := ioutil.ReadAll(result.Body)
+			result.Body.Close()
+
+			assert.Equal(t, tt.wantCode, result.StatusCode)
+			assert.Contains(t, string(body), tt.wantBody)
+			if tt.wantCode != http.StatusCreated {
+				assert.Equal(t, responses.ProblemContentType, result.Header.Get("Content-Type"))
+			}
+		})
+	}
+}
+
+func TestWebhooks(t *testing.T) {
+	notFound := custom_errors.NewCustomError(errors.New("webhook not found"), http.StatusNotFound)
+	service := new(MockWebhookServiceInterface)
+	service.On("ListWebhooks", mock.Anything, "user-1").
+		Return([]responses.WebhookResponse{{ID: "hook-1", URL: "https://cms.example.com/hooks", Events: []string{}}}, nil)
+	service.On("DeleteWebhook", mock.Anything, "user-1", "hook-1").Return(nil)
+	service.On("DeleteWebhook", mock.Anything, "user-1", "hook-2").Return(notFound)
+	service.On("ListDeliveries", mock.Anything, "user-1", "hook-1").Return([]webhooks.Delivery{
+		{ID: "delivery-2", WebhookID: "hook-1", Event: webhooks.EventLinkCreated, Attempt: 2, Success: true, StatusCode: 200},
+		{ID: "delivery-1", WebhookID: "hook-1", Event: webhooks.EventLinkCreated, Attempt: 1, StatusCode: 500, Error: "unexpected status 500"},
+	}, nil)
+	service.On("ListDeliveries", mock.Anything, "user-1", "hook-2").Return(nil, notFound)
+	service.On("TestWebhook", mock.Anything, "user-1", "hook-1").
+		Return(webhooks.Delivery{ID: "delivery-3", WebhookID: "hook-1", Event: webhooks.EventTest, Attempt: 1, StatusCode: 502, Error: "unexpected status 502"}, nil)
+	router, sessions := setupWebhookRouter(service)
+	token, _ := sessions.Issue("user-1")
+
+	tests := []struct {
+		name     string
+		method   string
+		target   string
+		wantCode int
+		wantBody string
+	}{
+		{name: "list", method: http.MethodGet, target: "/api/user/webhooks", wantCode: http.StatusOK, wantBody: `"id": "hook-1"`},
+		{name: "deliveries", method: http.MethodGet, target: "/api/user/webhooks/hook-1/deliveries", wantCode: http.StatusOK, wantBody: `"error": "unexpected status 500"`},
+		{name: "deliveries of unknown webhook", method: http.MethodGet, target: "/api/user/webhooks/hook-2/deliveries", wantCode: http.StatusNotFound},
+		{name: "test", method: http.MethodPost, target: "/api/user/webhooks/hook-1/test", wantCode: http.StatusOK, wantBody: `"status_code": 502`},
+		{name: "delete", method: http.MethodDelete, target: "/api/user/webhooks/hook-1", wantCode: http.StatusNoContent},
+		{name: "delete unknown webhook", method: http.MethodDelete, target: "/api/user/webhooks/hook-2", wantCode: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, tt.target, nil)
+			r.AddCookie(sessions.Cookie(token))
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, r)
+
+			assert.Equal(t, tt.wantCode, w.Code)
+			assert.Contains(t, w.Body.String(), tt.wantBody)
+		})
+	}
+	service.AssertExpectations(t)
+}
@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/app/responses"
+	custom_errors "github.com/p7chkn/go-musthave-shortener-tpl/internal/errors"
+)
+
+//go:generate mockery --name=WorkspaceServiceInterface --case camel --inpackage
+
+// WorkspaceServiceInterface - интерфейс сервиса рабочих пространств.
+type WorkspaceServiceInterface interface {
+	Create(ctx context.Context, userID string, name string) (responses.WorkspaceResponse, error)
+	List(ctx context.Context, userID string) ([]responses.WorkspaceResponse, error)
+	Members(ctx context.Context, workspaceID string, userID string) ([]responses.MemberResponse, error)
+	SetMember(ctx context.Context, workspaceID string, userID string, request responses.SetMember) (responses.MemberResponse, error)
+	RemoveMember(ctx context.Context, workspaceID string, userID string, memberID string) error
+	AddURLs(ctx context.Context, workspaceID string, userID string, shortURLs []string) (responses.WorkspaceURLsResponse, error)
+}
+
+// WorkspaceHandler - обработчик запросов рабочих пространств.
+// Для анонимного пользователя на все запросы - код ответа 401.
+// Если пользователь не участник рабочего пространства - код ответа 404,
+// если его роли недостаточно - код ответа 403.
+type WorkspaceHandler struct {
+	service WorkspaceServiceInterface
+}
+
+// NewWorkspaceHandler - создание обработчика запросов рабочих пространств.
+func NewWorkspaceHandler(service WorkspaceServiceInterface) *WorkspaceHandler {
+	return &WorkspaceHandler{
+		service: service,
+	}
+}
+
+// Create - создание рабочего пространства, пользователь становится его
+// владельцем.
+// Формат запроса CreateWorkspace.
+// При успешном создании код ответа 201 и WorkspaceResponse.
+// В случае некорректного названия - код ответа 400.
+func (wh *WorkspaceHandler) Create(c *gin.Context) {
+	var request responses.CreateWorkspace
+	if err := readJSON(c, &request); err != nil {
+		handleProblem(c, http.StatusBadRequest, err)
+		return
+	}
+	workspace, err := wh.service.Create(c.Request.Context(), c.GetString("userId"), request.Name)
+	if err != nil {
+		handleProblem(c, custom_errors.ParseError(err), err)
+		return
+	}
+	c.IndentedJSON(http.StatusCreated, workspace)
+}
+
+// List - рабочие пространства пользователя с его ролями, код ответа 200.
+func (wh *WorkspaceHandler) List(c *gin.Context) {
+	result, err := wh.service.List(c.Request.Context(), c.GetString("userId"))
+	if err != nil {
+		handleProblem(c, custom_errors.ParseError(err), err)
+		return
+	}
+	c.IndentedJSON(http.StatusOK, result)
+}
+
+// Members - участники рабочего пространства id, код ответа 200.
+// Доступно любому участнику.
+func (wh *WorkspaceHandler) Members(c *gin.Context) {
+	result, err := wh.service.Members(c.Request.Context(), c.Param("id"), c.GetString("userId"))
+	if err != nil {
+		handleProblem(c, custom_errors.ParseError(err), err)
+		return
+	}
+	c.IndentedJSON(http.StatusOK, result)
+}
+
+// SetMember - добавление участника по логину или изменение его роли.
+// Формат запроса SetMember. Доступно владельцу.
+// При успешном изменении код ответа 200 и MemberResponse.
+// В случае неизвестной роли - код ответа 400.
+// Если учетной записи с логином нет - код ответа 404.
+// Если у рабочего пространства не останется владельца - код ответа 409.
+func (wh *WorkspaceHandler) SetMember(c *gin.Context) {
+	var request responses.SetMember
+	if err := readJSON(c, &request); err != nil {
+		handleProblem(c, http.StatusBadRequest, err)
+		return
+	}
+	member, err := wh.service.SetMember(c.Request.Context(), c.Param("id"), c.GetString("userId"), request)
+	if err != nil {
+		handleProblem(c, custom_errors.ParseError(err), err)
+		return
+	}
+	c.IndentedJSON(http.StatusOK, member)
+}
+
+// RemoveMember - удаление участника user_id из рабочего пространства.
+// Доступно владельцу, а также самому участнику, чтобы выйти из него.
+// При успешном удалении код ответа 204.
+// Если у рабочего пространства не останется владельца - код ответа 409.
+func (wh *WorkspaceHandler) RemoveMember(c *gin.Context) {
+	err := wh.service.RemoveMember(c.Request.Context(), c.Param("id"), c.GetString("userId"), c.Param("user_id"))
+	if err != nil {
+		handleProblem(c, custom_errors.ParseError(err), err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// AddURLs - перенос ссылок в рабочее пространство.
+// В запросе ожидается список коротких URL. Доступно владельцу и редактору.
+// Переносятся только ссылки, которые пользователь может изменять.
+// При успешном переносе код ответа 200 и WorkspaceURLsResponse.
+func (wh *WorkspaceHandler) AddURLs(c *gin.Context) {
+	var urls []string
+	if err := readJSON(c, &urls); err != nil {
+		handleProblem(c, http.StatusBadRequest, err)
+		return
+	}
+	keys, err := linkIDs(c, urls)
+	if err != nil {
+		handleProblem(c, custom_errors.ParseError(err), err)
+		return
+	}
+	result, err := wh.service.AddURLs(c.Request.Context(), c.Param("id"), c.GetString("userId"), keys)
+	if err != nil {
+		handleProblem(c, custom_errors.ParseError(err), err)
+		return
+	}
+	c.IndentedJSON(http.StatusOK, result)
+}
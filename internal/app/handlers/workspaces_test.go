@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/app/responses"
+	custom_errors "github.com/p7chkn/go-musthave-shortener-tpl/internal/errors"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/session"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func setupWorkspaceRouter(service WorkspaceServiceInterface) (*gin.Engine, *session.Manager) {
+	router, sessions := setupRouter(new(MockUserUseCaseInterface))
+	handler := NewWorkspaceHandler(service)
+	router.POST("/api/workspaces", handler.Create)
+	router.GET("/api/workspaces/:id/members", handler.Members)
+	router.POST("/api/workspaces/:id/members", handler.SetMember)
+	router.DELETE("/api/workspaces/:id/members/:user_id", handler.RemoveMember)
+	router.POST("/api/workspaces/:id/urls", handler.AddURLs)
+	return router, sessions
+}
+
+func TestWorkspaceHandler(t *testing.T) {
+	type want struct {
+		code     int
+		response string
+	}
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		setup  func(service *MockWorkspaceServiceInterface)
+		want   want
+	}{
+		{
+			name:   "create workspace",
+			method: http.MethodPost,
+			path:   "/api/workspaces",
+			body:   `{"name":"Marketing"}`,
+			setup: func(service *MockWorkspaceServiceInterface) {
+				service.On("Create", mock.Anything, "account-1", "Marketing").
+					Return(responses.WorkspaceResponse{ID: "ws-1", Name: "Marketing", Role: "owner"}, nil)
+			},
+			want: want{code: http.StatusCreated, response: `"role": "owner"`},
+		},
+		{
+			name:   "set member by viewer",
+			method: http.MethodPost,
+			path:   "/api/workspaces/ws-1/members",
+			body:   `{"login":"bob","role":"editor"}`,
+			setup: func(service *MockWorkspaceServiceInterface) {
+				service.On("SetMember", mock.Anything, "ws-1", "account-1", responses.SetMember{Login: "bob", Role: "editor"}).
+					Return(responses.MemberResponse{}, custom_errors.NewCustomError(errors.New("insufficient workspace role"), http.StatusForbidden))
+			},
+			want: want{code: http.StatusForbidden, response: `insufficient workspace role`},
+		},
+		{
+			name:   "members of foreign workspace",
+			method: http.MethodGet,
+			path:   "/api/workspaces/ws-2/members",
+			setup: func(service *MockWorkspaceServiceInterface) {
+				service.On("Members", mock.Anything, "ws-2", "account-1").
+					Return(nil, custom_errors.NewCustomError(errors.New("workspace not found"), http.StatusNotFound))
+			},
+			want: want{code: http.StatusNotFound, response: `workspace not found`},
+		},
+		{
+			name:   "remove last owner",
+			method: http.MethodDelete,
+			path:   "/api/workspaces/ws-1/members/account-1",
+			setup: func(service *MockWorkspaceServiceInterface) {
+				service.On("RemoveMember", mock.Anything, "ws-1", "account-1", "account-1").
+					Return(custom_errors.NewCustomError(errors.New("workspace must keep at least one owner"), http.StatusConflict))
+			},
+			want: want{code: http.StatusConflict, response: `at least one owner`},
+		},
+		{
+			name:   "add urls",
+			method: http.MethodPost,
+			path:   "/api/workspaces/ws-1/urls",
+			body:   `["abc","def"]`,
+			setup: func(service *MockWorkspaceServiceInterface) {
+				service.On("AddURLs", mock.Anything, "ws-1", "account-1", []string{"abc", "def"}).
+					Return(responses.WorkspaceURLsResponse{Moved: 2}, nil)
+			},
+			want: want{code: http.StatusOK, response: `"moved": 2`},
+		},
+		{
+			name:   "add urls of other domain",
+			method: http.MethodPost,
+			path:   "/api/workspaces/ws-1/urls",
+			body:   `["abc","go.example.com/def"]`,
+			setup:  func(service *MockWorkspaceServiceInterface) {},
+			want:   want{code: http.StatusBadRequest, response: `invalid link id`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := new(MockWorkspaceServiceInterface)
+			tt.setup(service)
+			router, sessions := setupWorkspaceRouter(service)
+			token, _ := sessions.Issue("account-1")
+
+			request := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			request.AddCookie(sessions.Cookie(token))
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, request)
+			result := w.Result()
+			body, _ := ioutil.ReadAll(result.Body)
+			result.Body.Close()
+
+			assert.Equal(t, tt.want.code, result.StatusCode)
+			assert.Contains(t, string(body), tt.want.response)
+			service.AssertExpectations(t)
+		})
+	}
+}
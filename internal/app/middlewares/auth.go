@@ -0,0 +1,91 @@
+package middlewares
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/app/responses"
+	custom_errors "github.com/p7chkn/go-musthave-shortener-tpl/internal/errors"
+)
+
+var (
+	// errAccountRequired - запрос от имени учетной записи без входа в нее.
+	errAccountRequired = errors.New("login or api key of this account required")
+	// errForeignUser - запрос от имени другого пользователя.
+	errForeignUser = errors.New("user_id does not match session")
+)
+
+// Authenticator - проверка ключей API и учетных записей.
+type Authenticator interface {
+	Authenticate(ctx context.Context, apiKey string) (string, error)
+	IsAccount(ctx context.Context, userID string) (bool, error)
+}
+
+// APIKeyMiddleware - определение пользователя по ключу API из заголовка
+// "Authorization: Bearer <ключ>". Ключ имеет приоритет над cookie.
+// Неизвестный или отозванный ключ - код ответа 401.
+// Если auth равен nil, ключи API не принимаются.
+func APIKeyMiddleware(auth Authenticator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		apiKey := BearerToken(c.GetHeader("Authorization"))
+		if auth == nil || apiKey == "" {
+			c.Next()
+			return
+		}
+		userID, err := auth.Authenticate(c.Request.Context(), apiKey)
+		if err != nil {
+			statusCode := custom_errors.ParseError(err)
+			c.Header("Content-Type", responses.ProblemContentType)
+			c.AbortWithStatusJSON(statusCode, responses.NewProblem(statusCode, err.Error()))
+			return
+		}
+		c.Set("userId", userID)
+		c.Set(NewUserKey, false)
+		c.Next()
+	}
+}
+
+// RequestUserMiddleware - запросы от имени пользователя, указанного в
+// запросе (см. userID), разрешены только ему самому: пользователю из cookie
+// сессии или из ключа API. Для учетной записи - код ответа 401, чтобы
+// клиент вошел в нее, для другого пользователя - 403. Если auth равен nil,
+// учетные записи не отличаются от анонимных пользователей.
+func RequestUserMiddleware(auth Authenticator, userID func(c *gin.Context) string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requested := userID(c)
+		if requested == "" || requested == c.GetString("userId") {
+			c.Next()
+			return
+		}
+		isAccount := false
+		if auth != nil {
+			var err error
+			isAccount, err = auth.IsAccount(c.Request.Context(), requested)
+			if err != nil {
+				c.Header("Content-Type", responses.ProblemContentType)
+				c.AbortWithStatusJSON(http.StatusInternalServerError,
+					responses.NewProblem(http.StatusInternalServerError, err.Error()))
+				return
+			}
+		}
+		c.Header("Content-Type", responses.ProblemContentType)
+		if isAccount {
+			c.AbortWithStatusJSON(http.StatusUnauthorized,
+				responses.NewProblem(http.StatusUnauthorized, errAccountRequired.Error()))
+			return
+		}
+		c.AbortWithStatusJSON(http.StatusForbidden, responses.NewProblem(http.StatusForbidden, errForeignUser.Error()))
+	}
+}
+
+// BearerToken - токен из значения заголовка Authorization со схемой Bearer.
+func BearerToken(header string) string {
+	const prefix = "bearer "
+	if len(header) <= len(prefix) || !strings.EqualFold(header[:len(prefix)], prefix) {
+		return ""
+	}
+	return strings.TrimSpace(header[len(prefix):])
+}
@@ -0,0 +1,58 @@
+package middlewares
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	custom_errors "github.com/p7chkn/go-musthave-shortener-tpl/internal/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+type stubAuthenticator struct{}
+
+func (stubAuthenticator) Authenticate(ctx context.Context, apiKey string) (string, error) {
+	if apiKey == "sk_valid" {
+		return "account-1", nil
+	}
+	return "", custom_errors.NewCustomError(errors.New("invalid api key"), http.StatusUnauthorized)
+}
+
+func (stubAuthenticator) IsAccount(ctx context.Context, userID string) (bool, error) {
+	return userID == "account-1", nil
+}
+
+func TestAPIKeyMiddleware(t *testing.T) {
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("userId", "anonymous-1")
+	})
+	router.Use(APIKeyMiddleware(stubAuthenticator{}))
+	router.GET("/users/:id", RequestUserMiddleware(stubAuthenticator{}, func(c *gin.Context) string {
+		return c.Param("id")
+	}), func(c *gin.Context) {
+		c.String(http.StatusOK, c.GetString("userId"))
+	})
+	request := func(path string, authorization string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, path, nil)
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := request("/users/account-1", "Bearer sk_valid")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "account-1", w.Body.String())
+
+	assert.Equal(t, http.StatusUnauthorized, request("/users/account-1", "Bearer sk_revoked").Code)
+	assert.Equal(t, http.StatusUnauthorized, request("/users/account-1", "").Code)
+	assert.Equal(t, http.StatusForbidden, request("/users/anonymous-2", "").Code)
+	assert.Equal(t, http.StatusForbidden, request("/users/anonymous-1", "Bearer sk_valid").Code)
+	assert.Equal(t, http.StatusOK, request("/users/anonymous-1", "Basic abc").Code)
+}
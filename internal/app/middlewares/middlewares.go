@@ -7,8 +7,8 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/gofrs/uuid"
-	"github.com/p7chkn/go-musthave-shortener-tpl/cmd/shortener/configuration"
-	"github.com/p7chkn/go-musthave-shortener-tpl/internal/utils"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/domains"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/session"
 )
 
 type gzipWriter struct {
@@ -57,18 +57,20 @@ func GzipDecodeMiddleware() gin.HandlerFunc {
 	}
 }
 
-func CookiMiddleware(cfg *configuration.Config) gin.HandlerFunc {
+// CookiMiddleware - определение пользователя по токену сессии из cookie.
+// Если cookie нет или токен не прошел проверку, выдается новый userId.
+// Токены старого формата и токены, которым пора обновиться, перевыпускаются.
+func CookiMiddleware(sessions *session.Manager) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		defer c.Next()
-		cookie, _ := c.Request.Cookie("userId")
-		encryptor, err := utils.New(cfg.Key)
-		if err != nil {
-			return
-		}
+		cookie, _ := c.Request.Cookie(sessions.CookieName())
 		if cookie != nil {
-			value, e := encryptor.DecodeUUIDFromString(cookie.Value)
-			if e == nil {
-				c.Set("userId", value)
+			claims, err := sessions.Parse(cookie.Value)
+			if err == nil {
+				c.Set("userId", claims.Subject)
+				if sessions.NeedsRefresh(cookie.Value, claims) {
+					setSessionCookie(c, sessions, claims.Subject)
+				}
 				return
 			}
 		}
@@ -76,8 +78,29 @@ func CookiMiddleware(cfg *configuration.Config) gin.HandlerFunc {
 		if err != nil {
 			return
 		}
-		value := encryptor.EncodeUUIDtoString(id.Bytes())
-		c.SetCookie("userId", value, 864000, "/", cfg.BaseURL, false, false)
+		if err := setSessionCookie(c, sessions, id.String()); err != nil {
+			return
+		}
 		c.Set("userId", id.String())
+		c.Set(NewUserKey, true)
+	}
+}
+
+// setSessionCookie - выдача нового токена сессии пользователю.
+func setSessionCookie(c *gin.Context, sessions *session.Manager, userID string) error {
+	token, err := sessions.Issue(userID)
+	if err != nil {
+		return err
+	}
+	http.SetCookie(c.Writer, sessions.Cookie(token))
+	return nil
+}
+
+// DomainMiddleware - определение домена ссылок по заголовку Host запроса.
+// Для основного и неизвестных доменов сохраняется пустое пространство имен.
+func DomainMiddleware(dom *domains.Domains) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set("domain", dom.Domain(c.Request.Host))
+		c.Next()
 	}
 }
@@ -0,0 +1,92 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gofrs/uuid"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/domains"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/session"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCookiMiddleware(t *testing.T) {
+	secret := []byte("0123456789abcdef")
+	encryptor, _ := utils.New(secret)
+	key, _ := session.NewKey(secret)
+	sessions, _ := session.NewManager([]session.Key{key}, session.Options{
+		TTL:        time.Hour,
+		CookieName: "userId",
+		Legacy:     encryptor,
+	})
+	router := gin.New()
+	router.Use(CookiMiddleware(sessions))
+	router.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, c.GetString("userId"))
+	})
+	request := func(cookie *http.Cookie) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/", nil)
+		if cookie != nil {
+			req.AddCookie(cookie)
+		}
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := request(nil)
+	cookies := w.Result().Cookies()
+	if assert.Len(t, cookies, 1) {
+		assert.True(t, cookies[0].HttpOnly)
+		assert.Equal(t, http.SameSiteLaxMode, cookies[0].SameSite)
+		assert.Equal(t, w.Body.String(), request(cookies[0]).Body.String())
+		assert.Empty(t, request(cookies[0]).Result().Cookies())
+	}
+
+	id, _ := uuid.NewV4()
+	legacy := &http.Cookie{Name: "userId", Value: encryptor.EncodeUUIDtoString(id.Bytes())}
+	w = request(legacy)
+	assert.Equal(t, id.String(), w.Body.String())
+	migrated := w.Result().Cookies()
+	if assert.Len(t, migrated, 1) {
+		assert.NotEqual(t, legacy.Value, migrated[0].Value)
+		assert.Equal(t, id.String(), request(migrated[0]).Body.String())
+	}
+
+	w = request(&http.Cookie{Name: "userId", Value: "forged"})
+	assert.NotEmpty(t, w.Body.String())
+	assert.Len(t, w.Result().Cookies(), 1)
+}
+
+func TestDomainMiddleware(t *testing.T) {
+	dom, err := domains.New("http://localhost:8080", []string{"go.example.com"})
+	require.NoError(t, err)
+	router := gin.New()
+	router.Use(DomainMiddleware(dom))
+	router.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, c.GetString("domain"))
+	})
+	tests := []struct {
+		host string
+		want string
+	}{
+		{host: "localhost:8080", want: ""},
+		{host: "go.example.com", want: "go.example.com"},
+		{host: "go.example.com:443", want: "go.example.com"},
+		{host: "other.example.com", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodGet, "/", nil)
+			req.Host = tt.host
+			router.ServeHTTP(w, req)
+			assert.Equal(t, tt.want, w.Body.String())
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package middlewares
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/app/responses"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/ratelimit"
+)
+
+// NewUserKey - ключ контекста gin, по которому CookiMiddleware отмечает,
+// что userId выдан в текущем запросе.
+const NewUserKey = "newUser"
+
+// errTooManyRequests - ошибка при превышении лимита запросов.
+var errTooManyRequests = errors.New("too many requests")
+
+// RateLimitMiddleware - ограничение частоты запросов. Запросы считаются по
+// userId из cookie, если он был предъявлен клиентом, иначе по IP клиента.
+// При превышении лимита - код ответа 429 и заголовок Retry-After.
+// Если limiter равен nil, запросы не ограничиваются.
+func RateLimitMiddleware(limiter ratelimit.Limiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if limiter == nil {
+			c.Next()
+			return
+		}
+		key := "ip:" + c.ClientIP()
+		if userID := c.GetString("userId"); userID != "" && !c.GetBool(NewUserKey) {
+			key = "user:" + userID
+		}
+		allowed, wait, err := limiter.Allow(c.Request.Context(), key)
+		if err != nil {
+			log.Printf("Error while checking rate limit: %v\n", err)
+			c.Next()
+			return
+		}
+		if !allowed {
+			c.Header("Retry-After", ratelimit.RetryAfter(wait))
+			c.Header("Content-Type", responses.ProblemContentType)
+			c.AbortWithStatusJSON(http.StatusTooManyRequests,
+				responses.NewProblem(http.StatusTooManyRequests, errTooManyRequests.Error()))
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,42 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/app/responses"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/ratelimit"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimitMiddleware(t *testing.T) {
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		if userID := c.GetHeader("X-User"); userID != "" {
+			c.Set("userId", userID)
+		}
+	})
+	router.GET("/", RateLimitMiddleware(ratelimit.NewMemoryLimiter(1)), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	request := func(userID string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		req.Header.Set("X-User", userID)
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusOK, request("").Code)
+	w := request("")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "60", w.Header().Get("Retry-After"))
+	assert.Equal(t, responses.ProblemContentType, w.Header().Get("Content-Type"))
+
+	assert.Equal(t, http.StatusOK, request("user-1").Code)
+	assert.Equal(t, http.StatusTooManyRequests, request("user-1").Code)
+	assert.Equal(t, http.StatusOK, request("user-2").Code)
+}
@@ -0,0 +1,173 @@
+package middlewares
+
+import (
+	"encoding/json"
+	"mime"
+	"net/http"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/gin-gonic/gin"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/app/responses"
+)
+
+// pathParam - параметр пути OpenAPI вида {id}.
+var pathParam = regexp.MustCompile(`\{([^}]+)\}`)
+
+// StreamBodyExtension - расширение операции OpenAPI, у которой тело запроса
+// не проверяется и не читается заранее, чтобы обработчик мог читать его
+// потоком.
+const StreamBodyExtension = "x-stream-body"
+
+// ValidationMiddleware - проверка запросов по документу OpenAPI. Маршрут
+// запроса сопоставляется с операцией документа по методу и шаблону пути gin.
+// Запросы к маршрутам gin с параметром вида *path, например к REST шлюзу
+// gRPC сервиса, сопоставляются с шаблонами путей документа по самому пути
+// запроса.
+// Некорректные запросы отклоняются с кодом 400 и описанием ошибки в формате
+// application/problem+json, запросы без операции в документе пропускаются.
+// У операций с расширением StreamBodyExtension проверяются только
+// параметры.
+func ValidationMiddleware(doc *openapi3.T) gin.HandlerFunc {
+	routes := map[string]*routers.Route{}
+	var templates []*routers.Route
+	for docPath, item := range doc.Paths {
+		ginPath := pathParam.ReplaceAllString(docPath, ":$1")
+		for method, operation := range item.Operations() {
+			route := &routers.Route{
+				Spec:      doc,
+				Path:      docPath,
+				PathItem:  item,
+				Method:    method,
+				Operation: operation,
+			}
+			routes[method+" "+ginPath] = route
+			templates = append(templates, route)
+		}
+	}
+	// Шаблоны с меньшим числом параметров проверяются раньше, чтобы путь
+	// .../urls/delete не совпал с шаблоном .../urls/{id}.
+	sort.Slice(templates, func(i, j int) bool {
+		ci, cj := strings.Count(templates[i].Path, "{"), strings.Count(templates[j].Path, "{")
+		if ci != cj {
+			return ci < cj
+		}
+		return templates[i].Path < templates[j].Path
+	})
+	options := &openapi3filter.Options{
+		AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+	}
+	streamOptions := &openapi3filter.Options{
+		AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+		ExcludeRequestBody: true,
+	}
+
+	return func(c *gin.Context) {
+		route, ok := routes[c.Request.Method+" "+c.FullPath()]
+		params := map[string]string{}
+		if ok {
+			for _, param := range c.Params {
+				params[param.Key] = param.Value
+			}
+		} else if strings.Contains(c.FullPath(), "*") {
+			route, params, ok = matchTemplate(templates, c.Request.Method, c.Request.URL.Path)
+		}
+		if !ok {
+			c.Next()
+			return
+		}
+		request := c.Request.Clone(c.Request.Context())
+		setDeclaredContentType(request, route.Operation)
+
+		routeOptions := options
+		if streamBody(route.Operation) {
+			routeOptions = streamOptions
+		}
+		err := openapi3filter.ValidateRequest(c.Request.Context(), &openapi3filter.RequestValidationInput{
+			Request:    request,
+			PathParams: params,
+			Route:      route,
+			Options:    routeOptions,
+		})
+		c.Request.Body = request.Body
+		if err != nil {
+			c.Header("Content-Type", responses.ProblemContentType)
+			c.AbortWithStatusJSON(http.StatusBadRequest, responses.NewProblem(http.StatusBadRequest, err.Error()))
+			return
+		}
+		c.Next()
+	}
+}
+
+// matchTemplate - операция документа с методом method, шаблон пути которой
+// совпадает с путем запроса requestPath, и значения параметров пути.
+// Параметр шаблона совпадает с любым непустым сегментом пути.
+func matchTemplate(templates []*routers.Route, method, requestPath string) (*routers.Route, map[string]string, bool) {
+	segments := strings.Split(path.Clean("/"+requestPath), "/")
+	for _, route := range templates {
+		if route.Method != method {
+			continue
+		}
+		if params, ok := matchSegments(strings.Split(route.Path, "/"), segments); ok {
+			return route, params, true
+		}
+	}
+	return nil, nil, false
+}
+
+// matchSegments - совпадение сегментов шаблона пути с сегментами пути
+// запроса и значения параметров пути.
+func matchSegments(template, segments []string) (map[string]string, bool) {
+	if len(template) != len(segments) {
+		return nil, false
+	}
+	params := map[string]string{}
+	for i, part := range template {
+		name := pathParam.FindStringSubmatch(part)
+		switch {
+		case name != nil && name[0] == part && segments[i] != "":
+			params[name[1]] = segments[i]
+		case part != segments[i]:
+			return nil, false
+		}
+	}
+	return params, true
+}
+
+// streamBody - задано ли у операции расширение StreamBodyExtension со
+// значением true.
+func streamBody(operation *openapi3.Operation) bool {
+	switch value := operation.Extensions[StreamBodyExtension].(type) {
+	case bool:
+		return value
+	case json.RawMessage:
+		return string(value) == "true"
+	default:
+		return false
+	}
+}
+
+// setDeclaredContentType - обработчики читают тело запроса независимо от
+// заголовка Content-Type, поэтому тело с отсутствующим или не описанным в
+// операции типом проверяется как единственный описанный тип.
+func setDeclaredContentType(request *http.Request, operation *openapi3.Operation) {
+	if operation.RequestBody == nil || operation.RequestBody.Value == nil {
+		return
+	}
+	content := operation.RequestBody.Value.Content
+	if len(content) != 1 {
+		return
+	}
+	mediaType, _, _ := mime.ParseMediaType(request.Header.Get("Content-Type"))
+	if _, ok := content[mediaType]; ok {
+		return
+	}
+	for declared := range content {
+		request.Header.Set("Content-Type", declared)
+	}
+}
@@ -0,0 +1,120 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/app/openapi"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/app/responses"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidationMiddleware(t *testing.T) {
+	tests := []struct {
+		name        string
+		method      string
+		target      string
+		contentType string
+		body        string
+		wantCode    int
+	}{
+		{
+			name:        "valid shorten",
+			method:      http.MethodPost,
+			target:      "/api/shorten",
+			contentType: "application/json",
+			body:        `{"url": "http://iloverestaurant.ru/"}`,
+			wantCode:    http.StatusOK,
+		},
+		{
+			name:     "valid shorten without content type",
+			method:   http.MethodPost,
+			target:   "/api/shorten",
+			body:     `{"url": "http://iloverestaurant.ru/"}`,
+			wantCode: http.StatusOK,
+		},
+		{
+			name:        "shorten without url",
+			method:      http.MethodPost,
+			target:      "/api/shorten",
+			contentType: "application/json",
+			body:        `{"url2": "http://iloverestaurant.ru/"}`,
+			wantCode:    http.StatusBadRequest,
+		},
+		{
+			name:        "malformed batch",
+			method:      http.MethodPost,
+			target:      "/api/shorten/batch",
+			contentType: "application/json",
+			body:        `{"correlation_id": "1"}`,
+			wantCode:    http.StatusBadRequest,
+		},
+		{
+			name:     "plain text create",
+			method:   http.MethodPost,
+			target:   "/",
+			body:     `http://iloverestaurant.ru/`,
+			wantCode: http.StatusOK,
+		},
+		{
+			name:        "streamed import body",
+			method:      http.MethodPost,
+			target:      "/api/user/urls/import",
+			contentType: "text/csv",
+			body:        "original_url\nhttp://iloverestaurant.ru/\n",
+			wantCode:    http.StatusOK,
+		},
+		{
+			name:        "import with unknown format",
+			method:      http.MethodPost,
+			target:      "/api/user/urls/import?format=xml",
+			contentType: "text/csv",
+			body:        "original_url\nhttp://iloverestaurant.ru/\n",
+			wantCode:    http.StatusBadRequest,
+		},
+		{
+			name:     "route without operation",
+			method:   http.MethodGet,
+			target:   "/undocumented",
+			wantCode: http.StatusOK,
+		},
+	}
+	doc, err := openapi.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.Use(ValidationMiddleware(doc))
+			var body string
+			ok := func(c *gin.Context) {
+				data, _ := c.GetRawData()
+				body = string(data)
+				c.Status(http.StatusOK)
+			}
+			router.POST("/", ok)
+			router.POST("/api/shorten", ok)
+			router.POST("/api/shorten/batch", ok)
+			router.POST("/api/user/urls/import", ok)
+			router.GET("/undocumented", ok)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantCode, w.Code)
+			if tt.wantCode == http.StatusOK {
+				assert.Equal(t, tt.body, body)
+			} else {
+				assert.Equal(t, responses.ProblemContentType, w.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
@@ -0,0 +1,35 @@
+// Package openapi - машиночитаемое описание HTTP API сервиса в формате
+// OpenAPI 3.
+package openapi
+
+import (
+	"context"
+	_ "embed"
+	"net/http"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/gin-gonic/gin"
+)
+
+//go:embed openapi.json
+var document []byte
+
+// Load - загрузка и проверка документа OpenAPI.
+// Ошибки проверки по схеме не содержат дамп схемы и значения, так как
+// их текст отдается клиенту.
+func Load() (*openapi3.T, error) {
+	openapi3.SchemaErrorDetailsDisabled = true
+	doc, err := openapi3.NewLoader().LoadFromData(document)
+	if err != nil {
+		return nil, err
+	}
+	if err := doc.Validate(context.Background()); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// Handler - отдача документа OpenAPI.
+func Handler(c *gin.Context) {
+	c.Data(http.StatusOK, "application/json; charset=utf-8", document)
+}
@@ -1,25 +1,261 @@
 package responses
 
+import (
+	"net/http"
+	"time"
+
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/access"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/redirect"
+)
+
 type PostURL struct {
-	URL string
+	URL string `json:"url"`
+}
+
+// LinkMeta - необязательные заголовок, описание и теги ссылки.
+type LinkMeta struct {
+	Title       string   `json:"title,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
 }
 
+// ShortenURL - запрос создания ссылки с заголовком, описанием и тегами.
+// Domain - домен ссылки, по умолчанию домен запроса.
+type ShortenURL struct {
+	URL    string `json:"url"`
+	Domain string `json:"domain,omitempty"`
+	LinkMeta
+}
+
+// ManyPostURL - ссылка пакета. Domain - домен ссылки, по умолчанию домен
+// запроса. ID - id ссылки в репозитории, его заполняет сервис.
 type ManyPostURL struct {
 	CorrelationID string `json:"correlation_id"`
 	OriginalURL   string `json:"original_url"`
+	Domain        string `json:"domain,omitempty"`
+	ID            string `json:"-"`
+	LinkMeta
 }
 
+// ManyPostResponse - созданная ссылка пакета. QR - QR код ссылки в виде
+// data URL, если он запрошен.
 type ManyPostResponse struct {
 	CorrelationID string `json:"correlation_id"`
 	ShortURL      string `json:"short_url"`
+	QR            string `json:"qr,omitempty"`
 }
 
+// GetURL - ссылка пользователя. WorkspaceID заполнен для ссылок рабочего
+// пространства. ID - id короткой ссылки в ее домене Domain, CreatedAt -
+// время ее создания, отсутствует для ссылок, созданных до появления этого
+// поля. Репозитории возвращают в ID id репозитория, а ShortURL и Domain
+// заполняет сервис.
 type GetURL struct {
-	ShortURL    string `json:"short_url"`
-	OriginalURL string `json:"original_url"`
+	ID          string     `json:"id,omitempty"`
+	Domain      string     `json:"domain,omitempty"`
+	ShortURL    string     `json:"short_url"`
+	OriginalURL string     `json:"original_url"`
+	WorkspaceID string     `json:"workspace_id,omitempty"`
+	CreatedAt   *time.Time `json:"created_at,omitempty"`
+	LinkMeta
+}
+
+// LinkPreview - сведения о ссылке для страницы предпросмотра: адрес
+// назначения, время создания, заголовок, описание, теги и количество
+// переходов. Blocked - ссылка отключена политикой адресов назначения.
+// Destinations - переходы по адресам распределения, если оно задано.
+// Protected - ссылка защищена паролем, адреса назначения не показываются.
+type LinkPreview struct {
+	GetURL
+	Clicks       int64               `json:"clicks"`
+	Blocked      bool                `json:"blocked,omitempty"`
+	Protected    bool                `json:"protected,omitempty"`
+	Destinations []DestinationClicks `json:"destinations,omitempty"`
+}
+
+// DestinationClicks - адрес распределения, его вес и количество
+// переходов на него.
+type DestinationClicks struct {
+	URL    string `json:"url"`
+	Weight int    `json:"weight"`
+	Clicks int64  `json:"clicks"`
+}
+
+// UserURLs - страница ссылок пользователя. NextCursor - курсор следующей
+// страницы, пустой для последней страницы.
+type UserURLs struct {
+	URLs       []GetURL
+	NextCursor string
+}
+
+// ExportURL - ссылка в файле импорта и экспорта. UserID - владелец
+// ссылки, DeletedAt - время удаления ссылки и Redirect - настройки перехода
+// заполняются только в выгрузке администратора.
+type ExportURL struct {
+	GetURL
+	UserID    string             `json:"user_id,omitempty"`
+	DeletedAt *time.Time         `json:"deleted_at,omitempty"`
+	Redirect  *redirect.Settings `json:"redirect,omitempty"`
+	Access    *access.Access     `json:"access,omitempty"`
+}
+
+// LinkAccess - ограничение доступа к ссылке: пароль, пустой - без пароля,
+// и максимальное количество переходов, 0 - без ограничения.
+type LinkAccess struct {
+	Password  string `json:"password,omitempty"`
+	MaxClicks int64  `json:"max_clicks,omitempty"`
+}
+
+// AccessState - действующее ограничение доступа к ссылке: нужен ли пароль,
+// максимальное и оставшееся количество переходов.
+type AccessState struct {
+	Protected bool  `json:"protected"`
+	MaxClicks int64 `json:"max_clicks"`
+	Remaining int64 `json:"remaining"`
+}
+
+// ImportReport - результат импорта ссылок: количество созданных ссылок и
+// ошибки в строках файла.
+type ImportReport struct {
+	Imported int           `json:"imported"`
+	Failed   int           `json:"failed"`
+	Errors   []ImportError `json:"errors"`
+}
+
+// ImportError - ошибка в строке Row файла импорта, строки нумеруются с 1
+// без учета заголовка CSV.
+type ImportError struct {
+	Row   int    `json:"row"`
+	Error string `json:"error"`
+}
+
+// URLRevision - прежний адрес назначения ссылки, который заменил ChangedBy
+// в CreatedAt. Revision - номер изменения ссылки, начиная с 1.
+type URLRevision struct {
+	Revision    int       `json:"revision"`
+	OriginalURL string    `json:"original_url"`
+	ChangedBy   string    `json:"changed_by"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// RestoreURLsResponse - количество восстановленных удаленных ссылок.
+type RestoreURLsResponse struct {
+	Restored int `json:"restored"`
 }
 
 type StatResponse struct {
 	CountURL  int `json:"urls"`
 	CountUser int `json:"users"`
 }
+
+type AdminStatResponse struct {
+	StatResponse
+	Workers     int  `json:"workers"`
+	QueuedTasks int  `json:"queued_tasks"`
+	Draining    bool `json:"draining"`
+}
+
+// Credentials - логин и пароль для регистрации и входа. Если Claim, ссылки
+// анонимного пользователя из cookie переносятся в учетную запись.
+type Credentials struct {
+	Login    string `json:"login"`
+	Password string `json:"password"`
+	Claim    bool   `json:"claim"`
+}
+
+type AccountResponse struct {
+	ID          string `json:"id"`
+	Login       string `json:"login"`
+	ClaimedURLs int    `json:"claimed_urls"`
+}
+
+type CreateAPIKey struct {
+	Name string `json:"name"`
+}
+
+// APIKeyResponse - описание ключа API. Key заполняется только при создании.
+type APIKeyResponse struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Prefix    string    `json:"prefix"`
+	CreatedAt time.Time `json:"created_at"`
+	Key       string    `json:"key,omitempty"`
+}
+
+type CreateWorkspace struct {
+	Name string `json:"name"`
+}
+
+// WorkspaceResponse - рабочее пространство и роль в нем пользователя.
+type WorkspaceResponse struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// SetMember - добавление участника по логину или изменение его роли.
+type SetMember struct {
+	Login string `json:"login"`
+	Role  string `json:"role"`
+}
+
+type MemberResponse struct {
+	UserID string `json:"user_id"`
+	Login  string `json:"login"`
+	Role   string `json:"role"`
+}
+
+// WorkspaceURLsResponse - количество ссылок, перенесенных в рабочее
+// пространство.
+type WorkspaceURLsResponse struct {
+	Moved int `json:"moved"`
+}
+
+// LinkState - владелец, адрес назначения, сохраненные переходы и признак
+// удаления ссылки, по ним URLService отправляет события вебхукам.
+type LinkState struct {
+	UserID      string
+	OriginalURL string
+	Clicks      int64
+	Deleted     bool
+}
+
+// CreateWebhook - регистрация вебхука. Пустой Events - все события.
+type CreateWebhook struct {
+	URL            string   `json:"url"`
+	Events         []string `json:"events"`
+	ClickThreshold int64    `json:"click_threshold"`
+}
+
+// WebhookResponse - описание вебхука. Secret заполняется только при
+// создании.
+type WebhookResponse struct {
+	ID             string    `json:"id"`
+	URL            string    `json:"url"`
+	Events         []string  `json:"events"`
+	ClickThreshold int64     `json:"click_threshold,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+	Secret         string    `json:"secret,omitempty"`
+}
+
+// ProblemContentType - тип содержимого ответа с ошибкой.
+const ProblemContentType = "application/problem+json"
+
+// Problem - описание ошибки в формате application/problem+json (RFC 7807).
+type Problem struct {
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// NewProblem - создание описания ошибки для HTTP кода.
+func NewProblem(status int, detail string) Problem {
+	return Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+	}
+}
@@ -0,0 +1,58 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/access"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/app/responses"
+	custom_errors "github.com/p7chkn/go-musthave-shortener-tpl/internal/errors"
+)
+
+// errLinkExhausted - разрешенные переходы по ссылке закончились, ссылка
+// отвечает так же, как удаленная.
+var errLinkExhausted = custom_errors.NewCustomError(errors.New("click limit reached"), http.StatusGone)
+
+// UpdateAccess - замена ограничения доступа к ссылке с id shortURL: пароль
+// хешируется, счетчик переходов начинается заново. Некорректный пароль
+// или количество переходов - ошибка с кодом 400.
+func (us *URLService) UpdateAccess(ctx context.Context, shortURL string, req responses.LinkAccess, userID string) (responses.AccessState, error) {
+	a, err := access.New(req.Password, req.MaxClicks)
+	if errors.Is(err, access.ErrInvalidPassword) || errors.Is(err, access.ErrInvalidMaxClicks) {
+		return responses.AccessState{}, custom_errors.NewCustomError(err, http.StatusBadRequest)
+	}
+	if err != nil {
+		return responses.AccessState{}, err
+	}
+	if err := us.repo.UpdateAccess(ctx, shortURL, a, userID); err != nil {
+		return responses.AccessState{}, err
+	}
+	return accessState(a), nil
+}
+
+// checkAccess - проверка пароля visit и оставшихся переходов перед
+// переходом по ссылке. Неверный или отсутствующий пароль - ошибка с кодом
+// 401, закончившиеся переходы - 410.
+func (us *URLService) checkAccess(ctx context.Context, shortURL string, password string) (access.Access, error) {
+	a, err := us.repo.GetAccess(ctx, shortURL)
+	if err != nil {
+		return a, err
+	}
+	if a.Exhausted() {
+		return a, errLinkExhausted
+	}
+	if err := a.CheckPassword(password); err != nil {
+		return a, custom_errors.NewCustomError(err, http.StatusUnauthorized)
+	}
+	return a, nil
+}
+
+// accessState - действующее ограничение доступа для ответа API.
+func accessState(a access.Access) responses.AccessState {
+	return responses.AccessState{
+		Protected: a.Protected(),
+		MaxClicks: a.MaxClicks,
+		Remaining: a.Remaining(),
+	}
+}
@@ -0,0 +1,60 @@
+package services_test
+
+import (
+	"context"
+	"net/http"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/p7chkn/go-musthave-shortener-tpl/cmd/shortener/configuration"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/app/responses"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/app/services"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/domains"
+	custom_errors "github.com/p7chkn/go-musthave-shortener-tpl/internal/errors"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/filebase"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/redirect"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedirectClickLimit(t *testing.T) {
+	const maxClicks = 3
+	ctx := context.Background()
+	repo := filebase.NewRepositoryMap(ctx, filepath.Join(t.TempDir(), "urls.jsonl"))
+	dom, err := domains.New(configuration.BaseURL, nil)
+	require.NoError(t, err)
+	service := services.NewURLService(repo, dom, nil, nil, nil, nil, 0, nil, nil, nil)
+	require.NoError(t, repo.AddURL(ctx, "https://example.com/", "abc", "user-1", responses.LinkMeta{}))
+	_, err = service.UpdateAccess(ctx, "abc", responses.LinkAccess{MaxClicks: maxClicks}, "user-1")
+	require.NoError(t, err)
+
+	codes := make(chan int, 20)
+	var wg sync.WaitGroup
+	for i := 0; i < cap(codes); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			target, err := service.Redirect(ctx, "abc", redirect.Visit{})
+			if err != nil {
+				codes <- custom_errors.ParseError(err)
+				return
+			}
+			assert.Equal(t, "https://example.com/", target.URL)
+			codes <- 0
+		}()
+	}
+	wg.Wait()
+	close(codes)
+	got := map[int]int{}
+	for code := range codes {
+		got[code]++
+	}
+	// 0 - переход разрешен.
+	assert.Equal(t, map[int]int{0: maxClicks, http.StatusGone: cap(codes) - maxClicks}, got)
+
+	_, err = service.Redirect(ctx, "abc", redirect.Visit{})
+	assert.Equal(t, http.StatusGone, custom_errors.ParseError(err))
+	_, err = service.Preview(ctx, "abc")
+	assert.Equal(t, http.StatusGone, custom_errors.ParseError(err))
+}
@@ -0,0 +1,177 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/accounts"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/app/responses"
+	custom_errors "github.com/p7chkn/go-musthave-shortener-tpl/internal/errors"
+)
+
+// AccountRepositoryInterface - хранилище учетных записей и ключей API.
+// Отсутствующие записи возвращаются ошибкой с кодом 404, занятый логин -
+// ошибкой с кодом 409.
+type AccountRepositoryInterface interface {
+	CreateAccount(ctx context.Context, account accounts.Account) error
+	GetAccountByLogin(ctx context.Context, login string) (accounts.Account, error)
+	AccountExists(ctx context.Context, userID string) (bool, error)
+	AddAPIKey(ctx context.Context, key accounts.APIKey) error
+	GetAPIKey(ctx context.Context, hash string) (accounts.APIKey, error)
+	ListAPIKeys(ctx context.Context, userID string) ([]accounts.APIKey, error)
+	DeleteAPIKey(ctx context.Context, userID string, keyID string) error
+	ClaimURLs(ctx context.Context, fromUser string, toUser string) (int, error)
+}
+
+var (
+	// errUnauthorized - действие доступно только учетной записи.
+	errUnauthorized = custom_errors.NewCustomError(errors.New("login required"), http.StatusUnauthorized)
+	// errBadAPIKey - неизвестный или отозванный ключ API.
+	errBadAPIKey = custom_errors.NewCustomError(errors.New("invalid api key"), http.StatusUnauthorized)
+)
+
+func NewAccountService(repo AccountRepositoryInterface) *AccountService {
+	return &AccountService{
+		repo: repo,
+	}
+}
+
+// AccountService - регистрация и вход пользователей, ключи API.
+type AccountService struct {
+	repo AccountRepositoryInterface
+}
+
+// Register - создание учетной записи. Если claim, ссылки анонимного
+// пользователя anonymousID переносятся в новую учетную запись.
+func (as *AccountService) Register(ctx context.Context, credentials responses.Credentials, anonymousID string) (responses.AccountResponse, error) {
+	account, err := accounts.NewAccount(credentials.Login, credentials.Password)
+	if err != nil {
+		if errors.Is(err, accounts.ErrInvalidLogin) || errors.Is(err, accounts.ErrInvalidPassword) {
+			return responses.AccountResponse{}, custom_errors.NewCustomError(err, http.StatusBadRequest)
+		}
+		return responses.AccountResponse{}, err
+	}
+	if err := as.repo.CreateAccount(ctx, account); err != nil {
+		return responses.AccountResponse{}, err
+	}
+	return as.claim(ctx, account, anonymousID, credentials.Claim)
+}
+
+// Login - вход в учетную запись по логину и паролю. Если claim, ссылки
+// анонимного пользователя anonymousID переносятся в учетную запись.
+func (as *AccountService) Login(ctx context.Context, credentials responses.Credentials, anonymousID string) (responses.AccountResponse, error) {
+	badCredentials := custom_errors.NewCustomError(accounts.ErrBadCredentials, http.StatusUnauthorized)
+	account, err := as.repo.GetAccountByLogin(ctx, credentials.Login)
+	if custom_errors.ParseError(err) == http.StatusNotFound {
+		return responses.AccountResponse{}, badCredentials
+	}
+	if err != nil {
+		return responses.AccountResponse{}, err
+	}
+	if err := account.CheckPassword(credentials.Password); err != nil {
+		return responses.AccountResponse{}, badCredentials
+	}
+	return as.claim(ctx, account, anonymousID, credentials.Claim)
+}
+
+// CreateAPIKey - создание ключа API для учетной записи userID.
+func (as *AccountService) CreateAPIKey(ctx context.Context, userID string, name string) (responses.APIKeyResponse, error) {
+	if err := as.requireAccount(ctx, userID); err != nil {
+		return responses.APIKeyResponse{}, err
+	}
+	plain, key, err := accounts.NewAPIKey(userID, name)
+	if err != nil {
+		return responses.APIKeyResponse{}, err
+	}
+	if err := as.repo.AddAPIKey(ctx, key); err != nil {
+		return responses.APIKeyResponse{}, err
+	}
+	response := apiKeyResponse(key)
+	response.Key = plain
+	return response, nil
+}
+
+// ListAPIKeys - список ключей API учетной записи userID.
+func (as *AccountService) ListAPIKeys(ctx context.Context, userID string) ([]responses.APIKeyResponse, error) {
+	if err := as.requireAccount(ctx, userID); err != nil {
+		return nil, err
+	}
+	keys, err := as.repo.ListAPIKeys(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]responses.APIKeyResponse, 0, len(keys))
+	for _, key := range keys {
+		result = append(result, apiKeyResponse(key))
+	}
+	return result, nil
+}
+
+// DeleteAPIKey - отзыв ключа API учетной записи userID.
+func (as *AccountService) DeleteAPIKey(ctx context.Context, userID string, keyID string) error {
+	if err := as.requireAccount(ctx, userID); err != nil {
+		return err
+	}
+	return as.repo.DeleteAPIKey(ctx, userID, keyID)
+}
+
+// Authenticate - получение учетной записи по ключу API.
+func (as *AccountService) Authenticate(ctx context.Context, apiKey string) (string, error) {
+	key, err := as.repo.GetAPIKey(ctx, accounts.HashAPIKey(apiKey))
+	if custom_errors.ParseError(err) == http.StatusNotFound {
+		return "", errBadAPIKey
+	}
+	if err != nil {
+		return "", err
+	}
+	return key.UserID, nil
+}
+
+// IsAccount - принадлежит ли userID учетной записи, а не анонимному
+// пользователю.
+func (as *AccountService) IsAccount(ctx context.Context, userID string) (bool, error) {
+	if userID == "" {
+		return false, nil
+	}
+	return as.repo.AccountExists(ctx, userID)
+}
+
+// claim - перенос ссылок анонимного пользователя в учетную запись.
+// Ссылки другой учетной записи не переносятся.
+func (as *AccountService) claim(ctx context.Context, account accounts.Account, anonymousID string, claim bool) (responses.AccountResponse, error) {
+	response := responses.AccountResponse{
+		ID:    account.ID,
+		Login: account.Login,
+	}
+	if !claim || anonymousID == "" || anonymousID == account.ID {
+		return response, nil
+	}
+	isAccount, err := as.IsAccount(ctx, anonymousID)
+	if err != nil || isAccount {
+		return response, err
+	}
+	response.ClaimedURLs, err = as.repo.ClaimURLs(ctx, anonymousID, account.ID)
+	return response, err
+}
+
+// requireAccount - ошибка с кодом 401, если userID не учетная запись.
+func (as *AccountService) requireAccount(ctx context.Context, userID string) error {
+	isAccount, err := as.IsAccount(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !isAccount {
+		return errUnauthorized
+	}
+	return nil
+}
+
+func apiKeyResponse(key accounts.APIKey) responses.APIKeyResponse {
+	return responses.APIKeyResponse{
+		ID:        key.ID,
+		Name:      key.Name,
+		Prefix:    key.Prefix,
+		CreatedAt: key.CreatedAt,
+	}
+}
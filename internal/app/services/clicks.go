@@ -0,0 +1,140 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/app/responses"
+	custom_errors "github.com/p7chkn/go-musthave-shortener-tpl/internal/errors"
+)
+
+// errPreviewNotFound - предпросмотр запрошен для отсутствующей ссылки.
+var errPreviewNotFound = custom_errors.NewCustomError(errors.New("url not found"), http.StatusNotFound)
+
+// RecordClick - учет перехода по ссылке с id shortURL на адрес
+// распределения destination, пустой - распределение не применялось.
+// Переходы копятся в памяти и сохраняются в репозитории FlushClicks.
+func (us *URLService) RecordClick(shortURL string, destination string) {
+	us.clicksMu.Lock()
+	defer us.clicksMu.Unlock()
+	us.clicks[shortURL]++
+	if destination == "" {
+		return
+	}
+	if us.destinationClicks[shortURL] == nil {
+		us.destinationClicks[shortURL] = map[string]int64{}
+	}
+	us.destinationClicks[shortURL][destination]++
+}
+
+// FlushClicks - сохранение накопленных переходов в репозитории. При ошибке
+// переходы возвращаются в очередь до следующей попытки.
+func (us *URLService) FlushClicks(ctx context.Context) error {
+	us.clicksMu.Lock()
+	clicks, destinationClicks := us.clicks, us.destinationClicks
+	us.clicks = map[string]int64{}
+	us.destinationClicks = map[string]map[string]int64{}
+	us.clicksMu.Unlock()
+	if len(clicks) == 0 && len(destinationClicks) == 0 {
+		return nil
+	}
+	if err := us.repo.AddClicks(ctx, clicks); err != nil {
+		us.requeueClicks(clicks, destinationClicks)
+		return err
+	}
+	if err := us.repo.AddDestinationClicks(ctx, destinationClicks); err != nil {
+		us.requeueClicks(nil, destinationClicks)
+		return err
+	}
+	return us.publishThresholds(ctx, clicks)
+}
+
+// requeueClicks - возврат несохраненных переходов в очередь.
+func (us *URLService) requeueClicks(clicks map[string]int64, destinationClicks map[string]map[string]int64) {
+	us.clicksMu.Lock()
+	defer us.clicksMu.Unlock()
+	for shortURL, count := range clicks {
+		us.clicks[shortURL] += count
+	}
+	for shortURL, counts := range destinationClicks {
+		if us.destinationClicks[shortURL] == nil {
+			us.destinationClicks[shortURL] = map[string]int64{}
+		}
+		for destination, count := range counts {
+			us.destinationClicks[shortURL][destination] += count
+		}
+	}
+}
+
+// WatchClicks - периодическое сохранение накопленных переходов с
+// интервалом interval до отмены ctx. После остановки WorkerPool задачи
+// сохранения не ставятся.
+func (us *URLService) WatchClicks(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			us.wp.TryPush(ctx, us.FlushClicks)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Preview - сведения о ссылке с id shortURL для страницы предпросмотра:
+// адрес назначения, время создания, заголовок, описание, теги и количество
+// переходов вместе с еще не сохраненными, в том числе по адресам
+// распределения. Удаленная ссылка и ссылка, переходы по которой
+// закончились, - ошибка с кодом 410, отсутствующая - 404. Ссылка,
+// отключенная политикой, показывается с признаком Blocked. У ссылки с
+// паролем адреса назначения не показываются.
+func (us *URLService) Preview(ctx context.Context, shortURL string) (responses.LinkPreview, error) {
+	long, settings, err := us.repo.GetRedirect(ctx, shortURL)
+	blocked := errors.Is(err, ErrLinkBlocked)
+	if err != nil && !blocked {
+		return responses.LinkPreview{}, err
+	}
+	if long == "" {
+		return responses.LinkPreview{}, errPreviewNotFound
+	}
+	a, err := us.repo.GetAccess(ctx, shortURL)
+	if err != nil {
+		return responses.LinkPreview{}, err
+	}
+	if a.Exhausted() {
+		return responses.LinkPreview{}, errLinkExhausted
+	}
+	preview, err := us.repo.GetPreview(ctx, shortURL)
+	if err != nil {
+		return responses.LinkPreview{}, err
+	}
+	preview.GetURL = us.link(preview.GetURL)
+	preview.WorkspaceID = ""
+	preview.Blocked = blocked
+	if a.Protected() {
+		preview.OriginalURL = ""
+		preview.Protected = true
+		settings.Destinations = nil
+	}
+	var destinationClicks map[string]int64
+	if len(settings.Destinations) > 0 {
+		destinationClicks, err = us.repo.GetDestinationClicks(ctx, shortURL)
+		if err != nil {
+			return responses.LinkPreview{}, err
+		}
+	}
+	us.clicksMu.Lock()
+	defer us.clicksMu.Unlock()
+	preview.Clicks += us.clicks[shortURL]
+	for _, destination := range settings.Destinations {
+		preview.Destinations = append(preview.Destinations, responses.DestinationClicks{
+			URL:    destination.URL,
+			Weight: destination.Weight,
+			Clicks: destinationClicks[destination.URL] + us.destinationClicks[shortURL][destination.URL],
+		})
+	}
+	return preview, nil
+}
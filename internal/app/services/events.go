@@ -0,0 +1,101 @@
+package services
+
+import (
+	"context"
+	"sort"
+
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/app/responses"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/webhooks"
+)
+
+// EventPublisher - отправка событий о ссылках вебхукам пользователей.
+// Publish не должен блокировать вызывающего: события публикуются в том
+// числе из задач WorkerPool.
+type EventPublisher interface {
+	Publish(userID string, event webhooks.Event)
+}
+
+// publish - отправка события eventType о ссылке link вебхукам
+// пользователя userID.
+func (us *URLService) publish(userID string, eventType string, link *webhooks.Link) {
+	if us.events == nil {
+		return
+	}
+	us.events.Publish(userID, webhooks.NewEvent(eventType, link))
+}
+
+// eventLink - ссылка с id репозитория shortURL для события.
+func (us *URLService) eventLink(shortURL string, originalURL string) *webhooks.Link {
+	u := us.link(responses.GetURL{ID: shortURL, OriginalURL: originalURL})
+	return &webhooks.Link{
+		ID:          u.ID,
+		Domain:      u.Domain,
+		ShortURL:    u.ShortURL,
+		OriginalURL: u.OriginalURL,
+	}
+}
+
+// publishCreated - событие о создании ссылки из пакета.
+func (us *URLService) publishCreated(userID string, u responses.ManyPostURL) {
+	us.publish(userID, webhooks.EventLinkCreated, us.eventLink(u.ID, u.OriginalURL))
+}
+
+// deleteURLs - удаление ссылок пользователя. Событие об удалении
+// отправляется вебхукам владельца каждой ссылки, которую удалил именно этот
+// запрос, в том числе ссылки рабочего пространства, удаленной его
+// участником.
+func (us *URLService) deleteURLs(ctx context.Context, urls []string, userID string) error {
+	if us.events == nil {
+		return us.repo.DeleteManyURL(ctx, urls, userID)
+	}
+	before, err := us.repo.GetLinkStates(ctx, urls)
+	if err != nil {
+		return err
+	}
+	if err := us.repo.DeleteManyURL(ctx, urls, userID); err != nil {
+		return err
+	}
+	after, err := us.repo.GetLinkStates(ctx, urls)
+	if err != nil {
+		return err
+	}
+	seen := map[string]bool{}
+	for _, shortURL := range urls {
+		state, ok := after[shortURL]
+		if seen[shortURL] || !ok || !state.Deleted || before[shortURL].Deleted {
+			continue
+		}
+		seen[shortURL] = true
+		us.publish(state.UserID, webhooks.EventLinkDeleted, us.eventLink(shortURL, state.OriginalURL))
+	}
+	return nil
+}
+
+// publishThresholds - события о переходах по ссылкам после сохранения
+// clicks. Вебхуки получают событие, только если сохраненные переходы
+// перешли их порог (см. webhooks.Webhook.Wants).
+func (us *URLService) publishThresholds(ctx context.Context, clicks map[string]int64) error {
+	if us.events == nil || len(clicks) == 0 {
+		return nil
+	}
+	shortURLs := make([]string, 0, len(clicks))
+	for shortURL := range clicks {
+		shortURLs = append(shortURLs, shortURL)
+	}
+	sort.Strings(shortURLs)
+	states, err := us.repo.GetLinkStates(ctx, shortURLs)
+	if err != nil {
+		return err
+	}
+	for _, shortURL := range shortURLs {
+		state, ok := states[shortURL]
+		if !ok || state.Deleted {
+			continue
+		}
+		link := us.eventLink(shortURL, state.OriginalURL)
+		link.Clicks = state.Clicks
+		link.PreviousClicks = state.Clicks - clicks[shortURL]
+		us.publish(state.UserID, webhooks.EventClickThreshold, link)
+	}
+	return nil
+}
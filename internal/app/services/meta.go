@@ -0,0 +1,90 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/app/responses"
+	custom_errors "github.com/p7chkn/go-musthave-shortener-tpl/internal/errors"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/titles"
+)
+
+// Ограничения заголовка, описания и тегов ссылки.
+const (
+	MaxDescriptionLength = 1024
+	MaxTags              = 20
+	MaxTagLength         = 50
+)
+
+// UpdateMeta - замена заголовка, описания и тегов ссылки с id shortURL.
+// Возвращает сохраненные значения после очистки.
+func (us *URLService) UpdateMeta(ctx context.Context, shortURL string, meta responses.LinkMeta, userID string) (responses.LinkMeta, error) {
+	meta, err := cleanMeta(meta)
+	if err != nil {
+		return responses.LinkMeta{}, err
+	}
+	if err := us.repo.UpdateMeta(ctx, shortURL, meta, userID); err != nil {
+		return responses.LinkMeta{}, err
+	}
+	return meta, nil
+}
+
+// fetchTitle - ставит в очередь WorkerPool получение заголовка страницы
+// longURL для ссылки без заголовка. Заголовок сохраняется, только если
+// пользователь не успел задать его сам.
+func (us *URLService) fetchTitle(shortURL string, longURL string, meta responses.LinkMeta) {
+	if us.titles == nil || meta.Title != "" {
+		return
+	}
+	us.wp.Push(func(ctx context.Context) error {
+		title, err := us.titles.Fetch(ctx, longURL)
+		if errors.Is(err, titles.ErrNoTitle) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("fetch title of %s: %w", longURL, err)
+		}
+		return us.repo.FillTitle(ctx, shortURL, title)
+	})
+}
+
+// cleanMeta - проверка и очистка заголовка, описания и тегов: пробелы по
+// краям убираются, теги приводятся к нижнему регистру без повторов.
+// Слишком длинные значения - ошибка с кодом 400.
+func cleanMeta(meta responses.LinkMeta) (responses.LinkMeta, error) {
+	result := responses.LinkMeta{
+		Title:       strings.TrimSpace(meta.Title),
+		Description: strings.TrimSpace(meta.Description),
+	}
+	if utf8.RuneCountInString(result.Title) > titles.MaxLength {
+		return responses.LinkMeta{}, metaError("title is longer than %d characters", titles.MaxLength)
+	}
+	if utf8.RuneCountInString(result.Description) > MaxDescriptionLength {
+		return responses.LinkMeta{}, metaError("description is longer than %d characters", MaxDescriptionLength)
+	}
+	seen := map[string]bool{}
+	for _, tag := range meta.Tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		if utf8.RuneCountInString(tag) > MaxTagLength {
+			return responses.LinkMeta{}, metaError("tag %q is longer than %d characters", tag, MaxTagLength)
+		}
+		seen[tag] = true
+		result.Tags = append(result.Tags, tag)
+	}
+	if len(result.Tags) > MaxTags {
+		return responses.LinkMeta{}, metaError("more than %d tags", MaxTags)
+	}
+	return result, nil
+}
+
+// metaError - ошибка проверки заголовка, описания или тегов с кодом 400.
+func metaError(format string, args ...interface{}) error {
+	return custom_errors.NewCustomError(fmt.Errorf(format, args...), http.StatusBadRequest)
+}
@@ -0,0 +1,32 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	custom_errors "github.com/p7chkn/go-musthave-shortener-tpl/internal/errors"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/qr"
+)
+
+// errQRNotFound - QR код запрошен для отсутствующей или удаленной ссылки.
+var errQRNotFound = custom_errors.NewCustomError(errors.New("url not found"), http.StatusNotFound)
+
+// QRCode - QR код короткой ссылки с id shortURL в формате format. Код
+// строится и для ссылки, отключенной политикой: при переходе по ней будет
+// показана страница с предупреждением. Некорректные параметры - ошибка с
+// кодом 400, отсутствующая или удаленная ссылка - 404.
+func (us *URLService) QRCode(ctx context.Context, shortURL string, format string, opts qr.Options) ([]byte, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, custom_errors.NewCustomError(err, http.StatusBadRequest)
+	}
+	long, err := us.repo.GetURL(ctx, shortURL)
+	if (err != nil && !errors.Is(err, ErrLinkBlocked)) || long == "" {
+		return nil, errQRNotFound
+	}
+	data, err := qr.Encode(us.domains.ShortURL(shortURL), format, opts)
+	if errors.Is(err, qr.ErrUnknownFormat) || errors.Is(err, qr.ErrTooSmall) {
+		return nil, custom_errors.NewCustomError(err, http.StatusBadRequest)
+	}
+	return data, err
+}
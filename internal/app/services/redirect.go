@@ -0,0 +1,103 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	custom_errors "github.com/p7chkn/go-musthave-shortener-tpl/internal/errors"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/redirect"
+)
+
+// errRedirectNotFound - переход по отсутствующей ссылке.
+var errRedirectNotFound = custom_errors.NewCustomError(errors.New("not found"), http.StatusNotFound)
+
+// Redirect - переход по ссылке с id shortURL по ее настройкам. Правила
+// перехода выбираются по платформе, устройству, языку и стране посетителя
+// из visit, адрес распределения закрепляется за посетителем visit.Visitor
+// отдельно для каждой ссылки. Для ссылки с паролем нужен верный
+// visit.Password, иначе ошибка с кодом 401. Переход по ссылке с
+// ограничением количества переходов учитывается сразу, после последнего
+// разрешенного перехода ссылка отвечает кодом 410. Для ссылки, отключенной
+// политикой, возвращается ErrLinkBlocked и исходный адрес назначения в
+// Target.URL.
+func (us *URLService) Redirect(ctx context.Context, shortURL string, visit redirect.Visit) (redirect.Target, error) {
+	long, settings, err := us.repo.GetRedirect(ctx, shortURL)
+	blocked := errors.Is(err, ErrLinkBlocked)
+	if err != nil && !blocked {
+		return redirect.Target{}, err
+	}
+	if long == "" {
+		return redirect.Target{}, errRedirectNotFound
+	}
+	// Адрес отключенной ссылки показывается на странице предупреждения,
+	// поэтому пароль проверяется и для нее.
+	a, err := us.checkAccess(ctx, shortURL, visit.Password)
+	if err != nil {
+		return redirect.Target{}, err
+	}
+	if blocked {
+		return redirect.Target{URL: long}, ErrLinkBlocked
+	}
+	if a.MaxClicks > 0 {
+		ok, err := us.repo.UseLink(ctx, shortURL)
+		if err != nil {
+			return redirect.Target{}, err
+		}
+		if !ok {
+			return redirect.Target{}, errLinkExhausted
+		}
+	}
+	var client redirect.Client
+	if len(settings.Rules) > 0 {
+		client = redirect.ParseClient(visit.UserAgent, visit.AcceptLanguage)
+		if us.geo != nil {
+			client.Country = us.geo.Country(visit.IP)
+		}
+	}
+	if visit.Visitor != "" {
+		client.ID = shortURL + "/" + visit.Visitor
+	}
+	return settings.Target(long, visit.Query, client), nil
+}
+
+// UpdateRedirect - замена настроек перехода по ссылке с id shortURL.
+// Адреса правил и распределения нормализуются и проверяются политикой, как
+// при создании ссылки. Возвращает сохраненные настройки после очистки.
+// Некорректные настройки - ошибка с кодом 400, запрещенный адрес правила -
+// 403.
+func (us *URLService) UpdateRedirect(ctx context.Context, shortURL string, settings redirect.Settings, userID string) (redirect.Settings, error) {
+	settings = settings.Clean()
+	if err := settings.Validate(); err != nil {
+		return redirect.Settings{}, custom_errors.NewCustomError(err, http.StatusBadRequest)
+	}
+	for i := range settings.Rules {
+		long, err := us.normalizer.Normalize(settings.Rules[i].URL)
+		if err != nil {
+			return redirect.Settings{}, custom_errors.NewCustomError(fmt.Errorf("rule %d: %w", i+1, err), http.StatusBadRequest)
+		}
+		if err := us.checkPolicy(ctx, long); err != nil {
+			return redirect.Settings{}, err
+		}
+		settings.Rules[i].URL = long
+	}
+	for i := range settings.Destinations {
+		long, err := us.normalizer.Normalize(settings.Destinations[i].URL)
+		if err != nil {
+			return redirect.Settings{}, custom_errors.NewCustomError(fmt.Errorf("destination %d: %w", i+1, err), http.StatusBadRequest)
+		}
+		if err := us.checkPolicy(ctx, long); err != nil {
+			return redirect.Settings{}, err
+		}
+		settings.Destinations[i].URL = long
+	}
+	// Адреса, разные до нормализации, могут совпасть после нее.
+	if err := settings.Validate(); err != nil {
+		return redirect.Settings{}, custom_errors.NewCustomError(err, http.StatusBadRequest)
+	}
+	if err := us.repo.UpdateRedirect(ctx, shortURL, settings, userID); err != nil {
+		return redirect.Settings{}, err
+	}
+	return settings, nil
+}
@@ -0,0 +1,152 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/app/responses"
+	custom_errors "github.com/p7chkn/go-musthave-shortener-tpl/internal/errors"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/listing"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/transfer"
+)
+
+// ImportChunkSize - сколько строк импорта создаются одним пакетом.
+const ImportChunkSize = 100
+
+// ImportURLs - создание ссылок из файла импорта. Строки читаются по мере
+// создания ссылок и проверяются по отдельности, как в CreateBatch:
+// некорректные строки попадают в отчет, остальные создаются пакетами по
+// ImportChunkSize. Импорт ограничен transfer.MaxRows строками.
+func (us *URLService) ImportURLs(ctx context.Context, reader transfer.Reader, userID string) (responses.ImportReport, error) {
+	report := responses.ImportReport{Errors: []responses.ImportError{}}
+	if us.Draining() {
+		return report, errDraining
+	}
+	chunk := make([]responses.ManyPostURL, 0, ImportChunkSize)
+	flush := func() {
+		report.Imported += us.importChunk(ctx, chunk, userID, &report)
+		chunk = chunk[:0]
+	}
+	for {
+		u, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		var rowErr *transfer.RowError
+		if errors.As(err, &rowErr) {
+			report.Errors = append(report.Errors, responses.ImportError{Row: rowErr.Row, Error: rowErr.Err.Error()})
+			continue
+		}
+		if err != nil {
+			return report, custom_errors.NewCustomError(err, http.StatusBadRequest)
+		}
+		if reader.Row() > transfer.MaxRows {
+			report.Errors = append(report.Errors, responses.ImportError{
+				Row:   reader.Row(),
+				Error: fmt.Sprintf("import is limited to %d rows", transfer.MaxRows),
+			})
+			break
+		}
+		chunk = append(chunk, responses.ManyPostURL{
+			CorrelationID: strconv.Itoa(reader.Row()),
+			OriginalURL:   u.OriginalURL,
+			Domain:        u.Domain,
+			LinkMeta:      u.LinkMeta,
+		})
+		if len(chunk) == ImportChunkSize {
+			flush()
+		}
+	}
+	flush()
+	sort.SliceStable(report.Errors, func(i, j int) bool {
+		return report.Errors[i].Row < report.Errors[j].Row
+	})
+	report.Failed = len(report.Errors)
+	return report, nil
+}
+
+// importChunk - создание ссылок из части файла импорта, ошибки строк
+// добавляются в report. Если пакет не удалось создать целиком, например из-за
+// уже существующей ссылки, ссылки создаются по одной. Возвращает количество
+// созданных ссылок.
+func (us *URLService) importChunk(ctx context.Context, chunk []responses.ManyPostURL, userID string,
+	report *responses.ImportReport) int {
+	valid := make([]responses.ManyPostURL, 0, len(chunk))
+	for _, u := range chunk {
+		prepared, err := us.prepareBatchURL(ctx, u)
+		if err != nil {
+			report.Errors = append(report.Errors, importError(u.CorrelationID, err))
+			continue
+		}
+		valid = append(valid, prepared)
+	}
+	if len(valid) == 0 {
+		return 0
+	}
+	if err := us.repo.AddManyURL(ctx, valid, userID); err == nil {
+		for _, u := range valid {
+			us.fetchTitle(u.ID, u.OriginalURL, u.LinkMeta)
+			us.publishCreated(userID, u)
+		}
+		return len(valid)
+	}
+	imported := 0
+	for _, u := range valid {
+		err := us.repo.AddURL(ctx, u.OriginalURL, u.ID, userID, u.LinkMeta)
+		if custom_errors.ParseError(err) == http.StatusConflict {
+			err = errors.New("link already exists")
+		}
+		if err != nil {
+			report.Errors = append(report.Errors, importError(u.CorrelationID, err))
+			continue
+		}
+		us.fetchTitle(u.ID, u.OriginalURL, u.LinkMeta)
+		us.publishCreated(userID, u)
+		imported++
+	}
+	return imported
+}
+
+// importError - ошибка строки импорта с номером row.
+func importError(row string, err error) responses.ImportError {
+	number, _ := strconv.Atoi(row)
+	return responses.ImportError{Row: number, Error: err.Error()}
+}
+
+// ExportURLs - запись всех ссылок пользователя в writer в порядке создания.
+// Ссылки читаются страницами по listing.MaxLimit, каждая страница сразу
+// записывается.
+func (us *URLService) ExportURLs(ctx context.Context, userID string, writer transfer.Writer) error {
+	query := listing.Query{Limit: listing.MaxLimit, Ascending: true}
+	for {
+		page, err := us.GetUserURL(ctx, userID, query)
+		if custom_errors.ParseError(err) == http.StatusNoContent {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		for _, u := range page.URLs {
+			if err := writer.Write(responses.ExportURL{GetURL: u}); err != nil {
+				return err
+			}
+		}
+		if err := writer.Flush(); err != nil {
+			return err
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		after, err := listing.ParseCursor(page.NextCursor)
+		if err != nil {
+			return err
+		}
+		query.After = &after
+	}
+	return writer.Flush()
+}
@@ -2,50 +2,252 @@ package services
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/access"
 	"github.com/p7chkn/go-musthave-shortener-tpl/internal/app/responses"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/domains"
+	custom_errors "github.com/p7chkn/go-musthave-shortener-tpl/internal/errors"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/geoip"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/listing"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/normalizer"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/policy"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/redirect"
 	"github.com/p7chkn/go-musthave-shortener-tpl/internal/shortener"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/titles"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/webhooks"
 	"github.com/p7chkn/go-musthave-shortener-tpl/internal/workers"
+	"log"
 	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
+// UserRepositoryInterface - хранилище ссылок. Ссылки в нем определяются id
+// репозитория, в который входит домен ссылки (см. пакет domains); короткие
+// адреса ссылок репозиторий не собирает.
 type UserRepositoryInterface interface {
-	AddURL(ctx context.Context, longURL string, shortURL string, user string) error
+	AddURL(ctx context.Context, longURL string, shortURL string, user string, meta responses.LinkMeta) error
 	GetURL(ctx context.Context, shortURL string) (string, error)
-	GetUserURL(ctx context.Context, user string) ([]responses.GetURL, error)
-	AddManyURL(ctx context.Context, urls []responses.ManyPostURL, user string) ([]responses.ManyPostResponse, error)
+	// GetUserURL - ссылки пользователя, подходящие под query, в порядке
+	// сортировки query, не больше query.Limit.
+	GetUserURL(ctx context.Context, user string, query listing.Query) ([]responses.GetURL, error)
+	// AddManyURL - сохранение пакета ссылок с id из ManyPostURL.ID.
+	AddManyURL(ctx context.Context, urls []responses.ManyPostURL, user string) error
 	DeleteManyURL(ctx context.Context, urls []string, user string) error
 	GetStats(ctx context.Context) (responses.StatResponse, error)
 	Ping(ctx context.Context) error
+	// ForEachURL - обход всех сохраненных ссылок с их настройками перехода.
+	ForEachURL(ctx context.Context, fn func(shortURL string, longURL string, settings redirect.Settings) error) error
+	SetBlocked(ctx context.Context, shortURLs []string, blocked bool) error
+	// UpdateURL - замена адреса назначения ссылки, которую пользователь
+	// может изменять, с сохранением прежнего адреса в истории изменений.
+	// Отсутствующая, удаленная или чужая ссылка - ошибка с кодом 404.
+	UpdateURL(ctx context.Context, shortURL string, longURL string, user string) error
+	// GetRevisions - история изменений ссылки, которую пользователь может
+	// изменять, в порядке изменений.
+	GetRevisions(ctx context.Context, shortURL string, user string) ([]responses.URLRevision, error)
+	// RestoreURLs - восстановление удаленных ссылок, которые пользователь
+	// может изменять и которые удалены не раньше deletedSince. Возвращает
+	// количество восстановленных ссылок.
+	RestoreURLs(ctx context.Context, urls []string, user string, deletedSince time.Time) (int, error)
+	// PurgeURLs - окончательное удаление ссылок вместе со всеми данными о
+	// них. Возвращает количество удаленных ссылок.
+	PurgeURLs(ctx context.Context, urls []string) (int, error)
+	// PurgeDeleted - окончательное удаление ссылок, удаленных раньше
+	// deletedBefore.
+	PurgeDeleted(ctx context.Context, deletedBefore time.Time) (int, error)
+	// UpdateMeta - замена заголовка, описания и тегов ссылки, которую
+	// пользователь может изменять. Отсутствующая, удаленная или чужая
+	// ссылка - ошибка с кодом 404.
+	UpdateMeta(ctx context.Context, shortURL string, meta responses.LinkMeta, user string) error
+	// FillTitle - сохранение заголовка ссылки, если он еще не задан.
+	FillTitle(ctx context.Context, shortURL string, title string) error
+	// ExportURLs - обход всех ссылок вместе с их владельцами для выгрузки
+	// администратором. Удаленные ссылки обходятся, только если deleted.
+	ExportURLs(ctx context.Context, deleted bool, fn func(u responses.ExportURL) error) error
+	// ImportURL - сохранение ссылки из выгрузки с ее id, владельцем,
+	// временем создания, заголовком, описанием, тегами и временем удаления.
+	// Если ссылка с таким id уже есть, она не меняется и возвращается false.
+	ImportURL(ctx context.Context, u responses.ExportURL) (bool, error)
+	// AddClicks - добавление переходов по ссылкам: ключ - id ссылки,
+	// значение - число переходов.
+	AddClicks(ctx context.Context, clicks map[string]int64) error
+	// AddDestinationClicks - добавление переходов по адресам распределения:
+	// ключи - id ссылки и адрес, значение - число переходов.
+	AddDestinationClicks(ctx context.Context, clicks map[string]map[string]int64) error
+	// GetDestinationClicks - переходы по адресам распределения ссылки.
+	GetDestinationClicks(ctx context.Context, shortURL string) (map[string]int64, error)
+	// GetPreview - сведения о ссылке для страницы предпросмотра, в том
+	// числе удаленной.
+	GetPreview(ctx context.Context, shortURL string) (responses.LinkPreview, error)
+	// GetRedirect - адрес назначения и настройки перехода по ссылке.
+	// Ошибки такие же, как у GetURL.
+	GetRedirect(ctx context.Context, shortURL string) (string, redirect.Settings, error)
+	// UpdateRedirect - замена настроек перехода по ссылке, которую
+	// пользователь может изменять.
+	UpdateRedirect(ctx context.Context, shortURL string, settings redirect.Settings, user string) error
+	// GetAccess - ограничение доступа к ссылке.
+	GetAccess(ctx context.Context, shortURL string) (access.Access, error)
+	// UpdateAccess - замена ограничения доступа к ссылке, которую
+	// пользователь может изменять. Счетчик переходов начинается заново.
+	UpdateAccess(ctx context.Context, shortURL string, a access.Access, user string) error
+	// UseLink - атомарный учет перехода по ссылке с ограничением
+	// количества переходов. false - разрешенные переходы закончились.
+	UseLink(ctx context.Context, shortURL string) (bool, error)
+	// GetLinkStates - владельцы, адреса назначения, сохраненные переходы и
+	// признаки удаления ссылок. Отсутствующих ссылок в результате нет.
+	GetLinkStates(ctx context.Context, shortURLs []string) (map[string]responses.LinkState, error)
 }
 
-func NewURLService(repo UserRepositoryInterface, baseURL string, wp *workers.WorkerPool, subnet *net.IPNet) *URLService {
+// CacheFlusher - репозиторий, который держит данные в памяти и умеет
+// сбрасывать их.
+type CacheFlusher interface {
+	FlushCache(ctx context.Context) error
+}
+
+// errDraining - ошибка при попытке создать ссылку в режиме drain.
+var errDraining = custom_errors.NewCustomError(errors.New("service is draining"), http.StatusServiceUnavailable)
+
+// errRevisionNotFound - в истории изменений ссылки нет такого номера.
+var errRevisionNotFound = custom_errors.NewCustomError(errors.New("revision not found"), http.StatusNotFound)
+
+// ErrLinkBlocked - ссылка отключена, так как адрес назначения запрещен
+// политикой. Репозиторий возвращает ее вместе с оригинальным URL.
+var ErrLinkBlocked = custom_errors.NewCustomError(errors.New("link is disabled by policy"), http.StatusForbidden)
+
+func NewURLService(repo UserRepositoryInterface, dom *domains.Domains, wp *workers.WorkerPool, subnet *net.IPNet,
+	norm *normalizer.Normalizer, pol *policy.Engine, retention time.Duration, fetcher titles.Fetcher, geo geoip.Resolver,
+	events EventPublisher) *URLService {
+	if norm == nil {
+		norm = normalizer.New(normalizer.DefaultMaxLength, false)
+	}
 	return &URLService{
-		repo:    repo,
-		baseURL: baseURL,
-		wp:      wp,
-		subnet:  subnet,
+		repo:              repo,
+		domains:           dom,
+		wp:                wp,
+		subnet:            subnet,
+		normalizer:        norm,
+		policy:            pol,
+		retention:         retention,
+		titles:            fetcher,
+		geo:               geo,
+		events:            events,
+		clicks:            map[string]int64{},
+		destinationClicks: map[string]map[string]int64{},
 	}
 }
 
 type URLService struct {
-	repo    UserRepositoryInterface
-	baseURL string
-	wp      *workers.WorkerPool
-	subnet  *net.IPNet
+	repo UserRepositoryInterface
+	// domains - домены ссылок, по ним собираются короткие адреса.
+	domains    *domains.Domains
+	wp         *workers.WorkerPool
+	subnet     *net.IPNet
+	normalizer *normalizer.Normalizer
+	policy     *policy.Engine
+	// retention - срок, в течение которого удаленные ссылки можно
+	// восстановить, 0 - бессрочно.
+	retention time.Duration
+	// titles - получение заголовков страниц для ссылок без заголовка, nil -
+	// заголовки не заполняются.
+	titles titles.Fetcher
+	// geo - определение страны посетителя для правил перехода, nil -
+	// страна не определяется.
+	geo geoip.Resolver
+	// events - отправка событий о ссылках вебхукам, nil - события не
+	// отправляются.
+	events EventPublisher
+	// draining - признак режима drain, 1 если новые ссылки не принимаются.
+	draining int32
+	// clicksMu - защита clicks.
+	clicksMu sync.Mutex
+	// clicks - переходы по ссылкам, еще не сохраненные в репозитории.
+	clicks map[string]int64
+	// destinationClicks - переходы по адресам распределения, еще не
+	// сохраненные в репозитории.
+	destinationClicks map[string]map[string]int64
 }
 
 func (us *URLService) GetURL(ctx context.Context, userID string) (string, error) {
 	return us.repo.GetURL(ctx, userID)
 }
 
-func (us *URLService) CreateURL(ctx context.Context, longURL string, user string) (string, error) {
-	shortURL := shortener.ShorterURL(longURL)
-	err := us.repo.AddURL(ctx, longURL, shortURL, user)
-	return us.baseURL + shortURL, err
+// CreateURL - создание ссылки в домене domain с необязательными
+// заголовком, описанием и тегами meta. Если заголовок не задан, он
+// заполняется в фоне по странице назначения. Неизвестный домен - ошибка с
+// кодом 400.
+func (us *URLService) CreateURL(ctx context.Context, longURL string, meta responses.LinkMeta, domain string, user string) (string, error) {
+	if us.Draining() {
+		return "", errDraining
+	}
+	domain, err := us.resolveDomain(domain)
+	if err != nil {
+		return "", err
+	}
+	longURL, err = us.normalizer.Normalize(longURL)
+	if err != nil {
+		return "", custom_errors.NewCustomError(err, http.StatusBadRequest)
+	}
+	if err := us.checkPolicy(ctx, longURL); err != nil {
+		return "", err
+	}
+	meta, err = cleanMeta(meta)
+	if err != nil {
+		return "", err
+	}
+	shortURL := domains.Key(domain, shortener.ShorterURL(longURL))
+	err = us.repo.AddURL(ctx, longURL, shortURL, user, meta)
+	if err == nil {
+		us.fetchTitle(shortURL, longURL, meta)
+		us.publish(user, webhooks.EventLinkCreated, us.eventLink(shortURL, longURL))
+	}
+	return us.domains.ShortURL(shortURL), err
 }
 
-func (us *URLService) GetUserURL(ctx context.Context, userID string) ([]responses.GetURL, error) {
-	return us.repo.GetUserURL(ctx, userID)
+// resolveDomain - домен ссылок по имени, которое выбрал пользователь.
+// Неизвестный домен - ошибка с кодом 400.
+func (us *URLService) resolveDomain(name string) (string, error) {
+	domain, err := us.domains.Resolve(name)
+	if err != nil {
+		return "", custom_errors.NewCustomError(err, http.StatusBadRequest)
+	}
+	return domain, nil
+}
+
+// link - ссылка из репозитория с id ссылки в ее домене, хостом домена и
+// коротким адресом.
+func (us *URLService) link(u responses.GetURL) responses.GetURL {
+	u.ShortURL = us.domains.ShortURL(u.ID)
+	domain, id := domains.Split(u.ID)
+	u.ID = id
+	u.Domain = us.domains.Name(domain)
+	return u
+}
+
+// GetUserURL - страница ссылок пользователя. Из репозитория запрашивается
+// на одну ссылку больше, чтобы узнать, есть ли следующая страница.
+func (us *URLService) GetUserURL(ctx context.Context, userID string, query listing.Query) (responses.UserURLs, error) {
+	fetch := query
+	if query.Limit > 0 {
+		fetch.Limit = query.Limit + 1
+	}
+	urls, err := us.repo.GetUserURL(ctx, userID, fetch)
+	if err != nil {
+		return responses.UserURLs{URLs: urls}, err
+	}
+	result := responses.UserURLs{URLs: urls}
+	if query.Limit > 0 && len(urls) > query.Limit {
+		result.URLs = urls[:query.Limit]
+		// Курсор строится по id репозитория, до замены id ссылок.
+		result.NextCursor = listing.CursorOf(result.URLs[query.Limit-1]).String()
+	}
+	for i := range result.URLs {
+		result.URLs[i] = us.link(result.URLs[i])
+	}
+	return result, nil
 }
 
 func (us *URLService) PingDB(ctx context.Context) error {
@@ -53,7 +255,58 @@ func (us *URLService) PingDB(ctx context.Context) error {
 }
 
 func (us *URLService) CreateBatch(ctx context.Context, urls []responses.ManyPostURL, userID string) ([]responses.ManyPostResponse, error) {
-	return us.repo.AddManyURL(ctx, urls, userID)
+	if us.Draining() {
+		return nil, errDraining
+	}
+	normalized := make([]responses.ManyPostURL, 0, len(urls))
+	for _, u := range urls {
+		u, err := us.prepareBatchURL(ctx, u)
+		if err != nil {
+			wrapped := fmt.Errorf("correlation_id %s: %w", u.CorrelationID, err)
+			return nil, custom_errors.NewCustomError(wrapped, custom_errors.ParseError(err))
+		}
+		normalized = append(normalized, u)
+	}
+	if err := us.repo.AddManyURL(ctx, normalized, userID); err != nil {
+		return nil, err
+	}
+	result := make([]responses.ManyPostResponse, 0, len(normalized))
+	for _, u := range normalized {
+		us.fetchTitle(u.ID, u.OriginalURL, u.LinkMeta)
+		us.publishCreated(userID, u)
+		result = append(result, responses.ManyPostResponse{
+			CorrelationID: u.CorrelationID,
+			ShortURL:      us.domains.ShortURL(u.ID),
+		})
+	}
+	return result, nil
+}
+
+// prepareBatchURL - нормализация, проверка политикой и очистка заголовка,
+// описания и тегов ссылки из пакета, выбор ее домена и id. Некорректная
+// ссылка или неизвестный домен - ошибка с кодом 400, запрещенная политикой -
+// 403.
+func (us *URLService) prepareBatchURL(ctx context.Context, u responses.ManyPostURL) (responses.ManyPostURL, error) {
+	domain, err := us.resolveDomain(u.Domain)
+	if err != nil {
+		return u, err
+	}
+	longURL, err := us.normalizer.Normalize(u.OriginalURL)
+	if err != nil {
+		return u, custom_errors.NewCustomError(err, http.StatusBadRequest)
+	}
+	if err := us.checkPolicy(ctx, longURL); err != nil {
+		return u, err
+	}
+	meta, err := cleanMeta(u.LinkMeta)
+	if err != nil {
+		return u, err
+	}
+	u.OriginalURL = longURL
+	u.LinkMeta = meta
+	u.Domain = domain
+	u.ID = domains.Key(domain, shortener.ShorterURL(longURL))
+	return u, nil
 }
 
 func (us *URLService) DeleteBatch(urls []string, userID string) {
@@ -68,13 +321,115 @@ func (us *URLService) DeleteBatch(urls []string, userID string) {
 	for _, item := range sliceData {
 		func(taskData []string) {
 			us.wp.Push(func(ctx context.Context) error {
-				err := us.repo.DeleteManyURL(ctx, taskData, userID)
-				return err
+				return us.deleteURLs(ctx, taskData, userID)
 			})
 		}(item)
 	}
 }
 
+// RestoreURLs - восстановление удаленных ссылок, если срок хранения
+// удаленных ссылок еще не истек.
+func (us *URLService) RestoreURLs(ctx context.Context, urls []string, userID string) (responses.RestoreURLsResponse, error) {
+	var deletedSince time.Time
+	if us.retention > 0 {
+		deletedSince = time.Now().Add(-us.retention)
+	}
+	restored, err := us.repo.RestoreURLs(ctx, urls, userID, deletedSince)
+	if err != nil {
+		return responses.RestoreURLsResponse{}, err
+	}
+	return responses.RestoreURLsResponse{Restored: restored}, nil
+}
+
+// PurgeURLs - окончательное удаление ссылок по запросу администратора,
+// например для удаления персональных данных.
+func (us *URLService) PurgeURLs(ctx context.Context, urls []string) (int, error) {
+	return us.repo.PurgeURLs(ctx, urls)
+}
+
+// WatchRetention - раз в interval ставит в WorkerPool задачу окончательного
+// удаления ссылок, срок хранения которых после удаления истек. Работает до
+// завершения контекста, при нулевом сроке хранения ничего не делает. После
+// остановки WorkerPool задачи не ставятся.
+func (us *URLService) WatchRetention(ctx context.Context, interval time.Duration) {
+	if us.retention <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			us.wp.TryPush(ctx, us.purgeExpired)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// purgeExpired - задача окончательного удаления ссылок с истекшим сроком
+// хранения.
+func (us *URLService) purgeExpired(ctx context.Context) error {
+	purged, err := us.repo.PurgeDeleted(ctx, time.Now().Add(-us.retention))
+	if err != nil {
+		return err
+	}
+	if purged > 0 {
+		log.Printf("Purged %v deleted links\n", purged)
+	}
+	return nil
+}
+
+// UpdateURL - замена адреса назначения ссылки с id shortURL. Новый адрес
+// нормализуется и проверяется политикой, как при создании ссылки. Ссылка
+// остается отключенной, если политикой запрещены адреса ее правил перехода
+// или распределения.
+func (us *URLService) UpdateURL(ctx context.Context, shortURL string, longURL string, userID string) (responses.GetURL, error) {
+	longURL, err := us.normalizer.Normalize(longURL)
+	if err != nil {
+		return responses.GetURL{}, custom_errors.NewCustomError(err, http.StatusBadRequest)
+	}
+	if err := us.checkPolicy(ctx, longURL); err != nil {
+		return responses.GetURL{}, err
+	}
+	if err := us.repo.UpdateURL(ctx, shortURL, longURL, userID); err != nil {
+		return responses.GetURL{}, err
+	}
+	// Ссылка могла быть отключена политикой из-за прежнего адреса.
+	_, settings, err := us.repo.GetRedirect(ctx, shortURL)
+	if err != nil && !errors.Is(err, ErrLinkBlocked) {
+		return responses.GetURL{}, err
+	}
+	blocked := us.policy != nil && us.blockedByPolicy(longURL, settings)
+	if err := us.repo.SetBlocked(ctx, []string{shortURL}, blocked); err != nil {
+		return responses.GetURL{}, err
+	}
+	return us.link(responses.GetURL{
+		ID:          shortURL,
+		OriginalURL: longURL,
+	}), nil
+}
+
+// GetRevisions - история изменений адреса назначения ссылки с id shortURL.
+func (us *URLService) GetRevisions(ctx context.Context, shortURL string, userID string) ([]responses.URLRevision, error) {
+	return us.repo.GetRevisions(ctx, shortURL, userID)
+}
+
+// RestoreRevision - возврат адреса назначения ссылки из истории изменений.
+// Текущий адрес при этом тоже сохраняется в истории.
+func (us *URLService) RestoreRevision(ctx context.Context, shortURL string, revision int, userID string) (responses.GetURL, error) {
+	revisions, err := us.repo.GetRevisions(ctx, shortURL, userID)
+	if err != nil {
+		return responses.GetURL{}, err
+	}
+	for _, r := range revisions {
+		if r.Revision == revision {
+			return us.UpdateURL(ctx, shortURL, r.OriginalURL, userID)
+		}
+	}
+	return responses.GetURL{}, errRevisionNotFound
+}
+
 func (us *URLService) GetStats(ctx context.Context, ip net.IP) (bool, responses.StatResponse, error) {
 	if us.subnet == nil || !us.subnet.Contains(ip) {
 		return false, responses.StatResponse{}, nil
@@ -82,3 +437,122 @@ func (us *URLService) GetStats(ctx context.Context, ip net.IP) (bool, responses.
 	response, err := us.repo.GetStats(ctx)
 	return true, response, err
 }
+
+// Health - проверка готовности сервиса: не включен режим drain, пул воркеров
+// запущен и репозиторий доступен.
+func (us *URLService) Health(ctx context.Context) error {
+	if us.Draining() {
+		return errors.New("service is draining")
+	}
+	if !us.wp.Running() {
+		return errors.New("worker pool is not running")
+	}
+	return us.repo.Ping(ctx)
+}
+
+// SetDraining - включение и выключение режима drain.
+func (us *URLService) SetDraining(draining bool) {
+	var value int32
+	if draining {
+		value = 1
+	}
+	atomic.StoreInt32(&us.draining, value)
+}
+
+// Draining - включен ли режим drain.
+func (us *URLService) Draining() bool {
+	return atomic.LoadInt32(&us.draining) == 1
+}
+
+// FlushCache - сброс данных репозитория в памяти. Возвращает false, если
+// репозиторий не держит данные в памяти.
+func (us *URLService) FlushCache(ctx context.Context) (bool, error) {
+	flusher, ok := us.repo.(CacheFlusher)
+	if !ok {
+		return false, nil
+	}
+	return true, flusher.FlushCache(ctx)
+}
+
+// ResizeWorkers - изменение количества воркеров в пуле.
+func (us *URLService) ResizeWorkers(numOfWorkers int) error {
+	return us.wp.Resize(numOfWorkers)
+}
+
+// AdminStats - расширенная статистика сервиса для администратора.
+func (us *URLService) AdminStats(ctx context.Context) (responses.AdminStatResponse, error) {
+	stats, err := us.repo.GetStats(ctx)
+	if err != nil {
+		return responses.AdminStatResponse{}, err
+	}
+	numOfWorkers, queued := us.wp.Stats()
+	return responses.AdminStatResponse{
+		StatResponse: stats,
+		Workers:      numOfWorkers,
+		QueuedTasks:  queued,
+		Draining:     us.Draining(),
+	}, nil
+}
+
+// checkPolicy - проверка адреса назначения политикой. Запрещенный адрес
+// возвращает ошибку с кодом 403.
+func (us *URLService) checkPolicy(ctx context.Context, longURL string) error {
+	if us.policy == nil {
+		return nil
+	}
+	err := us.policy.Check(ctx, longURL)
+	if errors.Is(err, policy.ErrBlocked) {
+		return custom_errors.NewCustomError(err, http.StatusForbidden)
+	}
+	if err != nil {
+		return custom_errors.NewCustomError(err, http.StatusBadRequest)
+	}
+	return nil
+}
+
+// ApplyPolicy - ставит в очередь WorkerPool проверку всех сохраненных ссылок
+// текущими правилами политики: ссылки, у которых запрещен адрес назначения,
+// адрес правила перехода или адрес распределения, отключаются, а ссылки,
+// которые правилам больше не соответствуют, включаются обратно.
+func (us *URLService) ApplyPolicy() {
+	if us.policy == nil {
+		return
+	}
+	us.wp.Push(func(ctx context.Context) error {
+		var blocked, allowed []string
+		err := us.repo.ForEachURL(ctx, func(shortURL string, longURL string, settings redirect.Settings) error {
+			if us.blockedByPolicy(longURL, settings) {
+				blocked = append(blocked, shortURL)
+			} else {
+				allowed = append(allowed, shortURL)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if err := us.repo.SetBlocked(ctx, allowed, false); err != nil {
+			return err
+		}
+		return us.repo.SetBlocked(ctx, blocked, true)
+	})
+}
+
+// blockedByPolicy - адрес назначения ссылки longURL или один из адресов
+// правил перехода и распределения ее настроек перехода запрещен политикой.
+func (us *URLService) blockedByPolicy(longURL string, settings redirect.Settings) bool {
+	if errors.Is(us.policy.Match(longURL), policy.ErrBlocked) {
+		return true
+	}
+	for _, r := range settings.Rules {
+		if errors.Is(us.policy.Match(r.URL), policy.ErrBlocked) {
+			return true
+		}
+	}
+	for _, d := range settings.Destinations {
+		if errors.Is(us.policy.Match(d.URL), policy.ErrBlocked) {
+			return true
+		}
+	}
+	return false
+}
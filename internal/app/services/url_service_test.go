@@ -0,0 +1,65 @@
+package services_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/p7chkn/go-musthave-shortener-tpl/cmd/shortener/configuration"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/app/responses"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/app/services"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/domains"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/filebase"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/policy"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/redirect"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdateURLKeepsPolicyBlock(t *testing.T) {
+	tests := []struct {
+		name        string
+		settings    redirect.Settings
+		wantBlocked bool
+	}{
+		{name: "no settings"},
+		{
+			name:        "blocked rule target",
+			settings:    redirect.Settings{Rules: []redirect.Rule{{URL: "https://blocked.com/de", Countries: []string{"DE"}}}},
+			wantBlocked: true,
+		},
+		{
+			name: "blocked split destination",
+			settings: redirect.Settings{Destinations: []redirect.Destination{
+				{URL: "https://example.com/a"},
+				{URL: "https://blocked.com/b"},
+			}},
+			wantBlocked: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := filebase.NewRepositoryMap(ctx, filepath.Join(t.TempDir(), "urls.jsonl"))
+			dom, err := domains.New(configuration.BaseURL, nil)
+			require.NoError(t, err)
+			pol, err := policy.New(policy.Rules{DenyDomains: []string{"blocked.com"}}, false, "", nil)
+			require.NoError(t, err)
+			service := services.NewURLService(repo, dom, nil, nil, nil, pol, 0, nil, nil, nil)
+
+			require.NoError(t, repo.AddURL(ctx, "https://blocked.com/", "abc", "user-1", responses.LinkMeta{}))
+			require.NoError(t, repo.UpdateRedirect(ctx, "abc", tt.settings, "user-1"))
+			require.NoError(t, repo.SetBlocked(ctx, []string{"abc"}, true))
+
+			_, err = service.UpdateURL(ctx, "abc", "https://example.com/", "user-1")
+			require.NoError(t, err)
+			url, err := repo.GetURL(ctx, "abc")
+			assert.Equal(t, "https://example.com/", url)
+			if tt.wantBlocked {
+				assert.ErrorIs(t, err, services.ErrLinkBlocked)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package services_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/p7chkn/go-musthave-shortener-tpl/cmd/shortener/configuration"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/app/services"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/domains"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/filebase"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/workers"
+	"github.com/stretchr/testify/require"
+)
+
+// stoppedPoolService - сервис с остановленным WorkerPool, очередь которого
+// заполнена.
+func stoppedPoolService(t *testing.T, retention time.Duration) *services.URLService {
+	ctx, cancel := context.WithCancel(context.Background())
+	wp := workers.New(ctx, 1, 1)
+	stopped := make(chan struct{})
+	go func() {
+		wp.Run(ctx)
+		close(stopped)
+	}()
+	require.Eventually(t, wp.Running, time.Second, time.Millisecond)
+	cancel()
+	<-stopped
+	wp.Push(func(ctx context.Context) error { return nil })
+
+	repo := filebase.NewRepositoryMap(context.Background(), filepath.Join(t.TempDir(), "urls.jsonl"))
+	dom, err := domains.New(configuration.BaseURL, nil)
+	require.NoError(t, err)
+	return services.NewURLService(repo, dom, wp, nil, nil, nil, retention, nil, nil, nil)
+}
+
+// requireReturns - watch завершается после отмены контекста, хотя его
+// задачи некуда поставить.
+func requireReturns(t *testing.T, watch func(ctx context.Context, interval time.Duration)) {
+	ctx, cancel := context.WithCancel(context.Background())
+	returned := make(chan struct{})
+	go func() {
+		watch(ctx, time.Millisecond)
+		close(returned)
+	}()
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+	select {
+	case <-returned:
+	case <-time.After(time.Second):
+		t.Fatal("watcher did not return after cancel")
+	}
+}
+
+func TestWatchClicksAfterPoolStop(t *testing.T) {
+	requireReturns(t, stoppedPoolService(t, 0).WatchClicks)
+}
+
+func TestWatchRetentionAfterPoolStop(t *testing.T) {
+	requireReturns(t, stoppedPoolService(t, time.Hour).WatchRetention)
+}
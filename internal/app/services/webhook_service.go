@@ -0,0 +1,120 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/app/responses"
+	custom_errors "github.com/p7chkn/go-musthave-shortener-tpl/internal/errors"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/webhooks"
+)
+
+// WebhookRepositoryInterface - хранилище вебхуков и журнала доставок.
+// Отсутствующий или чужой вебхук возвращается ошибкой с кодом 404.
+type WebhookRepositoryInterface interface {
+	CreateWebhook(ctx context.Context, hook webhooks.Webhook) error
+	ListWebhooks(ctx context.Context, userID string) ([]webhooks.Webhook, error)
+	GetWebhook(ctx context.Context, id string, userID string) (webhooks.Webhook, error)
+	DeleteWebhook(ctx context.Context, id string, userID string) error
+	AddDelivery(ctx context.Context, delivery webhooks.Delivery) error
+	ListDeliveries(ctx context.Context, webhookID string, limit int) ([]webhooks.Delivery, error)
+}
+
+// errTooManyWebhooks - у пользователя уже webhooks.MaxPerUser вебхуков.
+var errTooManyWebhooks = custom_errors.NewCustomError(
+	fmt.Errorf("no more than %d webhooks per user", webhooks.MaxPerUser), http.StatusConflict)
+
+func NewWebhookService(repo WebhookRepositoryInterface, dispatcher *webhooks.Dispatcher) *WebhookService {
+	return &WebhookService{
+		repo:       repo,
+		dispatcher: dispatcher,
+	}
+}
+
+// WebhookService - вебхуки пользователей и журнал их доставок.
+type WebhookService struct {
+	repo       WebhookRepositoryInterface
+	dispatcher *webhooks.Dispatcher
+}
+
+// CreateWebhook - регистрация вебхука пользователя userID. Ключ подписи
+// возвращается только в ответе на создание.
+func (ws *WebhookService) CreateWebhook(ctx context.Context, userID string, req responses.CreateWebhook) (responses.WebhookResponse, error) {
+	hook, err := webhooks.NewWebhook(userID, req.URL, req.Events, req.ClickThreshold)
+	if err != nil {
+		if errors.Is(err, webhooks.ErrInvalidURL) || errors.Is(err, webhooks.ErrUnknownEvent) ||
+			errors.Is(err, webhooks.ErrInvalidThreshold) {
+			return responses.WebhookResponse{}, custom_errors.NewCustomError(err, http.StatusBadRequest)
+		}
+		return responses.WebhookResponse{}, err
+	}
+	existing, err := ws.repo.ListWebhooks(ctx, userID)
+	if err != nil {
+		return responses.WebhookResponse{}, err
+	}
+	if len(existing) >= webhooks.MaxPerUser {
+		return responses.WebhookResponse{}, errTooManyWebhooks
+	}
+	if err := ws.repo.CreateWebhook(ctx, hook); err != nil {
+		return responses.WebhookResponse{}, err
+	}
+	response := webhookResponse(hook)
+	response.Secret = hook.Secret
+	return response, nil
+}
+
+// ListWebhooks - вебхуки пользователя userID без ключей подписи.
+func (ws *WebhookService) ListWebhooks(ctx context.Context, userID string) ([]responses.WebhookResponse, error) {
+	hooks, err := ws.repo.ListWebhooks(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]responses.WebhookResponse, 0, len(hooks))
+	for _, hook := range hooks {
+		result = append(result, webhookResponse(hook))
+	}
+	return result, nil
+}
+
+// DeleteWebhook - удаление вебхука пользователя userID. Неотправленные
+// повторы доставок удаленного вебхука отменяются.
+func (ws *WebhookService) DeleteWebhook(ctx context.Context, userID string, id string) error {
+	return ws.repo.DeleteWebhook(ctx, id, userID)
+}
+
+// ListDeliveries - последние доставки вебхука пользователя userID,
+// начиная с новых.
+func (ws *WebhookService) ListDeliveries(ctx context.Context, userID string, id string) ([]webhooks.Delivery, error) {
+	if _, err := ws.repo.GetWebhook(ctx, id, userID); err != nil {
+		return nil, err
+	}
+	return ws.repo.ListDeliveries(ctx, id, webhooks.MaxDeliveries)
+}
+
+// TestWebhook - отправка проверочного события webhook.test на адрес
+// вебхука пользователя userID. Доставка выполняется сразу, без повторов, и
+// записывается в журнал; неудачная доставка не ошибка, ее результат
+// возвращается в Delivery.
+func (ws *WebhookService) TestWebhook(ctx context.Context, userID string, id string) (webhooks.Delivery, error) {
+	hook, err := ws.repo.GetWebhook(ctx, id, userID)
+	if err != nil {
+		return webhooks.Delivery{}, err
+	}
+	return ws.dispatcher.Deliver(ctx, hook, webhooks.NewEvent(webhooks.EventTest, nil), 1)
+}
+
+func webhookResponse(hook webhooks.Webhook) responses.WebhookResponse {
+	events := hook.Events
+	if events == nil {
+		events = []string{}
+	}
+	return responses.WebhookResponse{
+		ID:             hook.ID,
+		URL:            hook.URL,
+		Events:         events,
+		ClickThreshold: hook.ClickThreshold,
+		CreatedAt:      hook.CreatedAt,
+	}
+}
@@ -0,0 +1,233 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/app/responses"
+	custom_errors "github.com/p7chkn/go-musthave-shortener-tpl/internal/errors"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/workspaces"
+)
+
+// WorkspaceRepositoryInterface - хранилище рабочих пространств и их
+// участников. Отсутствующие записи возвращаются ошибкой с кодом 404.
+type WorkspaceRepositoryInterface interface {
+	CreateWorkspace(ctx context.Context, workspace workspaces.Workspace, ownerID string) error
+	ListWorkspaces(ctx context.Context, userID string) ([]workspaces.Membership, error)
+	// GetRole - роль пользователя в рабочем пространстве, пустая, если он
+	// не участник.
+	GetRole(ctx context.Context, workspaceID string, userID string) (workspaces.Role, error)
+	ListMembers(ctx context.Context, workspaceID string) ([]workspaces.Member, error)
+	SetMember(ctx context.Context, member workspaces.Member) error
+	RemoveMember(ctx context.Context, workspaceID string, userID string) error
+	// AddURLsToWorkspace - перенос в рабочее пространство ссылок, которые
+	// пользователь может изменять: своих личных и ссылок рабочих
+	// пространств, где он owner или editor.
+	AddURLsToWorkspace(ctx context.Context, workspaceID string, shortURLs []string, userID string) (int, error)
+}
+
+var (
+	// errWorkspaceNotFound - рабочего пространства нет или пользователь не
+	// его участник.
+	errWorkspaceNotFound = custom_errors.NewCustomError(errors.New("workspace not found"), http.StatusNotFound)
+	// errForbiddenRole - у роли пользователя нет прав на действие.
+	errForbiddenRole = custom_errors.NewCustomError(errors.New("insufficient workspace role"), http.StatusForbidden)
+	// errLastOwner - у рабочего пространства не остается владельцев.
+	errLastOwner = custom_errors.NewCustomError(errors.New("workspace must keep at least one owner"), http.StatusConflict)
+)
+
+func NewWorkspaceService(repo WorkspaceRepositoryInterface, accounts AccountRepositoryInterface) *WorkspaceService {
+	return &WorkspaceService{
+		repo:     repo,
+		accounts: accounts,
+	}
+}
+
+// WorkspaceService - рабочие пространства, их участники и роли. Работать с
+// рабочими пространствами могут только учетные записи.
+type WorkspaceService struct {
+	repo     WorkspaceRepositoryInterface
+	accounts AccountRepositoryInterface
+}
+
+// Create - создание рабочего пространства, userID становится его владельцем.
+func (ws *WorkspaceService) Create(ctx context.Context, userID string, name string) (responses.WorkspaceResponse, error) {
+	if err := ws.requireAccount(ctx, userID); err != nil {
+		return responses.WorkspaceResponse{}, err
+	}
+	workspace, err := workspaces.NewWorkspace(name)
+	if err != nil {
+		return responses.WorkspaceResponse{}, custom_errors.NewCustomError(err, http.StatusBadRequest)
+	}
+	if err := ws.repo.CreateWorkspace(ctx, workspace, userID); err != nil {
+		return responses.WorkspaceResponse{}, err
+	}
+	return workspaceResponse(workspaces.Membership{Workspace: workspace, Role: workspaces.RoleOwner}), nil
+}
+
+// List - рабочие пространства, в которых участвует userID.
+func (ws *WorkspaceService) List(ctx context.Context, userID string) ([]responses.WorkspaceResponse, error) {
+	if err := ws.requireAccount(ctx, userID); err != nil {
+		return nil, err
+	}
+	memberships, err := ws.repo.ListWorkspaces(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]responses.WorkspaceResponse, 0, len(memberships))
+	for _, membership := range memberships {
+		result = append(result, workspaceResponse(membership))
+	}
+	return result, nil
+}
+
+// Members - участники рабочего пространства, доступно любому участнику.
+func (ws *WorkspaceService) Members(ctx context.Context, workspaceID string, userID string) ([]responses.MemberResponse, error) {
+	if _, err := ws.authorize(ctx, workspaceID, userID, workspaces.RoleViewer); err != nil {
+		return nil, err
+	}
+	members, err := ws.repo.ListMembers(ctx, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]responses.MemberResponse, 0, len(members))
+	for _, member := range members {
+		result = append(result, memberResponse(member))
+	}
+	return result, nil
+}
+
+// SetMember - добавление учетной записи с логином request.Login в рабочее
+// пространство или изменение ее роли, доступно владельцу.
+func (ws *WorkspaceService) SetMember(ctx context.Context, workspaceID string, userID string,
+	request responses.SetMember) (responses.MemberResponse, error) {
+	if _, err := ws.authorize(ctx, workspaceID, userID, workspaces.RoleOwner); err != nil {
+		return responses.MemberResponse{}, err
+	}
+	role, err := workspaces.ParseRole(request.Role)
+	if err != nil {
+		return responses.MemberResponse{}, custom_errors.NewCustomError(err, http.StatusBadRequest)
+	}
+	account, err := ws.accounts.GetAccountByLogin(ctx, request.Login)
+	if err != nil {
+		return responses.MemberResponse{}, err
+	}
+	if role != workspaces.RoleOwner {
+		if err := ws.keepOwner(ctx, workspaceID, account.ID); err != nil {
+			return responses.MemberResponse{}, err
+		}
+	}
+	member := workspaces.Member{
+		WorkspaceID: workspaceID,
+		UserID:      account.ID,
+		Login:       account.Login,
+		Role:        role,
+	}
+	if err := ws.repo.SetMember(ctx, member); err != nil {
+		return responses.MemberResponse{}, err
+	}
+	return memberResponse(member), nil
+}
+
+// RemoveMember - удаление участника memberID из рабочего пространства.
+// Доступно владельцу, а также самому участнику, чтобы выйти из него.
+func (ws *WorkspaceService) RemoveMember(ctx context.Context, workspaceID string, userID string, memberID string) error {
+	minRole := workspaces.RoleOwner
+	if memberID == userID {
+		minRole = workspaces.RoleViewer
+	}
+	if _, err := ws.authorize(ctx, workspaceID, userID, minRole); err != nil {
+		return err
+	}
+	if err := ws.keepOwner(ctx, workspaceID, memberID); err != nil {
+		return err
+	}
+	return ws.repo.RemoveMember(ctx, workspaceID, memberID)
+}
+
+// AddURLs - перенос ссылок в рабочее пространство, доступно владельцу и
+// редактору. Переносятся только ссылки, которые userID может изменять.
+func (ws *WorkspaceService) AddURLs(ctx context.Context, workspaceID string, userID string,
+	shortURLs []string) (responses.WorkspaceURLsResponse, error) {
+	if _, err := ws.authorize(ctx, workspaceID, userID, workspaces.RoleEditor); err != nil {
+		return responses.WorkspaceURLsResponse{}, err
+	}
+	moved, err := ws.repo.AddURLsToWorkspace(ctx, workspaceID, shortURLs, userID)
+	return responses.WorkspaceURLsResponse{Moved: moved}, err
+}
+
+// authorize - проверка, что у userID в рабочем пространстве есть роль не
+// ниже minRole. Не участнику рабочее пространство не раскрывается.
+func (ws *WorkspaceService) authorize(ctx context.Context, workspaceID string, userID string,
+	minRole workspaces.Role) (workspaces.Role, error) {
+	if err := ws.requireAccount(ctx, userID); err != nil {
+		return "", err
+	}
+	role, err := ws.repo.GetRole(ctx, workspaceID, userID)
+	if custom_errors.ParseError(err) == http.StatusNotFound {
+		return "", errWorkspaceNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	if role == "" {
+		return "", errWorkspaceNotFound
+	}
+	if !role.AtLeast(minRole) {
+		return role, errForbiddenRole
+	}
+	return role, nil
+}
+
+// keepOwner - ошибка, если userID единственный владелец рабочего
+// пространства и перестает им быть.
+func (ws *WorkspaceService) keepOwner(ctx context.Context, workspaceID string, userID string) error {
+	members, err := ws.repo.ListMembers(ctx, workspaceID)
+	if err != nil {
+		return err
+	}
+	isOwner, owners := false, 0
+	for _, member := range members {
+		if member.Role == workspaces.RoleOwner {
+			owners++
+			isOwner = isOwner || member.UserID == userID
+		}
+	}
+	if isOwner && owners == 1 {
+		return errLastOwner
+	}
+	return nil
+}
+
+// requireAccount - ошибка с кодом 401, если userID не учетная запись.
+func (ws *WorkspaceService) requireAccount(ctx context.Context, userID string) error {
+	if userID == "" {
+		return errUnauthorized
+	}
+	isAccount, err := ws.accounts.AccountExists(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !isAccount {
+		return errUnauthorized
+	}
+	return nil
+}
+
+func workspaceResponse(membership workspaces.Membership) responses.WorkspaceResponse {
+	return responses.WorkspaceResponse{
+		ID:        membership.ID,
+		Name:      membership.Name,
+		Role:      string(membership.Role),
+		CreatedAt: membership.CreatedAt,
+	}
+}
+
+func memberResponse(member workspaces.Member) responses.MemberResponse {
+	return responses.MemberResponse{
+		UserID: member.UserID,
+		Login:  member.Login,
+		Role:   string(member.Role),
+	}
+}
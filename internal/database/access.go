@@ -0,0 +1,56 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/access"
+)
+
+// GetAccess - ограничение доступа к ссылке.
+func (db *PostgresDataBase) GetAccess(ctx context.Context, shortURL string) (access.Access, error) {
+	sqlGetAccess := `SELECT password_hash, max_clicks, uses FROM urls WHERE short_url=$1;`
+	var a access.Access
+	err := db.conn.QueryRowContext(ctx, sqlGetAccess, shortURL).Scan(&a.PasswordHash, &a.MaxClicks, &a.Uses)
+	if errors.Is(err, sql.ErrNoRows) {
+		return access.Access{}, nil
+	}
+	return a, err
+}
+
+// UpdateAccess - замена ограничения доступа к ссылке со сбросом счетчика
+// переходов.
+func (db *PostgresDataBase) UpdateAccess(ctx context.Context, shortURL string, a access.Access, user string) error {
+	sqlUpdateAccess := `UPDATE urls SET password_hash=$4, max_clicks=$5, uses=0
+						WHERE short_url=$1 AND is_deleted=false AND ` + sqlEditableURL + `;`
+	result, err := db.conn.ExecContext(ctx, sqlUpdateAccess, shortURL, user, editRoles, a.PasswordHash, a.MaxClicks)
+	if err != nil {
+		return err
+	}
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if updated == 0 {
+		return errURLNotFound
+	}
+	return nil
+}
+
+// UseLink - учет перехода по ссылке с ограничением количества переходов
+// одним условным UPDATE, поэтому лишних переходов не бывает и при
+// одновременных запросах к нескольким экземплярам сервиса.
+func (db *PostgresDataBase) UseLink(ctx context.Context, shortURL string) (bool, error) {
+	sqlUseLink := `UPDATE urls SET uses = uses + 1
+				   WHERE short_url=$1 AND (max_clicks = 0 OR uses < max_clicks);`
+	result, err := db.conn.ExecContext(ctx, sqlUseLink, shortURL)
+	if err != nil {
+		return false, err
+	}
+	used, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return used > 0, nil
+}
@@ -0,0 +1,86 @@
+package database_test
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gofrs/uuid"
+	_ "github.com/lib/pq"
+	"github.com/p7chkn/go-musthave-shortener-tpl/cmd/shortener/setup"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/access"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/app/responses"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestDatabase - репозиторий в базе данных из переменной окружения
+// DATABASE_DSN. Без нее тест пропускается.
+func newTestDatabase(t *testing.T) *database.PostgresDataBase {
+	dsn := os.Getenv("DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("DATABASE_DSN is not set")
+	}
+	db, err := sql.Open("postgres", dsn)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	require.NoError(t, setup.SetUpDataBase(db, context.Background()))
+	return database.NewDatabase(db)
+}
+
+// addLimitedLink - ссылка с ограничением maxClicks переходов, которая
+// окончательно удаляется после теста.
+func addLimitedLink(t *testing.T, repo *database.PostgresDataBase, maxClicks int64) string {
+	ctx := context.Background()
+	user := uuid.Must(uuid.NewV4()).String()
+	shortURL := uuid.Must(uuid.NewV4()).String()
+	require.NoError(t, repo.AddURL(ctx, "https://example.com/", shortURL, user, responses.LinkMeta{}))
+	t.Cleanup(func() { repo.PurgeURLs(context.Background(), []string{shortURL}) })
+	require.NoError(t, repo.UpdateAccess(ctx, shortURL, access.Access{MaxClicks: maxClicks}, user))
+	return shortURL
+}
+
+func TestUseLinkConcurrent(t *testing.T) {
+	const maxClicks = 5
+	ctx := context.Background()
+	repo := newTestDatabase(t)
+	shortURL := addLimitedLink(t, repo, maxClicks)
+
+	var used int64
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ok, err := repo.UseLink(ctx, shortURL)
+			assert.NoError(t, err)
+			if ok {
+				atomic.AddInt64(&used, 1)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int64(maxClicks), used)
+
+	a, err := repo.GetAccess(ctx, shortURL)
+	require.NoError(t, err)
+	assert.Equal(t, int64(maxClicks), a.Uses)
+	assert.True(t, a.Exhausted())
+}
+
+func TestUseLinkExhausted(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestDatabase(t)
+	shortURL := addLimitedLink(t, repo, 1)
+
+	ok, err := repo.UseLink(ctx, shortURL)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = repo.UseLink(ctx, shortURL)
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
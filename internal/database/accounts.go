@@ -0,0 +1,113 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/jackc/pgerrcode"
+	"github.com/lib/pq"
+
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/accounts"
+	custom_errors "github.com/p7chkn/go-musthave-shortener-tpl/internal/errors"
+)
+
+// CreateAccount - добавление учетной записи.
+func (db *PostgresDataBase) CreateAccount(ctx context.Context, account accounts.Account) error {
+	sqlAddAccount := `INSERT INTO accounts (id, login, password_hash, created_at)
+					  VALUES ($1, $2, $3, $4)`
+	_, err := db.conn.ExecContext(ctx, sqlAddAccount, account.ID, account.Login, account.PasswordHash, account.CreatedAt)
+
+	if err, ok := err.(*pq.Error); ok {
+		if err.Code == pgerrcode.UniqueViolation {
+			return custom_errors.NewCustomError(errors.New("login already taken"), http.StatusConflict)
+		}
+	}
+	return err
+}
+
+// GetAccountByLogin - получение учетной записи по логину.
+func (db *PostgresDataBase) GetAccountByLogin(ctx context.Context, login string) (accounts.Account, error) {
+	sqlGetAccount := `SELECT id, login, password_hash, created_at FROM accounts WHERE login=$1;`
+	account := accounts.Account{}
+	err := db.conn.QueryRowContext(ctx, sqlGetAccount, login).
+		Scan(&account.ID, &account.Login, &account.PasswordHash, &account.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return account, custom_errors.NewCustomError(errors.New("account not found"), http.StatusNotFound)
+	}
+	return account, err
+}
+
+// AccountExists - есть ли учетная запись с таким идентификатором.
+func (db *PostgresDataBase) AccountExists(ctx context.Context, userID string) (bool, error) {
+	sqlAccountExists := `SELECT EXISTS (SELECT 1 FROM accounts WHERE id::text=$1);`
+	var exists bool
+	err := db.conn.QueryRowContext(ctx, sqlAccountExists, userID).Scan(&exists)
+	return exists, err
+}
+
+// AddAPIKey - добавление ключа API.
+func (db *PostgresDataBase) AddAPIKey(ctx context.Context, key accounts.APIKey) error {
+	sqlAddKey := `INSERT INTO api_keys (id, user_id, name, prefix, key_hash, created_at)
+				  VALUES ($1, $2, $3, $4, $5, $6)`
+	_, err := db.conn.ExecContext(ctx, sqlAddKey, key.ID, key.UserID, key.Name, key.Prefix, key.Hash, key.CreatedAt)
+	return err
+}
+
+// GetAPIKey - получение ключа API по хешу.
+func (db *PostgresDataBase) GetAPIKey(ctx context.Context, hash string) (accounts.APIKey, error) {
+	sqlGetKey := `SELECT id, user_id, name, prefix, key_hash, created_at FROM api_keys WHERE key_hash=$1;`
+	key := accounts.APIKey{}
+	err := db.conn.QueryRowContext(ctx, sqlGetKey, hash).
+		Scan(&key.ID, &key.UserID, &key.Name, &key.Prefix, &key.Hash, &key.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return key, custom_errors.NewCustomError(errors.New("api key not found"), http.StatusNotFound)
+	}
+	return key, err
+}
+
+// ListAPIKeys - ключи API учетной записи в порядке создания.
+func (db *PostgresDataBase) ListAPIKeys(ctx context.Context, userID string) ([]accounts.APIKey, error) {
+	sqlListKeys := `SELECT id, user_id, name, prefix, key_hash, created_at FROM api_keys
+					WHERE user_id=$1 ORDER BY created_at;`
+	rows, err := db.conn.QueryContext(ctx, sqlListKeys, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []accounts.APIKey
+	for rows.Next() {
+		key := accounts.APIKey{}
+		if err := rows.Scan(&key.ID, &key.UserID, &key.Name, &key.Prefix, &key.Hash, &key.CreatedAt); err != nil {
+			return nil, err
+		}
+		result = append(result, key)
+	}
+	return result, rows.Err()
+}
+
+// DeleteAPIKey - отзыв ключа API учетной записи.
+func (db *PostgresDataBase) DeleteAPIKey(ctx context.Context, userID string, keyID string) error {
+	sqlDeleteKey := `DELETE FROM api_keys WHERE id::text=$1 AND user_id=$2;`
+	res, err := db.conn.ExecContext(ctx, sqlDeleteKey, keyID, userID)
+	if err != nil {
+		return err
+	}
+	if count, err := res.RowsAffected(); err == nil && count == 0 {
+		return custom_errors.NewCustomError(errors.New("api key not found"), http.StatusNotFound)
+	}
+	return err
+}
+
+// ClaimURLs - передача всех ссылок пользователя fromUser пользователю toUser.
+func (db *PostgresDataBase) ClaimURLs(ctx context.Context, fromUser string, toUser string) (int, error) {
+	sqlClaim := `UPDATE urls SET user_id=$2 WHERE user_id::text=$1;`
+	res, err := db.conn.ExecContext(ctx, sqlClaim, fromUser, toUser)
+	if err != nil {
+		return 0, err
+	}
+	count, err := res.RowsAffected()
+	return int(count), err
+}
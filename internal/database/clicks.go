@@ -0,0 +1,116 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/app/responses"
+)
+
+// AddClicks - добавление переходов по ссылкам одним запросом.
+func (db *PostgresDataBase) AddClicks(ctx context.Context, clicks map[string]int64) error {
+	if len(clicks) == 0 {
+		return nil
+	}
+	shortURLs := make([]string, 0, len(clicks))
+	counts := make([]int64, 0, len(clicks))
+	for shortURL, count := range clicks {
+		shortURLs = append(shortURLs, shortURL)
+		counts = append(counts, count)
+	}
+	sqlAddClicks := `UPDATE urls SET clicks = urls.clicks + c.n
+					 FROM unnest($1::text[], $2::bigint[]) AS c(short_url, n)
+					 WHERE urls.short_url = c.short_url;`
+	_, err := db.conn.ExecContext(ctx, sqlAddClicks, pq.Array(shortURLs), pq.Array(counts))
+	return err
+}
+
+// AddDestinationClicks - добавление переходов по адресам распределения
+// одним запросом. Переходы по отсутствующим ссылкам не сохраняются.
+func (db *PostgresDataBase) AddDestinationClicks(ctx context.Context, clicks map[string]map[string]int64) error {
+	var shortURLs, destinations []string
+	var counts []int64
+	for shortURL, byDestination := range clicks {
+		for destination, count := range byDestination {
+			shortURLs = append(shortURLs, shortURL)
+			destinations = append(destinations, destination)
+			counts = append(counts, count)
+		}
+	}
+	if len(counts) == 0 {
+		return nil
+	}
+	sqlAddDestinationClicks := `INSERT INTO destination_clicks (short_url, url, clicks)
+								SELECT c.short_url, c.url, c.n
+								FROM unnest($1::text[], $2::text[], $3::bigint[]) AS c(short_url, url, n)
+								JOIN urls ON urls.short_url = c.short_url
+								ON CONFLICT (short_url, url) DO UPDATE SET clicks = destination_clicks.clicks + EXCLUDED.clicks;`
+	_, err := db.conn.ExecContext(ctx, sqlAddDestinationClicks, pq.Array(shortURLs), pq.Array(destinations), pq.Array(counts))
+	return err
+}
+
+// GetDestinationClicks - переходы по адресам распределения ссылки.
+func (db *PostgresDataBase) GetDestinationClicks(ctx context.Context, shortURL string) (map[string]int64, error) {
+	sqlGetDestinationClicks := `SELECT url, clicks FROM destination_clicks WHERE short_url=$1;`
+	rows, err := db.conn.QueryContext(ctx, sqlGetDestinationClicks, shortURL)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	result := map[string]int64{}
+	for rows.Next() {
+		var destination string
+		var count int64
+		if err := rows.Scan(&destination, &count); err != nil {
+			return nil, err
+		}
+		result[destination] = count
+	}
+	return result, rows.Err()
+}
+
+// GetPreview - сведения о ссылке для страницы предпросмотра, в том числе
+// удаленной. Отсутствующая ссылка - ошибка с кодом 404.
+func (db *PostgresDataBase) GetPreview(ctx context.Context, shortURL string) (responses.LinkPreview, error) {
+	sqlGetPreview := `SELECT origin_url, created_at, title, description, tags, clicks
+					  FROM urls WHERE short_url=$1;`
+	var result responses.LinkPreview
+	var createdAt time.Time
+	err := db.conn.QueryRowContext(ctx, sqlGetPreview, shortURL).Scan(&result.OriginalURL, &createdAt,
+		&result.Title, &result.Description, pq.Array(&result.Tags), &result.Clicks)
+	if errors.Is(err, sql.ErrNoRows) {
+		return result, errURLNotFound
+	}
+	if err != nil {
+		return result, err
+	}
+	result.ID = shortURL
+	result.CreatedAt = &createdAt
+	return result, nil
+}
+
+// GetLinkStates - владельцы, адреса назначения, переходы и признаки
+// удаления ссылок. Отсутствующих ссылок в результате нет.
+func (db *PostgresDataBase) GetLinkStates(ctx context.Context, shortURLs []string) (map[string]responses.LinkState, error) {
+	sqlGetStates := `SELECT short_url, user_id, origin_url, clicks, is_deleted FROM urls WHERE short_url = ANY ($1);`
+	rows, err := db.conn.QueryContext(ctx, sqlGetStates, pq.Array(shortURLs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := map[string]responses.LinkState{}
+	for rows.Next() {
+		var shortURL string
+		state := responses.LinkState{}
+		if err := rows.Scan(&shortURL, &state.UserID, &state.OriginalURL, &state.Clicks, &state.Deleted); err != nil {
+			return nil, err
+		}
+		result[shortURL] = state
+	}
+	return result, rows.Err()
+}
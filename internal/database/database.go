@@ -9,36 +9,38 @@ import (
 	"github.com/p7chkn/go-musthave-shortener-tpl/internal/app/responses"
 	"github.com/p7chkn/go-musthave-shortener-tpl/internal/app/services"
 	custom_errors "github.com/p7chkn/go-musthave-shortener-tpl/internal/errors"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/listing"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/redirect"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/jackc/pgerrcode"
 	"github.com/lib/pq"
-
-	"github.com/p7chkn/go-musthave-shortener-tpl/internal/shortener"
 )
 
 // GetURLData - структура для возвращения данных о URL.
 type GetURLData struct {
 	OriginURL string
 	IsDeleted bool
+	IsBlocked bool
 }
 
 // PostgresDataBase - структура для взаимодейтсивя с базой данных.
 type PostgresDataBase struct {
-	conn    *sql.DB
-	baseURL string
+	conn *sql.DB
 }
 
 // NewDatabaseRepository - создание нового интерфейства для репозитория.
-func NewDatabaseRepository(baseURL string, db *sql.DB) services.UserRepositoryInterface {
-	return services.UserRepositoryInterface(NewDatabase(baseURL, db))
+func NewDatabaseRepository(db *sql.DB) services.UserRepositoryInterface {
+	return services.UserRepositoryInterface(NewDatabase(db))
 }
 
 // NewDatabase - создание новой структуры взаимодействия с базой данных.
-func NewDatabase(baseURL string, db *sql.DB) *PostgresDataBase {
+func NewDatabase(db *sql.DB) *PostgresDataBase {
 	result := &PostgresDataBase{
-		conn:    db,
-		baseURL: baseURL,
+		conn: db,
 	}
 	return result
 }
@@ -55,12 +57,12 @@ func (db *PostgresDataBase) Ping(ctx context.Context) error {
 }
 
 // AddURL - добавление записи о новой сокращенной URL.
-func (db *PostgresDataBase) AddURL(ctx context.Context, longURL string, shortURL string, user string) error {
+func (db *PostgresDataBase) AddURL(ctx context.Context, longURL string, shortURL string, user string, meta responses.LinkMeta) error {
 
-	sqlAddRow := `INSERT INTO urls (user_id, origin_url, short_url)
-				  VALUES ($1, $2, $3)`
+	sqlAddRow := `INSERT INTO urls (user_id, origin_url, short_url, title, description, tags)
+				  VALUES ($1, $2, $3, $4, $5, $6)`
 
-	_, err := db.conn.ExecContext(ctx, sqlAddRow, user, longURL, shortURL)
+	_, err := db.conn.ExecContext(ctx, sqlAddRow, user, longURL, shortURL, meta.Title, meta.Description, tags(meta.Tags))
 
 	if err, ok := err.(*pq.Error); ok {
 		if err.Code == pgerrcode.UniqueViolation {
@@ -74,10 +76,10 @@ func (db *PostgresDataBase) AddURL(ctx context.Context, longURL string, shortURL
 // GetURL - получение данных о изначальном URL по сокращенному URL.
 func (db *PostgresDataBase) GetURL(ctx context.Context, shortURL string) (string, error) {
 
-	sqlGetURLRow := `SELECT origin_url, is_deleted FROM urls WHERE short_url=$1 FETCH FIRST ROW ONLY;`
+	sqlGetURLRow := `SELECT origin_url, is_deleted, is_blocked FROM urls WHERE short_url=$1 FETCH FIRST ROW ONLY;`
 	query := db.conn.QueryRowContext(ctx, sqlGetURLRow, shortURL)
 	result := GetURLData{}
-	if err := query.Scan(&result.OriginURL, &result.IsDeleted); err != nil {
+	if err := query.Scan(&result.OriginURL, &result.IsDeleted, &result.IsBlocked); err != nil {
 		return "", nil
 	}
 	if result.OriginURL == "" {
@@ -86,16 +88,21 @@ func (db *PostgresDataBase) GetURL(ctx context.Context, shortURL string) (string
 	if result.IsDeleted {
 		return "", custom_errors.NewCustomError(errors.New("deleted"), http.StatusGone)
 	}
+	if result.IsBlocked {
+		return result.OriginURL, services.ErrLinkBlocked
+	}
 	return result.OriginURL, nil
 }
 
-// GetUserURL - получение всех URL пользователя.
-func (db *PostgresDataBase) GetUserURL(ctx context.Context, user string) ([]responses.GetURL, error) {
+// GetUserURL - получение URL пользователя: личных и ссылок рабочих
+// пространств, в которых он участвует с любой ролью. Фильтры, курсор и
+// сортировка query применяются в запросе.
+func (db *PostgresDataBase) GetUserURL(ctx context.Context, user string, query listing.Query) ([]responses.GetURL, error) {
 
 	var result []responses.GetURL
 
-	sqlGetUserURL := `SELECT origin_url, short_url FROM urls WHERE user_id=$1 AND is_deleted=false;`
-	rows, err := db.conn.QueryContext(ctx, sqlGetUserURL, user)
+	sqlGetUserURL, args := userURLQuery(user, query)
+	rows, err := db.conn.QueryContext(ctx, sqlGetUserURL, args...)
 	if err != nil {
 		return result, err
 	}
@@ -106,11 +113,12 @@ func (db *PostgresDataBase) GetUserURL(ctx context.Context, user string) ([]resp
 
 	for rows.Next() {
 		var u responses.GetURL
-		err = rows.Scan(&u.OriginalURL, &u.ShortURL)
+		var createdAt time.Time
+		err = rows.Scan(&u.OriginalURL, &u.ID, &u.WorkspaceID, &createdAt, &u.Title, &u.Description, pq.Array(&u.Tags))
 		if err != nil {
 			return result, err
 		}
-		u.ShortURL = db.baseURL + u.ShortURL
+		u.CreatedAt = &createdAt
 		result = append(result, u)
 	}
 	if len(result) == 0 {
@@ -119,55 +127,97 @@ func (db *PostgresDataBase) GetUserURL(ctx context.Context, user string) ([]resp
 	return result, nil
 }
 
-// AddManyURL - добавление многих URL сразу.
-func (db *PostgresDataBase) AddManyURL(ctx context.Context, urls []responses.ManyPostURL, user string) ([]responses.ManyPostResponse, error) {
+// sqlURLHost - хост оригинального URL в нижнем регистре.
+const sqlURLHost = `lower(substring(origin_url from '^[^:/?#]+://(?:[^@/?#]*@)?([^/:?#]+)'))`
+
+// userURLQuery - запрос ссылок пользователя с условиями из query и его
+// параметры.
+func userURLQuery(user string, query listing.Query) (string, []interface{}) {
+	args := []interface{}{user}
+	arg := func(value interface{}) string {
+		args = append(args, value)
+		return "$" + strconv.Itoa(len(args))
+	}
+	sqlGetUserURL := `SELECT origin_url, short_url, COALESCE(workspace_id::text, ''), created_at, title, description, tags FROM urls
+			WHERE is_deleted=false AND ((user_id=$1 AND workspace_id IS NULL) OR workspace_id IN
+			(SELECT workspace_id FROM workspace_members WHERE user_id=$1))`
+	if !query.CreatedFrom.IsZero() {
+		sqlGetUserURL += ` AND created_at >= ` + arg(query.CreatedFrom)
+	}
+	if !query.CreatedTo.IsZero() {
+		sqlGetUserURL += ` AND created_at < ` + arg(query.CreatedTo)
+	}
+	if query.Domain != "" {
+		domain := arg(query.Domain)
+		sqlGetUserURL += ` AND (` + sqlURLHost + ` = ` + domain + ` OR ` + sqlURLHost + ` LIKE '%.' || ` +
+			arg(escapeLike(query.Domain)) + `)`
+	}
+	if query.Tag != "" {
+		sqlGetUserURL += ` AND ` + arg(query.Tag) + ` = ANY (tags)`
+	}
+	if query.Search != "" {
+		search := arg("%" + escapeLike(query.Search) + "%")
+		sqlGetUserURL += ` AND (origin_url ILIKE ` + search + ` OR short_url ILIKE ` + search +
+			` OR title ILIKE ` + search + ` OR description ILIKE ` + search + `)`
+	}
+	order := "DESC"
+	compare := "<"
+	if query.Ascending {
+		order = "ASC"
+		compare = ">"
+	}
+	if query.After != nil {
+		sqlGetUserURL += ` AND (created_at, short_url) ` + compare + ` (` + arg(query.After.CreatedAt) + `, ` + arg(query.After.ID) + `)`
+	}
+	sqlGetUserURL += ` ORDER BY created_at ` + order + `, short_url ` + order
+	if query.Limit > 0 {
+		sqlGetUserURL += ` LIMIT ` + arg(query.Limit)
+	}
+	return sqlGetUserURL + `;`, args
+}
+
+// escapeLike - экранирование спецсимволов шаблона LIKE.
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+}
+
+// AddManyURL - добавление многих URL сразу с их id.
+func (db *PostgresDataBase) AddManyURL(ctx context.Context, urls []responses.ManyPostURL, user string) error {
 
-	var result []responses.ManyPostResponse
 	tx, err := db.conn.Begin()
 
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	defer tx.Rollback()
 
-	stmt, err := tx.PrepareContext(ctx, `INSERT INTO urls (user_id, origin_url, short_url) VALUES ($1, $2, $3)`)
+	stmt, err := tx.PrepareContext(ctx, `INSERT INTO urls (user_id, origin_url, short_url, title, description, tags)
+										 VALUES ($1, $2, $3, $4, $5, $6)`)
 
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	defer stmt.Close()
 
 	for _, u := range urls {
-		shortURL := shortener.ShorterURL(u.OriginalURL)
-		if _, err = stmt.ExecContext(ctx, user, u.OriginalURL, shortURL); err != nil {
-			return nil, err
+		if _, err = stmt.ExecContext(ctx, user, u.OriginalURL, u.ID, u.Title, u.Description, tags(u.Tags)); err != nil {
+			return err
 		}
-		result = append(result, responses.ManyPostResponse{
-			CorrelationID: u.CorrelationID,
-			ShortURL:      db.baseURL + shortURL,
-		})
 	}
 
-	if err != nil {
-		return nil, err
-	}
-	err = tx.Commit()
-	return result, err
+	return tx.Commit()
 }
 
-// DeleteManyURL - удаление многиз URL по id.
+// DeleteManyURL - удаление многих URL по id. Удаляются только ссылки,
+// которые пользователь может изменять: личные и ссылки рабочих пространств,
+// где он owner или editor.
 func (db *PostgresDataBase) DeleteManyURL(ctx context.Context, urls []string, user string) error {
 
-	sqlDeleteURL := `UPDATE urls SET is_deleted = true WHERE short_url = ANY ($1);`
-	var urlsToDelete []string
-	for _, url := range urls {
-		if db.isOwner(ctx, url, user) {
-			urlsToDelete = append(urlsToDelete, url)
-		}
-	}
-	_, err := db.conn.ExecContext(ctx, sqlDeleteURL, pq.Array(urlsToDelete))
+	sqlDeleteURL := `UPDATE urls SET is_deleted = true, deleted_at = now()
+					 WHERE short_url = ANY ($1) AND is_deleted = false AND ` + sqlEditableURL + `;`
+	_, err := db.conn.ExecContext(ctx, sqlDeleteURL, pq.Array(urls), user, editRoles)
 	if err != nil {
 		return err
 	}
@@ -184,12 +234,30 @@ func (db *PostgresDataBase) GetStats(ctx context.Context) (responses.StatRespons
 
 }
 
-// isOwner - вспомогательная функция, которая определняет владелец ли переданный
-// пользователь, указанной записи сокращенного URL.
-func (db *PostgresDataBase) isOwner(ctx context.Context, url string, user string) bool {
-	sqlGetURLRow := `SELECT user_id FROM urls WHERE short_url=$1 FETCH FIRST ROW ONLY;`
-	query := db.conn.QueryRowContext(ctx, sqlGetURLRow, url)
-	result := ""
-	query.Scan(&result)
-	return result == user
+// ForEachURL - обход всех сохраненных URL с их настройками перехода.
+func (db *PostgresDataBase) ForEachURL(ctx context.Context, fn func(shortURL string, longURL string, settings redirect.Settings) error) error {
+	rows, err := db.conn.QueryContext(ctx, `SELECT short_url, origin_url, redirect FROM urls;`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var shortURL, longURL string
+		var settings redirect.Settings
+		if err := rows.Scan(&shortURL, &longURL, jsonColumn{&settings}); err != nil {
+			return err
+		}
+		if err := fn(shortURL, longURL, settings); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// SetBlocked - отключение или включение ссылок политикой.
+func (db *PostgresDataBase) SetBlocked(ctx context.Context, shortURLs []string, blocked bool) error {
+	sqlSetBlocked := `UPDATE urls SET is_blocked = $2 WHERE short_url = ANY ($1) AND is_blocked <> $2;`
+	_, err := db.conn.ExecContext(ctx, sqlSetBlocked, pq.Array(shortURLs), blocked)
+	return err
 }
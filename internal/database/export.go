@@ -0,0 +1,88 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/access"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/app/responses"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/redirect"
+)
+
+// ExportURLs - обход всех ссылок вместе с их владельцами в порядке создания,
+// удаленных - только если deleted. У ссылок, удаленных до появления
+// колонки deleted_at, время удаления нулевое.
+func (db *PostgresDataBase) ExportURLs(ctx context.Context, deleted bool, fn func(u responses.ExportURL) error) error {
+	sqlExportURLs := `SELECT short_url, origin_url, COALESCE(user_id::text, ''), COALESCE(workspace_id::text, ''),
+					  created_at, title, description, tags, is_deleted, deleted_at, redirect,
+					  password_hash, max_clicks, uses FROM urls
+					  WHERE is_deleted=false OR $1 ORDER BY created_at, short_url;`
+	rows, err := db.conn.QueryContext(ctx, sqlExportURLs, deleted)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var u responses.ExportURL
+		var isDeleted bool
+		var deletedAt sql.NullTime
+		var settings redirect.Settings
+		var a access.Access
+		u.CreatedAt = new(time.Time)
+		err := rows.Scan(&u.ID, &u.OriginalURL, &u.UserID, &u.WorkspaceID, u.CreatedAt,
+			&u.Title, &u.Description, pq.Array(&u.Tags), &isDeleted, &deletedAt, jsonColumn{&settings},
+			&a.PasswordHash, &a.MaxClicks, &a.Uses)
+		if err != nil {
+			return err
+		}
+		if !settings.IsZero() {
+			u.Redirect = &settings
+		}
+		if !a.IsZero() {
+			u.Access = &a
+		}
+		if isDeleted {
+			u.DeletedAt = &deletedAt.Time
+		}
+		if err := fn(u); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// ImportURL - сохранение ссылки из выгрузки вместе с настройками перехода
+// и ограничением доступа. Ссылка без времени создания получает текущее
+// время, ссылка без владельца сохраняется без него. Рабочее пространство
+// ссылки восстанавливается, если оно есть в базе, иначе ссылка остается
+// только у владельца: сами рабочие пространства не переносятся.
+func (db *PostgresDataBase) ImportURL(ctx context.Context, u responses.ExportURL) (bool, error) {
+	var settings redirect.Settings
+	if u.Redirect != nil {
+		settings = *u.Redirect
+	}
+	var a access.Access
+	if u.Access != nil {
+		a = *u.Access
+	}
+	sqlImportURL := `INSERT INTO urls (user_id, origin_url, short_url, title, description, tags,
+					 created_at, is_deleted, deleted_at, redirect, password_hash, max_clicks, uses, workspace_id)
+					 VALUES (NULLIF($1, '')::uuid, $2, $3, $4, $5, $6, COALESCE($7, now()), $8, $9, $10, $11, $12, $13,
+					 (SELECT id FROM workspaces WHERE id::text = NULLIF($14, '')))
+					 ON CONFLICT (short_url) DO NOTHING;`
+	res, err := db.conn.ExecContext(ctx, sqlImportURL, u.UserID, u.OriginalURL, u.ID,
+		u.Title, u.Description, tags(u.Tags), u.CreatedAt, u.DeletedAt != nil, u.DeletedAt, jsonColumn{settings},
+		a.PasswordHash, a.MaxClicks, a.Uses, u.WorkspaceID)
+	if err != nil {
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
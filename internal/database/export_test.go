@@ -0,0 +1,99 @@
+package database_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/accounts"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/app/responses"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/database"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/filebase"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/migration"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/workspaces"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// exportedURL - ссылка shortURL из выгрузки repo.
+func exportedURL(t *testing.T, repo *database.PostgresDataBase, shortURL string) responses.ExportURL {
+	var found *responses.ExportURL
+	require.NoError(t, repo.ExportURLs(context.Background(), true, func(u responses.ExportURL) error {
+		if u.ID == shortURL {
+			found = &u
+		}
+		return nil
+	}))
+	require.NotNil(t, found, "link %s is not exported", shortURL)
+	return *found
+}
+
+func TestImportURLWithoutOwner(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestDatabase(t)
+	source := filebase.NewRepositoryMap(ctx, filepath.Join(t.TempDir(), "urls.log"))
+	shortURL := uuid.Must(uuid.NewV4()).String()
+	imported, err := source.ImportURL(ctx, responses.ExportURL{
+		GetURL: responses.GetURL{ID: shortURL, OriginalURL: "https://example.com/"},
+	})
+	require.NoError(t, err)
+	require.True(t, imported)
+	t.Cleanup(func() { repo.PurgeURLs(context.Background(), []string{shortURL}) })
+
+	stats, err := migration.Copy(ctx, source, repo, nil)
+	require.NoError(t, err)
+	assert.Equal(t, migration.Stats{Read: 1, Copied: 1}, stats)
+
+	u := exportedURL(t, repo, shortURL)
+	assert.Equal(t, "", u.UserID)
+	assert.Equal(t, "https://example.com/", u.OriginalURL)
+
+	// Обратный перенос возвращает ту же ссылку.
+	target := filebase.NewRepositoryMap(ctx, filepath.Join(t.TempDir(), "urls.log"))
+	imported, err = target.ImportURL(ctx, u)
+	require.NoError(t, err)
+	require.True(t, imported)
+	_, _, err = migration.Verify(ctx, source, target)
+	require.NoError(t, err)
+}
+
+func TestImportURLWorkspace(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestDatabase(t)
+	owner := accounts.Account{
+		ID:        uuid.Must(uuid.NewV4()).String(),
+		Login:     uuid.Must(uuid.NewV4()).String(),
+		CreatedAt: time.Now(),
+	}
+	require.NoError(t, repo.CreateAccount(ctx, owner))
+	workspace := workspaces.Workspace{ID: uuid.Must(uuid.NewV4()).String(), Name: "team", CreatedAt: time.Now()}
+	require.NoError(t, repo.CreateWorkspace(ctx, workspace, owner.ID))
+
+	tests := []struct {
+		name        string
+		workspaceID string
+		want        string
+	}{
+		{name: "existing workspace", workspaceID: workspace.ID, want: workspace.ID},
+		{name: "unknown workspace", workspaceID: uuid.Must(uuid.NewV4()).String(), want: ""},
+		{name: "no workspace", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shortURL := uuid.Must(uuid.NewV4()).String()
+			t.Cleanup(func() { repo.PurgeURLs(context.Background(), []string{shortURL}) })
+			imported, err := repo.ImportURL(ctx, responses.ExportURL{
+				GetURL: responses.GetURL{ID: shortURL, OriginalURL: "https://example.com/", WorkspaceID: tt.workspaceID},
+				UserID: owner.ID,
+			})
+			require.NoError(t, err)
+			require.True(t, imported)
+
+			u := exportedURL(t, repo, shortURL)
+			assert.Equal(t, owner.ID, u.UserID)
+			assert.Equal(t, tt.want, u.WorkspaceID)
+		})
+	}
+}
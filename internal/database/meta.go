@@ -0,0 +1,43 @@
+package database
+
+import (
+	"context"
+
+	"github.com/lib/pq"
+
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/app/responses"
+)
+
+// UpdateMeta - замена заголовка, описания и тегов ссылки.
+func (db *PostgresDataBase) UpdateMeta(ctx context.Context, shortURL string, meta responses.LinkMeta, user string) error {
+	sqlUpdateMeta := `UPDATE urls SET title=$4, description=$5, tags=$6
+					  WHERE short_url=$1 AND is_deleted=false AND ` + sqlEditableURL + `;`
+	result, err := db.conn.ExecContext(ctx, sqlUpdateMeta, shortURL, user, editRoles,
+		meta.Title, meta.Description, tags(meta.Tags))
+	if err != nil {
+		return err
+	}
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if updated == 0 {
+		return errURLNotFound
+	}
+	return nil
+}
+
+// FillTitle - сохранение заголовка ссылки, если он еще не задан.
+func (db *PostgresDataBase) FillTitle(ctx context.Context, shortURL string, title string) error {
+	sqlFillTitle := `UPDATE urls SET title=$2 WHERE short_url=$1 AND title='';`
+	_, err := db.conn.ExecContext(ctx, sqlFillTitle, shortURL, title)
+	return err
+}
+
+// tags - теги ссылки для записи в колонку TEXT[], пустой список вместо nil.
+func tags(values []string) interface{} {
+	if values == nil {
+		values = []string{}
+	}
+	return pq.Array(values)
+}
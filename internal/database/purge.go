@@ -0,0 +1,63 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// RestoreURLs - восстановление удаленных ссылок, которые пользователь может
+// изменять и которые удалены не раньше deletedSince. Ссылки, удаленные до
+// появления deleted_at, не восстанавливаются.
+func (db *PostgresDataBase) RestoreURLs(ctx context.Context, urls []string, user string, deletedSince time.Time) (int, error) {
+	sqlRestoreURL := `UPDATE urls SET is_deleted = false, deleted_at = NULL
+					  WHERE short_url = ANY ($1) AND is_deleted = true AND deleted_at >= $4 AND ` + sqlEditableURL + `;`
+	result, err := db.conn.ExecContext(ctx, sqlRestoreURL, pq.Array(urls), user, editRoles, deletedSince)
+	if err != nil {
+		return 0, err
+	}
+	restored, err := result.RowsAffected()
+	return int(restored), err
+}
+
+// PurgeURLs - окончательное удаление ссылок вместе с историей изменений и
+// переходами по адресам распределения.
+func (db *PostgresDataBase) PurgeURLs(ctx context.Context, urls []string) (int, error) {
+	return db.purge(ctx, `short_url = ANY ($1)`, pq.Array(urls))
+}
+
+// PurgeDeleted - окончательное удаление ссылок, удаленных раньше
+// deletedBefore. Ссылки, удаленные до появления deleted_at, не удаляются:
+// время их удаления неизвестно.
+func (db *PostgresDataBase) PurgeDeleted(ctx context.Context, deletedBefore time.Time) (int, error) {
+	return db.purge(ctx, `is_deleted = true AND deleted_at < $1`, deletedBefore)
+}
+
+// purge - окончательное удаление ссылок, подходящих под условие where с
+// параметром $1, и всех связанных с ними строк.
+func (db *PostgresDataBase) purge(ctx context.Context, where string, arg interface{}) (int, error) {
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	sqlPurgeRevisions := `DELETE FROM url_revisions WHERE short_url IN (SELECT short_url FROM urls WHERE ` + where + `);`
+	if _, err := tx.ExecContext(ctx, sqlPurgeRevisions, arg); err != nil {
+		return 0, err
+	}
+	sqlPurgeDestinationClicks := `DELETE FROM destination_clicks WHERE short_url IN (SELECT short_url FROM urls WHERE ` + where + `);`
+	if _, err := tx.ExecContext(ctx, sqlPurgeDestinationClicks, arg); err != nil {
+		return 0, err
+	}
+	result, err := tx.ExecContext(ctx, `DELETE FROM urls WHERE `+where+`;`, arg)
+	if err != nil {
+		return 0, err
+	}
+	purged, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return int(purged), tx.Commit()
+}
@@ -0,0 +1,85 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/app/services"
+	custom_errors "github.com/p7chkn/go-musthave-shortener-tpl/internal/errors"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/redirect"
+)
+
+// GetRedirect - адрес назначения и настройки перехода по ссылке. Ошибки
+// такие же, как у GetURL.
+func (db *PostgresDataBase) GetRedirect(ctx context.Context, shortURL string) (string, redirect.Settings, error) {
+	sqlGetRedirect := `SELECT origin_url, is_deleted, is_blocked, redirect FROM urls WHERE short_url=$1;`
+	var result GetURLData
+	var settings redirect.Settings
+	err := db.conn.QueryRowContext(ctx, sqlGetRedirect, shortURL).
+		Scan(&result.OriginURL, &result.IsDeleted, &result.IsBlocked, jsonColumn{&settings})
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", settings, custom_errors.NewCustomError(errors.New("not found"), http.StatusNotFound)
+	}
+	if err != nil {
+		return "", settings, err
+	}
+	if result.IsDeleted {
+		return "", settings, custom_errors.NewCustomError(errors.New("deleted"), http.StatusGone)
+	}
+	if result.IsBlocked {
+		return result.OriginURL, settings, services.ErrLinkBlocked
+	}
+	return result.OriginURL, settings, nil
+}
+
+// UpdateRedirect - замена настроек перехода по ссылке.
+func (db *PostgresDataBase) UpdateRedirect(ctx context.Context, shortURL string, settings redirect.Settings, user string) error {
+	sqlUpdateRedirect := `UPDATE urls SET redirect=$4
+						  WHERE short_url=$1 AND is_deleted=false AND ` + sqlEditableURL + `;`
+	result, err := db.conn.ExecContext(ctx, sqlUpdateRedirect, shortURL, user, editRoles, jsonColumn{settings})
+	if err != nil {
+		return err
+	}
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if updated == 0 {
+		return errURLNotFound
+	}
+	return nil
+}
+
+// jsonColumn - значение колонки JSONB: при записи v кодируется в JSON, при
+// чтении v должен быть указателем.
+type jsonColumn struct {
+	v interface{}
+}
+
+// Value - значение для записи в колонку.
+func (c jsonColumn) Value() (driver.Value, error) {
+	data, err := json.Marshal(c.v)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// Scan - чтение значения колонки.
+func (c jsonColumn) Scan(src interface{}) error {
+	switch data := src.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return json.Unmarshal(data, c.v)
+	case string:
+		return json.Unmarshal([]byte(data), c.v)
+	default:
+		return fmt.Errorf("unsupported json column type %T", src)
+	}
+}
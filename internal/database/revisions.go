@@ -0,0 +1,80 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/app/responses"
+	custom_errors "github.com/p7chkn/go-musthave-shortener-tpl/internal/errors"
+)
+
+// errURLNotFound - ссылки нет, она удалена или пользователь не может ее
+// изменять.
+var errURLNotFound = custom_errors.NewCustomError(errors.New("url not found"), http.StatusNotFound)
+
+// UpdateURL - замена адреса назначения ссылки с сохранением прежнего адреса
+// в url_revisions.
+func (db *PostgresDataBase) UpdateURL(ctx context.Context, shortURL string, longURL string, user string) error {
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	sqlGetURL := `SELECT origin_url FROM urls WHERE short_url=$1 AND is_deleted=false AND ` +
+		sqlEditableURL + ` FOR UPDATE;`
+	var current string
+	err = tx.QueryRowContext(ctx, sqlGetURL, shortURL, user, editRoles).Scan(&current)
+	if errors.Is(err, sql.ErrNoRows) {
+		return errURLNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if current == longURL {
+		return nil
+	}
+	sqlAddRevision := `INSERT INTO url_revisions (short_url, revision, origin_url, changed_by)
+					   SELECT $1, COALESCE(MAX(revision), 0) + 1, $2, $3 FROM url_revisions WHERE short_url=$1;`
+	if _, err := tx.ExecContext(ctx, sqlAddRevision, shortURL, current, user); err != nil {
+		return err
+	}
+	sqlUpdateURL := `UPDATE urls SET origin_url=$2 WHERE short_url=$1;`
+	if _, err := tx.ExecContext(ctx, sqlUpdateURL, shortURL, longURL); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetRevisions - история изменений ссылки в порядке изменений.
+func (db *PostgresDataBase) GetRevisions(ctx context.Context, shortURL string, user string) ([]responses.URLRevision, error) {
+	sqlCanEdit := `SELECT EXISTS (SELECT 1 FROM urls WHERE short_url=$1 AND is_deleted=false AND ` +
+		sqlEditableURL + `);`
+	var canEdit bool
+	if err := db.conn.QueryRowContext(ctx, sqlCanEdit, shortURL, user, editRoles).Scan(&canEdit); err != nil {
+		return nil, err
+	}
+	if !canEdit {
+		return nil, errURLNotFound
+	}
+
+	sqlGetRevisions := `SELECT revision, origin_url, COALESCE(changed_by::text, ''), created_at FROM url_revisions
+						WHERE short_url=$1 ORDER BY revision;`
+	rows, err := db.conn.QueryContext(ctx, sqlGetRevisions, shortURL)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []responses.URLRevision
+	for rows.Next() {
+		var r responses.URLRevision
+		if err := rows.Scan(&r.Revision, &r.OriginalURL, &r.ChangedBy, &r.CreatedAt); err != nil {
+			return nil, err
+		}
+		result = append(result, r)
+	}
+	return result, rows.Err()
+}
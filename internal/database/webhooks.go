@@ -0,0 +1,127 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/lib/pq"
+
+	custom_errors "github.com/p7chkn/go-musthave-shortener-tpl/internal/errors"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/webhooks"
+)
+
+// errWebhookNotFound - вебхука нет или он принадлежит другому пользователю.
+var errWebhookNotFound = custom_errors.NewCustomError(errors.New("webhook not found"), http.StatusNotFound)
+
+// sqlWebhookColumns - колонки вебхука в порядке scanWebhook.
+const sqlWebhookColumns = `id, user_id, url, secret, events, click_threshold, created_at`
+
+// CreateWebhook - добавление вебхука.
+func (db *PostgresDataBase) CreateWebhook(ctx context.Context, hook webhooks.Webhook) error {
+	sqlAddWebhook := `INSERT INTO webhooks (` + sqlWebhookColumns + `)
+					  VALUES ($1, $2, $3, $4, $5, $6, $7)`
+	_, err := db.conn.ExecContext(ctx, sqlAddWebhook, hook.ID, hook.UserID, hook.URL, hook.Secret,
+		tags(hook.Events), hook.ClickThreshold, hook.CreatedAt)
+	return err
+}
+
+// ListWebhooks - вебхуки пользователя в порядке создания.
+func (db *PostgresDataBase) ListWebhooks(ctx context.Context, userID string) ([]webhooks.Webhook, error) {
+	sqlListWebhooks := `SELECT ` + sqlWebhookColumns + ` FROM webhooks
+						WHERE user_id::text=$1 ORDER BY created_at;`
+	rows, err := db.conn.QueryContext(ctx, sqlListWebhooks, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []webhooks.Webhook
+	for rows.Next() {
+		hook, err := scanWebhook(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, hook)
+	}
+	return result, rows.Err()
+}
+
+// GetWebhook - вебхук пользователя по id.
+func (db *PostgresDataBase) GetWebhook(ctx context.Context, id string, userID string) (webhooks.Webhook, error) {
+	sqlGetWebhook := `SELECT ` + sqlWebhookColumns + ` FROM webhooks WHERE id::text=$1 AND user_id::text=$2;`
+	hook, err := scanWebhook(db.conn.QueryRowContext(ctx, sqlGetWebhook, id, userID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return hook, errWebhookNotFound
+	}
+	return hook, err
+}
+
+// DeleteWebhook - удаление вебхука пользователя вместе с журналом доставок.
+func (db *PostgresDataBase) DeleteWebhook(ctx context.Context, id string, userID string) error {
+	sqlDeleteWebhook := `DELETE FROM webhooks WHERE id::text=$1 AND user_id::text=$2;`
+	res, err := db.conn.ExecContext(ctx, sqlDeleteWebhook, id, userID)
+	if err != nil {
+		return err
+	}
+	if count, err := res.RowsAffected(); err == nil && count == 0 {
+		return errWebhookNotFound
+	}
+	return err
+}
+
+// AddDelivery - запись в журнал доставок и удаление из него доставок
+// вебхука старше webhooks.MaxDeliveries последних. Доставки удаленного
+// вебхука не записываются.
+func (db *PostgresDataBase) AddDelivery(ctx context.Context, delivery webhooks.Delivery) error {
+	sqlAddDelivery := `INSERT INTO webhook_deliveries
+					   (id, webhook_id, event_id, event, attempt, success, status_code, error, created_at)
+					   SELECT $1::uuid, $2::uuid, $3::uuid, $4, $5, $6, $7, $8, $9
+					   WHERE EXISTS (SELECT 1 FROM webhooks WHERE id=$2::uuid);`
+	_, err := db.conn.ExecContext(ctx, sqlAddDelivery, delivery.ID, delivery.WebhookID, delivery.EventID,
+		delivery.Event, delivery.Attempt, delivery.Success, delivery.StatusCode, delivery.Error, delivery.CreatedAt)
+	if err != nil {
+		return err
+	}
+	sqlTrimDeliveries := `DELETE FROM webhook_deliveries WHERE webhook_id=$1 AND id NOT IN
+						  (SELECT id FROM webhook_deliveries WHERE webhook_id=$1 ORDER BY created_at DESC LIMIT $2);`
+	_, err = db.conn.ExecContext(ctx, sqlTrimDeliveries, delivery.WebhookID, webhooks.MaxDeliveries)
+	return err
+}
+
+// ListDeliveries - последние доставки вебхука, начиная с новых, не больше
+// limit.
+func (db *PostgresDataBase) ListDeliveries(ctx context.Context, webhookID string, limit int) ([]webhooks.Delivery, error) {
+	sqlListDeliveries := `SELECT id, webhook_id, event_id, event, attempt, success, status_code, error, created_at
+						  FROM webhook_deliveries WHERE webhook_id::text=$1 ORDER BY created_at DESC LIMIT $2;`
+	rows, err := db.conn.QueryContext(ctx, sqlListDeliveries, webhookID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []webhooks.Delivery{}
+	for rows.Next() {
+		d := webhooks.Delivery{}
+		if err := rows.Scan(&d.ID, &d.WebhookID, &d.EventID, &d.Event, &d.Attempt, &d.Success,
+			&d.StatusCode, &d.Error, &d.CreatedAt); err != nil {
+			return nil, err
+		}
+		result = append(result, d)
+	}
+	return result, rows.Err()
+}
+
+// rowScanner - строка результата запроса, *sql.Row или *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanWebhook - чтение вебхука из строки с колонками sqlWebhookColumns.
+func scanWebhook(row rowScanner) (webhooks.Webhook, error) {
+	hook := webhooks.Webhook{}
+	err := row.Scan(&hook.ID, &hook.UserID, &hook.URL, &hook.Secret, pq.Array(&hook.Events),
+		&hook.ClickThreshold, &hook.CreatedAt)
+	return hook, err
+}
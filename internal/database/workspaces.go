@@ -0,0 +1,132 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/lib/pq"
+
+	custom_errors "github.com/p7chkn/go-musthave-shortener-tpl/internal/errors"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/workspaces"
+)
+
+// sqlEditableURL - условие на ссылки, которые может изменять пользователь
+// $2: его личные ссылки и ссылки рабочих пространств, где его роль входит
+// в $3.
+const sqlEditableURL = `((user_id=$2 AND workspace_id IS NULL) OR workspace_id IN
+						(SELECT workspace_id FROM workspace_members WHERE user_id=$2 AND role = ANY ($3)))`
+
+// editRoles - роли, которые могут изменять ссылки рабочего пространства.
+var editRoles = pq.Array([]string{string(workspaces.RoleOwner), string(workspaces.RoleEditor)})
+
+// CreateWorkspace - добавление рабочего пространства с владельцем ownerID.
+func (db *PostgresDataBase) CreateWorkspace(ctx context.Context, workspace workspaces.Workspace, ownerID string) error {
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	sqlAddWorkspace := `INSERT INTO workspaces (id, name, created_at) VALUES ($1, $2, $3);`
+	if _, err := tx.ExecContext(ctx, sqlAddWorkspace, workspace.ID, workspace.Name, workspace.CreatedAt); err != nil {
+		return err
+	}
+	sqlAddOwner := `INSERT INTO workspace_members (workspace_id, user_id, role) VALUES ($1, $2, $3);`
+	if _, err := tx.ExecContext(ctx, sqlAddOwner, workspace.ID, ownerID, workspaces.RoleOwner); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ListWorkspaces - рабочие пространства пользователя в порядке создания.
+func (db *PostgresDataBase) ListWorkspaces(ctx context.Context, userID string) ([]workspaces.Membership, error) {
+	sqlListWorkspaces := `SELECT w.id, w.name, w.created_at, m.role FROM workspaces w
+						  JOIN workspace_members m ON m.workspace_id = w.id
+						  WHERE m.user_id=$1 ORDER BY w.created_at;`
+	rows, err := db.conn.QueryContext(ctx, sqlListWorkspaces, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []workspaces.Membership
+	for rows.Next() {
+		var membership workspaces.Membership
+		if err := rows.Scan(&membership.ID, &membership.Name, &membership.CreatedAt, &membership.Role); err != nil {
+			return nil, err
+		}
+		result = append(result, membership)
+	}
+	return result, rows.Err()
+}
+
+// GetRole - роль пользователя в рабочем пространстве.
+func (db *PostgresDataBase) GetRole(ctx context.Context, workspaceID string, userID string) (workspaces.Role, error) {
+	sqlGetRole := `SELECT COALESCE((SELECT role FROM workspace_members
+						WHERE workspace_id = w.id AND user_id::text=$2), '')
+				   FROM workspaces w WHERE w.id::text=$1;`
+	var role workspaces.Role
+	err := db.conn.QueryRowContext(ctx, sqlGetRole, workspaceID, userID).Scan(&role)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", custom_errors.NewCustomError(errors.New("workspace not found"), http.StatusNotFound)
+	}
+	return role, err
+}
+
+// ListMembers - участники рабочего пространства с логинами.
+func (db *PostgresDataBase) ListMembers(ctx context.Context, workspaceID string) ([]workspaces.Member, error) {
+	sqlListMembers := `SELECT m.workspace_id, m.user_id, a.login, m.role FROM workspace_members m
+					   JOIN accounts a ON a.id = m.user_id
+					   WHERE m.workspace_id::text=$1 ORDER BY a.login;`
+	rows, err := db.conn.QueryContext(ctx, sqlListMembers, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []workspaces.Member
+	for rows.Next() {
+		var member workspaces.Member
+		if err := rows.Scan(&member.WorkspaceID, &member.UserID, &member.Login, &member.Role); err != nil {
+			return nil, err
+		}
+		result = append(result, member)
+	}
+	return result, rows.Err()
+}
+
+// SetMember - добавление участника или изменение его роли.
+func (db *PostgresDataBase) SetMember(ctx context.Context, member workspaces.Member) error {
+	sqlSetMember := `INSERT INTO workspace_members (workspace_id, user_id, role) VALUES ($1, $2, $3)
+					 ON CONFLICT (workspace_id, user_id) DO UPDATE SET role = EXCLUDED.role;`
+	_, err := db.conn.ExecContext(ctx, sqlSetMember, member.WorkspaceID, member.UserID, member.Role)
+	return err
+}
+
+// RemoveMember - удаление участника рабочего пространства.
+func (db *PostgresDataBase) RemoveMember(ctx context.Context, workspaceID string, userID string) error {
+	sqlRemoveMember := `DELETE FROM workspace_members WHERE workspace_id::text=$1 AND user_id::text=$2;`
+	res, err := db.conn.ExecContext(ctx, sqlRemoveMember, workspaceID, userID)
+	if err != nil {
+		return err
+	}
+	if count, err := res.RowsAffected(); err == nil && count == 0 {
+		return custom_errors.NewCustomError(errors.New("member not found"), http.StatusNotFound)
+	}
+	return err
+}
+
+// AddURLsToWorkspace - перенос в рабочее пространство ссылок, которые
+// пользователь может изменять.
+func (db *PostgresDataBase) AddURLsToWorkspace(ctx context.Context, workspaceID string, shortURLs []string, userID string) (int, error) {
+	sqlMoveURLs := `UPDATE urls SET workspace_id=$4
+					WHERE short_url = ANY ($1) AND is_deleted=false AND ` + sqlEditableURL + `;`
+	res, err := db.conn.ExecContext(ctx, sqlMoveURLs, pq.Array(shortURLs), userID, editRoles, workspaceID)
+	if err != nil {
+		return 0, err
+	}
+	count, err := res.RowsAffected()
+	return int(count), err
+}
@@ -0,0 +1,172 @@
+// Package domains - пакет для доменов коротких ссылок. У каждого домена
+// свое пространство id ссылок: в репозитории ссылка дополнительного домена
+// хранится с id вида "<домен>/<id>", а ссылка домена по умолчанию - с id
+// без префикса, как до появления доменов.
+package domains
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// separator - разделитель домена и id ссылки в id репозитория. В id,
+// которые выдает сервис, его не бывает.
+const separator = "/"
+
+// ErrUnknownDomain - домен не входит в число доменов сервиса.
+var ErrUnknownDomain = errors.New("unknown domain")
+
+// ErrInvalidID - id ссылки содержит разделитель домена.
+var ErrInvalidID = errors.New("invalid link id")
+
+// Domains - домен по умолчанию и дополнительные домены коротких ссылок.
+type Domains struct {
+	// baseURL и host - адрес и хост домена по умолчанию.
+	baseURL string
+	host    string
+	// extra - адреса дополнительных доменов по их хостам.
+	extra map[string]string
+}
+
+// New - домены с адресом по умолчанию baseURL и дополнительными адресами
+// extra. Дополнительный адрес без схемы считается адресом https.
+func New(baseURL string, extra []string) (*Domains, error) {
+	baseURL, host, err := parse(baseURL)
+	if err != nil {
+		return nil, err
+	}
+	d := &Domains{baseURL: baseURL, host: host, extra: map[string]string{}}
+	for _, raw := range extra {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+		if !strings.Contains(raw, "://") {
+			raw = "https://" + raw
+		}
+		extraURL, extraHost, err := parse(raw)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := d.extra[extraHost]; ok || extraHost == host {
+			return nil, fmt.Errorf("domain %s: duplicate", extraHost)
+		}
+		d.extra[extraHost] = extraURL
+	}
+	return d, nil
+}
+
+// parse - адрес домена с "/" на конце и его хост в нижнем регистре.
+func parse(raw string) (string, string, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", "", fmt.Errorf("domain %s: %w", raw, err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", "", fmt.Errorf("domain %s: http or https address required", raw)
+	}
+	if !strings.HasSuffix(raw, "/") {
+		raw += "/"
+	}
+	return raw, strings.ToLower(u.Host), nil
+}
+
+// Domain - домен ссылок запроса к хосту host. Для хоста домена по
+// умолчанию и неизвестных хостов - пустая строка.
+func (d *Domains) Domain(host string) string {
+	if d == nil {
+		return ""
+	}
+	host = strings.ToLower(host)
+	if _, ok := d.extra[host]; ok {
+		return host
+	}
+	// Хост запроса может прийти с портом по умолчанию.
+	if name, _, err := net.SplitHostPort(host); err == nil {
+		if _, ok := d.extra[name]; ok {
+			return name
+		}
+	}
+	return ""
+}
+
+// Resolve - домен ссылок по имени, выбранному пользователем: хосту домена
+// по умолчанию или дополнительного домена. Пустое имя - домен по
+// умолчанию. Неизвестное имя - ошибка ErrUnknownDomain.
+func (d *Domains) Resolve(name string) (string, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" || (d != nil && name == d.host) {
+		return "", nil
+	}
+	if d != nil {
+		if _, ok := d.extra[name]; ok {
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %s", ErrUnknownDomain, name)
+}
+
+// Name - хост домена ссылок domain.
+func (d *Domains) Name(domain string) string {
+	if domain == "" && d != nil {
+		return d.host
+	}
+	return domain
+}
+
+// Names - хосты всех доменов: сначала домен по умолчанию, затем
+// дополнительные по алфавиту.
+func (d *Domains) Names() []string {
+	if d == nil {
+		return nil
+	}
+	names := make([]string, 0, len(d.extra)+1)
+	for host := range d.extra {
+		names = append(names, host)
+	}
+	sort.Strings(names)
+	return append([]string{d.host}, names...)
+}
+
+// ShortURL - короткий адрес ссылки с id репозитория key. Для ссылок
+// домена, которого больше нет в настройках, адрес собирается по хосту
+// домена со схемой https.
+func (d *Domains) ShortURL(key string) string {
+	domain, id := Split(key)
+	if domain == "" {
+		return d.baseURL + id
+	}
+	if base, ok := d.extra[domain]; ok {
+		return base + id
+	}
+	return "https://" + domain + "/" + id
+}
+
+// Key - id репозитория ссылки id домена domain.
+func Key(domain string, id string) string {
+	if domain == "" {
+		return id
+	}
+	return domain + separator + id
+}
+
+// CheckID - проверка id ссылки, полученного от клиента. Id с разделителем
+// домена указывал бы на ссылку другого домена - ошибка ErrInvalidID.
+func CheckID(id string) error {
+	if strings.Contains(id, separator) {
+		return fmt.Errorf("%w: %s", ErrInvalidID, id)
+	}
+	return nil
+}
+
+// Split - домен и id ссылки по id репозитория key.
+func Split(key string) (string, string) {
+	if i := strings.Index(key, separator); i >= 0 {
+		return key[:i], key[i+len(separator):]
+	}
+	return "", key
+}
@@ -0,0 +1,115 @@
+package domains
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		baseURL string
+		extra   []string
+		names   []string
+		wantErr bool
+	}{
+		{
+			name:    "default only",
+			baseURL: "http://localhost:8080/",
+			names:   []string{"localhost:8080"},
+		},
+		{
+			name:    "extra domains",
+			baseURL: "http://localhost:8080/",
+			extra:   []string{"https://Go.Example.com", " link.example.org ", ""},
+			names:   []string{"localhost:8080", "go.example.com", "link.example.org"},
+		},
+		{
+			name:    "invalid scheme",
+			baseURL: "http://localhost:8080/",
+			extra:   []string{"ftp://go.example.com/"},
+			wantErr: true,
+		},
+		{
+			name:    "duplicate domain",
+			baseURL: "http://localhost:8080/",
+			extra:   []string{"go.example.com", "http://go.example.com/"},
+			wantErr: true,
+		},
+		{
+			name:    "extra same as default",
+			baseURL: "https://go.example.com/",
+			extra:   []string{"go.example.com"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := New(tt.baseURL, tt.extra)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.names, d.Names())
+		})
+	}
+}
+
+func TestDomains(t *testing.T) {
+	d, err := New("http://localhost:8080/", []string{"go.example.com", "http://localhost:9090"})
+	require.NoError(t, err)
+
+	assert.Equal(t, "", d.Domain("localhost:8080"))
+	assert.Equal(t, "", d.Domain("unknown.example.com"))
+	assert.Equal(t, "go.example.com", d.Domain("GO.example.com"))
+	assert.Equal(t, "go.example.com", d.Domain("go.example.com:443"))
+	assert.Equal(t, "localhost:9090", d.Domain("localhost:9090"))
+
+	domain, err := d.Resolve("")
+	assert.NoError(t, err)
+	assert.Equal(t, "", domain)
+	domain, err = d.Resolve("localhost:8080")
+	assert.NoError(t, err)
+	assert.Equal(t, "", domain)
+	domain, err = d.Resolve("Go.Example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, "go.example.com", domain)
+	_, err = d.Resolve("evil.example.com")
+	assert.ErrorIs(t, err, ErrUnknownDomain)
+
+	assert.Equal(t, "localhost:8080", d.Name(""))
+	assert.Equal(t, "go.example.com", d.Name("go.example.com"))
+
+	assert.Equal(t, "http://localhost:8080/abc", d.ShortURL("abc"))
+	assert.Equal(t, "https://go.example.com/abc", d.ShortURL(Key("go.example.com", "abc")))
+	assert.Equal(t, "http://localhost:9090/abc", d.ShortURL(Key("localhost:9090", "abc")))
+	assert.Equal(t, "https://old.example.com/abc", d.ShortURL("old.example.com/abc"))
+}
+
+func TestKey(t *testing.T) {
+	assert.Equal(t, "abc", Key("", "abc"))
+	assert.Equal(t, "go.example.com/abc", Key("go.example.com", "abc"))
+
+	domain, id := Split("go.example.com/abc")
+	assert.Equal(t, "go.example.com", domain)
+	assert.Equal(t, "abc", id)
+	domain, id = Split("abc")
+	assert.Equal(t, "", domain)
+	assert.Equal(t, "abc", id)
+
+	assert.NoError(t, CheckID("abc"))
+	assert.ErrorIs(t, CheckID("go.example.com/abc"), ErrInvalidID)
+}
+
+func TestNilDomains(t *testing.T) {
+	var d *Domains
+	assert.Equal(t, "", d.Domain("go.example.com"))
+	domain, err := d.Resolve("")
+	assert.NoError(t, err)
+	assert.Equal(t, "", domain)
+	_, err = d.Resolve("go.example.com")
+	assert.ErrorIs(t, err, ErrUnknownDomain)
+}
@@ -0,0 +1,65 @@
+package filebase
+
+import (
+	"context"
+
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/access"
+)
+
+// GetAccess - ограничение доступа к ссылке.
+func (repo *RepositoryMap) GetAccess(ctx context.Context, shortURL string) (access.Access, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+	return repo.linkAccess[shortURL], nil
+}
+
+// UpdateAccess - замена ограничения доступа к ссылке.
+func (repo *RepositoryMap) UpdateAccess(ctx context.Context, shortURL string, a access.Access, user string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	if _, ok := repo.values[shortURL]; !ok || repo.deleted[shortURL] || !repo.canEdit(shortURL, user) {
+		return errURLNotFound
+	}
+	r := &row{ShortURL: shortURL, User: user, Action: actionAccess, Access: &a}
+	if err := repo.writeRow(r); err != nil {
+		return err
+	}
+	repo.applyAccessRow(r)
+	return nil
+}
+
+// UseLink - учет перехода по ссылке с ограничением количества переходов.
+// Проверка и запись выполняются под одной блокировкой, поэтому лишних
+// переходов не бывает и при одновременных запросах.
+func (repo *RepositoryMap) UseLink(ctx context.Context, shortURL string) (bool, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	if repo.linkAccess[shortURL].Exhausted() {
+		return false, nil
+	}
+	r := &row{ShortURL: shortURL, Action: actionUse}
+	if err := repo.writeRow(r); err != nil {
+		return false, err
+	}
+	repo.applyAccessRow(r)
+	return true, nil
+}
+
+// applyAccessRow - применение строки файла с ограничением доступа или с
+// переходом по ссылке с ограничением.
+func (repo *RepositoryMap) applyAccessRow(r *row) {
+	if r.Action == actionUse {
+		a, ok := repo.linkAccess[r.ShortURL]
+		if !ok {
+			return
+		}
+		a.Uses++
+		repo.linkAccess[r.ShortURL] = a
+		return
+	}
+	if r.Access == nil || r.Access.IsZero() {
+		delete(repo.linkAccess, r.ShortURL)
+		return
+	}
+	repo.linkAccess[r.ShortURL] = *r.Access
+}
@@ -0,0 +1,63 @@
+package filebase
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/access"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/app/responses"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUseLinkConcurrent(t *testing.T) {
+	const maxClicks = 5
+	ctx := context.Background()
+	repo := newTestRepository(t)
+	require.NoError(t, repo.AddURL(ctx, "https://example.com/", "abc", "user-1", responses.LinkMeta{}))
+	require.NoError(t, repo.UpdateAccess(ctx, "abc", access.Access{MaxClicks: maxClicks}, "user-1"))
+
+	var used int64
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ok, err := repo.UseLink(ctx, "abc")
+			assert.NoError(t, err)
+			if ok {
+				atomic.AddInt64(&used, 1)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int64(maxClicks), used)
+
+	require.NoError(t, repo.FlushCache(ctx))
+	a, err := repo.GetAccess(ctx, "abc")
+	require.NoError(t, err)
+	assert.Equal(t, int64(maxClicks), a.Uses)
+	assert.True(t, a.Exhausted())
+}
+
+func TestUseLinkExhausted(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepository(t)
+	require.NoError(t, repo.AddURL(ctx, "https://example.com/", "abc", "user-1", responses.LinkMeta{}))
+	require.NoError(t, repo.UpdateAccess(ctx, "abc", access.Access{MaxClicks: 1}, "user-1"))
+
+	ok, err := repo.UseLink(ctx, "abc")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = repo.UseLink(ctx, "abc")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	// Новое ограничение начинает счетчик переходов заново.
+	require.NoError(t, repo.UpdateAccess(ctx, "abc", access.Access{MaxClicks: 1}, "user-1"))
+	ok, err = repo.UseLink(ctx, "abc")
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
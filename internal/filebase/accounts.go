@@ -0,0 +1,135 @@
+package filebase
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sort"
+
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/accounts"
+	custom_errors "github.com/p7chkn/go-musthave-shortener-tpl/internal/errors"
+)
+
+// CreateAccount - добавление учетной записи.
+func (repo *RepositoryMap) CreateAccount(ctx context.Context, account accounts.Account) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	if _, ok := repo.logins[account.Login]; ok {
+		return custom_errors.NewCustomError(errors.New("login already taken"), http.StatusConflict)
+	}
+	r := &row{Action: actionAccount, Account: &account}
+	if err := repo.writeRow(r); err != nil {
+		return err
+	}
+	repo.applyAccountRow(r)
+	return nil
+}
+
+// GetAccountByLogin - получение учетной записи по логину.
+func (repo *RepositoryMap) GetAccountByLogin(ctx context.Context, login string) (accounts.Account, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+	id, ok := repo.logins[login]
+	if !ok {
+		return accounts.Account{}, custom_errors.NewCustomError(errors.New("account not found"), http.StatusNotFound)
+	}
+	return repo.accounts[id], nil
+}
+
+// AccountExists - есть ли учетная запись с таким идентификатором.
+func (repo *RepositoryMap) AccountExists(ctx context.Context, userID string) (bool, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+	_, ok := repo.accounts[userID]
+	return ok, nil
+}
+
+// AddAPIKey - добавление ключа API.
+func (repo *RepositoryMap) AddAPIKey(ctx context.Context, key accounts.APIKey) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	r := &row{Action: actionAPIKey, APIKey: &key}
+	if err := repo.writeRow(r); err != nil {
+		return err
+	}
+	repo.applyAccountRow(r)
+	return nil
+}
+
+// GetAPIKey - получение ключа API по хешу.
+func (repo *RepositoryMap) GetAPIKey(ctx context.Context, hash string) (accounts.APIKey, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+	key, ok := repo.apiKeys[hash]
+	if !ok {
+		return accounts.APIKey{}, custom_errors.NewCustomError(errors.New("api key not found"), http.StatusNotFound)
+	}
+	return key, nil
+}
+
+// ListAPIKeys - ключи API учетной записи в порядке создания.
+func (repo *RepositoryMap) ListAPIKeys(ctx context.Context, userID string) ([]accounts.APIKey, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+	var result []accounts.APIKey
+	for _, key := range repo.apiKeys {
+		if key.UserID == userID {
+			result = append(result, key)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].CreatedAt.Before(result[j].CreatedAt)
+	})
+	return result, nil
+}
+
+// DeleteAPIKey - отзыв ключа API учетной записи.
+func (repo *RepositoryMap) DeleteAPIKey(ctx context.Context, userID string, keyID string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	for _, key := range repo.apiKeys {
+		if key.ID == keyID && key.UserID == userID {
+			r := &row{Action: actionRevokeKey, APIKey: &key}
+			if err := repo.writeRow(r); err != nil {
+				return err
+			}
+			repo.applyAccountRow(r)
+			return nil
+		}
+	}
+	return custom_errors.NewCustomError(errors.New("api key not found"), http.StatusNotFound)
+}
+
+// ClaimURLs - передача всех ссылок пользователя fromUser пользователю toUser.
+func (repo *RepositoryMap) ClaimURLs(ctx context.Context, fromUser string, toUser string) (int, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	count := len(repo.usersURL[fromUser])
+	if count == 0 {
+		return 0, nil
+	}
+	if err := repo.writeRow(&row{Action: actionClaim, User: fromUser, Target: toUser}); err != nil {
+		return 0, err
+	}
+	repo.moveURLs(fromUser, toUser)
+	return count, nil
+}
+
+// applyAccountRow - применение строки с учетной записью или ключом API.
+func (repo *RepositoryMap) applyAccountRow(r *row) {
+	switch {
+	case r.Action == actionAccount && r.Account != nil:
+		repo.accounts[r.Account.ID] = *r.Account
+		repo.logins[r.Account.Login] = r.Account.ID
+	case r.Action == actionAPIKey && r.APIKey != nil:
+		repo.apiKeys[r.APIKey.Hash] = *r.APIKey
+	case r.Action == actionRevokeKey && r.APIKey != nil:
+		delete(repo.apiKeys, r.APIKey.Hash)
+	}
+}
+
+// moveURLs - перенос ссылок пользователя в памяти.
+func (repo *RepositoryMap) moveURLs(fromUser string, toUser string) {
+	repo.usersURL[toUser] = append(repo.usersURL[toUser], repo.usersURL[fromUser]...)
+	delete(repo.usersURL, fromUser)
+}
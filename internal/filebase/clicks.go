@@ -0,0 +1,116 @@
+package filebase
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/app/responses"
+	custom_errors "github.com/p7chkn/go-musthave-shortener-tpl/internal/errors"
+)
+
+// AddClicks - добавление переходов по ссылкам: по строке в файле на
+// каждую ссылку. Переходы по отсутствующим ссылкам не сохраняются.
+func (repo *RepositoryMap) AddClicks(ctx context.Context, clicks map[string]int64) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	for shortURL, count := range clicks {
+		if _, ok := repo.values[shortURL]; !ok || count <= 0 {
+			continue
+		}
+		r := &row{ShortURL: shortURL, Action: actionClicks, Clicks: count}
+		if err := repo.writeRow(r); err != nil {
+			return err
+		}
+		repo.applyClicksRow(r)
+	}
+	return nil
+}
+
+// AddDestinationClicks - добавление переходов по адресам распределения:
+// по строке в файле на каждый адрес. Переходы по отсутствующим ссылкам не
+// сохраняются.
+func (repo *RepositoryMap) AddDestinationClicks(ctx context.Context, clicks map[string]map[string]int64) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	for shortURL, counts := range clicks {
+		if _, ok := repo.values[shortURL]; !ok {
+			continue
+		}
+		for destination, count := range counts {
+			if count <= 0 {
+				continue
+			}
+			r := &row{ShortURL: shortURL, Action: actionClicks, Clicks: count, Destination: destination}
+			if err := repo.writeRow(r); err != nil {
+				return err
+			}
+			repo.applyClicksRow(r)
+		}
+	}
+	return nil
+}
+
+// GetDestinationClicks - переходы по адресам распределения ссылки.
+func (repo *RepositoryMap) GetDestinationClicks(ctx context.Context, shortURL string) (map[string]int64, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+	result := map[string]int64{}
+	for destination, count := range repo.destinationClicks[shortURL] {
+		result[destination] = count
+	}
+	return result, nil
+}
+
+// applyClicksRow - применение строки файла с переходами по ссылке или по
+// адресу ее распределения.
+func (repo *RepositoryMap) applyClicksRow(r *row) {
+	if r.Destination == "" {
+		repo.clicks[r.ShortURL] += r.Clicks
+		return
+	}
+	if repo.destinationClicks[r.ShortURL] == nil {
+		repo.destinationClicks[r.ShortURL] = map[string]int64{}
+	}
+	repo.destinationClicks[r.ShortURL][r.Destination] += r.Clicks
+}
+
+// GetPreview - сведения о ссылке для страницы предпросмотра, в том числе
+// удаленной. Отсутствующая ссылка - ошибка с кодом 404.
+func (repo *RepositoryMap) GetPreview(ctx context.Context, shortURL string) (responses.LinkPreview, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+	if _, ok := repo.values[shortURL]; !ok {
+		return responses.LinkPreview{}, custom_errors.NewCustomError(errors.New("url not found"), http.StatusNotFound)
+	}
+	return responses.LinkPreview{
+		GetURL: repo.userURL(shortURL, ""),
+		Clicks: repo.clicks[shortURL],
+	}, nil
+}
+
+// GetLinkStates - владельцы, адреса назначения, переходы и признаки
+// удаления ссылок. Отсутствующих ссылок в результате нет.
+func (repo *RepositoryMap) GetLinkStates(ctx context.Context, shortURLs []string) (map[string]responses.LinkState, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+	wanted := map[string]bool{}
+	for _, shortURL := range shortURLs {
+		wanted[shortURL] = true
+	}
+	result := map[string]responses.LinkState{}
+	for user, urls := range repo.usersURL {
+		for _, shortURL := range urls {
+			if _, ok := result[shortURL]; ok || !wanted[shortURL] {
+				continue
+			}
+			result[shortURL] = responses.LinkState{
+				UserID:      user,
+				OriginalURL: repo.values[shortURL],
+				Clicks:      repo.clicks[shortURL],
+				Deleted:     repo.deleted[shortURL],
+			}
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,97 @@
+package filebase
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/app/responses"
+)
+
+// ExportURLs - обход всех ссылок вместе с их владельцами, удаленных - только
+// если deleted. Пользователи обходятся по порядку id, ссылки - в порядке
+// добавления. У ссылок, удаленных до появления времени удаления в файле,
+// время удаления нулевое.
+func (repo *RepositoryMap) ExportURLs(ctx context.Context, deleted bool, fn func(u responses.ExportURL) error) error {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+	users := make([]string, 0, len(repo.usersURL))
+	for user := range repo.usersURL {
+		users = append(users, user)
+	}
+	sort.Strings(users)
+	seen := map[string]bool{}
+	for _, user := range users {
+		for _, shortURL := range repo.usersURL[user] {
+			if (repo.deleted[shortURL] && !deleted) || seen[shortURL] {
+				continue
+			}
+			seen[shortURL] = true
+			u := responses.ExportURL{
+				GetURL: repo.userURL(shortURL, repo.urlWorkspace[shortURL]),
+				UserID: user,
+			}
+			if settings, ok := repo.redirects[shortURL]; ok {
+				u.Redirect = &settings
+			}
+			if a, ok := repo.linkAccess[shortURL]; ok {
+				u.Access = &a
+			}
+			if repo.deleted[shortURL] {
+				deletedAt := repo.deletedAt[shortURL]
+				u.DeletedAt = &deletedAt
+			}
+			if err := fn(u); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ImportURL - сохранение ссылки из выгрузки вместе с настройками перехода.
+// Ссылка без времени создания получает текущее время.
+func (repo *RepositoryMap) ImportURL(ctx context.Context, u responses.ExportURL) (bool, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	if _, ok := repo.values[u.ID]; ok {
+		return false, nil
+	}
+	createdAt := time.Now()
+	if u.CreatedAt != nil {
+		createdAt = *u.CreatedAt
+	}
+	r := &row{LongURL: u.OriginalURL, ShortURL: u.ID, User: u.UserID, CreatedAt: &createdAt}
+	if !isEmptyMeta(u.LinkMeta) {
+		meta := u.LinkMeta
+		r.Meta = &meta
+	}
+	if err := repo.writeRow(r); err != nil {
+		return false, err
+	}
+	repo.applyAddRow(r)
+	if u.Redirect != nil && !u.Redirect.IsZero() {
+		rr := &row{ShortURL: u.ID, User: u.UserID, Action: actionRedirect, Redirect: u.Redirect}
+		if err := repo.writeRow(rr); err != nil {
+			return true, err
+		}
+		repo.applyRedirectRow(rr)
+	}
+	if u.Access != nil && !u.Access.IsZero() {
+		ar := &row{ShortURL: u.ID, User: u.UserID, Action: actionAccess, Access: u.Access}
+		if err := repo.writeRow(ar); err != nil {
+			return true, err
+		}
+		repo.applyAccessRow(ar)
+	}
+	if u.DeletedAt == nil {
+		return true, nil
+	}
+	deletedAt := *u.DeletedAt
+	if err := repo.writeRow(&row{ShortURL: u.ID, User: u.UserID, Action: actionDelete, DeletedAt: &deletedAt}); err != nil {
+		return true, err
+	}
+	repo.deleted[u.ID] = true
+	repo.deletedAt[u.ID] = deletedAt
+	return true, nil
+}
@@ -6,37 +6,88 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/access"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/accounts"
 	"github.com/p7chkn/go-musthave-shortener-tpl/internal/app/responses"
 	"github.com/p7chkn/go-musthave-shortener-tpl/internal/app/services"
 	custom_errors "github.com/p7chkn/go-musthave-shortener-tpl/internal/errors"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/listing"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/redirect"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/webhooks"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/workspaces"
 	"log"
 	"net/http"
 	"os"
+	"sync"
+	"time"
 
 	"github.com/p7chkn/go-musthave-shortener-tpl/cmd/shortener/configuration"
 )
 
 // NewFileRepository - создание нового интерфейса для репозитория.
-func NewFileRepository(ctx context.Context, filePath string, baseURL string) services.UserRepositoryInterface {
-	return services.UserRepositoryInterface(NewRepositoryMap(ctx, filePath, baseURL))
+func NewFileRepository(ctx context.Context, filePath string) services.UserRepositoryInterface {
+	return services.UserRepositoryInterface(NewRepositoryMap(ctx, filePath))
 }
 
+// errURLExists - ссылка с таким id уже существует.
+var errURLExists = custom_errors.NewCustomError(errors.New("url already exists"), http.StatusConflict)
+
 // RepositoryMap - структура для хранения данных в файле.
 type RepositoryMap struct {
+	mu       sync.RWMutex
 	values   map[string]string
 	filePath string
-	baseURL  string
 	usersURL map[string][]string
+	blocked  map[string]bool
+	accounts map[string]accounts.Account
+	logins   map[string]string
+	apiKeys  map[string]accounts.APIKey
+	deleted  map[string]bool
+	// createdAt - время создания ссылок, для созданных до его появления в
+	// файле отсутствует.
+	createdAt map[string]time.Time
+	// deletedAt - время удаления ссылок, для удаленных до его появления в
+	// файле отсутствует.
+	deletedAt map[string]time.Time
+	// workspaces - рабочие пространства по id, members - роли участников
+	// рабочих пространств, urlWorkspace - рабочее пространство ссылки.
+	workspaces   map[string]workspaces.Workspace
+	members      map[string]map[string]workspaces.Role
+	urlWorkspace map[string]string
+	// revisions - история изменений адресов назначения ссылок.
+	revisions map[string][]responses.URLRevision
+	// meta - заголовки, описания и теги ссылок.
+	meta map[string]responses.LinkMeta
+	// clicks - количество переходов по ссылкам.
+	clicks map[string]int64
+	// destinationClicks - количество переходов по адресам распределения
+	// ссылок.
+	destinationClicks map[string]map[string]int64
+	// redirects - настройки перехода по ссылкам, отличные от настроек по
+	// умолчанию.
+	redirects map[string]redirect.Settings
+	// linkAccess - ограничения доступа к ссылкам с паролем или
+	// ограничением количества переходов.
+	linkAccess map[string]access.Access
+	// webhooks - вебхуки пользователей по id, deliveries - последние
+	// доставки вебхуков по id вебхука в порядке доставки.
+	webhooks   map[string]webhooks.Webhook
+	deliveries map[string][]webhooks.Delivery
 }
 
 // NewRepositoryMap - создание новой структуры хранения данных в файлах.
-func NewRepositoryMap(ctx context.Context, filePath string, baseURL string) *RepositoryMap {
-	repo := RepositoryMap{
-		values:   map[string]string{},
+func NewRepositoryMap(ctx context.Context, filePath string) *RepositoryMap {
+	repo := &RepositoryMap{
 		filePath: filePath,
-		baseURL:  baseURL,
-		usersURL: map[string][]string{},
 	}
+	repo.reset()
+	repo.load()
+
+	return repo
+}
+
+// load - чтение данных из файла в память.
+func (repo *RepositoryMap) load() {
 	file, err := os.OpenFile(repo.filePath, os.O_RDONLY|os.O_CREATE, configuration.FilePerm)
 	if err != nil {
 		log.Printf("Error with reading file: %v\n", err)
@@ -55,37 +106,99 @@ func NewRepositoryMap(ctx context.Context, filePath string, baseURL string) *Rep
 			break
 		}
 	}
+}
 
-	return &repo
+// FlushCache - сброс данных в памяти и повторное чтение их из файла.
+func (repo *RepositoryMap) FlushCache(ctx context.Context) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	repo.reset()
+	repo.load()
+	return nil
+}
+
+// reset - очистка данных в памяти.
+func (repo *RepositoryMap) reset() {
+	repo.values = map[string]string{}
+	repo.usersURL = map[string][]string{}
+	repo.blocked = map[string]bool{}
+	repo.accounts = map[string]accounts.Account{}
+	repo.logins = map[string]string{}
+	repo.apiKeys = map[string]accounts.APIKey{}
+	repo.deleted = map[string]bool{}
+	repo.deletedAt = map[string]time.Time{}
+	repo.createdAt = map[string]time.Time{}
+	repo.workspaces = map[string]workspaces.Workspace{}
+	repo.members = map[string]map[string]workspaces.Role{}
+	repo.urlWorkspace = map[string]string{}
+	repo.revisions = map[string][]responses.URLRevision{}
+	repo.meta = map[string]responses.LinkMeta{}
+	repo.clicks = map[string]int64{}
+	repo.destinationClicks = map[string]map[string]int64{}
+	repo.redirects = map[string]redirect.Settings{}
+	repo.linkAccess = map[string]access.Access{}
+	repo.webhooks = map[string]webhooks.Webhook{}
+	repo.deliveries = map[string][]webhooks.Delivery{}
 }
 
 // AddURL - добавление записи о новой сокращенной URL.
-func (repo *RepositoryMap) AddURL(ctx context.Context, longURL string, shortURL string, user string) error {
-	repo.values[shortURL] = longURL
-	repo.writeRow(longURL, shortURL, repo.filePath, user)
-	repo.usersURL[user] = append(repo.usersURL[user], shortURL)
+func (repo *RepositoryMap) AddURL(ctx context.Context, longURL string, shortURL string, user string, meta responses.LinkMeta) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	return repo.addURL(longURL, shortURL, user, meta)
+}
+
+// addURL - запись строки о новой сокращенной URL, вызывается под
+// блокировкой mu. Существующая ссылка, в том числе удаленная, - ошибка с
+// кодом 409.
+func (repo *RepositoryMap) addURL(longURL string, shortURL string, user string, meta responses.LinkMeta) error {
+	if _, ok := repo.values[shortURL]; ok {
+		return errURLExists
+	}
+	now := time.Now()
+	r := &row{LongURL: longURL, ShortURL: shortURL, User: user, CreatedAt: &now}
+	if !isEmptyMeta(meta) {
+		r.Meta = &meta
+	}
+	if err := repo.writeRow(r); err != nil {
+		return err
+	}
+	repo.applyAddRow(r)
 	return nil
 }
 
 // GetURL - получение данных о изначальном URL по сокращенному URL.
 func (repo *RepositoryMap) GetURL(ctx context.Context, shortURL string) (string, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
 	resultURL, okey := repo.values[shortURL]
 	if !okey {
-		return "", errors.New("not found")
+		return "", custom_errors.NewCustomError(errors.New("not found"), http.StatusNotFound)
+	}
+	if repo.deleted[shortURL] {
+		return "", custom_errors.NewCustomError(errors.New("deleted"), http.StatusGone)
+	}
+	if repo.blocked[shortURL] {
+		return resultURL, services.ErrLinkBlocked
 	}
 	return resultURL, nil
 }
 
-// GetUserURL - получение всех URL пользователя.
-func (repo *RepositoryMap) GetUserURL(ctx context.Context, user string) ([]responses.GetURL, error) {
+// GetUserURL - получение URL пользователя: личных и ссылок рабочих
+// пространств, в которых он участвует с любой ролью. Фильтры, курсор и
+// сортировка query применяются в памяти.
+func (repo *RepositoryMap) GetUserURL(ctx context.Context, user string, query listing.Query) ([]responses.GetURL, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
 	var result []responses.GetURL
 	for _, url := range repo.usersURL[user] {
-		temp := responses.GetURL{
-			ShortURL:    repo.baseURL + url,
-			OriginalURL: repo.values[url],
+		if repo.deleted[url] || repo.urlWorkspace[url] != "" {
+			continue
 		}
-		result = append(result, temp)
+		result = append(result, repo.userURL(url, ""))
 	}
+	result = append(result, repo.memberURLs(user)...)
+	result = query.Apply(result)
 
 	if len(result) == 0 {
 		return result, custom_errors.NewCustomError(errors.New("no content"), http.StatusNoContent)
@@ -94,22 +207,107 @@ func (repo *RepositoryMap) GetUserURL(ctx context.Context, user string) ([]respo
 	return result, nil
 }
 
+// userURL - ссылка shortURL в списке ссылок пользователя.
+func (repo *RepositoryMap) userURL(shortURL string, workspaceID string) responses.GetURL {
+	u := responses.GetURL{
+		ID:          shortURL,
+		OriginalURL: repo.values[shortURL],
+		WorkspaceID: workspaceID,
+	}
+	if createdAt, ok := repo.createdAt[shortURL]; ok {
+		u.CreatedAt = &createdAt
+	}
+	u.LinkMeta = repo.meta[shortURL]
+	return u
+}
+
 // Ping - проверка подключения к базе данных. В данном случае замокан, чтобы реализовать
 // интерфейс.
 func (repo *RepositoryMap) Ping(ctx context.Context) error {
 	return nil
 }
 
-// AddManyURL - добавление многих URL сразу.
-func (repo *RepositoryMap) AddManyURL(ctx context.Context, urls []responses.ManyPostURL, user string) ([]responses.ManyPostResponse, error) {
-	return nil, nil
+// AddManyURL - добавление многих URL сразу с их id. Если хотя бы одна
+// ссылка уже существует или повторяется в urls, не добавляется ни одна.
+func (repo *RepositoryMap) AddManyURL(ctx context.Context, urls []responses.ManyPostURL, user string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	ids := make(map[string]bool, len(urls))
+	for _, u := range urls {
+		if _, ok := repo.values[u.ID]; ok || ids[u.ID] {
+			return errURLExists
+		}
+		ids[u.ID] = true
+	}
+	for _, u := range urls {
+		if err := repo.addURL(u.OriginalURL, u.ID, user, u.LinkMeta); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
+// Действия в строках файла. Строка без действия - добавление URL.
+const (
+	actionBlock     = "block"
+	actionUnblock   = "unblock"
+	actionAccount   = "account"
+	actionAPIKey    = "api_key"
+	actionRevokeKey = "revoke_key"
+	actionClaim     = "claim"
+	actionDelete    = "delete"
+	actionUpdate    = "update"
+	actionRestore   = "restore"
+	actionMeta      = "meta"
+	actionClicks    = "clicks"
+	actionRedirect  = "redirect"
+	actionAccess    = "access"
+	actionUse       = "use"
+	// Действия с рабочими пространствами.
+	actionWorkspace     = "workspace"
+	actionMember        = "member"
+	actionRemoveMember  = "remove_member"
+	actionWorkspaceURLs = "workspace_url"
+	// Действия с вебхуками.
+	actionWebhook       = "webhook"
+	actionRemoveWebhook = "remove_webhook"
+	actionDelivery      = "webhook_delivery"
+)
+
 // row - структура для строки данных в файле.
 type row struct {
 	ShortURL string `json:"short_url"`
 	LongURL  string `json:"long_url"`
 	User     string `json:"user"`
+	Action   string `json:"action,omitempty"`
+	// Target - пользователь, которому переданы ссылки User, или рабочее
+	// пространство, в которое перенесена ссылка ShortURL.
+	Target    string                `json:"target,omitempty"`
+	Account   *accounts.Account     `json:"account,omitempty"`
+	APIKey    *accounts.APIKey      `json:"api_key,omitempty"`
+	Workspace *workspaces.Workspace `json:"workspace,omitempty"`
+	Member    *workspaces.Member    `json:"member,omitempty"`
+	// Revision - прежний адрес назначения при изменении ссылки на LongURL.
+	Revision *responses.URLRevision `json:"revision,omitempty"`
+	// DeletedAt - время удаления ссылки ShortURL.
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	// CreatedAt - время создания ссылки ShortURL.
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+	// Meta - заголовок, описание и теги ссылки ShortURL.
+	Meta *responses.LinkMeta `json:"meta,omitempty"`
+	// Clicks - сколько переходов по ссылке ShortURL добавлено.
+	Clicks int64 `json:"clicks,omitempty"`
+	// Destination - адрес распределения, к переходам по которому
+	// добавляются Clicks. Пустой - переходы по самой ссылке.
+	Destination string `json:"destination,omitempty"`
+	// Redirect - настройки перехода по ссылке ShortURL.
+	Redirect *redirect.Settings `json:"redirect,omitempty"`
+	// Access - ограничение доступа к ссылке ShortURL.
+	Access *access.Access `json:"access,omitempty"`
+	// Webhook - добавленный или удаленный вебхук.
+	Webhook *webhooks.Webhook `json:"webhook,omitempty"`
+	// Delivery - запись журнала доставок вебхука.
+	Delivery *webhooks.Delivery `json:"delivery,omitempty"`
 }
 
 // readRow - прочтение строки данных из файла.
@@ -127,26 +325,66 @@ func (repo *RepositoryMap) readRow(reader *bufio.Scanner) (bool, error) {
 	if err != nil {
 		return false, err
 	}
-	repo.values[row.ShortURL] = row.LongURL
-	repo.usersURL[row.User] = append(repo.usersURL[row.User], row.ShortURL)
+	switch row.Action {
+	case actionBlock:
+		repo.blocked[row.ShortURL] = true
+	case actionUnblock:
+		delete(repo.blocked, row.ShortURL)
+	case actionAccount, actionAPIKey, actionRevokeKey:
+		repo.applyAccountRow(row)
+	case actionClaim:
+		repo.moveURLs(row.User, row.Target)
+	case actionDelete:
+		repo.deleted[row.ShortURL] = true
+		if row.DeletedAt != nil {
+			repo.deletedAt[row.ShortURL] = *row.DeletedAt
+		}
+	case actionRestore:
+		delete(repo.deleted, row.ShortURL)
+		delete(repo.deletedAt, row.ShortURL)
+	case actionUpdate:
+		repo.applyUpdateRow(row)
+	case actionMeta:
+		repo.applyMetaRow(row)
+	case actionClicks:
+		repo.applyClicksRow(row)
+	case actionRedirect:
+		repo.applyRedirectRow(row)
+	case actionAccess, actionUse:
+		repo.applyAccessRow(row)
+	case actionWorkspace, actionMember, actionRemoveMember, actionWorkspaceURLs:
+		repo.applyWorkspaceRow(row)
+	case actionWebhook, actionRemoveWebhook, actionDelivery:
+		repo.applyWebhookRow(row)
+	default:
+		repo.applyAddRow(row)
+	}
 
 	return true, nil
 }
 
+// applyAddRow - применение строки файла с добавлением URL. Время создания
+// ссылки, которую добавляют повторно, не меняется.
+func (repo *RepositoryMap) applyAddRow(r *row) {
+	repo.values[r.ShortURL] = r.LongURL
+	repo.usersURL[r.User] = append(repo.usersURL[r.User], r.ShortURL)
+	if _, ok := repo.createdAt[r.ShortURL]; !ok && r.CreatedAt != nil {
+		repo.createdAt[r.ShortURL] = *r.CreatedAt
+	}
+	repo.applyMetaRow(r)
+}
+
 // writeRow - запись строки данных в файл.
-func (repo *RepositoryMap) writeRow(longURL string, shortURL string, filePath string, user string) error {
+func (repo *RepositoryMap) writeRow(r *row) error {
 	file, err := os.OpenFile(repo.filePath, os.O_WRONLY|os.O_CREATE|os.O_APPEND, configuration.FilePerm)
 
 	if err != nil {
 		return err
 	}
+	defer file.Close()
 	writer := bufio.NewWriter(file)
 
-	data, err := json.Marshal(&row{
-		LongURL:  longURL,
-		ShortURL: shortURL,
-		User:     user,
-	})
+	data, err := json.Marshal(r)
 	if err != nil {
 		return err
 	}
@@ -162,12 +400,29 @@ func (repo *RepositoryMap) writeRow(longURL string, shortURL string, filePath st
 	return writer.Flush()
 }
 
-// DeleteManyURL - замокана для реализации интерфефса.
+// DeleteManyURL - удаление многих URL по id. Удаляются только ссылки,
+// которые пользователь может изменять: личные и ссылки рабочих пространств,
+// где он owner или editor.
 func (repo *RepositoryMap) DeleteManyURL(ctx context.Context, urls []string, user string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	for _, url := range urls {
+		if repo.deleted[url] || !repo.canEdit(url, user) {
+			continue
+		}
+		now := time.Now()
+		if err := repo.writeRow(&row{ShortURL: url, User: user, Action: actionDelete, DeletedAt: &now}); err != nil {
+			return err
+		}
+		repo.deleted[url] = true
+		repo.deletedAt[url] = now
+	}
 	return nil
 }
 
 func (repo *RepositoryMap) GetStats(ctx context.Context) (responses.StatResponse, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
 	result := responses.StatResponse{
 		CountURL:  len(repo.values),
 		CountUser: len(repo.usersURL),
@@ -175,3 +430,40 @@ func (repo *RepositoryMap) GetStats(ctx context.Context) (responses.StatResponse
 	return result, nil
 
 }
+
+// ForEachURL - обход всех сохраненных URL с их настройками перехода.
+func (repo *RepositoryMap) ForEachURL(ctx context.Context, fn func(shortURL string, longURL string, settings redirect.Settings) error) error {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+	for shortURL, longURL := range repo.values {
+		if err := fn(shortURL, longURL, repo.redirects[shortURL]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetBlocked - отключение или включение ссылок политикой. В файл
+// записываются только изменения состояния.
+func (repo *RepositoryMap) SetBlocked(ctx context.Context, shortURLs []string, blocked bool) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	action := actionUnblock
+	if blocked {
+		action = actionBlock
+	}
+	for _, shortURL := range shortURLs {
+		if repo.blocked[shortURL] == blocked {
+			continue
+		}
+		if err := repo.writeRow(&row{ShortURL: shortURL, Action: action}); err != nil {
+			return err
+		}
+		if blocked {
+			repo.blocked[shortURL] = true
+		} else {
+			delete(repo.blocked, shortURL)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package filebase
+
+import (
+	"context"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/app/responses"
+	custom_errors "github.com/p7chkn/go-musthave-shortener-tpl/internal/errors"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/listing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestRepository - репозиторий во временном файле.
+func newTestRepository(t *testing.T) *RepositoryMap {
+	return NewRepositoryMap(context.Background(), filepath.Join(t.TempDir(), "urls.jsonl"))
+}
+
+func TestAddURLDuplicate(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name    string
+		prepare func(t *testing.T, repo *RepositoryMap)
+		wantURL string
+		wantErr int
+	}{
+		{
+			name:    "existing link",
+			wantURL: "https://example.com/",
+		},
+		{
+			name: "edited link",
+			prepare: func(t *testing.T, repo *RepositoryMap) {
+				require.NoError(t, repo.UpdateURL(ctx, "abc", "https://example.com/edited", "user-1"))
+			},
+			wantURL: "https://example.com/edited",
+		},
+		{
+			name: "deleted link",
+			prepare: func(t *testing.T, repo *RepositoryMap) {
+				require.NoError(t, repo.DeleteManyURL(ctx, []string{"abc"}, "user-1"))
+			},
+			wantErr: http.StatusGone,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newTestRepository(t)
+			require.NoError(t, repo.AddURL(ctx, "https://example.com/", "abc", "user-1", responses.LinkMeta{}))
+			if tt.prepare != nil {
+				tt.prepare(t, repo)
+			}
+
+			err := repo.AddURL(ctx, "https://example.com/", "abc", "user-2", responses.LinkMeta{})
+			assert.Equal(t, http.StatusConflict, custom_errors.ParseError(err))
+
+			require.NoError(t, repo.FlushCache(ctx))
+			url, err := repo.GetURL(ctx, "abc")
+			if tt.wantErr != 0 {
+				assert.Equal(t, tt.wantErr, custom_errors.ParseError(err))
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.wantURL, url)
+			}
+			_, err = repo.GetUserURL(ctx, "user-2", listing.Query{})
+			assert.Equal(t, http.StatusNoContent, custom_errors.ParseError(err))
+		})
+	}
+}
+
+func TestAddManyURLDuplicate(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name string
+		urls []responses.ManyPostURL
+	}{
+		{
+			name: "existing link",
+			urls: []responses.ManyPostURL{
+				{ID: "new", OriginalURL: "https://example.com/new"},
+				{ID: "abc", OriginalURL: "https://example.com/"},
+			},
+		},
+		{
+			name: "repeated in batch",
+			urls: []responses.ManyPostURL{
+				{ID: "new", OriginalURL: "https://example.com/new"},
+				{ID: "new", OriginalURL: "https://example.com/new"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newTestRepository(t)
+			require.NoError(t, repo.AddURL(ctx, "https://example.com/", "abc", "user-1", responses.LinkMeta{}))
+
+			err := repo.AddManyURL(ctx, tt.urls, "user-2")
+			assert.Equal(t, http.StatusConflict, custom_errors.ParseError(err))
+
+			require.NoError(t, repo.FlushCache(ctx))
+			_, err = repo.GetURL(ctx, "new")
+			assert.Equal(t, http.StatusNotFound, custom_errors.ParseError(err))
+			_, err = repo.GetUserURL(ctx, "user-2", listing.Query{})
+			assert.Equal(t, http.StatusNoContent, custom_errors.ParseError(err))
+		})
+	}
+
+	repo := newTestRepository(t)
+	urls := []responses.ManyPostURL{
+		{ID: "abc", OriginalURL: "https://example.com/"},
+		{ID: "def", OriginalURL: "https://example.com/def"},
+	}
+	require.NoError(t, repo.AddManyURL(ctx, urls, "user-1"))
+	got, err := repo.GetUserURL(ctx, "user-1", listing.Query{})
+	require.NoError(t, err)
+	assert.Len(t, got, 2)
+}
@@ -0,0 +1,57 @@
+package filebase
+
+import (
+	"context"
+
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/app/responses"
+)
+
+// UpdateMeta - замена заголовка, описания и тегов ссылки.
+func (repo *RepositoryMap) UpdateMeta(ctx context.Context, shortURL string, meta responses.LinkMeta, user string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	if _, ok := repo.values[shortURL]; !ok || repo.deleted[shortURL] || !repo.canEdit(shortURL, user) {
+		return errURLNotFound
+	}
+	r := &row{ShortURL: shortURL, User: user, Action: actionMeta, Meta: &meta}
+	if err := repo.writeRow(r); err != nil {
+		return err
+	}
+	repo.applyMetaRow(r)
+	return nil
+}
+
+// FillTitle - сохранение заголовка ссылки, если он еще не задан. Для
+// отсутствующей ссылки ничего не делает.
+func (repo *RepositoryMap) FillTitle(ctx context.Context, shortURL string, title string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	meta := repo.meta[shortURL]
+	if _, ok := repo.values[shortURL]; !ok || meta.Title != "" {
+		return nil
+	}
+	meta.Title = title
+	r := &row{ShortURL: shortURL, Action: actionMeta, Meta: &meta}
+	if err := repo.writeRow(r); err != nil {
+		return err
+	}
+	repo.applyMetaRow(r)
+	return nil
+}
+
+// applyMetaRow - применение строки файла с заголовком, описанием и тегами.
+func (repo *RepositoryMap) applyMetaRow(r *row) {
+	if r.Meta == nil {
+		return
+	}
+	if isEmptyMeta(*r.Meta) {
+		delete(repo.meta, r.ShortURL)
+		return
+	}
+	repo.meta[r.ShortURL] = *r.Meta
+}
+
+// isEmptyMeta - не заданы ни заголовок, ни описание, ни теги.
+func isEmptyMeta(meta responses.LinkMeta) bool {
+	return meta.Title == "" && meta.Description == "" && len(meta.Tags) == 0
+}
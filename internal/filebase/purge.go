@@ -0,0 +1,124 @@
+package filebase
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/p7chkn/go-musthave-shortener-tpl/cmd/shortener/configuration"
+)
+
+// RestoreURLs - восстановление удаленных ссылок, которые пользователь может
+// изменять и которые удалены не раньше deletedSince. Ссылки, удаленные до
+// появления времени удаления в файле, не восстанавливаются.
+func (repo *RepositoryMap) RestoreURLs(ctx context.Context, urls []string, user string, deletedSince time.Time) (int, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	restored := 0
+	for _, url := range urls {
+		deletedAt, ok := repo.deletedAt[url]
+		if !repo.deleted[url] || !ok || deletedAt.Before(deletedSince) || !repo.canEdit(url, user) {
+			continue
+		}
+		if err := repo.writeRow(&row{ShortURL: url, User: user, Action: actionRestore}); err != nil {
+			return restored, err
+		}
+		delete(repo.deleted, url)
+		delete(repo.deletedAt, url)
+		restored++
+	}
+	return restored, nil
+}
+
+// PurgeURLs - окончательное удаление ссылок: файл перезаписывается без
+// строк с этими ссылками.
+func (repo *RepositoryMap) PurgeURLs(ctx context.Context, urls []string) (int, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	purge := map[string]bool{}
+	for _, url := range urls {
+		if _, ok := repo.values[url]; ok {
+			purge[url] = true
+		}
+	}
+	return repo.purge(purge)
+}
+
+// PurgeDeleted - окончательное удаление ссылок, удаленных раньше
+// deletedBefore. Ссылки, удаленные до появления времени удаления в файле, не
+// удаляются: время их удаления неизвестно.
+func (repo *RepositoryMap) PurgeDeleted(ctx context.Context, deletedBefore time.Time) (int, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	purge := map[string]bool{}
+	for url := range repo.deleted {
+		if deletedAt, ok := repo.deletedAt[url]; ok && deletedAt.Before(deletedBefore) {
+			purge[url] = true
+		}
+	}
+	return repo.purge(purge)
+}
+
+// purge - перезапись файла без строк со ссылками из urls и повторное чтение
+// данных из него, вызывается под блокировкой mu.
+func (repo *RepositoryMap) purge(urls map[string]bool) (int, error) {
+	if len(urls) == 0 {
+		return 0, nil
+	}
+	if err := repo.rewrite(func(r *row) bool { return urls[r.ShortURL] }); err != nil {
+		return 0, err
+	}
+	repo.reset()
+	repo.load()
+	return len(urls), nil
+}
+
+// rewrite - перезапись файла без строк, для которых skip возвращает true.
+// Новый файл записывается рядом и заменяет старый, чтобы при сбое не
+// потерять данные.
+func (repo *RepositoryMap) rewrite(skip func(r *row) bool) error {
+	file, err := os.Open(repo.filePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	tmp, err := os.CreateTemp(filepath.Dir(repo.filePath), filepath.Base(repo.filePath)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	reader := bufio.NewScanner(file)
+	writer := bufio.NewWriter(tmp)
+	for reader.Scan() {
+		data := reader.Bytes()
+		r := &row{}
+		if err := json.Unmarshal(data, r); err == nil && skip(r) {
+			continue
+		}
+		if _, err := writer.Write(data); err != nil {
+			return err
+		}
+		if err := writer.WriteByte('\n'); err != nil {
+			return err
+		}
+	}
+	if err := reader.Err(); err != nil {
+		return err
+	}
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+	if err := tmp.Chmod(configuration.FilePerm); err != nil {
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), repo.filePath)
+}
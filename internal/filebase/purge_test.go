@@ -0,0 +1,44 @@
+package filebase
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/app/responses"
+	custom_errors "github.com/p7chkn/go-musthave-shortener-tpl/internal/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPurgeDeleted(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepository(t)
+	for _, id := range []string{"legacy", "expired", "recent", "alive"} {
+		require.NoError(t, repo.AddURL(ctx, "https://example.com/"+id, id, "user-1", responses.LinkMeta{}))
+	}
+	// Удаление до появления времени удаления в файле.
+	require.NoError(t, repo.writeRow(&row{ShortURL: "legacy", User: "user-1", Action: actionDelete}))
+	expired := time.Now().Add(-48 * time.Hour)
+	require.NoError(t, repo.writeRow(&row{ShortURL: "expired", User: "user-1", Action: actionDelete, DeletedAt: &expired}))
+	require.NoError(t, repo.FlushCache(ctx))
+	require.NoError(t, repo.DeleteManyURL(ctx, []string{"recent"}, "user-1"))
+
+	purged, err := repo.PurgeDeleted(ctx, time.Now().Add(-24*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 1, purged)
+
+	require.NoError(t, repo.FlushCache(ctx))
+	wantCodes := map[string]int{
+		"legacy":  http.StatusGone,
+		"expired": http.StatusNotFound,
+		"recent":  http.StatusGone,
+	}
+	for id, want := range wantCodes {
+		_, err := repo.GetURL(ctx, id)
+		assert.Equal(t, want, custom_errors.ParseError(err), id)
+	}
+	_, err = repo.GetURL(ctx, "alive")
+	assert.NoError(t, err)
+}
@@ -0,0 +1,54 @@
+package filebase
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/app/services"
+	custom_errors "github.com/p7chkn/go-musthave-shortener-tpl/internal/errors"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/redirect"
+)
+
+// GetRedirect - адрес назначения и настройки перехода по ссылке. Ошибки
+// такие же, как у GetURL.
+func (repo *RepositoryMap) GetRedirect(ctx context.Context, shortURL string) (string, redirect.Settings, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+	resultURL, ok := repo.values[shortURL]
+	if !ok {
+		return "", redirect.Settings{}, custom_errors.NewCustomError(errors.New("not found"), http.StatusNotFound)
+	}
+	if repo.deleted[shortURL] {
+		return "", redirect.Settings{}, custom_errors.NewCustomError(errors.New("deleted"), http.StatusGone)
+	}
+	settings := repo.redirects[shortURL]
+	if repo.blocked[shortURL] {
+		return resultURL, settings, services.ErrLinkBlocked
+	}
+	return resultURL, settings, nil
+}
+
+// UpdateRedirect - замена настроек перехода по ссылке.
+func (repo *RepositoryMap) UpdateRedirect(ctx context.Context, shortURL string, settings redirect.Settings, user string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	if _, ok := repo.values[shortURL]; !ok || repo.deleted[shortURL] || !repo.canEdit(shortURL, user) {
+		return errURLNotFound
+	}
+	r := &row{ShortURL: shortURL, User: user, Action: actionRedirect, Redirect: &settings}
+	if err := repo.writeRow(r); err != nil {
+		return err
+	}
+	repo.applyRedirectRow(r)
+	return nil
+}
+
+// applyRedirectRow - применение строки файла с настройками перехода.
+func (repo *RepositoryMap) applyRedirectRow(r *row) {
+	if r.Redirect == nil || r.Redirect.IsZero() {
+		delete(repo.redirects, r.ShortURL)
+		return
+	}
+	repo.redirects[r.ShortURL] = *r.Redirect
+}
@@ -0,0 +1,64 @@
+package filebase
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/app/responses"
+	custom_errors "github.com/p7chkn/go-musthave-shortener-tpl/internal/errors"
+)
+
+// errURLNotFound - ссылки нет, она удалена или пользователь не может ее
+// изменять.
+var errURLNotFound = custom_errors.NewCustomError(errors.New("url not found"), http.StatusNotFound)
+
+// UpdateURL - замена адреса назначения ссылки с сохранением прежнего адреса
+// в истории изменений.
+func (repo *RepositoryMap) UpdateURL(ctx context.Context, shortURL string, longURL string, user string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	current, ok := repo.values[shortURL]
+	if !ok || repo.deleted[shortURL] || !repo.canEdit(shortURL, user) {
+		return errURLNotFound
+	}
+	if current == longURL {
+		return nil
+	}
+	r := &row{
+		ShortURL: shortURL,
+		LongURL:  longURL,
+		User:     user,
+		Action:   actionUpdate,
+		Revision: &responses.URLRevision{
+			Revision:    len(repo.revisions[shortURL]) + 1,
+			OriginalURL: current,
+			ChangedBy:   user,
+			CreatedAt:   time.Now(),
+		},
+	}
+	if err := repo.writeRow(r); err != nil {
+		return err
+	}
+	repo.applyUpdateRow(r)
+	return nil
+}
+
+// GetRevisions - история изменений ссылки в порядке изменений.
+func (repo *RepositoryMap) GetRevisions(ctx context.Context, shortURL string, user string) ([]responses.URLRevision, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+	if _, ok := repo.values[shortURL]; !ok || repo.deleted[shortURL] || !repo.canEdit(shortURL, user) {
+		return nil, errURLNotFound
+	}
+	return append([]responses.URLRevision(nil), repo.revisions[shortURL]...), nil
+}
+
+// applyUpdateRow - применение строки файла с изменением адреса назначения.
+func (repo *RepositoryMap) applyUpdateRow(r *row) {
+	repo.values[r.ShortURL] = r.LongURL
+	if r.Revision != nil {
+		repo.revisions[r.ShortURL] = append(repo.revisions[r.ShortURL], *r.Revision)
+	}
+}
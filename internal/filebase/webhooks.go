@@ -0,0 +1,119 @@
+package filebase
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sort"
+
+	custom_errors "github.com/p7chkn/go-musthave-shortener-tpl/internal/errors"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/webhooks"
+)
+
+// errWebhookNotFound - вебхука нет или он принадлежит другому пользователю.
+var errWebhookNotFound = custom_errors.NewCustomError(errors.New("webhook not found"), http.StatusNotFound)
+
+// CreateWebhook - добавление вебхука.
+func (repo *RepositoryMap) CreateWebhook(ctx context.Context, hook webhooks.Webhook) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	r := &row{Action: actionWebhook, Webhook: &hook}
+	if err := repo.writeRow(r); err != nil {
+		return err
+	}
+	repo.applyWebhookRow(r)
+	return nil
+}
+
+// ListWebhooks - вебхуки пользователя в порядке создания.
+func (repo *RepositoryMap) ListWebhooks(ctx context.Context, userID string) ([]webhooks.Webhook, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+	var result []webhooks.Webhook
+	for _, hook := range repo.webhooks {
+		if hook.UserID == userID {
+			result = append(result, hook)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].CreatedAt.Before(result[j].CreatedAt)
+	})
+	return result, nil
+}
+
+// GetWebhook - вебхук пользователя по id.
+func (repo *RepositoryMap) GetWebhook(ctx context.Context, id string, userID string) (webhooks.Webhook, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+	hook, ok := repo.webhooks[id]
+	if !ok || hook.UserID != userID {
+		return webhooks.Webhook{}, errWebhookNotFound
+	}
+	return hook, nil
+}
+
+// DeleteWebhook - удаление вебхука пользователя вместе с журналом доставок.
+func (repo *RepositoryMap) DeleteWebhook(ctx context.Context, id string, userID string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	hook, ok := repo.webhooks[id]
+	if !ok || hook.UserID != userID {
+		return errWebhookNotFound
+	}
+	r := &row{Action: actionRemoveWebhook, Webhook: &webhooks.Webhook{ID: id}}
+	if err := repo.writeRow(r); err != nil {
+		return err
+	}
+	repo.applyWebhookRow(r)
+	return nil
+}
+
+// AddDelivery - запись в журнал доставок. Доставки удаленного вебхука не
+// записываются.
+func (repo *RepositoryMap) AddDelivery(ctx context.Context, delivery webhooks.Delivery) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	if _, ok := repo.webhooks[delivery.WebhookID]; !ok {
+		return nil
+	}
+	r := &row{Action: actionDelivery, Delivery: &delivery}
+	if err := repo.writeRow(r); err != nil {
+		return err
+	}
+	repo.applyWebhookRow(r)
+	return nil
+}
+
+// ListDeliveries - последние доставки вебхука, начиная с новых, не больше
+// limit.
+func (repo *RepositoryMap) ListDeliveries(ctx context.Context, webhookID string, limit int) ([]webhooks.Delivery, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+	deliveries := repo.deliveries[webhookID]
+	result := make([]webhooks.Delivery, 0, len(deliveries))
+	for i := len(deliveries) - 1; i >= 0 && len(result) < limit; i-- {
+		result = append(result, deliveries[i])
+	}
+	return result, nil
+}
+
+// applyWebhookRow - применение строки с вебхуком или доставкой. В памяти
+// хранятся только webhooks.MaxDeliveries последних доставок вебхука.
+func (repo *RepositoryMap) applyWebhookRow(r *row) {
+	switch {
+	case r.Action == actionWebhook && r.Webhook != nil:
+		repo.webhooks[r.Webhook.ID] = *r.Webhook
+	case r.Action == actionRemoveWebhook && r.Webhook != nil:
+		delete(repo.webhooks, r.Webhook.ID)
+		delete(repo.deliveries, r.Webhook.ID)
+	case r.Action == actionDelivery && r.Delivery != nil:
+		if _, ok := repo.webhooks[r.Delivery.WebhookID]; !ok {
+			return
+		}
+		deliveries := append(repo.deliveries[r.Delivery.WebhookID], *r.Delivery)
+		if len(deliveries) > webhooks.MaxDeliveries {
+			deliveries = deliveries[len(deliveries)-webhooks.MaxDeliveries:]
+		}
+		repo.deliveries[r.Delivery.WebhookID] = deliveries
+	}
+}
@@ -0,0 +1,164 @@
+package filebase
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sort"
+
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/app/responses"
+	custom_errors "github.com/p7chkn/go-musthave-shortener-tpl/internal/errors"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/workspaces"
+)
+
+// CreateWorkspace - добавление рабочего пространства с владельцем ownerID.
+func (repo *RepositoryMap) CreateWorkspace(ctx context.Context, workspace workspaces.Workspace, ownerID string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	r := &row{Action: actionWorkspace, Workspace: &workspace, User: ownerID}
+	if err := repo.writeRow(r); err != nil {
+		return err
+	}
+	repo.applyWorkspaceRow(r)
+	return nil
+}
+
+// ListWorkspaces - рабочие пространства пользователя в порядке создания.
+func (repo *RepositoryMap) ListWorkspaces(ctx context.Context, userID string) ([]workspaces.Membership, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+	var result []workspaces.Membership
+	for id, members := range repo.members {
+		if role, ok := members[userID]; ok {
+			result = append(result, workspaces.Membership{Workspace: repo.workspaces[id], Role: role})
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].CreatedAt.Before(result[j].CreatedAt)
+	})
+	return result, nil
+}
+
+// GetRole - роль пользователя в рабочем пространстве.
+func (repo *RepositoryMap) GetRole(ctx context.Context, workspaceID string, userID string) (workspaces.Role, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+	if _, ok := repo.workspaces[workspaceID]; !ok {
+		return "", custom_errors.NewCustomError(errors.New("workspace not found"), http.StatusNotFound)
+	}
+	return repo.members[workspaceID][userID], nil
+}
+
+// ListMembers - участники рабочего пространства с логинами.
+func (repo *RepositoryMap) ListMembers(ctx context.Context, workspaceID string) ([]workspaces.Member, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+	var result []workspaces.Member
+	for userID, role := range repo.members[workspaceID] {
+		result = append(result, workspaces.Member{
+			WorkspaceID: workspaceID,
+			UserID:      userID,
+			Login:       repo.accounts[userID].Login,
+			Role:        role,
+		})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Login < result[j].Login
+	})
+	return result, nil
+}
+
+// SetMember - добавление участника или изменение его роли.
+func (repo *RepositoryMap) SetMember(ctx context.Context, member workspaces.Member) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	member.Login = ""
+	r := &row{Action: actionMember, Member: &member}
+	if err := repo.writeRow(r); err != nil {
+		return err
+	}
+	repo.applyWorkspaceRow(r)
+	return nil
+}
+
+// RemoveMember - удаление участника рабочего пространства.
+func (repo *RepositoryMap) RemoveMember(ctx context.Context, workspaceID string, userID string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	if _, ok := repo.members[workspaceID][userID]; !ok {
+		return custom_errors.NewCustomError(errors.New("member not found"), http.StatusNotFound)
+	}
+	r := &row{Action: actionRemoveMember, Member: &workspaces.Member{WorkspaceID: workspaceID, UserID: userID}}
+	if err := repo.writeRow(r); err != nil {
+		return err
+	}
+	repo.applyWorkspaceRow(r)
+	return nil
+}
+
+// AddURLsToWorkspace - перенос в рабочее пространство ссылок, которые
+// пользователь может изменять.
+func (repo *RepositoryMap) AddURLsToWorkspace(ctx context.Context, workspaceID string, shortURLs []string, userID string) (int, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	moved := 0
+	for _, shortURL := range shortURLs {
+		if repo.deleted[shortURL] || repo.urlWorkspace[shortURL] == workspaceID || !repo.canEdit(shortURL, userID) {
+			continue
+		}
+		r := &row{Action: actionWorkspaceURLs, ShortURL: shortURL, User: userID, Target: workspaceID}
+		if err := repo.writeRow(r); err != nil {
+			return moved, err
+		}
+		repo.applyWorkspaceRow(r)
+		moved++
+	}
+	return moved, nil
+}
+
+// applyWorkspaceRow - применение строки с рабочим пространством, его
+// участником или ссылкой.
+func (repo *RepositoryMap) applyWorkspaceRow(r *row) {
+	switch {
+	case r.Action == actionWorkspace && r.Workspace != nil:
+		repo.workspaces[r.Workspace.ID] = *r.Workspace
+		repo.members[r.Workspace.ID] = map[string]workspaces.Role{r.User: workspaces.RoleOwner}
+	case r.Action == actionMember && r.Member != nil:
+		if members, ok := repo.members[r.Member.WorkspaceID]; ok {
+			members[r.Member.UserID] = r.Member.Role
+		}
+	case r.Action == actionRemoveMember && r.Member != nil:
+		delete(repo.members[r.Member.WorkspaceID], r.Member.UserID)
+	case r.Action == actionWorkspaceURLs:
+		repo.urlWorkspace[r.ShortURL] = r.Target
+	}
+}
+
+// canEdit - может ли пользователь изменять ссылку: личную ссылку может ее
+// автор, ссылку рабочего пространства - его owner и editor.
+func (repo *RepositoryMap) canEdit(shortURL string, user string) bool {
+	if workspaceID := repo.urlWorkspace[shortURL]; workspaceID != "" {
+		return repo.members[workspaceID][user].CanEdit()
+	}
+	for _, url := range repo.usersURL[user] {
+		if url == shortURL {
+			return true
+		}
+	}
+	return false
+}
+
+// memberURLs - ссылки рабочих пространств, в которых участвует пользователь.
+func (repo *RepositoryMap) memberURLs(user string) []responses.GetURL {
+	var result []responses.GetURL
+	for shortURL, workspaceID := range repo.urlWorkspace {
+		if repo.deleted[shortURL] {
+			continue
+		}
+		if _, ok := repo.members[workspaceID][user]; !ok {
+			continue
+		}
+		result = append(result, repo.userURL(shortURL, workspaceID))
+	}
+	return result
+}
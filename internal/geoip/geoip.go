@@ -0,0 +1,57 @@
+// Package geoip - определение страны по IP адресу по локальной базе
+// GeoIP2 или GeoLite2 в формате MaxMind DB.
+package geoip
+
+import (
+	"net"
+	"strings"
+
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// Resolver - определение страны по IP адресу.
+type Resolver interface {
+	// Country - код страны ISO 3166-1 alpha-2 в верхнем регистре или пустая
+	// строка, если страну определить не удалось.
+	Country(ip net.IP) string
+}
+
+// DB - база MaxMind DB с кодами стран: GeoIP2 или GeoLite2 Country или
+// City.
+type DB struct {
+	reader *maxminddb.Reader
+}
+
+// record - часть записи базы с кодом страны.
+type record struct {
+	Country struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"country"`
+}
+
+// Open - открытие базы из файла path.
+func Open(path string) (*DB, error) {
+	reader, err := maxminddb.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	return &DB{reader: reader}, nil
+}
+
+// Country - код страны ISO 3166-1 alpha-2 для ip. Адреса, которых нет в
+// базе, и ошибки чтения дают пустую строку.
+func (db *DB) Country(ip net.IP) string {
+	if ip == nil {
+		return ""
+	}
+	var r record
+	if err := db.reader.Lookup(ip, &r); err != nil {
+		return ""
+	}
+	return strings.ToUpper(r.Country.ISOCode)
+}
+
+// Close - закрытие базы.
+func (db *DB) Close() error {
+	return db.reader.Close()
+}
@@ -0,0 +1,96 @@
+// Package keys - пакет для загрузки и проверки ключей шифрования сервиса.
+package keys
+
+import (
+	"bufio"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+)
+
+// ErrNoKeys - ключи не заданы.
+var ErrNoKeys = errors.New("no keys configured")
+
+// Validate - проверка размера ключа: 16, 24 или 32 байта (AES-128, AES-192
+// или AES-256).
+func Validate(key []byte) error {
+	switch len(key) {
+	case 16, 24, 32:
+		return nil
+	default:
+		return fmt.Errorf("invalid key size %d, must be 16, 24 or 32 bytes", len(key))
+	}
+}
+
+// Parse - декодирование и проверка ключей в base64. Пустые строки
+// пропускаются.
+func Parse(encoded []string) ([][]byte, error) {
+	var result [][]byte
+	for i, value := range encoded {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		key, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return nil, fmt.Errorf("key #%d: %w", i+1, err)
+		}
+		if err := Validate(key); err != nil {
+			return nil, fmt.Errorf("key #%d: %w", i+1, err)
+		}
+		result = append(result, key)
+	}
+	return result, nil
+}
+
+// LoadFile - чтение ключей из файла: по одному ключу в base64 на строку,
+// строки с "#" - комментарии.
+func LoadFile(path string) ([][]byte, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	if info, err := file.Stat(); err == nil && info.Mode().Perm()&0077 != 0 {
+		log.Printf("Keys file %s is accessible by other users\n", path)
+	}
+
+	var encoded []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		encoded = append(encoded, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return Parse(encoded)
+}
+
+// Load - загрузка ключей из строк в base64 и файла path. Первый ключ -
+// основной, остальные используются для проверки данных, подписанных до
+// ротации. Если ключи не заданы, возвращается ErrNoKeys.
+func Load(encoded []string, path string) ([][]byte, error) {
+	result, err := Parse(encoded)
+	if err != nil {
+		return nil, err
+	}
+	if path != "" {
+		fromFile, err := LoadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, fromFile...)
+	}
+	if len(result) == 0 {
+		return nil, ErrNoKeys
+	}
+	return result, nil
+}
@@ -0,0 +1,93 @@
+package keys
+
+import (
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func encode(size int) string {
+	return base64.StdEncoding.EncodeToString([]byte(strings.Repeat("k", size)))
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	keysFile := filepath.Join(dir, "keys")
+	content := "# previous keys\n" + encode(24) + "\n\n" + encode(16) + "\n"
+	if err := os.WriteFile(keysFile, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	badFile := filepath.Join(dir, "bad")
+	if err := os.WriteFile(badFile, []byte(encode(10)), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		encoded   []string
+		path      string
+		wantSizes []int
+		wantErr   bool
+	}{
+		{
+			name:    "nothing configured",
+			wantErr: true,
+		},
+		{
+			name:      "env keys",
+			encoded:   []string{encode(32), " " + encode(16) + " "},
+			wantSizes: []int{32, 16},
+		},
+		{
+			name:      "env and file keys",
+			encoded:   []string{encode(32)},
+			path:      keysFile,
+			wantSizes: []int{32, 24, 16},
+		},
+		{
+			name:    "invalid size",
+			encoded: []string{encode(20)},
+			wantErr: true,
+		},
+		{
+			name:    "invalid base64",
+			encoded: []string{"not base64!"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid key in file",
+			path:    badFile,
+			wantErr: true,
+		},
+		{
+			name:    "missing file",
+			path:    filepath.Join(dir, "missing"),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Load(tt.encoded, tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Load() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(got) != len(tt.wantSizes) {
+				t.Fatalf("Load() got %d keys, want %d", len(got), len(tt.wantSizes))
+			}
+			for i, key := range got {
+				if len(key) != tt.wantSizes[i] {
+					t.Errorf("key #%d size = %d, want %d", i, len(key), tt.wantSizes[i])
+				}
+			}
+		})
+	}
+}
+
+func TestLoadNoKeys(t *testing.T) {
+	if _, err := Load([]string{""}, ""); !errors.Is(err, ErrNoKeys) {
+		t.Errorf("Load() error = %v, want ErrNoKeys", err)
+	}
+}
@@ -0,0 +1,242 @@
+// Package listing - параметры выборки списка ссылок пользователя: фильтры,
+// сортировка по времени создания и постраничный вывод по курсору.
+package listing
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/app/responses"
+)
+
+// MaxLimit - максимальное количество ссылок на странице.
+const MaxLimit = 1000
+
+// Значения параметра сортировки: по времени создания по возрастанию и по
+// убыванию. По умолчанию сначала новые.
+const (
+	SortCreatedAsc  = "created_at"
+	SortCreatedDesc = "-created_at"
+)
+
+var (
+	// ErrInvalidLimit - размер страницы вне диапазона от 1 до MaxLimit.
+	ErrInvalidLimit = errors.New("limit must be from 1 to 1000")
+	// ErrInvalidCursor - курсор поврежден или получен не из этого сервиса.
+	ErrInvalidCursor = errors.New("invalid cursor")
+	// ErrInvalidSort - неизвестное значение сортировки.
+	ErrInvalidSort = errors.New("sort must be created_at or -created_at")
+	// ErrInvalidDate - дата не в формате RFC 3339.
+	ErrInvalidDate = errors.New("created_from and created_to must be RFC 3339 dates")
+)
+
+// Params - параметры выборки в том виде, в каком они приходят в запросе.
+// Пустые значения - без ограничения.
+type Params struct {
+	Limit       int
+	Cursor      string
+	Domain      string
+	Search      string
+	Tag         string
+	CreatedFrom string
+	CreatedTo   string
+	Sort        string
+}
+
+// Query - проверенные параметры выборки. Limit 0 - без ограничения,
+// After - курсор последней ссылки предыдущей страницы. Ссылки выбираются
+// созданные не раньше CreatedFrom и раньше CreatedTo, у которых хост
+// совпадает с Domain или является его поддоменом, среди тегов есть Tag, а
+// Search встречается в id, оригинальном URL, заголовке или описании без
+// учета регистра.
+type Query struct {
+	Limit       int
+	After       *Cursor
+	Domain      string
+	Search      string
+	Tag         string
+	CreatedFrom time.Time
+	CreatedTo   time.Time
+	Ascending   bool
+}
+
+// Cursor - позиция в списке ссылок: время создания и id последней ссылки
+// страницы.
+type Cursor struct {
+	CreatedAt time.Time
+	ID        string
+}
+
+// Query - проверка параметров и создание Query.
+func (p Params) Query() (Query, error) {
+	q := Query{
+		Limit:  p.Limit,
+		Domain: strings.ToLower(strings.TrimSpace(p.Domain)),
+		Search: strings.TrimSpace(p.Search),
+		Tag:    strings.ToLower(strings.TrimSpace(p.Tag)),
+	}
+	if p.Limit < 0 || p.Limit > MaxLimit {
+		return Query{}, ErrInvalidLimit
+	}
+	switch p.Sort {
+	case "", SortCreatedDesc:
+	case SortCreatedAsc:
+		q.Ascending = true
+	default:
+		return Query{}, ErrInvalidSort
+	}
+	if p.Cursor != "" {
+		cursor, err := ParseCursor(p.Cursor)
+		if err != nil {
+			return Query{}, err
+		}
+		q.After = &cursor
+	}
+	var err error
+	if q.CreatedFrom, err = parseDate(p.CreatedFrom); err != nil {
+		return Query{}, err
+	}
+	if q.CreatedTo, err = parseDate(p.CreatedTo); err != nil {
+		return Query{}, err
+	}
+	return q, nil
+}
+
+// parseDate - разбор даты в формате RFC 3339, пустая строка - нулевое время.
+func parseDate(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, ErrInvalidDate
+	}
+	return t, nil
+}
+
+// String - курсор в виде строки для передачи клиенту.
+func (c Cursor) String() string {
+	value := c.CreatedAt.UTC().Format(time.RFC3339Nano) + " " + c.ID
+	return base64.RawURLEncoding.EncodeToString([]byte(value))
+}
+
+// ParseCursor - разбор курсора, полученного от клиента.
+func ParseCursor(value string) (Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+	parts := strings.SplitN(string(data), " ", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return Cursor{}, ErrInvalidCursor
+	}
+	createdAt, err := time.Parse(time.RFC3339Nano, parts[0])
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+	return Cursor{CreatedAt: createdAt, ID: parts[1]}, nil
+}
+
+// CursorOf - курсор, указывающий на ссылку u. Ссылки без времени создания
+// считаются созданными раньше всех остальных.
+func CursorOf(u responses.GetURL) Cursor {
+	cursor := Cursor{ID: u.ID}
+	if u.CreatedAt != nil {
+		cursor.CreatedAt = *u.CreatedAt
+	}
+	return cursor
+}
+
+// Match - подходит ли ссылка под фильтры и находится ли она после курсора.
+func (q Query) Match(u responses.GetURL) bool {
+	position := CursorOf(u)
+	if !q.CreatedFrom.IsZero() && position.CreatedAt.Before(q.CreatedFrom) {
+		return false
+	}
+	if !q.CreatedTo.IsZero() && !position.CreatedAt.Before(q.CreatedTo) {
+		return false
+	}
+	if q.Domain != "" && !MatchDomain(Host(u.OriginalURL), q.Domain) {
+		return false
+	}
+	if q.Tag != "" && !hasTag(u.Tags, q.Tag) {
+		return false
+	}
+	if q.Search != "" && !contains(q.Search, u.ID, u.OriginalURL, u.Title, u.Description) {
+		return false
+	}
+	if q.After != nil && !q.less(*q.After, position) {
+		return false
+	}
+	return true
+}
+
+// less - идет ли a раньше b в порядке сортировки. При равном времени
+// создания ссылки упорядочиваются по id.
+func (q Query) less(a Cursor, b Cursor) bool {
+	if !a.CreatedAt.Equal(b.CreatedAt) {
+		return a.CreatedAt.Before(b.CreatedAt) == q.Ascending
+	}
+	if a.ID == b.ID {
+		return false
+	}
+	return (a.ID < b.ID) == q.Ascending
+}
+
+// Apply - выборка страницы из всех ссылок пользователя для хранилищ, которые
+// держат ссылки в памяти: фильтрация, сортировка и ограничение Limit.
+func (q Query) Apply(urls []responses.GetURL) []responses.GetURL {
+	result := make([]responses.GetURL, 0, len(urls))
+	for _, u := range urls {
+		if q.Match(u) {
+			result = append(result, u)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return q.less(CursorOf(result[i]), CursorOf(result[j]))
+	})
+	if q.Limit > 0 && len(result) > q.Limit {
+		result = result[:q.Limit]
+	}
+	return result
+}
+
+// hasTag - есть ли tag среди тегов ссылки.
+func hasTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// contains - встречается ли search хотя бы в одном из values без учета
+// регистра.
+func contains(search string, values ...string) bool {
+	search = strings.ToLower(search)
+	for _, value := range values {
+		if strings.Contains(strings.ToLower(value), search) {
+			return true
+		}
+	}
+	return false
+}
+
+// Host - хост URL в нижнем регистре, для некорректного URL - пустая строка.
+func Host(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Hostname())
+}
+
+// MatchDomain - совпадает ли хост с доменом или является его поддоменом.
+func MatchDomain(host string, domain string) bool {
+	return host == domain || strings.HasSuffix(host, "."+domain)
+}
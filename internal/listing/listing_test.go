@@ -0,0 +1,162 @@
+package listing
+
+import (
+	"testing"
+	"time"
+
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/app/responses"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParams_Query(t *testing.T) {
+	cursor := Cursor{CreatedAt: time.Date(2021, 5, 1, 10, 0, 0, 0, time.UTC), ID: "abc"}
+	tests := []struct {
+		name    string
+		params  Params
+		want    Query
+		wantErr error
+	}{
+		{
+			name: "empty params",
+			want: Query{},
+		},
+		{
+			name: "all params",
+			params: Params{
+				Limit:       10,
+				Cursor:      cursor.String(),
+				Domain:      " Example.COM ",
+				Search:      "docs",
+				Tag:         " Go ",
+				CreatedFrom: "2021-01-01T00:00:00Z",
+				CreatedTo:   "2022-01-01T00:00:00Z",
+				Sort:        SortCreatedAsc,
+			},
+			want: Query{
+				Limit:       10,
+				After:       &cursor,
+				Domain:      "example.com",
+				Search:      "docs",
+				Tag:         "go",
+				CreatedFrom: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC),
+				CreatedTo:   time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC),
+				Ascending:   true,
+			},
+		},
+		{
+			name:    "limit too big",
+			params:  Params{Limit: MaxLimit + 1},
+			wantErr: ErrInvalidLimit,
+		},
+		{
+			name:    "unknown sort",
+			params:  Params{Sort: "original_url"},
+			wantErr: ErrInvalidSort,
+		},
+		{
+			name:    "broken cursor",
+			params:  Params{Cursor: "not a cursor"},
+			wantErr: ErrInvalidCursor,
+		},
+		{
+			name:    "date without time zone",
+			params:  Params{CreatedFrom: "2021-01-01"},
+			wantErr: ErrInvalidDate,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.params.Query()
+			assert.Equal(t, tt.wantErr, err)
+			if tt.wantErr == nil {
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestQuery_Apply(t *testing.T) {
+	at := func(day int) *time.Time {
+		t := time.Date(2021, 5, day, 0, 0, 0, 0, time.UTC)
+		return &t
+	}
+	urls := []responses.GetURL{
+		{ID: "a", OriginalURL: "https://example.com/docs", CreatedAt: at(1)},
+		{ID: "b", OriginalURL: "https://blog.example.com/post", CreatedAt: at(3)},
+		{ID: "c", OriginalURL: "https://other.org/Docs", CreatedAt: at(2), LinkMeta: responses.LinkMeta{Tags: []string{"go", "news"}}},
+		{ID: "d", OriginalURL: "https://notexample.com/", CreatedAt: at(3), LinkMeta: responses.LinkMeta{Title: "Release notes", Tags: []string{"news"}}},
+		{ID: "legacy", OriginalURL: "https://example.com/old"},
+	}
+	ids := func(urls []responses.GetURL) []string {
+		var result []string
+		for _, u := range urls {
+			result = append(result, u.ID)
+		}
+		return result
+	}
+	tests := []struct {
+		name  string
+		query Query
+		want  []string
+	}{
+		{
+			name:  "newest first by default",
+			query: Query{},
+			want:  []string{"d", "b", "c", "a", "legacy"},
+		},
+		{
+			name:  "oldest first",
+			query: Query{Ascending: true},
+			want:  []string{"legacy", "a", "c", "b", "d"},
+		},
+		{
+			name:  "domain with subdomains",
+			query: Query{Domain: "example.com"},
+			want:  []string{"b", "a", "legacy"},
+		},
+		{
+			name:  "case insensitive search",
+			query: Query{Search: "docs"},
+			want:  []string{"c", "a"},
+		},
+		{
+			name:  "search in title",
+			query: Query{Search: "NOTES"},
+			want:  []string{"d"},
+		},
+		{
+			name:  "tag",
+			query: Query{Tag: "news"},
+			want:  []string{"d", "c"},
+		},
+		{
+			name:  "creation date range",
+			query: Query{CreatedFrom: *at(2), CreatedTo: *at(3)},
+			want:  []string{"c"},
+		},
+		{
+			name:  "limit",
+			query: Query{Limit: 2},
+			want:  []string{"d", "b"},
+		},
+		{
+			name:  "after cursor",
+			query: Query{Limit: 2, After: &Cursor{CreatedAt: *at(3), ID: "b"}},
+			want:  []string{"c", "a"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ids(tt.query.Apply(urls)))
+		})
+	}
+}
+
+func TestCursor_String(t *testing.T) {
+	cursor := Cursor{CreatedAt: time.Date(2021, 5, 1, 10, 0, 0, 123456789, time.UTC), ID: "1yhVmSPGQlZn3EnrI2kd7Oxu5UM="}
+	got, err := ParseCursor(cursor.String())
+	require.NoError(t, err)
+	assert.True(t, cursor.CreatedAt.Equal(got.CreatedAt))
+	assert.Equal(t, cursor.ID, got.ID)
+}
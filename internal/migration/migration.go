@@ -0,0 +1,134 @@
+// Package migration - пакет для переноса ссылок между хранилищами: файлом
+// и базой данных. Переносятся ссылки с владельцами, временем создания,
+// заголовками, описаниями, тегами и состоянием удаления.
+package migration
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/app/responses"
+)
+
+// ErrMismatch - ссылки в хранилищах после переноса не совпадают.
+var ErrMismatch = errors.New("storages do not match")
+
+// Repository - хранилище ссылок, из которого и в которое можно переносить
+// ссылки.
+type Repository interface {
+	ExportURLs(ctx context.Context, deleted bool, fn func(u responses.ExportURL) error) error
+	ImportURL(ctx context.Context, u responses.ExportURL) (bool, error)
+}
+
+// Stats - результат переноса: сколько ссылок прочитано из исходного
+// хранилища, сколько из них скопировано и сколько уже было в целевом.
+type Stats struct {
+	Read    int
+	Copied  int
+	Skipped int
+}
+
+// Summary - сводка хранилища для проверки переноса: количество ссылок,
+// количество удаленных ссылок и контрольная сумма ссылок.
+type Summary struct {
+	Links    int
+	Deleted  int
+	Checksum string
+}
+
+// String - сводка в виде строки для вывода.
+func (s Summary) String() string {
+	return fmt.Sprintf("%d links, %d deleted, checksum %s", s.Links, s.Deleted, s.Checksum)
+}
+
+// Copy - перенос всех ссылок, в том числе удаленных, из from в to. Ссылки,
+// которые уже есть в to, не меняются, поэтому прерванный перенос можно
+// запустить повторно и он продолжится с первой не перенесенной ссылки.
+// progress, если задан, вызывается после каждой ссылки.
+func Copy(ctx context.Context, from Repository, to Repository, progress func(Stats)) (Stats, error) {
+	var stats Stats
+	err := from.ExportURLs(ctx, true, func(u responses.ExportURL) error {
+		stats.Read++
+		copied, err := to.ImportURL(ctx, u)
+		if err != nil {
+			return fmt.Errorf("copy link %s: %w", u.ID, err)
+		}
+		if copied {
+			stats.Copied++
+		} else {
+			stats.Skipped++
+		}
+		if progress != nil {
+			progress(stats)
+		}
+		return nil
+	})
+	return stats, err
+}
+
+// Summarize - сводка ссылок хранилища. Контрольная сумма не зависит от
+// порядка обхода ссылок и учитывает id, адрес назначения, владельца,
+// заголовок, описание, теги и то, удалена ли ссылка. Время создания и
+// удаления не учитывается, так как хранилища хранят его с разной
+// точностью.
+func Summarize(ctx context.Context, repo Repository) (Summary, error) {
+	var summary Summary
+	var checksum [sha256.Size]byte
+	err := repo.ExportURLs(ctx, true, func(u responses.ExportURL) error {
+		summary.Links++
+		if u.DeletedAt != nil {
+			summary.Deleted++
+		}
+		sum := linkChecksum(u)
+		for i := range checksum {
+			checksum[i] ^= sum[i]
+		}
+		return nil
+	})
+	summary.Checksum = hex.EncodeToString(checksum[:])
+	return summary, err
+}
+
+// Verify - сравнение сводок from и to. Если они различаются, возвращает
+// ErrMismatch вместе со сводками.
+func Verify(ctx context.Context, from Repository, to Repository) (Summary, Summary, error) {
+	source, err := Summarize(ctx, from)
+	if err != nil {
+		return source, Summary{}, err
+	}
+	target, err := Summarize(ctx, to)
+	if err != nil {
+		return source, target, err
+	}
+	if source != target {
+		return source, target, fmt.Errorf("%w: source has %s, target has %s", ErrMismatch, source, target)
+	}
+	return source, target, nil
+}
+
+// linkChecksum - контрольная сумма одной ссылки. Настройки перехода и
+// ограничение доступа учитываются, только если они заданы.
+func linkChecksum(u responses.ExportURL) [sha256.Size]byte {
+	fields := []string{
+		u.ID, u.OriginalURL, u.UserID, u.Title, u.Description,
+		strings.Join(u.Tags, ","), strconv.FormatBool(u.DeletedAt != nil),
+	}
+	if u.Redirect != nil {
+		settings, _ := json.Marshal(u.Redirect)
+		fields = append(fields, string(settings))
+	}
+	if u.Access != nil {
+		a, _ := json.Marshal(u.Access)
+		fields = append(fields, string(a))
+	}
+	for i, field := range fields {
+		fields[i] = strconv.Quote(field)
+	}
+	return sha256.Sum256([]byte(strings.Join(fields, " ")))
+}
@@ -0,0 +1,90 @@
+package migration
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/app/responses"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/filebase"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCopy(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	source := filebase.NewRepositoryMap(ctx, filepath.Join(dir, "source.log"))
+	require.NoError(t, source.AddURL(ctx, "https://example.com/a", "a", "user-1",
+		responses.LinkMeta{Title: "A", Tags: []string{"go", "docs"}}))
+	require.NoError(t, source.AddURL(ctx, "https://example.com/b", "b", "user-1", responses.LinkMeta{}))
+	require.NoError(t, source.AddURL(ctx, "https://example.com/c", "c", "user-2", responses.LinkMeta{}))
+	require.NoError(t, source.DeleteManyURL(ctx, []string{"b"}, "user-1"))
+
+	targetPath := filepath.Join(dir, "target.log")
+	target := filebase.NewRepositoryMap(ctx, targetPath)
+	stats, err := Copy(ctx, source, target, nil)
+	require.NoError(t, err)
+	assert.Equal(t, Stats{Read: 3, Copied: 3}, stats)
+
+	sourceSummary, targetSummary, err := Verify(ctx, source, target)
+	require.NoError(t, err)
+	assert.Equal(t, 3, sourceSummary.Links)
+	assert.Equal(t, 1, sourceSummary.Deleted)
+	assert.Equal(t, sourceSummary, targetSummary)
+
+	// Повторный перенос ничего не меняет.
+	stats, err = Copy(ctx, source, target, nil)
+	require.NoError(t, err)
+	assert.Equal(t, Stats{Read: 3, Skipped: 3}, stats)
+
+	// Перенесенные данные, в том числе удаление, сохранены в файле.
+	reloaded := filebase.NewRepositoryMap(ctx, targetPath)
+	_, _, err = Verify(ctx, source, reloaded)
+	require.NoError(t, err)
+	var deleted []string
+	require.NoError(t, reloaded.ExportURLs(ctx, true, func(u responses.ExportURL) error {
+		if u.DeletedAt != nil {
+			deleted = append(deleted, u.ID)
+		}
+		return nil
+	}))
+	assert.Equal(t, []string{"b"}, deleted)
+}
+
+func TestCopy_PreservesCreatedAt(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	source := filebase.NewRepositoryMap(ctx, filepath.Join(dir, "source.log"))
+	createdAt := time.Date(2021, 5, 1, 10, 0, 0, 0, time.UTC)
+	imported, err := source.ImportURL(ctx, responses.ExportURL{
+		GetURL: responses.GetURL{ID: "a", OriginalURL: "https://example.com/a", CreatedAt: &createdAt},
+		UserID: "user-1",
+	})
+	require.NoError(t, err)
+	require.True(t, imported)
+
+	target := filebase.NewRepositoryMap(ctx, filepath.Join(dir, "target.log"))
+	_, err = Copy(ctx, source, target, nil)
+	require.NoError(t, err)
+	require.NoError(t, target.ExportURLs(ctx, true, func(u responses.ExportURL) error {
+		require.NotNil(t, u.CreatedAt)
+		assert.True(t, createdAt.Equal(*u.CreatedAt))
+		return nil
+	}))
+}
+
+func TestVerify_Mismatch(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	source := filebase.NewRepositoryMap(ctx, filepath.Join(dir, "source.log"))
+	target := filebase.NewRepositoryMap(ctx, filepath.Join(dir, "target.log"))
+	require.NoError(t, source.AddURL(ctx, "https://example.com/a", "a", "user-1", responses.LinkMeta{}))
+	require.NoError(t, target.AddURL(ctx, "https://example.com/other", "a", "user-1", responses.LinkMeta{}))
+
+	source1, target1, err := Verify(ctx, source, target)
+	assert.ErrorIs(t, err, ErrMismatch)
+	assert.Equal(t, source1.Links, target1.Links)
+	assert.NotEqual(t, source1.Checksum, target1.Checksum)
+}
@@ -0,0 +1,117 @@
+// Package normalizer - пакет для проверки и нормализации URL перед
+// сокращением.
+package normalizer
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/idna"
+)
+
+// DefaultMaxLength - максимальная длина URL по умолчанию.
+const DefaultMaxLength = 2048
+
+var (
+	// ErrEmpty - передан пустой URL.
+	ErrEmpty = errors.New("url is empty")
+	// ErrTooLong - URL длиннее допустимого.
+	ErrTooLong = errors.New("url is too long")
+	// ErrNotAbsolute - URL не абсолютный или без хоста.
+	ErrNotAbsolute = errors.New("url must be absolute and contain a host")
+	// ErrScheme - схема URL отличается от http и https.
+	ErrScheme = errors.New("url scheme must be http or https")
+	// ErrHost - хост URL не является ни IP адресом, ни доменным именем.
+	ErrHost = errors.New("invalid host")
+)
+
+// hostProfile - преобразование IDN хостов в punycode как для поиска в DNS,
+// но без ограничений STD3, чтобы проходили хосты с подчеркиванием.
+var hostProfile = idna.New(idna.MapForLookup(), idna.BidiRule(), idna.StrictDomainName(false))
+
+// defaultPorts - порты по умолчанию для поддерживаемых схем.
+var defaultPorts = map[string]string{
+	"http":  "80",
+	"https": "443",
+}
+
+// Normalizer - проверка и приведение URL к единому виду, чтобы одинаковые
+// адреса давали одну и ту же сокращенную ссылку.
+type Normalizer struct {
+	maxLength int
+	sortQuery bool
+}
+
+// New - создание нового Normalizer. При maxLength <= 0 используется
+// DefaultMaxLength, sortQuery включает сортировку параметров запроса.
+func New(maxLength int, sortQuery bool) *Normalizer {
+	if maxLength <= 0 {
+		maxLength = DefaultMaxLength
+	}
+	return &Normalizer{
+		maxLength: maxLength,
+		sortQuery: sortQuery,
+	}
+}
+
+// Normalize - проверка URL и приведение его к единому виду: схема и хост в
+// нижнем регистре, IDN в punycode, без порта по умолчанию и с путем "/"
+// вместо пустого.
+func (n *Normalizer) Normalize(rawURL string) (string, error) {
+	rawURL = strings.TrimSpace(rawURL)
+	if rawURL == "" {
+		return "", ErrEmpty
+	}
+	if len(rawURL) > n.maxLength {
+		return "", fmt.Errorf("%w: %d characters, maximum is %d", ErrTooLong, len(rawURL), n.maxLength)
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid url: %w", err)
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	if u.Scheme == "" || u.Opaque != "" || u.Host == "" {
+		return "", ErrNotAbsolute
+	}
+	if _, ok := defaultPorts[u.Scheme]; !ok {
+		return "", ErrScheme
+	}
+
+	host, port := strings.ToLower(u.Hostname()), u.Port()
+	// IP адреса, в том числе IPv6 в квадратных скобках, не проходят
+	// через IDNA.
+	if net.ParseIP(host) == nil {
+		host, err = hostProfile.ToASCII(host)
+		if err != nil {
+			return "", fmt.Errorf("%w: %v", ErrHost, err)
+		}
+	}
+	if port == defaultPorts[u.Scheme] {
+		port = ""
+	}
+	switch {
+	case port != "":
+		u.Host = net.JoinHostPort(host, port)
+	case strings.Contains(host, ":"):
+		u.Host = "[" + host + "]"
+	default:
+		u.Host = host
+	}
+
+	if u.Path == "" {
+		u.Path = "/"
+	}
+	if n.sortQuery && u.RawQuery != "" {
+		// Encode сортирует параметры по ключу.
+		u.RawQuery = u.Query().Encode()
+	}
+
+	result := u.String()
+	if len(result) > n.maxLength {
+		return "", fmt.Errorf("%w: %d characters, maximum is %d", ErrTooLong, len(result), n.maxLength)
+	}
+	return result, nil
+}
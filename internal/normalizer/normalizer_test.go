@@ -0,0 +1,110 @@
+package normalizer
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestNormalizer_Normalize(t *testing.T) {
+	tests := []struct {
+		name      string
+		sortQuery bool
+		rawURL    string
+		want      string
+		wantErr   error
+	}{
+		{
+			name:   "already normalized",
+			rawURL: "http://iloverestaurant.ru/",
+			want:   "http://iloverestaurant.ru/",
+		},
+		{
+			name:   "case, default port and empty path",
+			rawURL: "  HTTPS://Example.COM:443",
+			want:   "https://example.com/",
+		},
+		{
+			name:   "non default port is kept",
+			rawURL: "http://example.com:8080/a?b=1",
+			want:   "http://example.com:8080/a?b=1",
+		},
+		{
+			name:   "idn host",
+			rawURL: "http://пример.рф/путь",
+			want:   "http://xn--e1afmkfd.xn--p1ai/%D0%BF%D1%83%D1%82%D1%8C",
+		},
+		{
+			name:   "underscore in host",
+			rawURL: "http://A_B.example.com/",
+			want:   "http://a_b.example.com/",
+		},
+		{
+			name:   "ipv4 host",
+			rawURL: "http://127.0.0.1:80/x",
+			want:   "http://127.0.0.1/x",
+		},
+		{
+			name:   "ipv6 host with port",
+			rawURL: "http://[::1]:8080/x",
+			want:   "http://[::1]:8080/x",
+		},
+		{
+			name:   "ipv6 host",
+			rawURL: "HTTP://[2001:DB8::1]:80",
+			want:   "http://[2001:db8::1]/",
+		},
+		{
+			name:    "invalid punycode host",
+			rawURL:  "http://xn--a.example.com/",
+			wantErr: ErrHost,
+		},
+		{
+			name:      "sorted query",
+			sortQuery: true,
+			rawURL:    "http://example.com/?b=2&a=1",
+			want:      "http://example.com/?a=1&b=2",
+		},
+		{
+			name:   "unsorted query is kept",
+			rawURL: "http://example.com/?b=2&a=1",
+			want:   "http://example.com/?b=2&a=1",
+		},
+		{
+			name:    "empty",
+			rawURL:  " ",
+			wantErr: ErrEmpty,
+		},
+		{
+			name:    "javascript",
+			rawURL:  "javascript:alert(1)",
+			wantErr: ErrNotAbsolute,
+		},
+		{
+			name:    "relative",
+			rawURL:  "iloverestaurant.ru",
+			wantErr: ErrNotAbsolute,
+		},
+		{
+			name:    "ftp",
+			rawURL:  "ftp://example.com/file",
+			wantErr: ErrScheme,
+		},
+		{
+			name:    "too long",
+			rawURL:  "http://example.com/" + strings.Repeat("a", DefaultMaxLength),
+			wantErr: ErrTooLong,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := New(0, tt.sortQuery).Normalize(tt.rawURL)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Normalize() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Normalize() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,5 @@
+// Package pb - сгенерированный код gRPC сервиса, REST шлюза и OpenAPI
+// документации из internal/proto.
+package pb
+
+//go:generate protoc -I .. -I ../../third_party/googleapis --go_out=.. --go-grpc_out=.. --grpc-gateway_out=.. --openapiv2_out=.. ../proto/urls.proto ../proto/admin.proto ../proto/workspaces.proto
@@ -27,6 +27,11 @@ type URLClient interface {
 	GetUserURLs(ctx context.Context, in *GetUserURLsRequest, opts ...grpc.CallOption) (*GetUserURLsResponse, error)
 	CreateBatch(ctx context.Context, in *CreateBatchRequest, opts ...grpc.CallOption) (*CreateBatchResponse, error)
 	DeleteBatch(ctx context.Context, in *DeleteBatchRequest, opts ...grpc.CallOption) (*DeleteBatchResponse, error)
+	Update(ctx context.Context, in *UpdateRequest, opts ...grpc.CallOption) (*UpdateResponse, error)
+	UpdateMeta(ctx context.Context, in *UpdateMetaRequest, opts ...grpc.CallOption) (*UpdateMetaResponse, error)
+	UpdateRedirect(ctx context.Context, in *UpdateRedirectRequest, opts ...grpc.CallOption) (*UpdateRedirectResponse, error)
+	UpdateAccess(ctx context.Context, in *UpdateAccessRequest, opts ...grpc.CallOption) (*UpdateAccessResponse, error)
+	GetQRCode(ctx context.Context, in *GetQRCodeRequest, opts ...grpc.CallOption) (*GetQRCodeResponse, error)
 	GetStats(ctx context.Context, in *GetStatsRequest, opts ...grpc.CallOption) (*GetStatsResponse, error)
 }
 
@@ -83,6 +88,51 @@ func (c *uRLClient) DeleteBatch(ctx context.Context, in *DeleteBatchRequest, opt
 	return out, nil
 }
 
+func (c *uRLClient) Update(ctx context.Context, in *UpdateRequest, opts ...grpc.CallOption) (*UpdateResponse, error) {
+	out := new(UpdateResponse)
+	err := c.cc.Invoke(ctx, "/urls.URL/Update", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uRLClient) UpdateMeta(ctx context.Context, in *UpdateMetaRequest, opts ...grpc.CallOption) (*UpdateMetaResponse, error) {
+	out := new(UpdateMetaResponse)
+	err := c.cc.Invoke(ctx, "/urls.URL/UpdateMeta", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uRLClient) UpdateRedirect(ctx context.Context, in *UpdateRedirectRequest, opts ...grpc.CallOption) (*UpdateRedirectResponse, error) {
+	out := new(UpdateRedirectResponse)
+	err := c.cc.Invoke(ctx, "/urls.URL/UpdateRedirect", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uRLClient) UpdateAccess(ctx context.Context, in *UpdateAccessRequest, opts ...grpc.CallOption) (*UpdateAccessResponse, error) {
+	out := new(UpdateAccessResponse)
+	err := c.cc.Invoke(ctx, "/urls.URL/UpdateAccess", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uRLClient) GetQRCode(ctx context.Context, in *GetQRCodeRequest, opts ...grpc.CallOption) (*GetQRCodeResponse, error) {
+	out := new(GetQRCodeResponse)
+	err := c.cc.Invoke(ctx, "/urls.URL/GetQRCode", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *uRLClient) GetStats(ctx context.Context, in *GetStatsRequest, opts ...grpc.CallOption) (*GetStatsResponse, error) {
 	out := new(GetStatsResponse)
 	err := c.cc.Invoke(ctx, "/urls.URL/GetStats", in, out, opts...)
@@ -101,6 +151,11 @@ type URLServer interface {
 	GetUserURLs(context.Context, *GetUserURLsRequest) (*GetUserURLsResponse, error)
 	CreateBatch(context.Context, *CreateBatchRequest) (*CreateBatchResponse, error)
 	DeleteBatch(context.Context, *DeleteBatchRequest) (*DeleteBatchResponse, error)
+	Update(context.Context, *UpdateRequest) (*UpdateResponse, error)
+	UpdateMeta(context.Context, *UpdateMetaRequest) (*UpdateMetaResponse, error)
+	UpdateRedirect(context.Context, *UpdateRedirectRequest) (*UpdateRedirectResponse, error)
+	UpdateAccess(context.Context, *UpdateAccessRequest) (*UpdateAccessResponse, error)
+	GetQRCode(context.Context, *GetQRCodeRequest) (*GetQRCodeResponse, error)
 	GetStats(context.Context, *GetStatsRequest) (*GetStatsResponse, error)
 	mustEmbedUnimplementedURLServer()
 }
@@ -124,6 +179,21 @@ func (UnimplementedURLServer) CreateBatch(context.Context, *CreateBatchRequest)
 func (UnimplementedURLServer) DeleteBatch(context.Context, *DeleteBatchRequest) (*DeleteBatchResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method DeleteBatch not implemented")
 }
+func (UnimplementedURLServer) Update(context.Context, *UpdateRequest) (*UpdateResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Update not implemented")
+}
+func (UnimplementedURLServer) UpdateMeta(context.Context, *UpdateMetaRequest) (*UpdateMetaResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateMeta not implemented")
+}
+func (UnimplementedURLServer) UpdateRedirect(context.Context, *UpdateRedirectRequest) (*UpdateRedirectResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateRedirect not implemented")
+}
+func (UnimplementedURLServer) UpdateAccess(context.Context, *UpdateAccessRequest) (*UpdateAccessResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateAccess not implemented")
+}
+func (UnimplementedURLServer) GetQRCode(context.Context, *GetQRCodeRequest) (*GetQRCodeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetQRCode not implemented")
+}
 func (UnimplementedURLServer) GetStats(context.Context, *GetStatsRequest) (*GetStatsResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetStats not implemented")
 }
@@ -230,6 +300,96 @@ func _URL_DeleteBatch_Handler(srv interface{}, ctx context.Context, dec func(int
 	return interceptor(ctx, in, info, handler)
 }
 
+func _URL_Update_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(URLServer).Update(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/urls.URL/Update",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(URLServer).Update(ctx, req.(*UpdateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _URL_UpdateMeta_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateMetaRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(URLServer).UpdateMeta(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/urls.URL/UpdateMeta",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(URLServer).UpdateMeta(ctx, req.(*UpdateMetaRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _URL_UpdateRedirect_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateRedirectRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(URLServer).UpdateRedirect(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/urls.URL/UpdateRedirect",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(URLServer).UpdateRedirect(ctx, req.(*UpdateRedirectRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _URL_UpdateAccess_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateAccessRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(URLServer).UpdateAccess(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/urls.URL/UpdateAccess",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(URLServer).UpdateAccess(ctx, req.(*UpdateAccessRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _URL_GetQRCode_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetQRCodeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(URLServer).GetQRCode(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/urls.URL/GetQRCode",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(URLServer).GetQRCode(ctx, req.(*GetQRCodeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _URL_GetStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(GetStatsRequest)
 	if err := dec(in); err != nil {
@@ -275,6 +435,26 @@ var URL_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "DeleteBatch",
 			Handler:    _URL_DeleteBatch_Handler,
 		},
+		{
+			MethodName: "Update",
+			Handler:    _URL_Update_Handler,
+		},
+		{
+			MethodName: "UpdateMeta",
+			Handler:    _URL_UpdateMeta_Handler,
+		},
+		{
+			MethodName: "UpdateRedirect",
+			Handler:    _URL_UpdateRedirect_Handler,
+		},
+		{
+			MethodName: "UpdateAccess",
+			Handler:    _URL_UpdateAccess_Handler,
+		},
+		{
+			MethodName: "GetQRCode",
+			Handler:    _URL_GetQRCode_Handler,
+		},
 		{
 			MethodName: "GetStats",
 			Handler:    _URL_GetStats_Handler,
@@ -0,0 +1,255 @@
+// Package policy - пакет для проверки адресов назначения сокращенных ссылок
+// по правилам: списки разрешенных и запрещенных доменов, регулярные
+// выражения и запрет частных и локальных адресов.
+package policy
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// regexPrefix - префикс строки файла блок-листа с регулярным выражением.
+	regexPrefix = "re:"
+	// lookupTimeout - ограничение времени разрешения имени хоста.
+	lookupTimeout = 2 * time.Second
+)
+
+// ErrBlocked - адрес назначения запрещен политикой.
+var ErrBlocked = errors.New("destination is blocked by policy")
+
+// Resolver - разрешение имени хоста в IP адреса.
+type Resolver interface {
+	LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error)
+}
+
+// Rules - набор правил политики.
+type Rules struct {
+	// AllowDomains - если не пуст, разрешены только эти домены и их поддомены.
+	AllowDomains []string
+	// DenyDomains - запрещенные домены и их поддомены.
+	DenyDomains []string
+	// DenyPatterns - регулярные выражения для запрещенных URL.
+	DenyPatterns []*regexp.Regexp
+}
+
+// Engine - проверка адресов назначения по правилам из конфигурации и файла
+// блок-листа, который перечитывается при изменении.
+type Engine struct {
+	mu              sync.RWMutex
+	rules           Rules
+	fileRules       Rules
+	blockPrivateIPs bool
+	resolver        Resolver
+	blocklistFile   string
+	modTime         time.Time
+}
+
+// New - создание Engine. Если передан blocklistFile, правила из него
+// загружаются сразу; resolver используется для проверки частных адресов
+// по имени хоста и может быть nil.
+func New(rules Rules, blockPrivateIPs bool, blocklistFile string, resolver Resolver) (*Engine, error) {
+	engine := &Engine{
+		rules:           normalizeRules(rules),
+		blockPrivateIPs: blockPrivateIPs,
+		resolver:        resolver,
+		blocklistFile:   blocklistFile,
+	}
+	if blocklistFile != "" {
+		if _, err := engine.Reload(); err != nil {
+			return nil, err
+		}
+	}
+	return engine, nil
+}
+
+// ParsePatterns - компиляция регулярных выражений правил.
+func ParsePatterns(patterns []string) ([]*regexp.Regexp, error) {
+	var result []*regexp.Regexp
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, re)
+	}
+	return result, nil
+}
+
+// Check - проверка адреса назначения перед созданием ссылки. В отличие от
+// Match, имя хоста разрешается в IP адреса для проверки частных адресов.
+func (e *Engine) Check(ctx context.Context, rawURL string) error {
+	if err := e.Match(rawURL); err != nil {
+		return err
+	}
+	if !e.blockPrivateIPs || e.resolver == nil {
+		return nil
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	host := u.Hostname()
+	if net.ParseIP(host) != nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, lookupTimeout)
+	defer cancel()
+	addrs, err := e.resolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		// Несуществующий хост не дает обратиться к внутренним адресам.
+		return nil
+	}
+	for _, addr := range addrs {
+		if IsPrivate(addr.IP) {
+			return fmt.Errorf("%w: host %s resolves to private address %s", ErrBlocked, host, addr.IP)
+		}
+	}
+	return nil
+}
+
+// Match - проверка адреса назначения по правилам без сетевых запросов.
+func (e *Engine) Match(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	host := strings.ToLower(u.Hostname())
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	if len(e.rules.AllowDomains) > 0 && !matchDomain(host, e.rules.AllowDomains) {
+		return fmt.Errorf("%w: domain %s is not allowed", ErrBlocked, host)
+	}
+	for _, rules := range []Rules{e.rules, e.fileRules} {
+		if matchDomain(host, rules.DenyDomains) {
+			return fmt.Errorf("%w: domain %s is denied", ErrBlocked, host)
+		}
+		for _, re := range rules.DenyPatterns {
+			if re.MatchString(rawURL) {
+				return fmt.Errorf("%w: url matches %s", ErrBlocked, re)
+			}
+		}
+	}
+	if ip := net.ParseIP(host); e.blockPrivateIPs && ip != nil && IsPrivate(ip) {
+		return fmt.Errorf("%w: private address %s", ErrBlocked, ip)
+	}
+	return nil
+}
+
+// Reload - повторное чтение файла блок-листа, если он изменился.
+// Возвращает true, если правила были обновлены.
+func (e *Engine) Reload() (bool, error) {
+	info, err := os.Stat(e.blocklistFile)
+	if err != nil {
+		return false, err
+	}
+	e.mu.RLock()
+	unchanged := info.ModTime().Equal(e.modTime)
+	e.mu.RUnlock()
+	if unchanged {
+		return false, nil
+	}
+	rules, err := readBlocklist(e.blocklistFile)
+	if err != nil {
+		return false, err
+	}
+	e.mu.Lock()
+	e.fileRules = rules
+	e.modTime = info.ModTime()
+	e.mu.Unlock()
+	return true, nil
+}
+
+// Watch - проверка файла блок-листа раз в interval до завершения контекста.
+// После обновления правил вызывается onChange.
+func (e *Engine) Watch(ctx context.Context, interval time.Duration, onChange func()) {
+	if e.blocklistFile == "" {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			changed, err := e.Reload()
+			if err != nil {
+				log.Printf("Error while reloading blocklist: %v\n", err)
+				continue
+			}
+			if changed {
+				log.Println("Blocklist reloaded")
+				onChange()
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// readBlocklist - чтение файла блок-листа. Каждая строка - домен или
+// регулярное выражение с префиксом "re:", строки с "#" - комментарии.
+func readBlocklist(fileName string) (Rules, error) {
+	rules := Rules{}
+	file, err := os.Open(fileName)
+	if err != nil {
+		return rules, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "" || strings.HasPrefix(line, "#"):
+			continue
+		case strings.HasPrefix(line, regexPrefix):
+			re, err := regexp.Compile(strings.TrimPrefix(line, regexPrefix))
+			if err != nil {
+				return rules, err
+			}
+			rules.DenyPatterns = append(rules.DenyPatterns, re)
+		default:
+			rules.DenyDomains = append(rules.DenyDomains, strings.ToLower(line))
+		}
+	}
+	return rules, scanner.Err()
+}
+
+// normalizeRules - приведение доменов правил к нижнему регистру.
+func normalizeRules(rules Rules) Rules {
+	for i, domain := range rules.AllowDomains {
+		rules.AllowDomains[i] = strings.ToLower(strings.TrimSpace(domain))
+	}
+	for i, domain := range rules.DenyDomains {
+		rules.DenyDomains[i] = strings.ToLower(strings.TrimSpace(domain))
+	}
+	return rules
+}
+
+// matchDomain - совпадает ли хост с одним из доменов или их поддоменами.
+func matchDomain(host string, domains []string) bool {
+	for _, domain := range domains {
+		if domain != "" && (host == domain || strings.HasSuffix(host, "."+domain)) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsPrivate - относится ли адрес к частным, локальным или служебным.
+func IsPrivate(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast()
+}
@@ -0,0 +1,148 @@
+package policy
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+)
+
+type staticResolver map[string][]net.IPAddr
+
+func (r staticResolver) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	addrs, ok := r[host]
+	if !ok {
+		return nil, errors.New("no such host")
+	}
+	return addrs, nil
+}
+
+func TestEngine_Check(t *testing.T) {
+	resolver := staticResolver{
+		"internal.example": {{IP: net.ParseIP("10.0.0.5")}},
+		"public.example":   {{IP: net.ParseIP("93.184.216.34")}},
+	}
+	tests := []struct {
+		name         string
+		rules        Rules
+		blockPrivate bool
+		rawURL       string
+		wantBlocked  bool
+	}{
+		{
+			name:   "no rules",
+			rawURL: "http://iloverestaurant.ru/",
+		},
+		{
+			name:        "denied domain",
+			rules:       Rules{DenyDomains: []string{"Phishing.example"}},
+			rawURL:      "http://phishing.example/login",
+			wantBlocked: true,
+		},
+		{
+			name:        "denied subdomain",
+			rules:       Rules{DenyDomains: []string{"phishing.example"}},
+			rawURL:      "http://login.phishing.example/",
+			wantBlocked: true,
+		},
+		{
+			name:   "similar domain is not denied",
+			rules:  Rules{DenyDomains: []string{"phishing.example"}},
+			rawURL: "http://notphishing.example/",
+		},
+		{
+			name:        "not in allow list",
+			rules:       Rules{AllowDomains: []string{"example.com"}},
+			rawURL:      "http://other.org/",
+			wantBlocked: true,
+		},
+		{
+			name:   "in allow list",
+			rules:  Rules{AllowDomains: []string{"example.com"}},
+			rawURL: "http://www.example.com/",
+		},
+		{
+			name:        "regex rule",
+			rules:       Rules{DenyPatterns: []*regexp.Regexp{regexp.MustCompile(`\.exe$`)}},
+			rawURL:      "http://files.example/setup.exe",
+			wantBlocked: true,
+		},
+		{
+			name:         "loopback address",
+			blockPrivate: true,
+			rawURL:       "http://127.0.0.1:8080/admin",
+			wantBlocked:  true,
+		},
+		{
+			name:   "loopback address allowed",
+			rawURL: "http://127.0.0.1:8080/admin",
+		},
+		{
+			name:         "host resolves to private address",
+			blockPrivate: true,
+			rawURL:       "http://internal.example/",
+			wantBlocked:  true,
+		},
+		{
+			name:         "host resolves to public address",
+			blockPrivate: true,
+			rawURL:       "http://public.example/",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine, err := New(tt.rules, tt.blockPrivate, "", resolver)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = engine.Check(context.Background(), tt.rawURL)
+			if blocked := errors.Is(err, ErrBlocked); blocked != tt.wantBlocked {
+				t.Errorf("Check(%q) error = %v, want blocked %v", tt.rawURL, err, tt.wantBlocked)
+			}
+		})
+	}
+}
+
+func TestEngine_Reload(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "blocklist.txt")
+	if err := os.WriteFile(fileName, []byte("# comment\nphishing.example\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	engine, err := New(Rules{}, false, fileName, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := engine.Match("http://malware.example/a.exe"); err != nil {
+		t.Fatalf("unexpected error before reload: %v", err)
+	}
+	if err := engine.Match("http://phishing.example/"); !errors.Is(err, ErrBlocked) {
+		t.Fatalf("expected blocked domain from file, got %v", err)
+	}
+
+	if err := os.WriteFile(fileName, []byte("re:^http://malware\\.example/.*\\.exe$\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	future := time.Now().Add(time.Minute)
+	if err := os.Chtimes(fileName, future, future); err != nil {
+		t.Fatal(err)
+	}
+	changed, err := engine.Reload()
+	if err != nil || !changed {
+		t.Fatalf("Reload() = %v, %v, want true, nil", changed, err)
+	}
+	if err := engine.Match("http://malware.example/a.exe"); !errors.Is(err, ErrBlocked) {
+		t.Errorf("expected blocked pattern after reload, got %v", err)
+	}
+	if err := engine.Match("http://phishing.example/"); err != nil {
+		t.Errorf("expected removed domain to be allowed, got %v", err)
+	}
+
+	changed, err = engine.Reload()
+	if err != nil || changed {
+		t.Errorf("second Reload() = %v, %v, want false, nil", changed, err)
+	}
+}
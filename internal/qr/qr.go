@@ -0,0 +1,211 @@
+// Package qr - пакет для построения QR кодов коротких ссылок в форматах PNG
+// и SVG.
+package qr
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"path"
+	"strconv"
+	"strings"
+
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+// Форматы изображения QR кода.
+const (
+	FormatPNG = "png"
+	FormatSVG = "svg"
+)
+
+// Ограничения и значения по умолчанию параметров QR кода. Size - сторона
+// изображения в пикселях, Margin - ширина пустого поля вокруг кода в
+// модулях.
+const (
+	DefaultSize   = 256
+	MinSize       = 64
+	MaxSize       = 2048
+	DefaultMargin = 4
+	MaxMargin     = 16
+	DefaultLevel  = "M"
+)
+
+// levels - уровни коррекции ошибок: L - 7%, M - 15%, Q - 25%, H - 30%.
+var levels = map[string]qrcode.RecoveryLevel{
+	"L": qrcode.Low,
+	"M": qrcode.Medium,
+	"Q": qrcode.High,
+	"H": qrcode.Highest,
+}
+
+var (
+	// ErrUnknownFormat - формат изображения не поддерживается.
+	ErrUnknownFormat = errors.New("format must be png or svg")
+	// ErrInvalidSize - размер изображения вне диапазона.
+	ErrInvalidSize = fmt.Errorf("size must be from %d to %d", MinSize, MaxSize)
+	// ErrInvalidMargin - ширина поля вне диапазона.
+	ErrInvalidMargin = fmt.Errorf("margin must be from 0 to %d", MaxMargin)
+	// ErrInvalidLevel - неизвестный уровень коррекции ошибок.
+	ErrInvalidLevel = errors.New("level must be L, M, Q or H")
+	// ErrTooSmall - в изображении заданного размера модуль кода меньше
+	// пикселя.
+	ErrTooSmall = errors.New("size is too small for this code")
+)
+
+// Options - параметры QR кода.
+type Options struct {
+	Size   int
+	Margin int
+	Level  string
+}
+
+// DefaultOptions - параметры QR кода по умолчанию.
+func DefaultOptions() Options {
+	return Options{Size: DefaultSize, Margin: DefaultMargin, Level: DefaultLevel}
+}
+
+// ParseOptions - разбор параметров QR кода в том виде, в каком они приходят
+// в запросе. Пустые значения заменяются значениями по умолчанию.
+func ParseOptions(size string, margin string, level string) (Options, error) {
+	o := DefaultOptions()
+	var err error
+	if size != "" {
+		if o.Size, err = strconv.Atoi(size); err != nil {
+			return Options{}, ErrInvalidSize
+		}
+	}
+	if margin != "" {
+		if o.Margin, err = strconv.Atoi(margin); err != nil {
+			return Options{}, ErrInvalidMargin
+		}
+	}
+	if level != "" {
+		o.Level = strings.ToUpper(level)
+	}
+	return o, o.Validate()
+}
+
+// Validate - проверка параметров QR кода.
+func (o Options) Validate() error {
+	if o.Size < MinSize || o.Size > MaxSize {
+		return ErrInvalidSize
+	}
+	if o.Margin < 0 || o.Margin > MaxMargin {
+		return ErrInvalidMargin
+	}
+	if _, ok := levels[o.Level]; !ok {
+		return ErrInvalidLevel
+	}
+	return nil
+}
+
+// ParseFile - id ссылки и формат из имени файла вида <id>.png или
+// <id>.svg.
+func ParseFile(file string) (string, string, error) {
+	ext := path.Ext(file)
+	id := strings.TrimSuffix(file, ext)
+	format := strings.ToLower(strings.TrimPrefix(ext, "."))
+	if id == "" || (format != FormatPNG && format != FormatSVG) {
+		return "", "", ErrUnknownFormat
+	}
+	return id, format, nil
+}
+
+// ContentType - тип содержимого изображения в формате format.
+func ContentType(format string) string {
+	if format == FormatSVG {
+		return "image/svg+xml"
+	}
+	return "image/png"
+}
+
+// Encode - QR код с содержимым content в формате format.
+func Encode(content string, format string, o Options) ([]byte, error) {
+	if err := o.Validate(); err != nil {
+		return nil, err
+	}
+	code, err := qrcode.New(content, levels[o.Level])
+	if err != nil {
+		return nil, err
+	}
+	code.DisableBorder = true
+	modules := code.Bitmap()
+	switch format {
+	case FormatPNG:
+		return encodePNG(modules, o)
+	case FormatSVG:
+		return encodeSVG(modules, o), nil
+	default:
+		return nil, ErrUnknownFormat
+	}
+}
+
+// DataURL - QR код с содержимым content в формате PNG с параметрами по
+// умолчанию в виде data URL.
+func DataURL(content string) (string, error) {
+	data, err := Encode(content, FormatPNG, DefaultOptions())
+	if err != nil {
+		return "", err
+	}
+	return "data:image/png;base64," + base64.StdEncoding.EncodeToString(data), nil
+}
+
+// encodePNG - черно-белое изображение Size на Size пикселей. Модули кода
+// занимают целое число пикселей, остаток делится поровну по краям.
+func encodePNG(modules [][]bool, o Options) ([]byte, error) {
+	total := len(modules) + 2*o.Margin
+	scale := o.Size / total
+	if scale == 0 {
+		return nil, ErrTooSmall
+	}
+	offset := (o.Size-scale*total)/2 + scale*o.Margin
+	img := image.NewPaletted(image.Rect(0, 0, o.Size, o.Size), color.Palette{color.White, color.Black})
+	for y, row := range modules {
+		for x, dark := range row {
+			if !dark {
+				continue
+			}
+			for dy := 0; dy < scale; dy++ {
+				for dx := 0; dx < scale; dx++ {
+					img.SetColorIndex(offset+x*scale+dx, offset+y*scale+dy, 1)
+				}
+			}
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// encodeSVG - изображение Size на Size пикселей, в котором один модуль кода
+// - единица координат. Подряд идущие темные модули строки рисуются одним
+// прямоугольником.
+func encodeSVG(modules [][]bool, o Options) []byte {
+	total := len(modules) + 2*o.Margin
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`,
+		o.Size, o.Size, total, total)
+	fmt.Fprintf(&buf, `<rect width="%d" height="%d" fill="#fff"/><path fill="#000" d="`, total, total)
+	for y, row := range modules {
+		for x := 0; x < len(row); x++ {
+			if !row[x] {
+				continue
+			}
+			start := x
+			for x < len(row) && row[x] {
+				x++
+			}
+			fmt.Fprintf(&buf, "M%d %dh%dv1h-%dz", start+o.Margin, y+o.Margin, x-start, x-start)
+		}
+	}
+	buf.WriteString(`"/></svg>`)
+	buf.WriteString("\n")
+	return buf.Bytes()
+}
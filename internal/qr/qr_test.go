@@ -0,0 +1,125 @@
+package qr
+
+import (
+	"bytes"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		size    string
+		margin  string
+		level   string
+		want    Options
+		wantErr error
+	}{
+		{
+			name: "defaults",
+			want: DefaultOptions(),
+		},
+		{
+			name:   "all options",
+			size:   "512",
+			margin: "0",
+			level:  "h",
+			want:   Options{Size: 512, Margin: 0, Level: "H"},
+		},
+		{
+			name:    "size too small",
+			size:    "10",
+			wantErr: ErrInvalidSize,
+		},
+		{
+			name:    "size not a number",
+			size:    "big",
+			wantErr: ErrInvalidSize,
+		},
+		{
+			name:    "negative margin",
+			margin:  "-1",
+			wantErr: ErrInvalidMargin,
+		},
+		{
+			name:    "unknown level",
+			level:   "X",
+			wantErr: ErrInvalidLevel,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseOptions(tt.size, tt.margin, tt.level)
+			assert.ErrorIs(t, err, tt.wantErr)
+			if tt.wantErr == nil {
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestParseFile(t *testing.T) {
+	id, format, err := ParseFile("abc.png")
+	require.NoError(t, err)
+	assert.Equal(t, "abc", id)
+	assert.Equal(t, FormatPNG, format)
+
+	id, format, err = ParseFile("a.b=.SVG")
+	require.NoError(t, err)
+	assert.Equal(t, "a.b=", id)
+	assert.Equal(t, FormatSVG, format)
+
+	for _, file := range []string{"abc", "abc.gif", ".png"} {
+		_, _, err := ParseFile(file)
+		assert.ErrorIs(t, err, ErrUnknownFormat, file)
+	}
+}
+
+func TestEncodePNG(t *testing.T) {
+	opts := Options{Size: 300, Margin: 4, Level: "M"}
+	data, err := Encode("http://localhost:8080/abc", FormatPNG, opts)
+	require.NoError(t, err)
+	img, err := png.Decode(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Equal(t, 300, img.Bounds().Dx())
+	assert.Equal(t, 300, img.Bounds().Dy())
+
+	// Угол изображения - пустое поле, левый верхний модуль кода - часть
+	// поискового узора и всегда темный.
+	isDark := func(x, y int) bool {
+		r, _, _, _ := img.At(x, y).RGBA()
+		return r == 0
+	}
+	assert.False(t, isDark(0, 0))
+	// Код версии 2 - 25 модулей, с полем 33 модуля по 9 пикселей.
+	offset := (300-9*33)/2 + 9*4
+	assert.True(t, isDark(offset, offset))
+	assert.False(t, isDark(offset-1, offset-1))
+}
+
+func TestEncodeSVG(t *testing.T) {
+	data, err := Encode("http://localhost:8080/abc", FormatSVG, Options{Size: 128, Margin: 2, Level: "L"})
+	require.NoError(t, err)
+	svg := string(data)
+	assert.True(t, strings.HasPrefix(svg, `<svg xmlns="http://www.w3.org/2000/svg" width="128" height="128"`))
+	assert.Contains(t, svg, "M2 2h7v1h-7z")
+}
+
+func TestEncodeErrors(t *testing.T) {
+	_, err := Encode("http://localhost:8080/abc", "gif", DefaultOptions())
+	assert.ErrorIs(t, err, ErrUnknownFormat)
+	_, err = Encode("http://localhost:8080/abc", FormatPNG, Options{Size: 1, Level: "M"})
+	assert.ErrorIs(t, err, ErrInvalidSize)
+	_, err = Encode(strings.Repeat("x", 1000), FormatPNG, Options{Size: MinSize, Margin: MaxMargin, Level: "H"})
+	assert.ErrorIs(t, err, ErrTooSmall)
+}
+
+func TestDataURL(t *testing.T) {
+	dataURL, err := DataURL("http://localhost:8080/abc")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(dataURL, "data:image/png;base64,"))
+}
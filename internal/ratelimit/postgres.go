@@ -0,0 +1,57 @@
+package ratelimit
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// PostgresLimiter - ограничитель, который хранит корзины в таблице
+// rate_limits. Позволяет делить лимиты между несколькими репликами сервиса.
+type PostgresLimiter struct {
+	conn   *sql.DB
+	class  string
+	bucket Bucket
+}
+
+// NewPostgresLimiter - создание PostgresLimiter. Корзины разных классов
+// маршрутов (class) хранятся в одной таблице под разными ключами.
+func NewPostgresLimiter(db *sql.DB, class string, perMinute int) *PostgresLimiter {
+	return &PostgresLimiter{
+		conn:   db,
+		class:  class,
+		bucket: NewBucket(perMinute),
+	}
+}
+
+// Allow - разрешен ли очередной запрос по ключу. Состояние корзины
+// читается и обновляется в одной транзакции с блокировкой строки, время
+// берется из базы данных, чтобы не зависеть от часов реплик.
+func (l *PostgresLimiter) Allow(ctx context.Context, key string) (bool, time.Duration, error) {
+	tx, err := l.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return false, 0, err
+	}
+	defer tx.Rollback()
+
+	key = l.class + ":" + key
+	sqlInsert := `INSERT INTO rate_limits (key, tokens, updated_at) VALUES ($1, $2, now())
+				  ON CONFLICT (key) DO NOTHING;`
+	if _, err := tx.ExecContext(ctx, sqlInsert, key, l.bucket.burst); err != nil {
+		return false, 0, err
+	}
+
+	var tokens, elapsed float64
+	sqlSelect := `SELECT tokens, EXTRACT(EPOCH FROM now() - updated_at) FROM rate_limits
+				  WHERE key=$1 FOR UPDATE;`
+	if err := tx.QueryRowContext(ctx, sqlSelect, key).Scan(&tokens, &elapsed); err != nil {
+		return false, 0, err
+	}
+
+	tokens, allowed, wait := l.bucket.Take(tokens, time.Duration(elapsed*float64(time.Second)))
+	sqlUpdate := `UPDATE rate_limits SET tokens=$2, updated_at=now() WHERE key=$1;`
+	if _, err := tx.ExecContext(ctx, sqlUpdate, key, tokens); err != nil {
+		return false, 0, err
+	}
+	return allowed, wait, tx.Commit()
+}
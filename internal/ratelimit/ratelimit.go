@@ -0,0 +1,114 @@
+// Package ratelimit - пакет для ограничения частоты запросов по алгоритму
+// token bucket.
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// cleanupEvery - раз в сколько вызовов Allow удаляются простаивающие корзины.
+const cleanupEvery = 1024
+
+// Limiter - ограничитель частоты запросов по ключу.
+type Limiter interface {
+	// Allow - разрешен ли очередной запрос по ключу. Если запрос не
+	// разрешен, возвращается время, через которое стоит повторить запрос.
+	Allow(ctx context.Context, key string) (bool, time.Duration, error)
+}
+
+// Bucket - параметры корзины: perMinute запросов в минуту с возможным
+// всплеском до perMinute запросов.
+type Bucket struct {
+	rate  float64
+	burst float64
+}
+
+// NewBucket - создание параметров корзины из лимита запросов в минуту.
+func NewBucket(perMinute int) Bucket {
+	return Bucket{
+		rate:  float64(perMinute) / 60,
+		burst: float64(perMinute),
+	}
+}
+
+// Take - пополнение корзины за прошедшее время elapsed и попытка взять
+// токен. Возвращает новое количество токенов, разрешен ли запрос и время
+// до появления следующего токена.
+func (b Bucket) Take(tokens float64, elapsed time.Duration) (float64, bool, time.Duration) {
+	tokens = math.Min(b.burst, tokens+elapsed.Seconds()*b.rate)
+	if tokens >= 1 {
+		return tokens - 1, true, 0
+	}
+	wait := time.Duration((1 - tokens) / b.rate * float64(time.Second))
+	return tokens, false, wait
+}
+
+// idle - время, за которое пустая корзина заполняется полностью.
+func (b Bucket) idle() time.Duration {
+	return time.Duration(b.burst / b.rate * float64(time.Second))
+}
+
+// bucketState - состояние корзины одного ключа.
+type bucketState struct {
+	tokens  float64
+	updated time.Time
+}
+
+// MemoryLimiter - ограничитель, который хранит корзины в памяти процесса.
+type MemoryLimiter struct {
+	mu      sync.Mutex
+	bucket  Bucket
+	buckets map[string]*bucketState
+	calls   int
+	now     func() time.Time
+}
+
+// NewMemoryLimiter - создание MemoryLimiter с лимитом perMinute запросов
+// в минуту на ключ.
+func NewMemoryLimiter(perMinute int) *MemoryLimiter {
+	return &MemoryLimiter{
+		bucket:  NewBucket(perMinute),
+		buckets: map[string]*bucketState{},
+		now:     time.Now,
+	}
+}
+
+// Allow - разрешен ли очередной запрос по ключу.
+func (l *MemoryLimiter) Allow(ctx context.Context, key string) (bool, time.Duration, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.calls++
+	if l.calls%cleanupEvery == 0 {
+		l.cleanup(now)
+	}
+	state, ok := l.buckets[key]
+	if !ok {
+		state = &bucketState{tokens: l.bucket.burst, updated: now}
+		l.buckets[key] = state
+	}
+	tokens, allowed, wait := l.bucket.Take(state.tokens, now.Sub(state.updated))
+	state.tokens = tokens
+	state.updated = now
+	return allowed, wait, nil
+}
+
+// cleanup - удаление корзин, которые успели заполниться полностью.
+func (l *MemoryLimiter) cleanup(now time.Time) {
+	idle := l.bucket.idle()
+	for key, state := range l.buckets {
+		if now.Sub(state.updated) > idle {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// RetryAfter - значение заголовка Retry-After в целых секундах, не меньше 1.
+func RetryAfter(wait time.Duration) string {
+	return strconv.Itoa(int(math.Max(1, math.Ceil(wait.Seconds()))))
+}
@@ -0,0 +1,54 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryLimiter_Allow(t *testing.T) {
+	now := time.Date(2022, 4, 1, 12, 0, 0, 0, time.UTC)
+	limiter := NewMemoryLimiter(2)
+	limiter.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if allowed, _, _ := limiter.Allow(ctx, "user:1"); !allowed {
+			t.Fatalf("request %d within burst is not allowed", i)
+		}
+	}
+	allowed, wait, err := limiter.Allow(ctx, "user:1")
+	if err != nil || allowed {
+		t.Fatalf("Allow() = %v, %v, want false, nil", allowed, err)
+	}
+	if wait != 30*time.Second {
+		t.Errorf("wait = %v, want 30s", wait)
+	}
+	if allowed, _, _ := limiter.Allow(ctx, "user:2"); !allowed {
+		t.Error("other key is limited")
+	}
+
+	now = now.Add(30 * time.Second)
+	if allowed, _, _ := limiter.Allow(ctx, "user:1"); !allowed {
+		t.Error("request after refill is not allowed")
+	}
+	if allowed, _, _ := limiter.Allow(ctx, "user:1"); allowed {
+		t.Error("refill is more than one token")
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		wait time.Duration
+		want string
+	}{
+		{wait: 0, want: "1"},
+		{wait: 1500 * time.Millisecond, want: "2"},
+		{wait: 30 * time.Second, want: "30"},
+	}
+	for _, tt := range tests {
+		if got := RetryAfter(tt.wait); got != tt.want {
+			t.Errorf("RetryAfter(%v) = %v, want %v", tt.wait, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package redirect
+
+import (
+	"net"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Платформы посетителя, которые определяются по User-Agent.
+const (
+	PlatformIOS     = "ios"
+	PlatformAndroid = "android"
+	PlatformWindows = "windows"
+	PlatformMacOS   = "macos"
+	PlatformLinux   = "linux"
+)
+
+// Типы устройств посетителя, которые определяются по User-Agent.
+const (
+	DeviceMobile  = "mobile"
+	DeviceTablet  = "tablet"
+	DeviceDesktop = "desktop"
+)
+
+// Visit - запрос перехода по ссылке: параметры запроса, заголовки
+// User-Agent и Accept-Language, IP адрес посетителя, его постоянный
+// идентификатор Visitor, по которому посетитель закрепляется за адресом
+// распределения, и введенный пароль ссылки Password.
+type Visit struct {
+	Query          url.Values
+	UserAgent      string
+	AcceptLanguage string
+	IP             net.IP
+	Visitor        string
+	Password       string
+}
+
+// Client - посетитель, по которому выбирается правило перехода. Пустые
+// поля - значение не удалось определить.
+type Client struct {
+	// ID - идентификатор посетителя для выбора адреса распределения,
+	// пустой - адрес выбирается случайно.
+	ID       string
+	Platform string
+	Device   string
+	// Country - код страны ISO 3166-1 alpha-2 в верхнем регистре.
+	Country string
+	// Language - предпочтительный язык из Accept-Language в нижнем
+	// регистре, например en-us.
+	Language string
+}
+
+// ParseClient - платформа и тип устройства по заголовку User-Agent и
+// предпочтительный язык по заголовку Accept-Language.
+func ParseClient(userAgent string, acceptLanguage string) Client {
+	client := Client{}
+	client.Platform, client.Device = parseUserAgent(userAgent)
+	if languages := ParseAcceptLanguage(acceptLanguage); len(languages) > 0 {
+		client.Language = languages[0]
+	}
+	return client
+}
+
+// parseUserAgent - платформа и тип устройства по User-Agent.
+func parseUserAgent(userAgent string) (string, string) {
+	ua := strings.ToLower(userAgent)
+	switch {
+	case strings.Contains(ua, "ipad"):
+		return PlatformIOS, DeviceTablet
+	case strings.Contains(ua, "iphone"), strings.Contains(ua, "ipod"):
+		return PlatformIOS, DeviceMobile
+	case strings.Contains(ua, "android"):
+		if strings.Contains(ua, "mobile") {
+			return PlatformAndroid, DeviceMobile
+		}
+		return PlatformAndroid, DeviceTablet
+	case strings.Contains(ua, "windows phone"):
+		return PlatformWindows, DeviceMobile
+	case strings.Contains(ua, "windows"):
+		return PlatformWindows, DeviceDesktop
+	case strings.Contains(ua, "macintosh"), strings.Contains(ua, "mac os x"):
+		return PlatformMacOS, DeviceDesktop
+	case strings.Contains(ua, "linux"), strings.Contains(ua, "x11"):
+		return PlatformLinux, DeviceDesktop
+	}
+	return "", ""
+}
+
+// ParseAcceptLanguage - языки из заголовка Accept-Language в нижнем
+// регистре по убыванию веса. Языки с весом 0 и "*" пропускаются.
+func ParseAcceptLanguage(header string) []string {
+	type language struct {
+		tag    string
+		weight float64
+	}
+	var languages []language
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(part, ";")
+		tag := strings.ToLower(strings.TrimSpace(fields[0]))
+		if tag == "" || tag == "*" {
+			continue
+		}
+		weight := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if !strings.HasPrefix(param, "q=") {
+				continue
+			}
+			q, err := strconv.ParseFloat(strings.TrimPrefix(param, "q="), 64)
+			if err == nil {
+				weight = q
+			}
+		}
+		if weight <= 0 {
+			continue
+		}
+		languages = append(languages, language{tag: tag, weight: weight})
+	}
+	sort.SliceStable(languages, func(i, j int) bool {
+		return languages[i].weight > languages[j].weight
+	})
+	result := make([]string, 0, len(languages))
+	for _, l := range languages {
+		result = append(result, l.tag)
+	}
+	return result
+}
@@ -0,0 +1,69 @@
+package redirect
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseClient(t *testing.T) {
+	tests := []struct {
+		name      string
+		userAgent string
+		want      Client
+	}{
+		{
+			name:      "iphone",
+			userAgent: "Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) AppleWebKit/605.1.15 Mobile/15E148",
+			want:      Client{Platform: PlatformIOS, Device: DeviceMobile},
+		},
+		{
+			name:      "ipad",
+			userAgent: "Mozilla/5.0 (iPad; CPU OS 17_0 like Mac OS X) AppleWebKit/605.1.15",
+			want:      Client{Platform: PlatformIOS, Device: DeviceTablet},
+		},
+		{
+			name:      "android phone",
+			userAgent: "Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 Chrome/120.0 Mobile Safari/537.36",
+			want:      Client{Platform: PlatformAndroid, Device: DeviceMobile},
+		},
+		{
+			name:      "android tablet",
+			userAgent: "Mozilla/5.0 (Linux; Android 13; SM-X200) AppleWebKit/537.36 Chrome/120.0 Safari/537.36",
+			want:      Client{Platform: PlatformAndroid, Device: DeviceTablet},
+		},
+		{
+			name:      "windows",
+			userAgent: "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 Chrome/120.0 Safari/537.36",
+			want:      Client{Platform: PlatformWindows, Device: DeviceDesktop},
+		},
+		{
+			name:      "macos",
+			userAgent: "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 Safari/605.1.15",
+			want:      Client{Platform: PlatformMacOS, Device: DeviceDesktop},
+		},
+		{
+			name:      "linux",
+			userAgent: "Mozilla/5.0 (X11; Linux x86_64; rv:120.0) Gecko/20100101 Firefox/120.0",
+			want:      Client{Platform: PlatformLinux, Device: DeviceDesktop},
+		},
+		{
+			name:      "unknown",
+			userAgent: "curl/8.0",
+			want:      Client{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ParseClient(tt.userAgent, ""))
+		})
+	}
+}
+
+func TestParseAcceptLanguage(t *testing.T) {
+	assert.Equal(t, []string{"fr-ch", "fr", "en", "de"},
+		ParseAcceptLanguage("fr-CH, fr;q=0.9, en;q=0.8, de;q=0.7, *;q=0.5"))
+	assert.Equal(t, []string{"de", "en"}, ParseAcceptLanguage("en;q=0.5, ru;q=0, de"))
+	assert.Empty(t, ParseAcceptLanguage(""))
+	assert.Equal(t, "de", ParseClient("", "en;q=0.5, de").Language)
+}
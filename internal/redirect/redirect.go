@@ -0,0 +1,450 @@
+// Package redirect - настройки перехода по короткой ссылке: код ответа,
+// передача параметров запроса в адрес назначения, UTM метки, переход
+// через промежуточную страницу без передачи Referer, правила выбора
+// адреса назначения по платформе, устройству, стране и языку посетителя
+// и распределение посетителей между несколькими адресами по весам.
+package redirect
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// Режимы перехода. ModeHTTP - перенаправление кодом 3xx, ModeMeta и ModeJS -
+// страница с переходом через meta refresh или JavaScript, по которой
+// адрес короткой ссылки не передается в заголовке Referer.
+const (
+	ModeHTTP = "http"
+	ModeMeta = "meta"
+	ModeJS   = "js"
+)
+
+// DefaultCode - код ответа перенаправления по умолчанию.
+const DefaultCode = http.StatusTemporaryRedirect
+
+// MaxUTMLength - максимальная длина значения UTM метки.
+const MaxUTMLength = 200
+
+// MaxRules - максимальное количество правил перехода у ссылки.
+const MaxRules = 20
+
+// MaxDestinations - максимальное количество адресов в распределении.
+const MaxDestinations = 10
+
+// MaxWeight - максимальный вес адреса в распределении.
+const MaxWeight = 1000
+
+var (
+	// ErrInvalidCode - код перенаправления не 301, 302, 307 или 308.
+	ErrInvalidCode = errors.New("redirect code must be 301, 302, 307 or 308")
+	// ErrUnknownMode - неизвестный режим перехода.
+	ErrUnknownMode = errors.New("redirect mode must be http, meta or js")
+	// ErrUTMTooLong - слишком длинное значение UTM метки.
+	ErrUTMTooLong = errors.New("utm value is too long")
+	// ErrTooManyRules - правил перехода больше MaxRules.
+	ErrTooManyRules = fmt.Errorf("more than %d redirect rules", MaxRules)
+	// ErrRuleURL - у правила нет адреса назначения.
+	ErrRuleURL = errors.New("rule url is required")
+	// ErrRuleConditions - у правила нет ни одного условия.
+	ErrRuleConditions = errors.New("rule must have at least one condition")
+	// ErrUnknownPlatform - неизвестная платформа в правиле.
+	ErrUnknownPlatform = errors.New("platform must be ios, android, windows, macos or linux")
+	// ErrUnknownDevice - неизвестный тип устройства в правиле.
+	ErrUnknownDevice = errors.New("device must be mobile, tablet or desktop")
+	// ErrInvalidCountry - код страны в правиле не из двух латинских букв.
+	ErrInvalidCountry = errors.New("country must be an ISO 3166-1 alpha-2 code")
+	// ErrInvalidLanguage - некорректный тег языка в правиле.
+	ErrInvalidLanguage = errors.New("language must be a language tag like en or en-US")
+	// ErrTooFewDestinations - в распределении меньше двух адресов.
+	ErrTooFewDestinations = errors.New("split needs at least 2 destinations")
+	// ErrTooManyDestinations - адресов в распределении больше
+	// MaxDestinations.
+	ErrTooManyDestinations = fmt.Errorf("more than %d split destinations", MaxDestinations)
+	// ErrDestinationURL - у адреса распределения нет адреса назначения.
+	ErrDestinationURL = errors.New("destination url is required")
+	// ErrDuplicateDestination - адрес в распределении повторяется.
+	ErrDuplicateDestination = errors.New("destination url is duplicated")
+	// ErrInvalidWeight - вес адреса вне диапазона от 1 до MaxWeight.
+	ErrInvalidWeight = fmt.Errorf("destination weight must be from 1 to %d", MaxWeight)
+)
+
+// languagePattern - тег языка: код языка и необязательные подтеги.
+var languagePattern = regexp.MustCompile(`^[a-z]{2,3}(-[a-z0-9]{1,8})*$`)
+
+// UTM - метки, которые добавляются к адресу назначения как параметры
+// utm_source, utm_medium, utm_campaign, utm_term и utm_content.
+type UTM struct {
+	Source   string `json:"source,omitempty"`
+	Medium   string `json:"medium,omitempty"`
+	Campaign string `json:"campaign,omitempty"`
+	Term     string `json:"term,omitempty"`
+	Content  string `json:"content,omitempty"`
+}
+
+// params - параметры запроса меток в постоянном порядке.
+func (u UTM) params() [][2]string {
+	return [][2]string{
+		{"utm_source", u.Source},
+		{"utm_medium", u.Medium},
+		{"utm_campaign", u.Campaign},
+		{"utm_term", u.Term},
+		{"utm_content", u.Content},
+	}
+}
+
+// Settings - настройки перехода по ссылке. Нулевое значение - переход
+// кодом DefaultCode без изменения адреса назначения.
+type Settings struct {
+	// Code - код ответа перенаправления, 0 - DefaultCode.
+	Code int `json:"code,omitempty"`
+	// Mode - режим перехода, пустой - ModeHTTP.
+	Mode string `json:"mode,omitempty"`
+	// Passthrough - добавлять параметры запроса к короткой ссылке в адрес
+	// назначения.
+	Passthrough bool `json:"passthrough,omitempty"`
+	// UTM - метки, которые добавляются в адрес назначения, если в нем или
+	// в переданных параметрах запроса их еще нет.
+	UTM *UTM `json:"utm,omitempty"`
+	// Rules - правила выбора адреса назначения. Применяется первое
+	// подходящее правило, если подходящих нет - адрес назначения ссылки.
+	Rules []Rule `json:"rules,omitempty"`
+	// Destinations - адреса назначения для A/B теста. Посетители, для
+	// которых не подошло ни одно правило, распределяются между ними по
+	// весам вместо адреса назначения ссылки.
+	Destinations []Destination `json:"destinations,omitempty"`
+}
+
+// Destination - адрес назначения в распределении и его вес: доля
+// посетителей адреса равна его весу, деленному на сумму весов.
+type Destination struct {
+	URL    string `json:"url"`
+	Weight int    `json:"weight,omitempty"`
+}
+
+// Rule - правило перехода: адрес назначения для посетителей, которые
+// подходят под все заданные условия. В каждом условии достаточно
+// совпадения с одним из значений.
+type Rule struct {
+	URL       string   `json:"url"`
+	Platforms []string `json:"platforms,omitempty"`
+	Devices   []string `json:"devices,omitempty"`
+	Countries []string `json:"countries,omitempty"`
+	// Languages - теги языков. Тег без региона, например en, подходит и
+	// для языков с регионом, например en-us.
+	Languages []string `json:"languages,omitempty"`
+}
+
+// Match - посетитель client подходит под правило.
+func (r Rule) Match(client Client) bool {
+	if len(r.Platforms) > 0 && !contains(r.Platforms, client.Platform) {
+		return false
+	}
+	if len(r.Devices) > 0 && !contains(r.Devices, client.Device) {
+		return false
+	}
+	if len(r.Countries) > 0 && !contains(r.Countries, client.Country) {
+		return false
+	}
+	if len(r.Languages) > 0 {
+		matched := false
+		for _, language := range r.Languages {
+			if client.Language != "" && (client.Language == language || strings.HasPrefix(client.Language, language+"-")) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// clean - правило с адресом без пробелов по краям, платформами,
+// устройствами и языками в нижнем регистре и странами в верхнем.
+func (r Rule) clean() Rule {
+	return Rule{
+		URL:       strings.TrimSpace(r.URL),
+		Platforms: cleanValues(r.Platforms, strings.ToLower),
+		Devices:   cleanValues(r.Devices, strings.ToLower),
+		Countries: cleanValues(r.Countries, strings.ToUpper),
+		Languages: cleanValues(r.Languages, strings.ToLower),
+	}
+}
+
+// validate - проверка адреса и условий правила.
+func (r Rule) validate() error {
+	if r.URL == "" {
+		return ErrRuleURL
+	}
+	if len(r.Platforms)+len(r.Devices)+len(r.Countries)+len(r.Languages) == 0 {
+		return ErrRuleConditions
+	}
+	for _, platform := range r.Platforms {
+		switch platform {
+		case PlatformIOS, PlatformAndroid, PlatformWindows, PlatformMacOS, PlatformLinux:
+		default:
+			return ErrUnknownPlatform
+		}
+	}
+	for _, device := range r.Devices {
+		switch device {
+		case DeviceMobile, DeviceTablet, DeviceDesktop:
+		default:
+			return ErrUnknownDevice
+		}
+	}
+	for _, country := range r.Countries {
+		if len(country) != 2 || country[0] < 'A' || country[0] > 'Z' || country[1] < 'A' || country[1] > 'Z' {
+			return ErrInvalidCountry
+		}
+	}
+	for _, language := range r.Languages {
+		if !languagePattern.MatchString(language) {
+			return ErrInvalidLanguage
+		}
+	}
+	return nil
+}
+
+// Target - результат перехода по ссылке: итоговый адрес, код ответа и
+// режим. Destination - выбранный для посетителя адрес распределения,
+// пустой, если распределение не применялось.
+type Target struct {
+	URL         string
+	Code        int
+	Mode        string
+	Destination string
+}
+
+// Clean - настройки с приведенным к нижнему регистру режимом, UTM метками
+// без пробелов по краям, очищенными условиями правил и адресами
+// распределения без пробелов по краям. Пустые метки убираются, вес 0
+// заменяется на 1.
+func (s Settings) Clean() Settings {
+	s.Mode = strings.ToLower(strings.TrimSpace(s.Mode))
+	if s.Mode == ModeHTTP {
+		s.Mode = ""
+	}
+	if s.UTM != nil {
+		utm := UTM{
+			Source:   strings.TrimSpace(s.UTM.Source),
+			Medium:   strings.TrimSpace(s.UTM.Medium),
+			Campaign: strings.TrimSpace(s.UTM.Campaign),
+			Term:     strings.TrimSpace(s.UTM.Term),
+			Content:  strings.TrimSpace(s.UTM.Content),
+		}
+		s.UTM = &utm
+		if utm == (UTM{}) {
+			s.UTM = nil
+		}
+	}
+	var rules []Rule
+	for _, rule := range s.Rules {
+		rules = append(rules, rule.clean())
+	}
+	s.Rules = rules
+	var destinations []Destination
+	for _, destination := range s.Destinations {
+		destination.URL = strings.TrimSpace(destination.URL)
+		if destination.Weight == 0 {
+			destination.Weight = 1
+		}
+		destinations = append(destinations, destination)
+	}
+	s.Destinations = destinations
+	return s
+}
+
+// Validate - проверка кода ответа, режима, длины UTM меток, правил и
+// адресов распределения.
+func (s Settings) Validate() error {
+	switch s.Code {
+	case 0, http.StatusMovedPermanently, http.StatusFound, http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+	default:
+		return ErrInvalidCode
+	}
+	switch s.Mode {
+	case "", ModeHTTP, ModeMeta, ModeJS:
+	default:
+		return ErrUnknownMode
+	}
+	if s.UTM != nil {
+		for _, param := range s.UTM.params() {
+			if utf8.RuneCountInString(param[1]) > MaxUTMLength {
+				return ErrUTMTooLong
+			}
+		}
+	}
+	if len(s.Rules) > MaxRules {
+		return ErrTooManyRules
+	}
+	for i, rule := range s.Rules {
+		if err := rule.validate(); err != nil {
+			return fmt.Errorf("rule %d: %w", i+1, err)
+		}
+	}
+	if len(s.Destinations) == 0 {
+		return nil
+	}
+	if len(s.Destinations) < 2 {
+		return ErrTooFewDestinations
+	}
+	if len(s.Destinations) > MaxDestinations {
+		return ErrTooManyDestinations
+	}
+	seen := map[string]bool{}
+	for i, destination := range s.Destinations {
+		var err error
+		switch {
+		case destination.URL == "":
+			err = ErrDestinationURL
+		case seen[destination.URL]:
+			err = ErrDuplicateDestination
+		case destination.Weight < 1 || destination.Weight > MaxWeight:
+			err = ErrInvalidWeight
+		}
+		if err != nil {
+			return fmt.Errorf("destination %d: %w", i+1, err)
+		}
+		seen[destination.URL] = true
+	}
+	return nil
+}
+
+// IsZero - настройки по умолчанию.
+func (s Settings) IsZero() bool {
+	return s.Code == 0 && (s.Mode == "" || s.Mode == ModeHTTP) && !s.Passthrough && s.UTM == nil && len(s.Rules) == 0 &&
+		len(s.Destinations) == 0
+}
+
+// Target - переход по ссылке с адресом назначения long и параметрами
+// запроса query для посетителя client. Адрес назначения заменяется
+// адресом первого подходящего правила, а если подходящих нет - адресом
+// распределения, выбранным для посетителя.
+func (s Settings) Target(long string, query url.Values, client Client) Target {
+	matched := false
+	for _, rule := range s.Rules {
+		if rule.Match(client) {
+			long = rule.URL
+			matched = true
+			break
+		}
+	}
+	var destination string
+	if !matched && len(s.Destinations) > 0 {
+		destination = s.pick(client.ID)
+		long = destination
+	}
+	target := Target{URL: s.Destination(long, query), Code: s.Code, Mode: s.Mode, Destination: destination}
+	if target.Code == 0 {
+		target.Code = DefaultCode
+	}
+	if target.Mode == "" {
+		target.Mode = ModeHTTP
+	}
+	return target
+}
+
+// Destination - адрес назначения long с UTM метками и, если включен
+// Passthrough, параметрами запроса query. Параметры добавляются после
+// уже имеющихся в long, которые не меняются. Метка не добавляется, если
+// параметр с таким именем уже есть в long или в переданных параметрах.
+// Адрес, который не удается разобрать, возвращается без изменений.
+func (s Settings) Destination(long string, query url.Values) string {
+	if !s.Passthrough {
+		query = nil
+	}
+	if s.UTM == nil && len(query) == 0 {
+		return long
+	}
+	u, err := url.Parse(long)
+	if err != nil {
+		return long
+	}
+	existing := u.Query()
+	var extra []string
+	if s.UTM != nil {
+		for _, param := range s.UTM.params() {
+			if param[1] == "" {
+				continue
+			}
+			if _, ok := existing[param[0]]; ok {
+				continue
+			}
+			if _, ok := query[param[0]]; ok {
+				continue
+			}
+			extra = append(extra, param[0]+"="+url.QueryEscape(param[1]))
+		}
+	}
+	if len(query) > 0 {
+		extra = append(extra, query.Encode())
+	}
+	if len(extra) == 0 {
+		return long
+	}
+	if u.RawQuery != "" {
+		extra = append([]string{u.RawQuery}, extra...)
+	}
+	u.RawQuery = strings.Join(extra, "&")
+	u.ForceQuery = false
+	return u.String()
+}
+
+// pick - адрес распределения для посетителя с идентификатором id. Один и
+// тот же посетитель получает один и тот же адрес, пока не меняются адреса
+// и веса. Посетитель без идентификатора получает случайный адрес.
+func (s Settings) pick(id string) string {
+	total := 0
+	for _, destination := range s.Destinations {
+		total += destination.Weight
+	}
+	if total <= 0 {
+		return s.Destinations[0].URL
+	}
+	var point int
+	if id == "" {
+		point = rand.Intn(total)
+	} else {
+		h := fnv.New64a()
+		h.Write([]byte(id))
+		point = int(h.Sum64() % uint64(total))
+	}
+	for _, destination := range s.Destinations {
+		if point < destination.Weight {
+			return destination.URL
+		}
+		point -= destination.Weight
+	}
+	return s.Destinations[len(s.Destinations)-1].URL
+}
+
+// contains - values содержит value.
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// cleanValues - непустые значения values без пробелов по краям,
+// преобразованные normalize.
+func cleanValues(values []string, normalize func(string) string) []string {
+	var result []string
+	for _, value := range values {
+		if value = normalize(strings.TrimSpace(value)); value != "" {
+			result = append(result, value)
+		}
+	}
+	return result
+}
@@ -0,0 +1,315 @@
+package redirect
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSettings_Destination(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings Settings
+		long     string
+		query    url.Values
+		want     string
+	}{
+		{
+			name:  "defaults drop query",
+			long:  "https://example.com/docs?b=2&a=1",
+			query: url.Values{"x": {"1"}},
+			want:  "https://example.com/docs?b=2&a=1",
+		},
+		{
+			name:     "passthrough appends query",
+			settings: Settings{Passthrough: true},
+			long:     "https://example.com/docs?b=2&a=1#top",
+			query:    url.Values{"x": {"1 2"}, "a": {"3"}},
+			want:     "https://example.com/docs?b=2&a=1&a=3&x=1+2#top",
+		},
+		{
+			name:     "passthrough without query",
+			settings: Settings{Passthrough: true},
+			long:     "https://example.com/docs",
+			want:     "https://example.com/docs",
+		},
+		{
+			name:     "utm injection",
+			settings: Settings{UTM: &UTM{Source: "news letter", Campaign: "fall"}},
+			long:     "https://example.com/docs",
+			want:     "https://example.com/docs?utm_source=news+letter&utm_campaign=fall",
+		},
+		{
+			name:     "utm keeps existing and incoming values",
+			settings: Settings{Passthrough: true, UTM: &UTM{Source: "site", Medium: "email", Campaign: "fall"}},
+			long:     "https://example.com/docs?utm_source=partner",
+			query:    url.Values{"utm_medium": {"social"}},
+			want:     "https://example.com/docs?utm_source=partner&utm_campaign=fall&utm_medium=social",
+		},
+		{
+			name:     "unparsable destination",
+			settings: Settings{UTM: &UTM{Source: "site"}},
+			long:     "http://[::1",
+			want:     "http://[::1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.settings.Destination(tt.long, tt.query))
+		})
+	}
+}
+
+func TestSettings_Target(t *testing.T) {
+	target := Settings{}.Target("https://example.com/", nil, Client{})
+	assert.Equal(t, Target{URL: "https://example.com/", Code: DefaultCode, Mode: ModeHTTP}, target)
+
+	target = Settings{Code: http.StatusMovedPermanently, Mode: ModeMeta}.Target("https://example.com/", nil, Client{})
+	assert.Equal(t, Target{URL: "https://example.com/", Code: http.StatusMovedPermanently, Mode: ModeMeta}, target)
+
+	rules := Settings{Rules: []Rule{
+		{URL: "https://example.com/ios", Platforms: []string{PlatformIOS}},
+		{URL: "https://example.com/de", Countries: []string{"DE"}, Languages: []string{"de"}},
+	}}
+	target = rules.Target("https://example.com/", nil, Client{Platform: PlatformIOS, Country: "DE", Language: "de-de"})
+	assert.Equal(t, "https://example.com/ios", target.URL)
+	target = rules.Target("https://example.com/", nil, Client{Platform: PlatformAndroid, Country: "DE", Language: "de-de"})
+	assert.Equal(t, "https://example.com/de", target.URL)
+	target = rules.Target("https://example.com/", nil, Client{Platform: PlatformAndroid, Country: "AT", Language: "de-de"})
+	assert.Equal(t, "https://example.com/", target.URL)
+}
+
+func TestRule_Match(t *testing.T) {
+	tests := []struct {
+		name   string
+		rule   Rule
+		client Client
+		want   bool
+	}{
+		{
+			name:   "any of platforms",
+			rule:   Rule{Platforms: []string{PlatformIOS, PlatformAndroid}},
+			client: Client{Platform: PlatformAndroid, Device: DeviceMobile},
+			want:   true,
+		},
+		{
+			name:   "all conditions required",
+			rule:   Rule{Platforms: []string{PlatformAndroid}, Devices: []string{DeviceTablet}},
+			client: Client{Platform: PlatformAndroid, Device: DeviceMobile},
+			want:   false,
+		},
+		{
+			name:   "language prefix",
+			rule:   Rule{Languages: []string{"en"}},
+			client: Client{Language: "en-us"},
+			want:   true,
+		},
+		{
+			name:   "language with region",
+			rule:   Rule{Languages: []string{"en-gb"}},
+			client: Client{Language: "en-us"},
+			want:   false,
+		},
+		{
+			name:   "unknown country",
+			rule:   Rule{Countries: []string{"US"}},
+			client: Client{},
+			want:   false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.rule.Match(tt.client))
+		})
+	}
+}
+
+func TestSettings_Validate(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings Settings
+		wantErr  error
+	}{
+		{
+			name: "defaults",
+		},
+		{
+			name:     "permanent redirect in js mode",
+			settings: Settings{Code: http.StatusPermanentRedirect, Mode: ModeJS},
+		},
+		{
+			name:     "not a redirect code",
+			settings: Settings{Code: http.StatusOK},
+			wantErr:  ErrInvalidCode,
+		},
+		{
+			name:     "unknown mode",
+			settings: Settings{Mode: "frame"},
+			wantErr:  ErrUnknownMode,
+		},
+		{
+			name:     "utm too long",
+			settings: Settings{UTM: &UTM{Term: strings.Repeat("a", MaxUTMLength+1)}},
+			wantErr:  ErrUTMTooLong,
+		},
+		{
+			name:     "valid rule",
+			settings: Settings{Rules: []Rule{{URL: "https://example.com/", Countries: []string{"US"}, Languages: []string{"en-us"}}}},
+		},
+		{
+			name:     "rule without url",
+			settings: Settings{Rules: []Rule{{Platforms: []string{PlatformIOS}}}},
+			wantErr:  ErrRuleURL,
+		},
+		{
+			name:     "rule without conditions",
+			settings: Settings{Rules: []Rule{{URL: "https://example.com/"}}},
+			wantErr:  ErrRuleConditions,
+		},
+		{
+			name:     "unknown platform",
+			settings: Settings{Rules: []Rule{{URL: "https://example.com/", Platforms: []string{"symbian"}}}},
+			wantErr:  ErrUnknownPlatform,
+		},
+		{
+			name:     "unknown device",
+			settings: Settings{Rules: []Rule{{URL: "https://example.com/", Devices: []string{"watch"}}}},
+			wantErr:  ErrUnknownDevice,
+		},
+		{
+			name:     "invalid country",
+			settings: Settings{Rules: []Rule{{URL: "https://example.com/", Countries: []string{"USA"}}}},
+			wantErr:  ErrInvalidCountry,
+		},
+		{
+			name:     "invalid language",
+			settings: Settings{Rules: []Rule{{URL: "https://example.com/", Languages: []string{"english!"}}}},
+			wantErr:  ErrInvalidLanguage,
+		},
+		{
+			name: "valid split",
+			settings: Settings{Destinations: []Destination{
+				{URL: "https://example.com/a", Weight: 1},
+				{URL: "https://example.com/b", Weight: MaxWeight},
+			}},
+		},
+		{
+			name:     "split with one destination",
+			settings: Settings{Destinations: []Destination{{URL: "https://example.com/a", Weight: 1}}},
+			wantErr:  ErrTooFewDestinations,
+		},
+		{
+			name:     "too many destinations",
+			settings: Settings{Destinations: make([]Destination, MaxDestinations+1)},
+			wantErr:  ErrTooManyDestinations,
+		},
+		{
+			name: "destination without url",
+			settings: Settings{Destinations: []Destination{
+				{URL: "https://example.com/a", Weight: 1},
+				{Weight: 1},
+			}},
+			wantErr: ErrDestinationURL,
+		},
+		{
+			name: "duplicate destination",
+			settings: Settings{Destinations: []Destination{
+				{URL: "https://example.com/a", Weight: 1},
+				{URL: "https://example.com/a", Weight: 2},
+			}},
+			wantErr: ErrDuplicateDestination,
+		},
+		{
+			name: "invalid weight",
+			settings: Settings{Destinations: []Destination{
+				{URL: "https://example.com/a", Weight: 1},
+				{URL: "https://example.com/b", Weight: MaxWeight + 1},
+			}},
+			wantErr: ErrInvalidWeight,
+		},
+		{
+			name:     "too many rules",
+			settings: Settings{Rules: make([]Rule, MaxRules+1)},
+			wantErr:  ErrTooManyRules,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.settings.Validate()
+			if tt.wantErr == nil {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestSettings_Clean(t *testing.T) {
+	settings := Settings{Mode: " HTTP ", UTM: &UTM{Source: "  "}}.Clean()
+	assert.True(t, settings.IsZero())
+	assert.Nil(t, settings.UTM)
+
+	settings = Settings{Mode: "Meta", UTM: &UTM{Source: " site "}}.Clean()
+	assert.Equal(t, Settings{Mode: ModeMeta, UTM: &UTM{Source: "site"}}, settings)
+}
+
+func TestSettings_CleanRules(t *testing.T) {
+	settings := Settings{Rules: []Rule{{
+		URL:       " https://example.com/ ",
+		Platforms: []string{" iOS ", ""},
+		Countries: []string{"de"},
+		Languages: []string{"EN-us"},
+	}}}.Clean()
+	assert.Equal(t, []Rule{{
+		URL:       "https://example.com/",
+		Platforms: []string{PlatformIOS},
+		Countries: []string{"DE"},
+		Languages: []string{"en-us"},
+	}}, settings.Rules)
+	assert.False(t, settings.IsZero())
+}
+
+func TestSettings_TargetSplit(t *testing.T) {
+	settings := Settings{
+		Rules: []Rule{{URL: "https://apps.apple.com/", Platforms: []string{PlatformIOS}}},
+		Destinations: []Destination{
+			{URL: "https://example.com/a", Weight: 1},
+			{URL: "https://example.com/b", Weight: 3},
+		},
+	}
+
+	target := settings.Target("https://example.com/", nil, Client{ID: "visitor", Platform: PlatformIOS})
+	assert.Equal(t, "https://apps.apple.com/", target.URL)
+	assert.Empty(t, target.Destination)
+
+	first := settings.Target("https://example.com/", nil, Client{ID: "visitor"})
+	assert.Equal(t, first.Destination, first.URL)
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, first, settings.Target("https://example.com/", nil, Client{ID: "visitor"}))
+	}
+
+	counts := map[string]int{}
+	for i := 0; i < 4000; i++ {
+		counts[settings.Target("https://example.com/", nil, Client{ID: strconv.Itoa(i)}).Destination]++
+	}
+	assert.Len(t, counts, 2)
+	assert.InDelta(t, 1000, counts["https://example.com/a"], 150)
+	assert.InDelta(t, 3000, counts["https://example.com/b"], 150)
+}
+
+func TestSettings_CleanDestinations(t *testing.T) {
+	settings := Settings{Destinations: []Destination{
+		{URL: " https://example.com/a "},
+		{URL: "https://example.com/b", Weight: 5},
+	}}.Clean()
+	assert.Equal(t, []Destination{
+		{URL: "https://example.com/a", Weight: 1},
+		{URL: "https://example.com/b", Weight: 5},
+	}, settings.Destinations)
+	assert.False(t, settings.IsZero())
+}
@@ -0,0 +1,207 @@
+// Package session - пакет для выдачи и проверки подписанных токенов сессии
+// пользователя.
+package session
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/utils"
+)
+
+const (
+	// version - префикс формата токена.
+	version = "v1"
+	// keyContext - контекст для получения ключа шифрования токенов из секрета.
+	keyContext = "shortener session v1"
+	// minSecretSize - минимальный размер секрета в байтах.
+	minSecretSize = 16
+)
+
+var (
+	// ErrInvalid - токен поврежден, подписан неизвестным ключом или подделан.
+	ErrInvalid = errors.New("invalid session token")
+	// ErrExpired - срок действия токена истек.
+	ErrExpired = errors.New("session token expired")
+)
+
+// Key - секрет для шифрования токенов.
+type Key struct {
+	ID     string
+	Secret []byte
+}
+
+// NewKey - создание ключа, идентификатор которого - отпечаток секрета.
+func NewKey(secret []byte) (Key, error) {
+	if len(secret) < minSecretSize {
+		return Key{}, errors.New("session key must be at least 16 bytes")
+	}
+	sum := sha256.Sum256(secret)
+	return Key{ID: hex.EncodeToString(sum[:4]), Secret: secret}, nil
+}
+
+// Claims - данные токена сессии.
+type Claims struct {
+	Subject   string `json:"sub"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+	// Legacy - токен в старом формате без подписи и срока действия.
+	Legacy bool `json:"-"`
+}
+
+// Options - настройки выдачи токенов и cookie.
+type Options struct {
+	// TTL - срок действия токена.
+	TTL time.Duration
+	// CookieName - имя cookie с токеном.
+	CookieName string
+	// Secure - передавать cookie только по HTTPS.
+	Secure bool
+	// Legacy - расшифровка cookie старого формата на время миграции,
+	// nil если старые cookie не принимаются.
+	Legacy *utils.Encryptor
+}
+
+// Manager - выдача и проверка токенов сессии. Токены шифруются AES-GCM
+// первым ключом, проверяются всеми переданными ключами.
+type Manager struct {
+	primary string
+	aeads   map[string]cipher.AEAD
+	opts    Options
+	now     func() time.Time
+}
+
+// NewManager - создание Manager. Первый ключ используется для выдачи
+// токенов, остальные - только для проверки во время ротации.
+func NewManager(keys []Key, opts Options) (*Manager, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("at least one session key is required")
+	}
+	m := &Manager{
+		primary: keys[0].ID,
+		aeads:   map[string]cipher.AEAD{},
+		opts:    opts,
+		now:     time.Now,
+	}
+	for _, key := range keys {
+		aead, err := newAEAD(key.Secret)
+		if err != nil {
+			return nil, err
+		}
+		m.aeads[key.ID] = aead
+	}
+	return m, nil
+}
+
+// Issue - выдача токена для пользователя.
+func (m *Manager) Issue(userID string) (string, error) {
+	now := m.now()
+	payload, err := json.Marshal(&Claims{
+		Subject:   userID,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(m.opts.TTL).Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+	aead := m.aeads[m.primary]
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	header := version + "." + m.primary
+	sealed := aead.Seal(nonce, nonce, payload, []byte(header))
+	return header + "." + base64.RawURLEncoding.EncodeToString(sealed), nil
+}
+
+// Parse - проверка токена и получение его данных.
+func (m *Manager) Parse(token string) (Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || parts[0] != version {
+		return m.parseLegacy(token)
+	}
+	aead, ok := m.aeads[parts[1]]
+	if !ok {
+		return Claims{}, ErrInvalid
+	}
+	sealed, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return Claims{}, ErrInvalid
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	payload, err := aead.Open(nil, nonce, ciphertext, []byte(parts[0]+"."+parts[1]))
+	if err != nil {
+		return Claims{}, ErrInvalid
+	}
+	claims := Claims{}
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.Subject == "" {
+		return Claims{}, ErrInvalid
+	}
+	if m.now().Unix() >= claims.ExpiresAt {
+		return Claims{}, ErrExpired
+	}
+	return claims, nil
+}
+
+// NeedsRefresh - нужно ли выдать новый токен: токен старого формата,
+// подписан не основным ключом или прошла половина срока действия.
+func (m *Manager) NeedsRefresh(token string, claims Claims) bool {
+	if claims.Legacy || !strings.HasPrefix(token, version+"."+m.primary+".") {
+		return true
+	}
+	return m.now().Unix()-claims.IssuedAt > int64(m.opts.TTL.Seconds())/2
+}
+
+// Cookie - cookie с токеном. Cookie доступна только серверу и не
+// передается при межсайтовых запросах, кроме переходов по ссылкам.
+func (m *Manager) Cookie(token string) *http.Cookie {
+	return &http.Cookie{
+		Name:     m.opts.CookieName,
+		Value:    token,
+		Path:     "/",
+		MaxAge:   int(m.opts.TTL.Seconds()),
+		Secure:   m.opts.Secure,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	}
+}
+
+// CookieName - имя cookie с токеном.
+func (m *Manager) CookieName() string {
+	return m.opts.CookieName
+}
+
+// parseLegacy - расшифровка cookie старого формата.
+func (m *Manager) parseLegacy(token string) (Claims, error) {
+	// Старый формат - один блок AES в hex.
+	if m.opts.Legacy == nil || len(token) != 2*aes.BlockSize {
+		return Claims{}, ErrInvalid
+	}
+	userID, err := m.opts.Legacy.DecodeUUIDFromString(token)
+	if err != nil {
+		return Claims{}, ErrInvalid
+	}
+	return Claims{Subject: userID, Legacy: true}, nil
+}
+
+// newAEAD - создание AES-GCM с ключом, полученным из секрета через HMAC,
+// чтобы не использовать один ключ для разных шифров.
+func newAEAD(secret []byte) (cipher.AEAD, error) {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(keyContext))
+	block, err := aes.NewCipher(mac.Sum(nil))
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
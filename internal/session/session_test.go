@@ -0,0 +1,139 @@
+package session
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/utils"
+)
+
+func newTestManager(t *testing.T, opts Options, secrets ...string) *Manager {
+	var keys []Key
+	for _, secret := range secrets {
+		key, err := NewKey([]byte(secret))
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys = append(keys, key)
+	}
+	m, err := NewManager(keys, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestManager_IssueParse(t *testing.T) {
+	now := time.Date(2022, 4, 1, 12, 0, 0, 0, time.UTC)
+	m := newTestManager(t, Options{TTL: time.Hour, CookieName: "userId"}, "0123456789abcdef")
+	m.now = func() time.Time { return now }
+
+	token, err := m.Issue("user-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := m.Parse(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.Subject != "user-1" || claims.IssuedAt != now.Unix() || claims.ExpiresAt != now.Add(time.Hour).Unix() {
+		t.Errorf("unexpected claims %+v", claims)
+	}
+	if m.NeedsRefresh(token, claims) {
+		t.Error("fresh token needs refresh")
+	}
+
+	tampered := token[:len(token)-2] + "AA"
+	if _, err := m.Parse(tampered); !errors.Is(err, ErrInvalid) {
+		t.Errorf("tampered token error = %v, want ErrInvalid", err)
+	}
+
+	now = now.Add(31 * time.Minute)
+	if !m.NeedsRefresh(token, claims) {
+		t.Error("token after half of ttl does not need refresh")
+	}
+	now = now.Add(30 * time.Minute)
+	if _, err := m.Parse(token); !errors.Is(err, ErrExpired) {
+		t.Errorf("expired token error = %v, want ErrExpired", err)
+	}
+}
+
+func TestManager_KeyRotation(t *testing.T) {
+	opts := Options{TTL: time.Hour}
+	old := newTestManager(t, opts, "old-secret-0123456")
+	token, err := old.Issue("user-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rotated := newTestManager(t, opts, "new-secret-0123456", "old-secret-0123456")
+	claims, err := rotated.Parse(token)
+	if err != nil {
+		t.Fatalf("token signed with previous key: %v", err)
+	}
+	if !rotated.NeedsRefresh(token, claims) {
+		t.Error("token signed with previous key does not need refresh")
+	}
+
+	removed := newTestManager(t, opts, "new-secret-0123456")
+	if _, err := removed.Parse(token); !errors.Is(err, ErrInvalid) {
+		t.Errorf("token signed with removed key error = %v, want ErrInvalid", err)
+	}
+}
+
+func TestManager_Legacy(t *testing.T) {
+	secret := []byte("0123456789abcdef")
+	encryptor, err := utils.New(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, _ := uuid.NewV4()
+	legacyToken := encryptor.EncodeUUIDtoString(id.Bytes())
+
+	withLegacy := newTestManager(t, Options{TTL: time.Hour, Legacy: encryptor}, string(secret))
+	claims, err := withLegacy.Parse(legacyToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.Subject != id.String() || !claims.Legacy {
+		t.Errorf("unexpected claims %+v", claims)
+	}
+	if !withLegacy.NeedsRefresh(legacyToken, claims) {
+		t.Error("legacy token does not need refresh")
+	}
+	if _, err := withLegacy.Parse("abc"); !errors.Is(err, ErrInvalid) {
+		t.Errorf("short legacy token error = %v, want ErrInvalid", err)
+	}
+
+	withoutLegacy := newTestManager(t, Options{TTL: time.Hour}, string(secret))
+	if _, err := withoutLegacy.Parse(legacyToken); !errors.Is(err, ErrInvalid) {
+		t.Errorf("legacy token without migration error = %v, want ErrInvalid", err)
+	}
+}
+
+func TestManager_Cookie(t *testing.T) {
+	m := newTestManager(t, Options{TTL: time.Hour, CookieName: "userId", Secure: true}, "0123456789abcdef")
+	cookie := m.Cookie("token")
+	if cookie.Name != "userId" || cookie.MaxAge != 3600 || !cookie.Secure || !cookie.HttpOnly ||
+		cookie.SameSite != http.SameSiteLaxMode || cookie.Domain != "" {
+		t.Errorf("unexpected cookie %+v", cookie)
+	}
+	if !strings.Contains(cookie.String(), "SameSite=Lax") {
+		t.Errorf("cookie %q without SameSite", cookie.String())
+	}
+}
+
+func TestNewKey(t *testing.T) {
+	if _, err := NewKey([]byte("short")); err == nil {
+		t.Error("short key accepted")
+	}
+	first, _ := NewKey([]byte("0123456789abcdef"))
+	second, _ := NewKey([]byte("fedcba9876543210"))
+	if first.ID == second.ID {
+		t.Error("different keys have the same id")
+	}
+}
@@ -0,0 +1,148 @@
+// Package titles - пакет для получения заголовка страницы по адресу
+// назначения ссылки.
+package titles
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/policy"
+	"golang.org/x/net/html"
+)
+
+const (
+	// DefaultTimeout - ограничение времени получения страницы.
+	DefaultTimeout = 5 * time.Second
+	// maxBodySize - сколько байт страницы читается в поисках заголовка.
+	maxBodySize = 512 * 1024
+	// MaxLength - максимальная длина заголовка в символах, более длинный
+	// заголовок обрезается.
+	MaxLength = 256
+	// maxRedirects - максимальное количество перенаправлений.
+	maxRedirects = 5
+)
+
+var (
+	// ErrNoTitle - на странице нет заголовка.
+	ErrNoTitle = errors.New("page has no title")
+	// errPrivateAddress - адрес страницы частный или локальный.
+	errPrivateAddress = errors.New("private address is not allowed")
+)
+
+// Fetcher - получение заголовка страницы по URL.
+type Fetcher interface {
+	Fetch(ctx context.Context, rawURL string) (string, error)
+}
+
+// HTTPFetcher - получение заголовка страницы по HTTP из тега <title>.
+type HTTPFetcher struct {
+	client *http.Client
+}
+
+// NewHTTPFetcher - создание HTTPFetcher. Если blockPrivateIPs, соединения с
+// частными и локальными адресами запрещены, в том числе после
+// перенаправлений.
+func NewHTTPFetcher(timeout time.Duration, blockPrivateIPs bool) *HTTPFetcher {
+	dialer := &net.Dialer{Timeout: timeout}
+	if blockPrivateIPs {
+		dialer.Control = func(network string, address string, c syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || policy.IsPrivate(ip) {
+				return fmt.Errorf("%w: %s", errPrivateAddress, host)
+			}
+			return nil
+		}
+	}
+	transport := &http.Transport{
+		DialContext:         dialer.DialContext,
+		TLSHandshakeTimeout: timeout,
+	}
+	return &HTTPFetcher{
+		client: &http.Client{
+			Transport: transport,
+			Timeout:   timeout,
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				if len(via) >= maxRedirects {
+					return errors.New("too many redirects")
+				}
+				return nil
+			},
+		},
+	}
+}
+
+// Fetch - получение страницы rawURL и разбор ее заголовка.
+func (f *HTTPFetcher) Fetch(ctx context.Context, rawURL string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Accept", "text/html")
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return "", fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	if contentType := resp.Header.Get("Content-Type"); contentType != "" &&
+		!strings.HasPrefix(strings.ToLower(contentType), "text/html") {
+		return "", ErrNoTitle
+	}
+	return Parse(io.LimitReader(resp.Body, maxBodySize))
+}
+
+// Parse - заголовок HTML страницы из первого тега <title>. Пробельные
+// символы схлопываются, заголовок обрезается до MaxLength символов.
+func Parse(r io.Reader) (string, error) {
+	tokenizer := html.NewTokenizer(r)
+	inTitle := false
+	var title strings.Builder
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			if inTitle {
+				return clean(title.String())
+			}
+			return "", ErrNoTitle
+		case html.StartTagToken:
+			name, _ := tokenizer.TagName()
+			if string(name) == "title" {
+				inTitle = true
+			}
+		case html.TextToken:
+			if inTitle {
+				title.Write(tokenizer.Text())
+			}
+		case html.EndTagToken:
+			name, _ := tokenizer.TagName()
+			if inTitle && string(name) == "title" {
+				return clean(title.String())
+			}
+		}
+	}
+}
+
+// clean - схлопывание пробелов и обрезка заголовка до MaxLength символов.
+func clean(title string) (string, error) {
+	title = strings.Join(strings.Fields(title), " ")
+	if title == "" {
+		return "", ErrNoTitle
+	}
+	if runes := []rune(title); len(runes) > MaxLength {
+		title = strings.TrimSpace(string(runes[:MaxLength]))
+	}
+	return title, nil
+}
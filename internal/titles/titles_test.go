@@ -0,0 +1,81 @@
+package titles
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		page    string
+		want    string
+		wantErr error
+	}{
+		{
+			name: "title in head",
+			page: "<html><head><title>Go  &amp;\n Links</title></head><body><title>Other</title></body></html>",
+			want: "Go & Links",
+		},
+		{
+			name:    "no title",
+			page:    "<html><body><h1>Header</h1></body></html>",
+			wantErr: ErrNoTitle,
+		},
+		{
+			name:    "empty title",
+			page:    "<title>  </title>",
+			wantErr: ErrNoTitle,
+		},
+		{
+			name: "long title",
+			page: "<title>" + strings.Repeat("я", MaxLength+10) + "</title>",
+			want: strings.Repeat("я", MaxLength),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(strings.NewReader(tt.page))
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestHTTPFetcher_Fetch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/page":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			fmt.Fprint(w, "<title>Page</title>")
+		case "/image":
+			w.Header().Set("Content-Type", "image/png")
+			fmt.Fprint(w, "<title>Not a page</title>")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	fetcher := NewHTTPFetcher(DefaultTimeout, false)
+	title, err := fetcher.Fetch(context.Background(), server.URL+"/page")
+	require.NoError(t, err)
+	assert.Equal(t, "Page", title)
+
+	_, err = fetcher.Fetch(context.Background(), server.URL+"/image")
+	assert.Equal(t, ErrNoTitle, err)
+
+	_, err = fetcher.Fetch(context.Background(), server.URL+"/missing")
+	assert.Error(t, err)
+
+	_, err = NewHTTPFetcher(DefaultTimeout, true).Fetch(context.Background(), server.URL+"/page")
+	assert.True(t, errors.Is(err, errPrivateAddress))
+}
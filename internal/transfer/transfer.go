@@ -0,0 +1,282 @@
+// Package transfer - пакет для импорта и экспорта ссылок в форматах CSV и
+// JSONL.
+package transfer
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"strings"
+	"time"
+
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/app/responses"
+)
+
+// Форматы файлов со ссылками.
+const (
+	FormatCSV   = "csv"
+	FormatJSONL = "jsonl"
+)
+
+// MaxRows - максимальное количество строк в одном импорте.
+const MaxRows = 10000
+
+// tagSeparator - разделитель тегов в колонке tags файла CSV.
+const tagSeparator = "|"
+
+// maxLineSize - максимальная длина строки JSONL.
+const maxLineSize = 1024 * 1024
+
+// columns - колонки файла CSV в порядке записи.
+var columns = []string{"id", "domain", "short_url", "original_url", "user_id", "workspace_id", "created_at", "title", "description", "tags"}
+
+var (
+	// ErrUnknownFormat - формат файла не поддерживается.
+	ErrUnknownFormat = errors.New("format must be csv or jsonl")
+	// ErrNoURLColumn - в заголовке CSV нет колонки original_url.
+	ErrNoURLColumn = errors.New("csv header has no original_url column")
+)
+
+// RowError - ошибка разбора строки Row. Чтение можно продолжать со
+// следующей строки.
+type RowError struct {
+	Row int
+	Err error
+}
+
+func (e *RowError) Error() string {
+	return fmt.Sprintf("row %d: %v", e.Row, e.Err)
+}
+
+func (e *RowError) Unwrap() error {
+	return e.Err
+}
+
+// ParseFormat - формат по имени csv или jsonl или по типу содержимого.
+func ParseFormat(value string) (string, error) {
+	mediaType, _, err := mime.ParseMediaType(value)
+	if err != nil {
+		mediaType = value
+	}
+	switch strings.ToLower(mediaType) {
+	case FormatCSV, "text/csv":
+		return FormatCSV, nil
+	case FormatJSONL, "application/jsonl", "application/x-ndjson", "application/x-jsonlines":
+		return FormatJSONL, nil
+	default:
+		return "", ErrUnknownFormat
+	}
+}
+
+// ContentType - тип содержимого файла в формате format.
+func ContentType(format string) string {
+	if format == FormatCSV {
+		return "text/csv; charset=utf-8"
+	}
+	return "application/x-ndjson"
+}
+
+// Reader - чтение ссылок из файла.
+type Reader interface {
+	// Read - следующая ссылка файла. В конце файла возвращает io.EOF, для
+	// некорректной строки - *RowError.
+	Read() (responses.ExportURL, error)
+	// Row - номер последней прочитанной строки, начиная с 1 без учета
+	// заголовка CSV.
+	Row() int
+}
+
+// Writer - запись ссылок в файл.
+type Writer interface {
+	Write(u responses.ExportURL) error
+	// Flush - запись буферизованных данных.
+	Flush() error
+}
+
+// NewReader - чтение ссылок из r в формате format. Для CSV сразу читается
+// заголовок с названиями колонок.
+func NewReader(r io.Reader, format string) (Reader, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVReader(r)
+	case FormatJSONL:
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+		return &jsonlReader{scanner: scanner}, nil
+	default:
+		return nil, ErrUnknownFormat
+	}
+}
+
+// NewWriter - запись ссылок в w в формате format. Для CSV заголовок
+// записывается перед первой ссылкой.
+func NewWriter(w io.Writer, format string) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		return &csvWriter{writer: csv.NewWriter(w)}, nil
+	case FormatJSONL:
+		buffered := bufio.NewWriter(w)
+		return &jsonlWriter{buffered: buffered, encoder: json.NewEncoder(buffered)}, nil
+	default:
+		return nil, ErrUnknownFormat
+	}
+}
+
+// csvReader - чтение ссылок из CSV с заголовком.
+type csvReader struct {
+	reader *csv.Reader
+	index  map[string]int
+	row    int
+}
+
+func newCSVReader(r io.Reader) (*csvReader, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, ErrNoURLColumn
+	}
+	if err != nil {
+		return nil, err
+	}
+	index := map[string]int{}
+	for i, name := range header {
+		index[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	if _, ok := index["original_url"]; !ok {
+		return nil, ErrNoURLColumn
+	}
+	return &csvReader{reader: reader, index: index}, nil
+}
+
+func (r *csvReader) Read() (responses.ExportURL, error) {
+	record, err := r.reader.Read()
+	if errors.Is(err, io.EOF) {
+		return responses.ExportURL{}, io.EOF
+	}
+	r.row++
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return responses.ExportURL{}, &RowError{Row: r.row, Err: parseErr.Err}
+	}
+	if err != nil {
+		return responses.ExportURL{}, err
+	}
+	field := func(name string) string {
+		i, ok := r.index[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+	u := responses.ExportURL{
+		GetURL: responses.GetURL{
+			ID:          field("id"),
+			Domain:      field("domain"),
+			OriginalURL: field("original_url"),
+			WorkspaceID: field("workspace_id"),
+			LinkMeta: responses.LinkMeta{
+				Title:       field("title"),
+				Description: field("description"),
+			},
+		},
+		UserID: field("user_id"),
+	}
+	if tags := field("tags"); tags != "" {
+		u.Tags = strings.Split(tags, tagSeparator)
+	}
+	if createdAt := field("created_at"); createdAt != "" {
+		t, err := time.Parse(time.RFC3339, createdAt)
+		if err != nil {
+			return responses.ExportURL{}, &RowError{Row: r.row, Err: errors.New("created_at must be RFC 3339 date")}
+		}
+		u.CreatedAt = &t
+	}
+	return u, nil
+}
+
+func (r *csvReader) Row() int {
+	return r.row
+}
+
+// jsonlReader - чтение ссылок из JSONL, пустые строки пропускаются.
+type jsonlReader struct {
+	scanner *bufio.Scanner
+	row     int
+}
+
+func (r *jsonlReader) Read() (responses.ExportURL, error) {
+	for r.scanner.Scan() {
+		line := strings.TrimSpace(r.scanner.Text())
+		if line == "" {
+			continue
+		}
+		r.row++
+		var u responses.ExportURL
+		if err := json.Unmarshal([]byte(line), &u); err != nil {
+			return responses.ExportURL{}, &RowError{Row: r.row, Err: err}
+		}
+		return u, nil
+	}
+	if err := r.scanner.Err(); err != nil {
+		return responses.ExportURL{}, err
+	}
+	return responses.ExportURL{}, io.EOF
+}
+
+func (r *jsonlReader) Row() int {
+	return r.row
+}
+
+// csvWriter - запись ссылок в CSV.
+type csvWriter struct {
+	writer *csv.Writer
+	header bool
+}
+
+func (w *csvWriter) Write(u responses.ExportURL) error {
+	if !w.header {
+		if err := w.writer.Write(columns); err != nil {
+			return err
+		}
+		w.header = true
+	}
+	var createdAt string
+	if u.CreatedAt != nil {
+		createdAt = u.CreatedAt.UTC().Format(time.RFC3339Nano)
+	}
+	return w.writer.Write([]string{
+		u.ID, u.Domain, u.ShortURL, u.OriginalURL, u.UserID, u.WorkspaceID, createdAt,
+		u.Title, u.Description, strings.Join(u.Tags, tagSeparator),
+	})
+}
+
+func (w *csvWriter) Flush() error {
+	if !w.header {
+		if err := w.writer.Write(columns); err != nil {
+			return err
+		}
+		w.header = true
+	}
+	w.writer.Flush()
+	return w.writer.Error()
+}
+
+// jsonlWriter - запись ссылок в JSONL, по одному объекту на строку.
+type jsonlWriter struct {
+	buffered *bufio.Writer
+	encoder  *json.Encoder
+}
+
+func (w *jsonlWriter) Write(u responses.ExportURL) error {
+	return w.encoder.Encode(u)
+}
+
+func (w *jsonlWriter) Flush() error {
+	return w.buffered.Flush()
+}
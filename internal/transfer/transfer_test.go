@@ -0,0 +1,159 @@
+package transfer
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/app/responses"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFormat(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    string
+		wantErr error
+	}{
+		{value: "csv", want: FormatCSV},
+		{value: "JSONL", want: FormatJSONL},
+		{value: "text/csv; charset=utf-8", want: FormatCSV},
+		{value: "application/x-ndjson", want: FormatJSONL},
+		{value: "application/json", wantErr: ErrUnknownFormat},
+		{value: "", wantErr: ErrUnknownFormat},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseFormat(tt.value)
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	createdAt := time.Date(2021, 5, 1, 10, 0, 0, 0, time.UTC)
+	urls := []responses.ExportURL{
+		{
+			GetURL: responses.GetURL{
+				ID:          "abc",
+				Domain:      "go.example.com",
+				ShortURL:    "https://go.example.com/abc",
+				OriginalURL: "https://example.com/a?x=1,2",
+				WorkspaceID: "ws-1",
+				CreatedAt:   &createdAt,
+				LinkMeta: responses.LinkMeta{
+					Title:       "Docs, \"quoted\"",
+					Description: "line\nbreak",
+					Tags:        []string{"go", "docs"},
+				},
+			},
+			UserID: "user-1",
+		},
+		{
+			GetURL: responses.GetURL{ID: "def", OriginalURL: "https://example.com/b"},
+		},
+	}
+	for _, format := range []string{FormatCSV, FormatJSONL} {
+		t.Run(format, func(t *testing.T) {
+			var buf bytes.Buffer
+			writer, err := NewWriter(&buf, format)
+			require.NoError(t, err)
+			for _, u := range urls {
+				require.NoError(t, writer.Write(u))
+			}
+			require.NoError(t, writer.Flush())
+
+			reader, err := NewReader(&buf, format)
+			require.NoError(t, err)
+			var got []responses.ExportURL
+			for {
+				u, err := reader.Read()
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				require.NoError(t, err)
+				got = append(got, u)
+			}
+			require.Len(t, got, len(urls))
+			for i := range urls {
+				assert.Equal(t, urls[i].ID, got[i].ID)
+				assert.Equal(t, urls[i].Domain, got[i].Domain)
+				assert.Equal(t, urls[i].OriginalURL, got[i].OriginalURL)
+				assert.Equal(t, urls[i].UserID, got[i].UserID)
+				assert.Equal(t, urls[i].WorkspaceID, got[i].WorkspaceID)
+				assert.Equal(t, urls[i].LinkMeta, got[i].LinkMeta)
+				if urls[i].CreatedAt != nil {
+					require.NotNil(t, got[i].CreatedAt)
+					assert.True(t, urls[i].CreatedAt.Equal(*got[i].CreatedAt))
+				}
+			}
+			assert.Equal(t, 2, reader.Row())
+		})
+	}
+}
+
+func TestReader_RowErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		input   string
+		wantErr []int
+		wantURL []string
+	}{
+		{
+			name:    "csv bad date and quote",
+			format:  FormatCSV,
+			input:   "original_url,created_at\nhttps://a.ru,yesterday\nhttps://b\"ru,\nhttps://c.ru,\n",
+			wantErr: []int{1, 2},
+			wantURL: []string{"https://c.ru"},
+		},
+		{
+			name:    "csv short row",
+			format:  FormatCSV,
+			input:   "tags,original_url\ngo\nx,https://b.ru\n",
+			wantURL: []string{"", "https://b.ru"},
+		},
+		{
+			name:    "jsonl bad line",
+			format:  FormatJSONL,
+			input:   "{\"original_url\": \"https://a.ru\"}\n\nnot json\n{\"original_url\": \"https://c.ru\"}\n",
+			wantErr: []int{2},
+			wantURL: []string{"https://a.ru", "https://c.ru"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader, err := NewReader(strings.NewReader(tt.input), tt.format)
+			require.NoError(t, err)
+			var rows []int
+			var got []string
+			for {
+				u, err := reader.Read()
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				var rowErr *RowError
+				if errors.As(err, &rowErr) {
+					rows = append(rows, rowErr.Row)
+					continue
+				}
+				require.NoError(t, err)
+				got = append(got, u.OriginalURL)
+			}
+			assert.Equal(t, tt.wantErr, rows)
+			assert.Equal(t, tt.wantURL, got)
+		})
+	}
+}
+
+func TestNewReader_NoURLColumn(t *testing.T) {
+	_, err := NewReader(strings.NewReader("url\nhttps://a.ru\n"), FormatCSV)
+	assert.ErrorIs(t, err, ErrNoURLColumn)
+	_, err = NewReader(strings.NewReader(""), FormatCSV)
+	assert.ErrorIs(t, err, ErrNoURLColumn)
+}
@@ -9,7 +9,8 @@ import (
 	"github.com/gofrs/uuid"
 )
 
-// Encryptor - стрктура для шифрования/расшифрование.
+// Encryptor - стрктура для шифрования/расшифрование. Используется только
+// для чтения cookie сессии старого формата (см. пакет session).
 type Encryptor struct {
 	aesblock cipher.Block
 	key      []byte
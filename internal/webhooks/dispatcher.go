@@ -0,0 +1,225 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/policy"
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/workers"
+)
+
+const (
+	// DefaultTimeout - ограничение времени одной попытки доставки.
+	DefaultTimeout = 5 * time.Second
+	// userAgent - заголовок User-Agent запросов к адресам вебхуков.
+	userAgent = "shortener-webhooks/1.0"
+	// maxResponseSize - сколько байт ответа получателя читается перед
+	// закрытием соединения.
+	maxResponseSize = 64 * 1024
+)
+
+// errPrivateAddress - адрес вебхука частный или локальный.
+var errPrivateAddress = errors.New("private address is not allowed")
+
+// Store - хранилище вебхуков и журнала доставок.
+type Store interface {
+	ListWebhooks(ctx context.Context, userID string) ([]Webhook, error)
+	AddDelivery(ctx context.Context, delivery Delivery) error
+}
+
+// Sender - отправка событий на адреса вебхуков по HTTP.
+type Sender struct {
+	client *http.Client
+}
+
+// NewSender - создание Sender. Если blockPrivateIPs, соединения с частными
+// и локальными адресами запрещены. Перенаправления не выполняются.
+func NewSender(timeout time.Duration, blockPrivateIPs bool) *Sender {
+	dialer := &net.Dialer{Timeout: timeout}
+	if blockPrivateIPs {
+		dialer.Control = func(network string, address string, c syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || policy.IsPrivate(ip) {
+				return fmt.Errorf("%w: %s", errPrivateAddress, host)
+			}
+			return nil
+		}
+	}
+	return &Sender{
+		client: &http.Client{
+			Transport: &http.Transport{
+				DialContext:         dialer.DialContext,
+				TLSHandshakeTimeout: timeout,
+			},
+			Timeout: timeout,
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+}
+
+// Send - отправка события event на адрес вебхука hook с подписью тела
+// ключом вебхука. Возвращает код ответа получателя, ответ не 2xx - ошибка.
+func (s *Sender) Send(ctx context.Context, hook Webhook, event Event) (int, error) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return 0, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set(SignatureHeader, Sign(hook.Secret, body))
+	req.Header.Set(EventHeader, event.Type)
+	req.Header.Set(DeliveryHeader, event.ID)
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, io.LimitReader(resp.Body, maxResponseSize))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// Dispatcher - доставка событий вебхукам пользователей в WorkerPool.
+// Неудачная попытка повторяется через паузу backoff, пока не исчерпаны
+// MaxAttempts попыток. Каждая попытка записывается в журнал доставок.
+type Dispatcher struct {
+	wp      *workers.WorkerPool
+	store   Store
+	sender  *Sender
+	backoff func(attempt int) time.Duration
+}
+
+// NewDispatcher - создание Dispatcher. backoff - пауза после неудачной
+// попытки, nil - Backoff.
+func NewDispatcher(wp *workers.WorkerPool, store Store, sender *Sender, backoff func(attempt int) time.Duration) *Dispatcher {
+	if backoff == nil {
+		backoff = Backoff
+	}
+	return &Dispatcher{
+		wp:      wp,
+		store:   store,
+		sender:  sender,
+		backoff: backoff,
+	}
+}
+
+// Publish - ставит в очередь WorkerPool доставку события event вебхукам
+// пользователя userID, которые на него подписаны. Не блокирует вызывающего,
+// поэтому его можно вызывать из задач WorkerPool.
+func (d *Dispatcher) Publish(userID string, event Event) {
+	d.push(func(ctx context.Context) error {
+		hooks, err := d.store.ListWebhooks(ctx, userID)
+		if err != nil {
+			return fmt.Errorf("list webhooks of %s: %w", userID, err)
+		}
+		var errs []error
+		for _, hook := range hooks {
+			if !hook.Wants(event) {
+				continue
+			}
+			if err := d.attempt(ctx, hook, forWebhook(hook, event), 1); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		if len(errs) > 0 {
+			return fmt.Errorf("deliver %s: %v", event.Type, errs)
+		}
+		return nil
+	})
+}
+
+// push - постановка задачи в очередь WorkerPool в отдельной горутине, чтобы
+// задача, которая публикует события, не ждала места в заполненной очереди.
+// После остановки пула задачи не ставятся.
+func (d *Dispatcher) push(task func(ctx context.Context) error) {
+	go d.wp.TryPush(context.Background(), task)
+}
+
+// forWebhook - событие event для вебхука hook: в событие о пороге
+// переходов записывается порог вебхука.
+func forWebhook(hook Webhook, event Event) Event {
+	if event.Type == EventClickThreshold && event.Link != nil {
+		link := *event.Link
+		link.Threshold = hook.ClickThreshold
+		event.Link = &link
+	}
+	return event
+}
+
+// attempt - попытка доставки attempt события event вебхуку hook. При
+// неудаче следующая попытка ставится в очередь WorkerPool через паузу
+// backoff, если вебхук к тому времени не удален.
+func (d *Dispatcher) attempt(ctx context.Context, hook Webhook, event Event, attempt int) error {
+	delivery, err := d.Deliver(ctx, hook, event, attempt)
+	if err != nil {
+		return err
+	}
+	if delivery.Success {
+		return nil
+	}
+	if attempt >= MaxAttempts {
+		return fmt.Errorf("webhook %s: %d attempts failed, last error: %s", hook.ID, attempt, delivery.Error)
+	}
+	time.AfterFunc(d.backoff(attempt), func() {
+		d.wp.TryPush(ctx, func(ctx context.Context) error {
+			hooks, err := d.store.ListWebhooks(ctx, hook.UserID)
+			if err != nil {
+				return err
+			}
+			for _, current := range hooks {
+				if current.ID == hook.ID {
+					return d.attempt(ctx, current, event, attempt+1)
+				}
+			}
+			return nil
+		})
+	})
+	return nil
+}
+
+// Deliver - одна попытка attempt доставки события event вебхуку hook с
+// записью в журнал доставок. Ошибка - только ошибка записи в журнал,
+// результат доставки возвращается в Delivery.
+func (d *Dispatcher) Deliver(ctx context.Context, hook Webhook, event Event, attempt int) (Delivery, error) {
+	status, err := d.sender.Send(ctx, hook, event)
+	id, idErr := uuid.NewV4()
+	if idErr != nil {
+		return Delivery{}, idErr
+	}
+	delivery := Delivery{
+		ID:         id.String(),
+		WebhookID:  hook.ID,
+		EventID:    event.ID,
+		Event:      event.Type,
+		Attempt:    attempt,
+		Success:    err == nil,
+		StatusCode: status,
+		CreatedAt:  time.Now().UTC(),
+	}
+	if err != nil {
+		delivery.Error = err.Error()
+	}
+	return delivery, d.store.AddDelivery(ctx, delivery)
+}
@@ -0,0 +1,306 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/p7chkn/go-musthave-shortener-tpl/internal/workers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryStore - хранилище вебхуков и журнала доставок в памяти.
+type memoryStore struct {
+	mu         sync.Mutex
+	hooks      []Webhook
+	deliveries []Delivery
+}
+
+func (s *memoryStore) ListWebhooks(ctx context.Context, userID string) ([]Webhook, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var result []Webhook
+	for _, hook := range s.hooks {
+		if hook.UserID == userID {
+			result = append(result, hook)
+		}
+	}
+	return result, nil
+}
+
+func (s *memoryStore) AddDelivery(ctx context.Context, delivery Delivery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.deliveries = append(s.deliveries, delivery)
+	return nil
+}
+
+func (s *memoryStore) log() []Delivery {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Delivery(nil), s.deliveries...)
+}
+
+func (s *memoryStore) remove(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, hook := range s.hooks {
+		if hook.ID == id {
+			s.hooks = append(s.hooks[:i], s.hooks[i+1:]...)
+			return
+		}
+	}
+}
+
+// receiver - получатель вебхуков, который отвечает кодами из statuses по
+// очереди, а после них - 200.
+type receiver struct {
+	mu       sync.Mutex
+	statuses []int
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := ioutil.ReadAll(r.Body)
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.requests = append(rc.requests, r)
+	rc.bodies = append(rc.bodies, body)
+	status := http.StatusOK
+	if len(rc.statuses) > 0 {
+		status, rc.statuses = rc.statuses[0], rc.statuses[1:]
+	}
+	w.WriteHeader(status)
+}
+
+func (rc *receiver) received() ([]*http.Request, [][]byte) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return append([]*http.Request(nil), rc.requests...), append([][]byte(nil), rc.bodies...)
+}
+
+func startPool(t *testing.T) *workers.WorkerPool {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	wp := workers.New(ctx, 2, 10)
+	go wp.Run(ctx)
+	require.Eventually(t, wp.Running, time.Second, time.Millisecond)
+	return wp
+}
+
+// stoppablePool - запущенный пул воркеров, stop останавливает его и ждет
+// завершения Run.
+func stoppablePool(t *testing.T, numOfWorkers int, buffer int) (*workers.WorkerPool, func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	wp := workers.New(ctx, numOfWorkers, buffer)
+	stopped := make(chan struct{})
+	go func() {
+		wp.Run(ctx)
+		close(stopped)
+	}()
+	require.Eventually(t, wp.Running, time.Second, time.Millisecond)
+	return wp, func() {
+		cancel()
+		<-stopped
+	}
+}
+
+func fastBackoff(attempt int) time.Duration {
+	return time.Millisecond
+}
+
+func TestDispatcherPublish(t *testing.T) {
+	rc := &receiver{statuses: []int{http.StatusInternalServerError, http.StatusBadGateway}}
+	server := httptest.NewServer(rc)
+	defer server.Close()
+
+	hook, err := NewWebhook("user-1", server.URL, []string{EventLinkCreated}, 0)
+	require.NoError(t, err)
+	other, err := NewWebhook("user-1", server.URL, []string{EventLinkDeleted}, 0)
+	require.NoError(t, err)
+	store := &memoryStore{hooks: []Webhook{hook, other}}
+	dispatcher := NewDispatcher(startPool(t), store, NewSender(time.Second, false), fastBackoff)
+
+	event := NewEvent(EventLinkCreated, &Link{ID: "abc", ShortURL: "http://localhost:8080/abc", OriginalURL: "https://example.com/"})
+	dispatcher.Publish("user-1", event)
+	dispatcher.Publish("user-2", NewEvent(EventLinkCreated, nil))
+
+	require.Eventually(t, func() bool { return len(store.log()) == 3 }, 5*time.Second, 5*time.Millisecond)
+	deliveries := store.log()
+	for i, delivery := range deliveries {
+		assert.Equal(t, hook.ID, delivery.WebhookID)
+		assert.Equal(t, event.ID, delivery.EventID)
+		assert.Equal(t, EventLinkCreated, delivery.Event)
+		assert.Equal(t, i+1, delivery.Attempt)
+	}
+	assert.False(t, deliveries[0].Success)
+	assert.Equal(t, http.StatusInternalServerError, deliveries[0].StatusCode)
+	assert.Equal(t, "unexpected status 500", deliveries[0].Error)
+	assert.True(t, deliveries[2].Success)
+	assert.Equal(t, http.StatusOK, deliveries[2].StatusCode)
+
+	requests, bodies := rc.received()
+	require.Len(t, requests, 3)
+	for i, r := range requests {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.Equal(t, EventLinkCreated, r.Header.Get(EventHeader))
+		assert.Equal(t, event.ID, r.Header.Get(DeliveryHeader))
+		assert.True(t, Verify(hook.Secret, bodies[i], r.Header.Get(SignatureHeader)))
+	}
+	var got Event
+	require.NoError(t, json.Unmarshal(bodies[0], &got))
+	assert.Equal(t, event.ID, got.ID)
+	assert.Equal(t, "https://example.com/", got.Link.OriginalURL)
+}
+
+func TestDispatcherGivesUp(t *testing.T) {
+	statuses := make([]int, MaxAttempts+1)
+	for i := range statuses {
+		statuses[i] = http.StatusServiceUnavailable
+	}
+	rc := &receiver{statuses: statuses}
+	server := httptest.NewServer(rc)
+	defer server.Close()
+
+	hook, err := NewWebhook("user-1", server.URL, nil, 0)
+	require.NoError(t, err)
+	store := &memoryStore{hooks: []Webhook{hook}}
+	dispatcher := NewDispatcher(startPool(t), store, NewSender(time.Second, false), fastBackoff)
+	dispatcher.Publish("user-1", NewEvent(EventLinkDeleted, &Link{ID: "abc"}))
+
+	require.Eventually(t, func() bool { return len(store.log()) == MaxAttempts }, 5*time.Second, 5*time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	deliveries := store.log()
+	assert.Len(t, deliveries, MaxAttempts)
+	for _, delivery := range deliveries {
+		assert.False(t, delivery.Success)
+		assert.Equal(t, http.StatusServiceUnavailable, delivery.StatusCode)
+	}
+}
+
+func TestDispatcherStopsForDeletedWebhook(t *testing.T) {
+	rc := &receiver{statuses: []int{http.StatusInternalServerError}}
+	server := httptest.NewServer(rc)
+	defer server.Close()
+
+	hook, err := NewWebhook("user-1", server.URL, nil, 0)
+	require.NoError(t, err)
+	store := &memoryStore{hooks: []Webhook{hook}}
+	dispatcher := NewDispatcher(startPool(t), store, NewSender(time.Second, false), func(attempt int) time.Duration {
+		store.remove(hook.ID)
+		return time.Millisecond
+	})
+	dispatcher.Publish("user-1", NewEvent(EventLinkCreated, &Link{ID: "abc"}))
+
+	require.Eventually(t, func() bool { return len(store.log()) == 1 }, 5*time.Second, 5*time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	assert.Len(t, store.log(), 1)
+}
+
+func TestDispatcherClickThreshold(t *testing.T) {
+	rc := &receiver{}
+	server := httptest.NewServer(rc)
+	defer server.Close()
+
+	low, err := NewWebhook("user-1", server.URL, []string{EventClickThreshold}, 10)
+	require.NoError(t, err)
+	high, err := NewWebhook("user-1", server.URL, []string{EventClickThreshold}, 1000)
+	require.NoError(t, err)
+	store := &memoryStore{hooks: []Webhook{low, high}}
+	dispatcher := NewDispatcher(startPool(t), store, NewSender(time.Second, false), fastBackoff)
+	dispatcher.Publish("user-1", NewEvent(EventClickThreshold, &Link{ID: "abc", PreviousClicks: 8, Clicks: 12}))
+
+	require.Eventually(t, func() bool { return len(store.log()) == 1 }, 5*time.Second, 5*time.Millisecond)
+	assert.Equal(t, low.ID, store.log()[0].WebhookID)
+	_, bodies := rc.received()
+	var got Event
+	require.NoError(t, json.Unmarshal(bodies[0], &got))
+	assert.Equal(t, int64(12), got.Link.Clicks)
+	assert.Equal(t, int64(10), got.Link.Threshold)
+}
+
+func TestDispatcherRetryAfterStop(t *testing.T) {
+	rc := &receiver{statuses: []int{http.StatusInternalServerError}}
+	server := httptest.NewServer(rc)
+	defer server.Close()
+
+	hook, err := NewWebhook("user-1", server.URL, nil, 0)
+	require.NoError(t, err)
+	store := &memoryStore{hooks: []Webhook{hook}}
+	wp, stop := stoppablePool(t, 1, 10)
+	retry := make(chan struct{})
+	dispatcher := NewDispatcher(wp, store, NewSender(time.Second, false), func(attempt int) time.Duration {
+		close(retry)
+		return 20 * time.Millisecond
+	})
+	dispatcher.Publish("user-1", NewEvent(EventLinkCreated, &Link{ID: "abc"}))
+
+	// Повторная попытка срабатывает уже после остановки пула.
+	<-retry
+	stop()
+	time.Sleep(50 * time.Millisecond)
+	assert.Len(t, store.log(), 1)
+}
+
+func TestDispatcherPublishAfterStop(t *testing.T) {
+	store := &memoryStore{}
+	wp, stop := stoppablePool(t, 1, 1)
+	release := make(chan struct{})
+	wp.Push(func(ctx context.Context) error {
+		<-release
+		return nil
+	})
+	dispatcher := NewDispatcher(wp, store, NewSender(time.Second, false), fastBackoff)
+
+	// Очередь заполнена, события ждут места в ней во время остановки пула.
+	for i := 0; i < 5; i++ {
+		dispatcher.Publish("user-1", NewEvent(EventLinkCreated, &Link{ID: "abc"}))
+	}
+	time.Sleep(10 * time.Millisecond)
+	stopped := make(chan struct{})
+	go func() {
+		stop()
+		close(stopped)
+	}()
+	require.Eventually(t, func() bool { return !wp.Running() }, time.Second, time.Millisecond)
+	close(release)
+	<-stopped
+
+	dispatcher.Publish("user-1", NewEvent(EventLinkCreated, &Link{ID: "abc"}))
+	time.Sleep(20 * time.Millisecond)
+}
+
+func TestSenderBlocksPrivateAddresses(t *testing.T) {
+	rc := &receiver{}
+	server := httptest.NewServer(rc)
+	defer server.Close()
+
+	hook := Webhook{ID: "hook-1", URL: server.URL, Secret: "whsec_secret"}
+	status, err := NewSender(time.Second, true).Send(context.Background(), hook, NewEvent(EventTest, nil))
+	assert.True(t, errors.Is(err, errPrivateAddress))
+	assert.Zero(t, status)
+	requests, _ := rc.received()
+	assert.Empty(t, requests)
+}
+
+func TestSenderDoesNotFollowRedirects(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/elsewhere", http.StatusFound)
+	}))
+	defer server.Close()
+
+	hook := Webhook{ID: "hook-1", URL: server.URL, Secret: "whsec_secret"}
+	status, err := NewSender(time.Second, false).Send(context.Background(), hook, NewEvent(EventTest, nil))
+	assert.Error(t, err)
+	assert.Equal(t, http.StatusFound, status)
+}
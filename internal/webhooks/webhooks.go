@@ -0,0 +1,231 @@
+// Package webhooks - пакет с вебхуками пользователей: уведомлениями о
+// создании и удалении ссылок и о достижении порога переходов, которые
+// отправляются на адрес пользователя с подписью HMAC-SHA256.
+package webhooks
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gofrs/uuid"
+)
+
+const (
+	// EventLinkCreated - ссылка создана.
+	EventLinkCreated = "link.created"
+	// EventLinkDeleted - ссылка удалена.
+	EventLinkDeleted = "link.deleted"
+	// EventClickThreshold - количество переходов по ссылке достигло порога
+	// вебхука.
+	EventClickThreshold = "link.click_threshold"
+	// EventTest - проверочное событие, которое отправляется по запросу
+	// пользователя.
+	EventTest = "webhook.test"
+)
+
+const (
+	// SignatureHeader - заголовок с подписью тела запроса вида
+	// sha256=<hex>.
+	SignatureHeader = "X-Webhook-Signature"
+	// EventHeader - заголовок с типом события.
+	EventHeader = "X-Webhook-Event"
+	// DeliveryHeader - заголовок с id события, одинаковый во всех попытках
+	// доставки, чтобы получатель мог отбросить повторы.
+	DeliveryHeader = "X-Webhook-Delivery"
+	// signaturePrefix - префикс подписи с названием алгоритма.
+	signaturePrefix = "sha256="
+	// SecretPrefix - префикс ключей подписи, чтобы их было легко отличить
+	// от других секретов.
+	SecretPrefix = "whsec_"
+	// secretSize - количество случайных байт ключа подписи.
+	secretSize = 32
+)
+
+const (
+	// MaxPerUser - максимальное количество вебхуков пользователя.
+	MaxPerUser = 10
+	// MaxAttempts - количество попыток доставки события.
+	MaxAttempts = 5
+	// MaxDeliveries - сколько последних доставок вебхука хранится в
+	// журнале.
+	MaxDeliveries = 100
+	// baseBackoff и maxBackoff - пауза после первой неудачной попытки и
+	// наибольшая пауза между попытками.
+	baseBackoff = time.Second
+	maxBackoff  = time.Minute
+)
+
+// events - события, на которые можно подписаться.
+var events = map[string]bool{
+	EventLinkCreated:    true,
+	EventLinkDeleted:    true,
+	EventClickThreshold: true,
+}
+
+var (
+	// ErrInvalidURL - адрес вебхука не абсолютный адрес http или https.
+	ErrInvalidURL = errors.New("webhook url must be an absolute http or https url")
+	// ErrUnknownEvent - неизвестный тип события.
+	ErrUnknownEvent = errors.New("events must be link.created, link.deleted or link.click_threshold")
+	// ErrInvalidThreshold - порог переходов отрицательный или не задан для
+	// подписки на link.click_threshold.
+	ErrInvalidThreshold = errors.New("click_threshold must be positive for link.click_threshold")
+)
+
+// Webhook - адрес пользователя, на который отправляются события. Пустой
+// Events - все события ссылок, link.click_threshold только при
+// положительном ClickThreshold.
+type Webhook struct {
+	ID             string    `json:"id"`
+	UserID         string    `json:"user_id"`
+	URL            string    `json:"url"`
+	Secret         string    `json:"secret"`
+	Events         []string  `json:"events,omitempty"`
+	ClickThreshold int64     `json:"click_threshold,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// Link - ссылка в событии. PreviousClicks - переходы до последнего
+// сохранения, по ним определяется, что порог только что достигнут.
+type Link struct {
+	ID             string `json:"id"`
+	Domain         string `json:"domain,omitempty"`
+	ShortURL       string `json:"short_url"`
+	OriginalURL    string `json:"original_url,omitempty"`
+	Clicks         int64  `json:"clicks,omitempty"`
+	Threshold      int64  `json:"threshold,omitempty"`
+	PreviousClicks int64  `json:"-"`
+}
+
+// Event - событие, тело запроса к адресу вебхука.
+type Event struct {
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	CreatedAt time.Time `json:"created_at"`
+	Link      *Link     `json:"link,omitempty"`
+}
+
+// Delivery - запись журнала доставок: попытка отправить событие на адрес
+// вебхука, код ответа получателя или ошибка.
+type Delivery struct {
+	ID         string    `json:"id"`
+	WebhookID  string    `json:"webhook_id"`
+	EventID    string    `json:"event_id"`
+	Event      string    `json:"event"`
+	Attempt    int       `json:"attempt"`
+	Success    bool      `json:"success"`
+	StatusCode int       `json:"status_code,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// NewWebhook - создание вебхука пользователя userID со случайным ключом
+// подписи с проверкой адреса, событий и порога переходов.
+func NewWebhook(userID string, rawURL string, eventTypes []string, threshold int64) (Webhook, error) {
+	rawURL = strings.TrimSpace(rawURL)
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return Webhook{}, ErrInvalidURL
+	}
+	seen := map[string]bool{}
+	var types []string
+	for _, eventType := range eventTypes {
+		if !events[eventType] {
+			return Webhook{}, ErrUnknownEvent
+		}
+		if !seen[eventType] {
+			seen[eventType] = true
+			types = append(types, eventType)
+		}
+	}
+	if threshold < 0 || (seen[EventClickThreshold] && threshold == 0) {
+		return Webhook{}, ErrInvalidThreshold
+	}
+	secret := make([]byte, secretSize)
+	if _, err := rand.Read(secret); err != nil {
+		return Webhook{}, err
+	}
+	id, err := uuid.NewV4()
+	if err != nil {
+		return Webhook{}, err
+	}
+	return Webhook{
+		ID:             id.String(),
+		UserID:         userID,
+		URL:            rawURL,
+		Secret:         SecretPrefix + base64.RawURLEncoding.EncodeToString(secret),
+		Events:         types,
+		ClickThreshold: threshold,
+		CreatedAt:      time.Now().UTC(),
+	}, nil
+}
+
+// Wants - подписан ли вебхук на событие. Событие link.click_threshold
+// нужно, только если переходы по ссылке только что достигли порога
+// вебхука.
+func (w Webhook) Wants(event Event) bool {
+	if len(w.Events) > 0 && !contains(w.Events, event.Type) {
+		return false
+	}
+	if event.Type != EventClickThreshold {
+		return events[event.Type]
+	}
+	return w.ClickThreshold > 0 && event.Link != nil &&
+		event.Link.PreviousClicks < w.ClickThreshold && event.Link.Clicks >= w.ClickThreshold
+}
+
+// contains - есть ли value в values.
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// NewEvent - событие eventType о ссылке link, nil - событие без ссылки.
+func NewEvent(eventType string, link *Link) Event {
+	// Ошибка возможна только при сбое источника случайных чисел, id
+	// события тогда пустой.
+	id, _ := uuid.NewV4()
+	return Event{
+		ID:        id.String(),
+		Type:      eventType,
+		CreatedAt: time.Now().UTC(),
+		Link:      link,
+	}
+}
+
+// Sign - подпись тела запроса body ключом secret вида sha256=<hex>.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify - проверка подписи signature тела запроса body ключом secret
+// за постоянное время.
+func Verify(secret string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, body)), []byte(signature))
+}
+
+// Backoff - пауза перед следующей попыткой доставки после неудачной
+// попытки attempt: удваивается с каждой попыткой, но не больше минуты.
+func Backoff(attempt int) time.Duration {
+	backoff := baseBackoff
+	for i := 1; i < attempt && backoff < maxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > maxBackoff {
+		backoff = maxBackoff
+	}
+	return backoff
+}
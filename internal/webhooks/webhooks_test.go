@@ -0,0 +1,117 @@
+package webhooks
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewWebhook(t *testing.T) {
+	tests := []struct {
+		name       string
+		url        string
+		events     []string
+		threshold  int64
+		wantEvents []string
+		wantErr    error
+	}{
+		{name: "all events", url: "https://cms.example.com/hooks"},
+		{
+			name:       "selected events without duplicates",
+			url:        " http://cms.example.com/hooks ",
+			events:     []string{EventLinkCreated, EventLinkDeleted, EventLinkCreated},
+			wantEvents: []string{EventLinkCreated, EventLinkDeleted},
+		},
+		{
+			name:       "click threshold",
+			url:        "https://cms.example.com/hooks",
+			events:     []string{EventClickThreshold},
+			threshold:  100,
+			wantEvents: []string{EventClickThreshold},
+		},
+		{name: "relative url", url: "/hooks", wantErr: ErrInvalidURL},
+		{name: "unsupported scheme", url: "ftp://cms.example.com/hooks", wantErr: ErrInvalidURL},
+		{name: "unknown event", url: "https://cms.example.com/hooks", events: []string{"link.updated"}, wantErr: ErrUnknownEvent},
+		{name: "test event", url: "https://cms.example.com/hooks", events: []string{EventTest}, wantErr: ErrUnknownEvent},
+		{
+			name:    "click threshold without threshold",
+			url:     "https://cms.example.com/hooks",
+			events:  []string{EventClickThreshold},
+			wantErr: ErrInvalidThreshold,
+		},
+		{name: "negative threshold", url: "https://cms.example.com/hooks", threshold: -1, wantErr: ErrInvalidThreshold},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hook, err := NewWebhook("user-1", tt.url, tt.events, tt.threshold)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.NotEmpty(t, hook.ID)
+			assert.Equal(t, "user-1", hook.UserID)
+			assert.Equal(t, strings.TrimSpace(tt.url), hook.URL)
+			assert.True(t, strings.HasPrefix(hook.Secret, SecretPrefix))
+			assert.Equal(t, tt.wantEvents, hook.Events)
+			assert.Equal(t, tt.threshold, hook.ClickThreshold)
+		})
+	}
+
+	first, _ := NewWebhook("user-1", "https://cms.example.com/hooks", nil, 0)
+	second, _ := NewWebhook("user-1", "https://cms.example.com/hooks", nil, 0)
+	assert.NotEqual(t, first.Secret, second.Secret)
+}
+
+func TestWebhookWants(t *testing.T) {
+	clicks := func(previous int64, current int64) Event {
+		return NewEvent(EventClickThreshold, &Link{ID: "abc", PreviousClicks: previous, Clicks: current})
+	}
+	tests := []struct {
+		name  string
+		hook  Webhook
+		event Event
+		want  bool
+	}{
+		{name: "all events", hook: Webhook{}, event: NewEvent(EventLinkCreated, nil), want: true},
+		{name: "subscribed", hook: Webhook{Events: []string{EventLinkDeleted}}, event: NewEvent(EventLinkDeleted, nil), want: true},
+		{name: "not subscribed", hook: Webhook{Events: []string{EventLinkDeleted}}, event: NewEvent(EventLinkCreated, nil)},
+		{name: "unknown event", hook: Webhook{}, event: NewEvent("link.updated", nil)},
+		{name: "threshold reached", hook: Webhook{ClickThreshold: 100}, event: clicks(90, 105), want: true},
+		{name: "threshold reached exactly", hook: Webhook{ClickThreshold: 100}, event: clicks(99, 100), want: true},
+		{name: "threshold not reached", hook: Webhook{ClickThreshold: 100}, event: clicks(10, 99)},
+		{name: "threshold already passed", hook: Webhook{ClickThreshold: 100}, event: clicks(100, 120)},
+		{name: "no threshold", hook: Webhook{}, event: clicks(0, 1000)},
+		{
+			name:  "threshold without subscription",
+			hook:  Webhook{Events: []string{EventLinkCreated}, ClickThreshold: 100},
+			event: clicks(90, 105),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.hook.Wants(tt.event))
+		})
+	}
+}
+
+func TestSign(t *testing.T) {
+	body := []byte(`{"type":"link.created"}`)
+	signature := Sign("whsec_secret", body)
+	assert.Equal(t, "sha256=", signature[:7])
+	assert.Len(t, signature, 7+64)
+	assert.True(t, Verify("whsec_secret", body, signature))
+	assert.False(t, Verify("whsec_other", body, signature))
+	assert.False(t, Verify("whsec_secret", []byte(`{"type":"link.deleted"}`), signature))
+}
+
+func TestBackoff(t *testing.T) {
+	assert.Equal(t, time.Second, Backoff(1))
+	assert.Equal(t, 2*time.Second, Backoff(2))
+	assert.Equal(t, 16*time.Second, Backoff(5))
+	assert.Equal(t, time.Minute, Backoff(7))
+	assert.Equal(t, time.Minute, Backoff(100))
+}
@@ -3,6 +3,7 @@ package workers
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"sync"
@@ -10,8 +11,16 @@ import (
 
 // WorkerPool - структура для создания и управление пулом воркеров.
 type WorkerPool struct {
+	mu           sync.Mutex
+	wg           sync.WaitGroup
+	ctx          context.Context
 	numOfWorkers int
 	inputCh      chan func(ctx context.Context) error
+	stops        []chan struct{}
+	running      bool
+	// done закрывается после остановки пула. Канал задач не закрывается,
+	// чтобы постановка задачи после остановки не приводила к панике.
+	done chan struct{}
 }
 
 // New - создание структуры WorkerPool.
@@ -19,41 +28,128 @@ func New(ctx context.Context, numOfWorkers int, buffer int) *WorkerPool {
 	wp := &WorkerPool{
 		numOfWorkers: numOfWorkers,
 		inputCh:      make(chan func(ctx context.Context) error, buffer),
+		done:         make(chan struct{}),
 	}
 	return wp
 }
 
 // Run - запуск работы WorkerPool.
 // Запускается numOfWorkers горутин, которые выполняют полезную рабту.
-// Функция ждет завершения всех горутин.
+// Функция ждет завершения контекста и всех горутин.
 func (wp *WorkerPool) Run(ctx context.Context) {
-	wg := &sync.WaitGroup{}
+	wp.mu.Lock()
+	wp.ctx = ctx
+	wp.running = true
 	for i := 0; i < wp.numOfWorkers; i++ {
-		wg.Add(1)
-		go func(i int) {
-			fmt.Printf("Worker #%v start \n", i)
-		outer:
-			for {
-				select {
-				case f := <-wp.inputCh:
-					err := f(ctx)
-					if err != nil {
-						fmt.Printf("Error on worker #%v: %v\n", i, err.Error())
-					}
-				case <-ctx.Done():
-					break outer
-				}
-
-			}
-			log.Printf("Worker #%v close\n", i)
-			wg.Done()
-		}(i)
+		wp.startWorker()
 	}
-	wg.Wait()
-	close(wp.inputCh)
+	wp.mu.Unlock()
+
+	<-ctx.Done()
+
+	wp.mu.Lock()
+	wp.running = false
+	close(wp.done)
+	wp.mu.Unlock()
+	wp.wg.Wait()
 }
 
-// Push - загрузка задачи в канал выполнения.
+// Push - загрузка задачи в канал выполнения. Ждет места в очереди; задачи,
+// поставленные после остановки пула, не выполняются.
 func (wp *WorkerPool) Push(task func(ctx context.Context) error) {
 	wp.inputCh <- task
 }
+
+// TryPush - загрузка задачи в канал выполнения, пока пул не остановлен и
+// ctx не завершен. Ждет места в очереди, но не дольше остановки пула или
+// завершения ctx. Возвращает, поставлена ли задача.
+func (wp *WorkerPool) TryPush(ctx context.Context, task func(ctx context.Context) error) bool {
+	select {
+	case <-wp.done:
+		return false
+	case <-ctx.Done():
+		return false
+	default:
+	}
+	select {
+	case wp.inputCh <- task:
+		return true
+	case <-wp.done:
+		return false
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// Resize - изменение количества воркеров. Лишние воркеры завершаются после
+// выполнения текущей задачи.
+func (wp *WorkerPool) Resize(numOfWorkers int) error {
+	if numOfWorkers < 1 {
+		return errors.New("number of workers must be positive")
+	}
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+	if !wp.running {
+		wp.numOfWorkers = numOfWorkers
+		return nil
+	}
+	for len(wp.stops) < numOfWorkers {
+		wp.startWorker()
+	}
+	for len(wp.stops) > numOfWorkers {
+		last := len(wp.stops) - 1
+		close(wp.stops[last])
+		wp.stops = wp.stops[:last]
+	}
+	wp.numOfWorkers = numOfWorkers
+	return nil
+}
+
+// Running - запущен ли пул воркеров.
+func (wp *WorkerPool) Running() bool {
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+	return wp.running
+}
+
+// Stats - количество воркеров и задач, ожидающих выполнения.
+func (wp *WorkerPool) Stats() (workers int, queued int) {
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+	return wp.numOfWorkers, len(wp.inputCh)
+}
+
+// startWorker - запуск одного воркера, вызывается под блокировкой mu.
+func (wp *WorkerPool) startWorker() {
+	i := len(wp.stops)
+	stop := make(chan struct{})
+	wp.stops = append(wp.stops, stop)
+	wp.wg.Add(1)
+	go func() {
+		defer wp.wg.Done()
+		fmt.Printf("Worker #%v start \n", i)
+	outer:
+		for {
+			// Остановленный воркер не берет новых задач, даже если они есть в
+			// очереди.
+			select {
+			case <-stop:
+				break outer
+			default:
+			}
+			select {
+			case f := <-wp.inputCh:
+				err := f(wp.ctx)
+				if err != nil {
+					fmt.Printf("Error on worker #%v: %v\n", i, err.Error())
+				}
+			case <-stop:
+				break outer
+			case <-wp.ctx.Done():
+				break outer
+			}
+
+		}
+		log.Printf("Worker #%v close\n", i)
+	}()
+}
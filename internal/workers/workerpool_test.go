@@ -0,0 +1,216 @@
+package workers
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// gate - задачи, которые ждут release, и учет одновременно выполняемых
+// задач.
+type gate struct {
+	mu      sync.Mutex
+	release chan struct{}
+	active  int
+	max     int
+	done    int
+}
+
+func newGate() *gate {
+	return &gate{release: make(chan struct{})}
+}
+
+func (g *gate) task(ctx context.Context) error {
+	g.mu.Lock()
+	g.active++
+	if g.active > g.max {
+		g.max = g.active
+	}
+	g.mu.Unlock()
+	<-g.release
+	g.mu.Lock()
+	g.active--
+	g.done++
+	g.mu.Unlock()
+	return nil
+}
+
+func (g *gate) state() (active int, max int, done int) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.active, g.max, g.done
+}
+
+func startPool(t *testing.T, numOfWorkers int, buffer int) *WorkerPool {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	wp := New(ctx, numOfWorkers, buffer)
+	go wp.Run(ctx)
+	require.Eventually(t, wp.Running, time.Second, time.Millisecond)
+	return wp
+}
+
+func TestWorkerPoolStats(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	wp := New(ctx, 2, 10)
+	assert.False(t, wp.Running())
+	for i := 0; i < 3; i++ {
+		wp.Push(func(ctx context.Context) error { return nil })
+	}
+	workers, queued := wp.Stats()
+	assert.Equal(t, 2, workers)
+	assert.Equal(t, 3, queued)
+
+	stopped := make(chan struct{})
+	go func() {
+		wp.Run(ctx)
+		close(stopped)
+	}()
+	require.Eventually(t, wp.Running, time.Second, time.Millisecond)
+	require.Eventually(t, func() bool {
+		_, queued := wp.Stats()
+		return queued == 0
+	}, time.Second, time.Millisecond)
+
+	cancel()
+	<-stopped
+	assert.False(t, wp.Running())
+}
+
+func TestWorkerPoolResizeInvalid(t *testing.T) {
+	wp := startPool(t, 2, 10)
+	for _, size := range []int{0, -1} {
+		assert.Error(t, wp.Resize(size))
+		workers, _ := wp.Stats()
+		assert.Equal(t, 2, workers)
+	}
+}
+
+func TestWorkerPoolResizeBeforeRun(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	wp := New(ctx, 1, 10)
+	require.NoError(t, wp.Resize(3))
+	workers, _ := wp.Stats()
+	assert.Equal(t, 3, workers)
+
+	g := newGate()
+	defer close(g.release)
+	for i := 0; i < 5; i++ {
+		wp.Push(g.task)
+	}
+	go wp.Run(ctx)
+	require.Eventually(t, func() bool {
+		active, _, _ := g.state()
+		return active == 3
+	}, time.Second, time.Millisecond)
+}
+
+func TestWorkerPoolGrow(t *testing.T) {
+	wp := startPool(t, 1, 10)
+	g := newGate()
+	for i := 0; i < 5; i++ {
+		wp.Push(g.task)
+	}
+	require.Eventually(t, func() bool {
+		active, _, _ := g.state()
+		_, queued := wp.Stats()
+		return active == 1 && queued == 4
+	}, time.Second, time.Millisecond)
+
+	require.NoError(t, wp.Resize(3))
+	require.Eventually(t, func() bool {
+		active, _, _ := g.state()
+		workers, queued := wp.Stats()
+		return workers == 3 && active == 3 && queued == 2
+	}, time.Second, time.Millisecond)
+
+	close(g.release)
+	require.Eventually(t, func() bool {
+		_, _, done := g.state()
+		return done == 5
+	}, time.Second, time.Millisecond)
+	_, max, _ := g.state()
+	assert.Equal(t, 3, max)
+}
+
+func TestWorkerPoolShrink(t *testing.T) {
+	wp := startPool(t, 3, 10)
+	busy := newGate()
+	for i := 0; i < 3; i++ {
+		wp.Push(busy.task)
+	}
+	require.Eventually(t, func() bool {
+		active, _, _ := busy.state()
+		return active == 3
+	}, time.Second, time.Millisecond)
+
+	queued := newGate()
+	for i := 0; i < 4; i++ {
+		wp.Push(queued.task)
+	}
+	require.NoError(t, wp.Resize(1))
+	workers, n := wp.Stats()
+	assert.Equal(t, 1, workers)
+	assert.Equal(t, 4, n)
+
+	// Остановленные воркеры завершают текущие задачи, а задачи из очереди
+	// выполняет оставшийся воркер.
+	close(busy.release)
+	require.Eventually(t, func() bool {
+		_, _, done := busy.state()
+		active, _, _ := queued.state()
+		return done == 3 && active == 1
+	}, time.Second, time.Millisecond)
+	close(queued.release)
+	require.Eventually(t, func() bool {
+		_, _, done := queued.state()
+		return done == 4
+	}, time.Second, time.Millisecond)
+	_, max, _ := queued.state()
+	assert.Equal(t, 1, max)
+}
+
+func TestWorkerPoolTryPush(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	wp := New(ctx, 1, 1)
+	stopped := make(chan struct{})
+	go func() {
+		wp.Run(ctx)
+		close(stopped)
+	}()
+	require.Eventually(t, wp.Running, time.Second, time.Millisecond)
+
+	g := newGate()
+	require.True(t, wp.TryPush(context.Background(), g.task))
+	require.Eventually(t, func() bool {
+		active, _, _ := g.state()
+		return active == 1
+	}, time.Second, time.Millisecond)
+	require.True(t, wp.TryPush(context.Background(), g.task))
+
+	// Очередь заполнена: задача не ставится после завершения ctx.
+	pushCtx, pushCancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer pushCancel()
+	assert.False(t, wp.TryPush(pushCtx, g.task))
+
+	// Ожидающие места в очереди задачи не ставятся после остановки пула.
+	results := make(chan bool, 3)
+	for i := 0; i < cap(results); i++ {
+		go func() {
+			results <- wp.TryPush(context.Background(), g.task)
+		}()
+	}
+	cancel()
+	for i := 0; i < cap(results); i++ {
+		assert.False(t, <-results)
+	}
+	close(g.release)
+	<-stopped
+
+	assert.False(t, wp.TryPush(context.Background(), g.task))
+}
@@ -0,0 +1,100 @@
+// Package workspaces - пакет с общими рабочими пространствами для ссылок,
+// их участниками и ролями.
+package workspaces
+
+import (
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gofrs/uuid"
+)
+
+// MaxNameLength - максимальная длина названия рабочего пространства.
+const MaxNameLength = 100
+
+// Role - роль участника рабочего пространства.
+type Role string
+
+const (
+	// RoleOwner - управляет участниками, создает и удаляет ссылки.
+	RoleOwner Role = "owner"
+	// RoleEditor - создает и удаляет ссылки.
+	RoleEditor Role = "editor"
+	// RoleViewer - только просматривает ссылки.
+	RoleViewer Role = "viewer"
+)
+
+// roleRank - порядок ролей: каждая следующая включает права предыдущей.
+var roleRank = map[Role]int{
+	RoleViewer: 1,
+	RoleEditor: 2,
+	RoleOwner:  3,
+}
+
+var (
+	// ErrInvalidName - пустое или слишком длинное название.
+	ErrInvalidName = errors.New("workspace name must be from 1 to 100 characters")
+	// ErrInvalidRole - неизвестная роль.
+	ErrInvalidRole = errors.New("role must be one of owner, editor, viewer")
+)
+
+// Workspace - рабочее пространство, которому принадлежат общие ссылки.
+type Workspace struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Member - участник рабочего пространства. Login заполняется хранилищем
+// при чтении списка участников.
+type Member struct {
+	WorkspaceID string `json:"workspace_id"`
+	UserID      string `json:"user_id"`
+	Login       string `json:"login,omitempty"`
+	Role        Role   `json:"role"`
+}
+
+// Membership - рабочее пространство и роль в нем пользователя.
+type Membership struct {
+	Workspace
+	Role Role `json:"role"`
+}
+
+// NewWorkspace - создание рабочего пространства с проверкой названия.
+func NewWorkspace(name string) (Workspace, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > MaxNameLength {
+		return Workspace{}, ErrInvalidName
+	}
+	id, err := uuid.NewV4()
+	if err != nil {
+		return Workspace{}, err
+	}
+	return Workspace{
+		ID:        id.String(),
+		Name:      name,
+		CreatedAt: time.Now().UTC(),
+	}, nil
+}
+
+// ParseRole - разбор роли из строки.
+func ParseRole(s string) (Role, error) {
+	role := Role(strings.ToLower(strings.TrimSpace(s)))
+	if _, ok := roleRank[role]; !ok {
+		return "", ErrInvalidRole
+	}
+	return role, nil
+}
+
+// AtLeast - есть ли у роли все права роли min. Пустая роль (не участник)
+// не имеет прав.
+func (r Role) AtLeast(min Role) bool {
+	return roleRank[r] > 0 && roleRank[r] >= roleRank[min]
+}
+
+// CanEdit - может ли роль создавать и удалять ссылки.
+func (r Role) CanEdit() bool {
+	return r.AtLeast(RoleEditor)
+}
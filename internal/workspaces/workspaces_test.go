@@ -0,0 +1,38 @@
+package workspaces
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRole(t *testing.T) {
+	role, err := ParseRole(" Editor ")
+	assert.NoError(t, err)
+	assert.Equal(t, RoleEditor, role)
+
+	_, err = ParseRole("admin")
+	assert.ErrorIs(t, err, ErrInvalidRole)
+}
+
+func TestRoleAtLeast(t *testing.T) {
+	assert.True(t, RoleOwner.AtLeast(RoleEditor))
+	assert.True(t, RoleEditor.AtLeast(RoleViewer))
+	assert.False(t, RoleViewer.AtLeast(RoleEditor))
+	assert.False(t, Role("").AtLeast(RoleViewer))
+	assert.True(t, RoleEditor.CanEdit())
+	assert.False(t, RoleViewer.CanEdit())
+}
+
+func TestNewWorkspace(t *testing.T) {
+	workspace, err := NewWorkspace("  Marketing ")
+	assert.NoError(t, err)
+	assert.Equal(t, "Marketing", workspace.Name)
+	assert.NotEmpty(t, workspace.ID)
+
+	_, err = NewWorkspace(" ")
+	assert.ErrorIs(t, err, ErrInvalidName)
+	_, err = NewWorkspace(strings.Repeat("a", MaxNameLength+1))
+	assert.ErrorIs(t, err, ErrInvalidName)
+}